@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/tespkg/bytes-be/config"
+	"github.com/tespkg/bytes-be/svc/staff/logic"
+	"github.com/tespkg/bytes-be/svc/staff/model/dao"
+	"github.com/tespkg/bytes-be/svc/utils"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"io"
+	"os"
+	"tespkg.in/kit/log"
+)
+
+var catalogMerchantId int64
+var catalogFile string
+var catalogFormat string
+var catalogDryRun bool
+
+var catalogCmd = &cobra.Command{
+	Use:   "catalog",
+	Short: "import or export the catalog of a merchant",
+}
+
+var catalogImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "import the products from a csv or json file, upserted by SKU, nothing is saved if any row fails",
+	Run: func(cmd *cobra.Command, args []string) {
+		db, merchant := loadCatalogMerchant()
+
+		file, err := os.Open(catalogFile)
+		if err != nil {
+			log.Fatalf("open catalog file[%s], err: %v", catalogFile, err)
+		}
+		defer file.Close()
+
+		format := catalogFormat
+		if format == "" {
+			format = logic.CatalogFormatOf(catalogFile)
+		}
+
+		resp, err := logic.ImportCatalog(db, merchant, format, file, catalogDryRun)
+		if err != nil {
+			log.Fatalf("import catalog, err: %v", err)
+		}
+
+		//the report is printed so it can be piped, e.g. to jq
+		report, _ := json.MarshalIndent(resp, "", "  ")
+		fmt.Println(string(report))
+		if len(resp.Errors) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+var catalogExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "export the products in the format of the import, to stdout if no file",
+	Run: func(cmd *cobra.Command, args []string) {
+		db, merchant := loadCatalogMerchant()
+
+		format := catalogFormat
+		if format == "" {
+			format = logic.CatalogFormatOf(catalogFile)
+		}
+		if format == "" {
+			format = logic.CatalogFormatCSV
+		}
+
+		var w io.Writer = os.Stdout
+		if catalogFile != "" {
+			file, err := os.Create(catalogFile)
+			if err != nil {
+				log.Fatalf("create catalog file[%s], err: %v", catalogFile, err)
+			}
+			defer file.Close()
+			w = file
+		}
+
+		if err := logic.ExportCatalog(db, merchant, format, w); err != nil {
+			log.Fatalf("export catalog, err: %v", err)
+		}
+	},
+}
+
+// loadCatalogMerchant connects the database of the config, the schema is migrated like the server does
+func loadCatalogMerchant() (*gorm.DB, *dao.Merchant) {
+	cfg, err := config.LoadWithDefault(configPath)
+	if err != nil {
+		log.Fatalf("load config, path[%s], err: %v", configPath, err)
+	}
+
+	if err = utils.Migrate(cfg.Postgres.Dsn); err != nil {
+		log.Fatalf("migrate postgres, err: %v", err)
+	}
+	db, err := gorm.Open(postgres.Open(cfg.Postgres.Dsn), &gorm.Config{
+		AllowGlobalUpdate: false,
+		Logger:            logger.Default.LogMode(logger.Warn),
+	})
+	if err != nil {
+		log.Fatalf("connect postgres, err: %v", err)
+	}
+
+	merchant, err := dao.GetMerchantById(db, catalogMerchantId)
+	if err != nil {
+		log.Fatalf("load merchant[%d], err: %v", catalogMerchantId, err)
+	}
+	if merchant == nil || merchant.Id == 0 {
+		log.Fatalf("merchant[%d] not found", catalogMerchantId)
+	}
+	return db, merchant
+}
+
+func init() {
+	catalogCmd.PersistentFlags().StringVarP(
+		&configPath,
+		"config",
+		"c",
+		"server.yaml",
+		"the path of yaml file, the config are loaded by environment variable.",
+	)
+	catalogCmd.PersistentFlags().Int64VarP(&catalogMerchantId, "merchant", "m", 0, "the merchant id")
+	catalogCmd.PersistentFlags().StringVarP(&catalogFormat, "format", "", "", "csv or json, defaults to the extension of the file")
+	_ = catalogCmd.MarkPersistentFlagRequired("merchant")
+
+	catalogImportCmd.Flags().StringVarP(&catalogFile, "file", "f", "", "the catalog file")
+	catalogImportCmd.Flags().BoolVarP(&catalogDryRun, "dry-run", "", false, "validate and count without saving")
+	_ = catalogImportCmd.MarkFlagRequired("file")
+
+	catalogExportCmd.Flags().StringVarP(&catalogFile, "file", "f", "", "the output file, stdout if empty")
+
+	catalogCmd.AddCommand(catalogImportCmd)
+	catalogCmd.AddCommand(catalogExportCmd)
+	rootCmd.AddCommand(catalogCmd)
+}
@@ -0,0 +1,171 @@
+package allergen
+
+import (
+	"strings"
+	"unicode"
+)
+
+// the 14 major allergens which must be declared on food
+const (
+	Celery      = "celery"
+	Gluten      = "gluten" // cereals containing gluten
+	Crustaceans = "crustaceans"
+	Eggs        = "eggs"
+	Fish        = "fish"
+	Lupin       = "lupin"
+	Milk        = "milk"
+	Molluscs    = "molluscs"
+	Mustard     = "mustard"
+	Nuts        = "nuts" // tree nuts
+	Peanuts     = "peanuts"
+	Sesame      = "sesame"
+	Soya        = "soya"
+	Sulphites   = "sulphites"
+)
+
+var All = []string{Celery, Gluten, Crustaceans, Eggs, Fish, Lupin, Milk, Molluscs, Mustard, Nuts, Peanuts, Sesame, Soya, Sulphites}
+
+var aliases = map[string]string{
+	"dairy":     Milk,
+	"lactose":   Milk,
+	"wheat":     Gluten,
+	"egg":       Eggs,
+	"shellfish": Crustaceans,
+	"mollusks":  Molluscs,
+	"tree_nuts": Nuts,
+	"nut":       Nuts,
+	"peanut":    Peanuts,
+	"soy":       Soya,
+	"sulfites":  Sulphites,
+}
+
+type rule struct {
+	keywords []string
+	// excludes the ingredients which only look like the allergen, e.g. coconut milk
+	excludes []string
+}
+
+var rules = map[string]rule{
+	Celery: {keywords: []string{"celery", "celeriac"}},
+	Gluten: {
+		keywords: []string{"wheat", "flour", "bread", "breadcrumb", "panko", "crouton", "pasta", "spaghetti", "macaroni",
+			"penne", "lasagna", "noodle", "vermicelli", "barley", "rye", "oat", "spelt", "couscous", "bulgur", "freekeh",
+			"semolina", "pita", "tortilla", "naan", "paratha", "chapati", "roti", "bun", "dough", "pastry", "phyllo", "filo",
+			"batter", "cracker", "biscuit", "cake", "malt", "seitan", "soy sauce"},
+		excludes: []string{"rice flour", "corn flour", "chickpea flour", "gram flour", "almond flour", "rice noodle",
+			"glass noodle", "rice vermicelli", "corn tortilla", "gluten free"},
+	},
+	Crustaceans: {keywords: []string{"shrimp", "prawn", "crab", "lobster", "crayfish", "langoustine", "krill"}},
+	Eggs:        {keywords: []string{"egg", "mayonnaise", "mayo", "aioli", "meringue"}},
+	Fish: {
+		keywords: []string{"fish", "salmon", "tuna", "cod", "hammour", "grouper", "kingfish", "sardine", "anchovy",
+			"anchovies", "mackerel", "trout", "tilapia", "haddock", "halibut", "sea bass", "seabass", "snapper", "hake",
+			"pollock", "sole", "herring", "bonito", "catfish"},
+	},
+	Lupin: {keywords: []string{"lupin", "lupine", "lupini"}},
+	Milk: {
+		keywords: []string{"milk", "cheese", "butter", "cream", "yogurt", "yoghurt", "ghee", "paneer", "labneh", "laban",
+			"whey", "casein", "mozzarella", "parmesan", "cheddar", "feta", "halloumi", "ricotta", "mascarpone", "custard",
+			"bechamel", "lactose", "akkawi"},
+		excludes: []string{"coconut milk", "coconut cream", "almond milk", "oat milk", "soy milk", "rice milk",
+			"peanut butter", "cocoa butter", "nut butter", "almond butter", "dairy free"},
+	},
+	Molluscs: {keywords: []string{"squid", "calamari", "octopus", "mussel", "clam", "oyster", "scallop", "cuttlefish",
+		"snail", "escargot", "whelk"}},
+	Mustard: {keywords: []string{"mustard"}},
+	Nuts: {
+		keywords: []string{"nut", "almond", "walnut", "cashew", "pistachio", "hazelnut", "pecan", "macadamia", "praline",
+			"marzipan"},
+	},
+	Peanuts:   {keywords: []string{"peanut", "groundnut", "satay"}},
+	Sesame:    {keywords: []string{"sesame", "tahini", "tahina", "halva", "zaatar", "za atar"}},
+	Soya:      {keywords: []string{"soy", "soya", "soybean", "tofu", "edamame", "miso", "tempeh"}},
+	Sulphites: {keywords: []string{"sulphite", "sulfite", "wine", "raisin", "dried apricot"}},
+}
+
+// Normalize the code of an allergen or of an alias, e.g. dairy is milk
+func Normalize(name string) (string, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if _, ok := rules[name]; ok {
+		return name, true
+	}
+	code, ok := aliases[name]
+	return code, ok
+}
+
+// Detect the allergens of the ingredients in the order of All, matched by keywords so the list is a suggestion
+func Detect(ingredients []string) []string {
+	found := make(map[string]bool)
+	for _, ingredient := range ingredients {
+		words := tokenize(ingredient)
+		for code, r := range rules {
+			if found[code] || matchAny(words, r.excludes) {
+				continue
+			}
+			if matchAny(words, r.keywords) {
+				found[code] = true
+			}
+		}
+	}
+
+	codes := make([]string, 0, len(found))
+	for _, code := range All {
+		if found[code] {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+// Sort the known codes in the order of All without duplicates
+func Sort(codes []string) []string {
+	set := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		set[code] = true
+	}
+
+	sorted := make([]string, 0, len(set))
+	for _, code := range All {
+		if set[code] {
+			sorted = append(sorted, code)
+		}
+	}
+	return sorted
+}
+
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+}
+
+// matchAny true if the words have a phrase, the last word of the phrase may be plural
+func matchAny(words []string, phrases []string) bool {
+	for _, phrase := range phrases {
+		if matchPhrase(words, strings.Fields(phrase)) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchPhrase(words, phrase []string) bool {
+	for i := 0; i+len(phrase) <= len(words); i++ {
+		matched := true
+		for j, w := range phrase {
+			word := words[i+j]
+			if word == w {
+				continue
+			}
+			if j == len(phrase)-1 && (word == w+"s" || word == w+"es") {
+				continue
+			}
+			matched = false
+			break
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+package geo
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/tespkg/bytes-be/common/global"
+	"github.com/tespkg/bytes-be/common/xerr"
+	"github.com/tespkg/bytes-be/internal/geocode"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var ClientUnInitErr = errors.New("geocoding client not init")
+
+var redisKeyPrefix = "bytes_be:geo"
+
+type Options struct {
+	// Market biases the search results, nil searches worldwide
+	Market         *geocode.ViewBox
+	CountryCodes   string
+	AcceptLanguage string
+	CacheExpire    time.Duration
+	// RateLimitPerSecond is shared by every instance through redis
+	RateLimitPerSecond int
+}
+
+var DefaultOptions = Options{
+	CacheExpire:        24 * time.Hour,
+	RateLimitPerSecond: 1,
+}
+
+var options = DefaultOptions
+
+var geocoder geocode.Geocoder
+
+// SetUp sets the geocoder and overrides the default options, zero values keep the defaults
+func SetUp(g geocode.Geocoder, opts Options) {
+	geocoder = g
+	options.Market = opts.Market
+	options.CountryCodes = opts.CountryCodes
+	options.AcceptLanguage = opts.AcceptLanguage
+	if opts.CacheExpire > 0 {
+		options.CacheExpire = opts.CacheExpire
+	}
+	if opts.RateLimitPerSecond > 0 {
+		options.RateLimitPerSecond = opts.RateLimitPerSecond
+	}
+}
+
+type SearchReq struct {
+	Query    string
+	Limit    int
+	Bounded  bool // only return the results inside the market
+	Language string
+}
+
+// Search forwards the query to nominatim biased to the market, the results are cached
+func Search(ctx context.Context, req SearchReq) (*geocode.FeatureCollection, error) {
+	if geocoder == nil {
+		return nil, ClientUnInitErr
+	}
+
+	language := req.Language
+	if language == "" {
+		language = options.AcceptLanguage
+	}
+	query := strings.Join(strings.Fields(strings.ToLower(req.Query)), " ")
+	key := cacheKey("search", fmt.Sprintf("%s|%d|%t|%s", query, req.Limit, req.Bounded, language))
+
+	return cached(ctx, key, func() (*geocode.FeatureCollection, error) {
+		return geocoder.Search(ctx, query, geocode.SearchOptions{
+			ViewBox:        options.Market,
+			Bounded:        req.Bounded,
+			CountryCodes:   options.CountryCodes,
+			AcceptLanguage: language,
+			Limit:          req.Limit,
+		})
+	})
+}
+
+// Reverse resolves a pin to a place, pins closer than about a meter share the cache entry
+func Reverse(ctx context.Context, lat, lon float64, language string) (*geocode.FeatureCollection, error) {
+	if geocoder == nil {
+		return nil, ClientUnInitErr
+	}
+
+	if language == "" {
+		language = options.AcceptLanguage
+	}
+	lat, lon = roundCoordinate(lat), roundCoordinate(lon)
+	key := cacheKey("reverse", fmt.Sprintf("%.5f|%.5f|%s", lat, lon, language))
+
+	return cached(ctx, key, func() (*geocode.FeatureCollection, error) {
+		return geocoder.Reverse(ctx, lat, lon, language)
+	})
+}
+
+// cached reads the result from redis, on a miss it calls the geocoder which waits for the shared rate limit.
+// Redis failures only skip the cache, a place not found is cached as an empty result.
+func cached(ctx context.Context, key string, fetch func() (*geocode.FeatureCollection, error)) (*geocode.FeatureCollection, error) {
+	rdb := global.GlobalClientSets.RedisClient
+	if rdb != nil {
+		data, err := rdb.Get(ctx, key).Bytes()
+		if err == nil {
+			var fc geocode.FeatureCollection
+			if err = json.Unmarshal(data, &fc); err == nil {
+				return &fc, nil
+			}
+		}
+		if err != nil && !errors.Is(err, redis.Nil) {
+			logrus.Errorf("geo cache %s read fail: %s", key, err)
+		}
+	}
+
+	fc, err := fetch()
+	if errors.Is(err, geocode.ErrorNotFound) {
+		fc, err = &geocode.FeatureCollection{Type: "FeatureCollection"}, nil
+	}
+	if err != nil {
+		return nil, classify(err)
+	}
+	if fc.Features == nil {
+		fc.Features = []geocode.Feature{}
+	}
+
+	if rdb != nil {
+		data, _ := json.Marshal(fc)
+		if err = rdb.Set(ctx, key, data, options.CacheExpire).Err(); err != nil {
+			logrus.Errorf("geo cache %s write fail: %s", key, err)
+		}
+	}
+
+	return fc, nil
+}
+
+// classify turns the geocoder errors the client can act on into http errors
+func classify(err error) error {
+	switch {
+	case errors.Is(err, geocode.ErrorRateLimited):
+		return xerr.NewHttpError(http.StatusTooManyRequests, "geocoding is busy, try again later")
+	case errors.Is(err, geocode.ErrorUnavailable):
+		return xerr.NewHttpError(http.StatusServiceUnavailable, "geocoding is unavailable, try again later")
+	}
+	return errors.Wrap(err, ">>cached, geocoder request fail")
+}
+
+func cacheKey(kind, value string) string {
+	sum := sha1.Sum([]byte(value))
+	return fmt.Sprintf("%s:%s:%s", redisKeyPrefix, kind, hex.EncodeToString(sum[:]))
+}
+
+func roundCoordinate(value float64) float64 {
+	return math.Round(value*1e5) / 1e5
+}
@@ -0,0 +1,126 @@
+package geo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/tespkg/bytes-be/common/global"
+	"github.com/tespkg/bytes-be/common/xerr"
+	"github.com/tespkg/bytes-be/internal/geocode"
+	"github.com/tespkg/bytes-be/internal/geocode/geocodetest"
+	"github.com/tespkg/bytes-be/internal/testenv"
+)
+
+// setUpFake geocodes with a fake holding one place, cached in an in-memory redis
+func setUpFake(t *testing.T) *geocodetest.Fake {
+	t.Helper()
+
+	client, _ := testenv.Redis(t)
+	testenv.Global(t, global.ClientSet{RedisClient: client})
+
+	fake := geocodetest.NewFake()
+	fake.AddPlace(25.2867, 51.5333, geocode.Place{PlaceId: 1, Name: "Souq Waqif", DisplayName: "Souq Waqif, Doha, Qatar"})
+
+	previousGeocoder, previousOptions := geocoder, options
+	SetUp(fake, Options{RateLimitPerSecond: 1000})
+	t.Cleanup(func() {
+		geocoder, options = previousGeocoder, previousOptions
+	})
+	return fake
+}
+
+func TestSearchIsCached(t *testing.T) {
+	fake := setUpFake(t)
+	ctx := context.Background()
+
+	for _, query := range []string{"Souq", "  souq "} {
+		fc, err := Search(ctx, SearchReq{Query: query})
+		if err != nil {
+			t.Fatalf("search %q: %v", query, err)
+		}
+		if len(fc.Features) != 1 || fc.Features[0].Properties.PlaceId != 1 {
+			t.Fatalf("search %q: unexpected features %+v", query, fc.Features)
+		}
+	}
+
+	// the normalized query hits the cache
+	if search, _ := fake.Calls(); search != 1 {
+		t.Fatalf("got %d geocoder searches, want 1", search)
+	}
+}
+
+func TestReverseNotFoundIsCached(t *testing.T) {
+	fake := setUpFake(t)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		fc, err := Reverse(ctx, 0, 0, "")
+		if err != nil {
+			t.Fatalf("reverse: %v", err)
+		}
+		if fc.Features == nil || len(fc.Features) != 0 {
+			t.Fatalf("reverse: got %+v, want an empty result", fc.Features)
+		}
+	}
+
+	if _, reverse := fake.Calls(); reverse != 1 {
+		t.Fatalf("got %d geocoder reverses, want 1", reverse)
+	}
+}
+
+func TestReverseSharesNearbyPins(t *testing.T) {
+	fake := setUpFake(t)
+	ctx := context.Background()
+
+	for _, lat := range []float64{25.286701, 25.286704} {
+		fc, err := Reverse(ctx, lat, 51.5333, "")
+		if err != nil {
+			t.Fatalf("reverse: %v", err)
+		}
+		if len(fc.Features) != 1 {
+			t.Fatalf("reverse: unexpected features %+v", fc.Features)
+		}
+	}
+
+	if _, reverse := fake.Calls(); reverse != 1 {
+		t.Fatalf("got %d geocoder reverses, want 1", reverse)
+	}
+}
+
+func TestGeocoderErrorsAreClassified(t *testing.T) {
+	cases := []struct {
+		err      error
+		httpCode int
+	}{
+		{geocode.ErrorRateLimited, http.StatusTooManyRequests},
+		{geocode.ErrorUnavailable, http.StatusServiceUnavailable},
+	}
+	for _, c := range cases {
+		t.Run(c.err.Error(), func(t *testing.T) {
+			fake := setUpFake(t)
+			fake.Err = c.err
+
+			_, err := Search(context.Background(), SearchReq{Query: "souq"})
+			var httpErr *xerr.HttpError
+			if !errors.As(err, &httpErr) || httpErr.GetHttpCode() != c.httpCode {
+				t.Fatalf("search: got %v, want http code %d", err, c.httpCode)
+			}
+
+			// the failure is not cached, the next search asks the geocoder again
+			fake.Err = nil
+			if _, err = Search(context.Background(), SearchReq{Query: "souq"}); err != nil {
+				t.Fatalf("search: %v", err)
+			}
+		})
+	}
+
+	fake := setUpFake(t)
+	fake.Err = geocode.ErrorBadRequest
+	_, err := Search(context.Background(), SearchReq{Query: "souq"})
+	var httpErr *xerr.HttpError
+	if err == nil || errors.As(err, &httpErr) || !errors.Is(err, geocode.ErrorBadRequest) {
+		t.Fatalf("search: got %v, want the wrapped %v", err, geocode.ErrorBadRequest)
+	}
+}
@@ -0,0 +1,77 @@
+package geo
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/tespkg/bytes-be/common/global"
+	"github.com/tespkg/bytes-be/common/xerr"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// maxWait the request fails instead of queueing longer for a free slot
+const maxWait = 3 * time.Second
+
+var localMu sync.Mutex
+var localNext time.Time
+
+// Wait blocks until a nominatim request is allowed, the geocoder calls it before every attempt.
+// Every instance counts in the same redis window per second, without redis the requests are only spaced within this process.
+func Wait(ctx context.Context) error {
+	deadline := time.Now().Add(maxWait)
+
+	for {
+		allowed, err := take(ctx)
+		if err != nil {
+			return errors.Wrap(err, ">>Wait ")
+		}
+		if allowed {
+			return nil
+		}
+
+		now := time.Now()
+		next := now.Truncate(time.Second).Add(time.Second)
+		if next.After(deadline) {
+			return xerr.NewHttpError(http.StatusTooManyRequests, "geocoding is busy, try again later")
+		}
+
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func take(ctx context.Context) (bool, error) {
+	rdb := global.GlobalClientSets.RedisClient
+	if rdb == nil {
+		return takeLocal(), nil
+	}
+
+	key := fmt.Sprintf("%s:rate:%d", redisKeyPrefix, time.Now().Unix())
+	pipe := rdb.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, 2*time.Second)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, errors.Wrap(err, ">>take, redis pipeline fail")
+	}
+
+	return incr.Val() <= int64(options.RateLimitPerSecond), nil
+}
+
+func takeLocal() bool {
+	localMu.Lock()
+	defer localMu.Unlock()
+
+	now := time.Now()
+	if now.Before(localNext) {
+		return false
+	}
+	localNext = now.Add(time.Second / time.Duration(options.RateLimitPerSecond))
+	return true
+}
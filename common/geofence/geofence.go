@@ -0,0 +1,147 @@
+// Package geofence parses GeoJSON polygons and tells whether a WGS84 point is inside.
+// The coordinates are [lon, lat] as in GeoJSON, areas crossing the antimeridian aren't supported.
+package geofence
+
+import (
+	"encoding/json"
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	TypePolygon      = "Polygon"
+	TypeMultiPolygon = "MultiPolygon"
+
+	// MaxPositions bounds the size of an area, a delivery zone doesn't need more detail
+	MaxPositions = 10000
+)
+
+// Position [lon, lat]
+type Position [2]float64
+
+// Ring a closed line, the first and last positions are the same
+type Ring []Position
+
+// Polygon the first ring is the exterior, the others are holes
+type Polygon []Ring
+
+// Area a polygon or multi polygon with its bounding box
+type Area struct {
+	Polygons []Polygon
+	// Bbox [minLon, minLat, maxLon, maxLat]
+	Bbox [4]float64
+}
+
+type geometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+	Geometry    *geometry       `json:"geometry"`
+}
+
+// Parse accepts a Polygon or MultiPolygon geometry, or a Feature of one
+func Parse(data []byte) (*Area, error) {
+	var g geometry
+	if err := json.Unmarshal(data, &g); err != nil {
+		return nil, errors.Wrap(err, "the area is not a GeoJSON geometry")
+	}
+	if g.Type == "Feature" {
+		if g.Geometry == nil {
+			return nil, errors.New("the feature has no geometry")
+		}
+		g = *g.Geometry
+	}
+
+	area := &Area{}
+	switch g.Type {
+	case TypePolygon:
+		var polygon Polygon
+		if err := json.Unmarshal(g.Coordinates, &polygon); err != nil {
+			return nil, errors.Wrap(err, "the polygon coordinates are invalid")
+		}
+		area.Polygons = []Polygon{polygon}
+	case TypeMultiPolygon:
+		if err := json.Unmarshal(g.Coordinates, &area.Polygons); err != nil {
+			return nil, errors.Wrap(err, "the multi polygon coordinates are invalid")
+		}
+	default:
+		return nil, errors.Errorf("the geometry type %q isn't supported, only Polygon and MultiPolygon", g.Type)
+	}
+
+	if err := area.validate(); err != nil {
+		return nil, err
+	}
+	return area, nil
+}
+
+func (a *Area) validate() error {
+	if len(a.Polygons) == 0 {
+		return errors.New("the area has no polygon")
+	}
+
+	a.Bbox = [4]float64{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+	positions := 0
+	for _, polygon := range a.Polygons {
+		if len(polygon) == 0 {
+			return errors.New("a polygon has no ring")
+		}
+		for _, ring := range polygon {
+			if len(ring) < 4 {
+				return errors.New("a ring requires at least 4 positions")
+			}
+			if ring[0] != ring[len(ring)-1] {
+				return errors.New("a ring must end at its first position")
+			}
+			positions += len(ring)
+			if positions > MaxPositions {
+				return errors.Errorf("the area exceeds %d positions", MaxPositions)
+			}
+
+			for _, p := range ring {
+				if p[0] < -180 || p[0] > 180 || p[1] < -90 || p[1] > 90 {
+					return errors.Errorf("the position [%v, %v] is out of range", p[0], p[1])
+				}
+				a.Bbox[0], a.Bbox[1] = math.Min(a.Bbox[0], p[0]), math.Min(a.Bbox[1], p[1])
+				a.Bbox[2], a.Bbox[3] = math.Max(a.Bbox[2], p[0]), math.Max(a.Bbox[3], p[1])
+			}
+		}
+	}
+	return nil
+}
+
+// Contains the point is inside an exterior ring and outside its holes, a point on an edge may go either way
+func (a *Area) Contains(lon, lat float64) bool {
+	if lon < a.Bbox[0] || lon > a.Bbox[2] || lat < a.Bbox[1] || lat > a.Bbox[3] {
+		return false
+	}
+
+	for _, polygon := range a.Polygons {
+		if !polygon[0].contains(lon, lat) {
+			continue
+		}
+		inHole := false
+		for _, hole := range polygon[1:] {
+			if hole.contains(lon, lat) {
+				inHole = true
+				break
+			}
+		}
+		if !inHole {
+			return true
+		}
+	}
+	return false
+}
+
+// contains casts a ray to the east and counts the crossed edges, odd is inside
+func (r Ring) contains(lon, lat float64) bool {
+	inside := false
+	for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+		xi, yi := r[i][0], r[i][1]
+		xj, yj := r[j][0], r[j][1]
+		if (yi > lat) != (yj > lat) && lon < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
@@ -0,0 +1,94 @@
+package geofence
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// square a closed ring from (lon, lat) to (lon+size, lat+size)
+func square(lon, lat, size float64) string {
+	return fmt.Sprintf("[[%[1]v,%[2]v],[%[3]v,%[2]v],[%[3]v,%[4]v],[%[1]v,%[4]v],[%[1]v,%[2]v]]", lon, lat, lon+size, lat+size)
+}
+
+func mustParse(t *testing.T, data string) *Area {
+	t.Helper()
+
+	area, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("parse %s: %v", data, err)
+	}
+	return area
+}
+
+func TestParse(t *testing.T) {
+	tooMany := make([]string, 0, MaxPositions+1)
+	for i := 0; i < MaxPositions; i++ {
+		tooMany = append(tooMany, fmt.Sprintf("[%v,0]", float64(i)/MaxPositions))
+	}
+	tooMany = append(tooMany, "[0,1]", "[0,0]")
+
+	cases := []struct {
+		name  string
+		data  string
+		valid bool
+	}{
+		{"polygon", `{"type":"Polygon","coordinates":[` + square(58, 23, 1) + `]}`, true},
+		{"polygon with a hole", `{"type":"Polygon","coordinates":[` + square(58, 23, 1) + `,` + square(58.4, 23.4, 0.2) + `]}`, true},
+		{"multi polygon", `{"type":"MultiPolygon","coordinates":[[` + square(58, 23, 1) + `],[` + square(51, 25, 1) + `]]}`, true},
+		{"feature", `{"type":"Feature","properties":{},"geometry":{"type":"Polygon","coordinates":[` + square(58, 23, 1) + `]}}`, true},
+		{"feature without geometry", `{"type":"Feature","properties":{}}`, false},
+		{"point", `{"type":"Point","coordinates":[58,23]}`, false},
+		{"not json", `polygon`, false},
+		{"no ring", `{"type":"Polygon","coordinates":[]}`, false},
+		{"empty multi polygon", `{"type":"MultiPolygon","coordinates":[[]]}`, false},
+		{"short ring", `{"type":"Polygon","coordinates":[[[58,23],[59,23],[58,23]]]}`, false},
+		{"open ring", `{"type":"Polygon","coordinates":[[[58,23],[59,23],[59,24],[58,24]]]}`, false},
+		{"out of range", `{"type":"Polygon","coordinates":[` + square(180, 23, 1) + `]}`, false},
+		{"too many positions", `{"type":"Polygon","coordinates":[[` + strings.Join(tooMany, ",") + `]]}`, false},
+	}
+	for _, c := range cases {
+		_, err := Parse([]byte(c.data))
+		if c.valid && err != nil {
+			t.Fatalf("%s: got %v", c.name, err)
+		}
+		if !c.valid && err == nil {
+			t.Fatalf("%s: want an error", c.name)
+		}
+	}
+}
+
+func TestBbox(t *testing.T) {
+	area := mustParse(t, `{"type":"MultiPolygon","coordinates":[[`+square(58, 23, 1)+`],[`+square(51, 25, 0.5)+`]]}`)
+	if want := [4]float64{51, 23, 59, 25.5}; area.Bbox != want {
+		t.Fatalf("got bbox %v, want %v", area.Bbox, want)
+	}
+}
+
+func TestContains(t *testing.T) {
+	// a triangle, a square with a hole and another square
+	triangle := mustParse(t, `{"type":"Polygon","coordinates":[[[0,0],[4,0],[0,4],[0,0]]]}`)
+	holed := mustParse(t, `{"type":"MultiPolygon","coordinates":[[`+square(58, 23, 1)+`,`+square(58.4, 23.4, 0.2)+`],[`+square(51, 25, 1)+`]]}`)
+
+	cases := []struct {
+		name   string
+		area   *Area
+		lon    float64
+		lat    float64
+		inside bool
+	}{
+		{"inside the triangle", triangle, 1, 1, true},
+		{"beyond the hypotenuse", triangle, 3, 3, false},
+		{"in the bbox of the triangle only", triangle, 3.9, 3.9, false},
+		{"outside the bbox", triangle, -1, 1, false},
+		{"inside the exterior", holed, 58.1, 23.1, true},
+		{"inside the hole", holed, 58.5, 23.5, false},
+		{"inside the second polygon", holed, 51.5, 25.5, true},
+		{"between the polygons", holed, 55, 24, false},
+	}
+	for _, c := range cases {
+		if got := c.area.Contains(c.lon, c.lat); got != c.inside {
+			t.Fatalf("%s: got %t, want %t", c.name, got, c.inside)
+		}
+	}
+}
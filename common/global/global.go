@@ -15,6 +15,7 @@ type ClientSet struct {
 	SESClient               sespb.SESClient
 	EmailSender             string
 	TokenExpireDurationHour int
+	AccessTokenExpireSecond int
 	JwtSignedSecret         string
 	Broadcaster             *Broadcast
 }
@@ -35,6 +36,7 @@ func SetUp(clientSet ClientSet) {
 	GlobalClientSets.EnableSingleLogin = clientSet.EnableSingleLogin
 	GlobalClientSets.EmailSender = clientSet.EmailSender
 	GlobalClientSets.TokenExpireDurationHour = clientSet.TokenExpireDurationHour
+	GlobalClientSets.AccessTokenExpireSecond = clientSet.AccessTokenExpireSecond
 	GlobalClientSets.Broadcaster = clientSet.Broadcaster
 	GlobalClientSets.JwtSignedSecret = clientSet.JwtSignedSecret
 }
@@ -0,0 +1,151 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+	"path"
+	"strings"
+)
+
+const (
+	ThumbnailSmall  = "small"
+	ThumbnailMedium = "medium"
+
+	// MaxImagePixels the decoded image is held in memory, 4096x4096 takes 64MB
+	MaxImagePixels = 4096 * 4096
+
+	thumbnailQuality = 85
+)
+
+// thumbnailSizes the thumbnails fit in a square of the size, the smaller images aren't upscaled
+var thumbnailSizes = []struct {
+	name string
+	size int
+}{
+	{ThumbnailSmall, 160},
+	{ThumbnailMedium, 480},
+}
+
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+}
+
+var UnsupportedImageErr = errors.New("the image must be jpeg or png")
+
+var ImageTooLargeErr = errors.Errorf("the image exceeds %d pixels", MaxImagePixels)
+
+// DetectImageType the content type sniffed from the content, the one sent by the client isn't trusted
+func DetectImageType(content []byte) (string, error) {
+	contentType := http.DetectContentType(content)
+	if _, ok := imageExtensions[contentType]; !ok {
+		return "", UnsupportedImageErr
+	}
+	return contentType, nil
+}
+
+// UploadImage stores the image under dir with its thumbnails, and returns the key with the urls
+func UploadImage(ctx context.Context, dir string, content []byte) (*Image, error) {
+	if store == nil {
+		return nil, ClientUnInitErr
+	}
+
+	contentType, err := DetectImageType(content)
+	if err != nil {
+		return nil, err
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, UnsupportedImageErr
+	}
+	if config.Width*config.Height > MaxImagePixels {
+		return nil, ImageTooLargeErr
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, UnsupportedImageErr
+	}
+
+	key := path.Join(dir, uuid.NewString()+imageExtensions[contentType])
+	if err = store.Put(ctx, key, contentType, content); err != nil {
+		return nil, errors.Wrap(err, ">>UploadImage, put image fail")
+	}
+
+	flat := flatten(src)
+	for _, thumbnail := range thumbnailSizes {
+		var buf bytes.Buffer
+		if err = jpeg.Encode(&buf, fit(flat, thumbnail.size), &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+			return nil, errors.Wrap(err, ">>UploadImage, encode thumbnail fail")
+		}
+		if err = store.Put(ctx, ThumbnailKey(key, thumbnail.name), "image/jpeg", buf.Bytes()); err != nil {
+			return nil, errors.Wrap(err, ">>UploadImage, put thumbnail fail")
+		}
+	}
+
+	return ImageOf(key), nil
+}
+
+// ThumbnailKey the thumbnails are jpeg next to the image, x.png has x_small.jpg
+func ThumbnailKey(key, name string) string {
+	return strings.TrimSuffix(key, path.Ext(key)) + "_" + name + ".jpg"
+}
+
+// flatten draws the image on white, jpeg has no transparency
+func flatten(src image.Image) *image.RGBA {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), src, bounds.Min, draw.Over)
+	return dst
+}
+
+// fit scales the image down to fit in size x size, each pixel averages the source pixels it covers
+func fit(src *image.RGBA, size int) *image.RGBA {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := w, h
+	if w > size || h > size {
+		if w >= h {
+			dw, dh = size, max(1, h*size/w)
+		} else {
+			dw, dh = max(1, w*size/h), size
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		sy0, sy1 := y*h/dh, max((y+1)*h/dh, y*h/dh+1)
+		for x := 0; x < dw; x++ {
+			sx0, sx1 := x*w/dw, max((x+1)*w/dw, x*w/dw+1)
+
+			var r, g, b, a, n int
+			for sy := sy0; sy < sy1; sy++ {
+				offset := src.PixOffset(sx0, sy)
+				for sx := sx0; sx < sx1; sx++ {
+					r += int(src.Pix[offset])
+					g += int(src.Pix[offset+1])
+					b += int(src.Pix[offset+2])
+					a += int(src.Pix[offset+3])
+					offset += 4
+					n++
+				}
+			}
+
+			offset := dst.PixOffset(x, y)
+			dst.Pix[offset] = uint8(r / n)
+			dst.Pix[offset+1] = uint8(g / n)
+			dst.Pix[offset+2] = uint8(b / n)
+			dst.Pix[offset+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
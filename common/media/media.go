@@ -0,0 +1,132 @@
+package media
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/pkg/errors"
+	"github.com/tespkg/bytes-be/internal/storage"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ClientUnInitErr = errors.New("storage not init")
+
+var SignatureInvalidErr = errors.New("invalid or expired file signature")
+
+// maxCacheAge a signed file is cached one day at most
+const maxCacheAge = 24 * time.Hour
+
+type Options struct {
+	// PublicBaseUrl serves the objects directly, e.g. a public bucket behind a CDN, empty signs the urls
+	PublicBaseUrl string
+	// SignBaseUrl the file endpoint of this service serving the signed urls
+	SignBaseUrl     string
+	SignSecret      string
+	SignedUrlExpire time.Duration
+}
+
+var DefaultOptions = Options{
+	SignedUrlExpire: 24 * time.Hour,
+}
+
+var options = DefaultOptions
+
+var store storage.Storage
+
+// SetUp sets the storage and overrides the default options, zero values keep the defaults
+func SetUp(s storage.Storage, opts Options) {
+	store = s
+	options.PublicBaseUrl = strings.TrimRight(opts.PublicBaseUrl, "/")
+	options.SignBaseUrl = strings.TrimRight(opts.SignBaseUrl, "/")
+	options.SignSecret = opts.SignSecret
+	if opts.SignedUrlExpire > 0 {
+		options.SignedUrlExpire = opts.SignedUrlExpire
+	}
+}
+
+// Image the urls of a stored image, an absolute url stored before the uploads is returned as is
+type Image struct {
+	Key        string
+	Url        string
+	Thumbnails map[string]string
+}
+
+func ImageOf(key string) *Image {
+	image := &Image{Key: key, Thumbnails: make(map[string]string, len(thumbnailSizes))}
+	if IsExternal(key) {
+		image.Url = key
+		return image
+	}
+
+	image.Url = URL(key)
+	for _, thumbnail := range thumbnailSizes {
+		image.Thumbnails[thumbnail.name] = URL(ThumbnailKey(key, thumbnail.name))
+	}
+	return image
+}
+
+// IsExternal an absolute url, not a key of the storage
+func IsExternal(key string) bool {
+	return strings.HasPrefix(key, "http://") || strings.HasPrefix(key, "https://")
+}
+
+// URL the public url of the key, or a signed url of the file endpoint.
+// The expiry is aligned on the expire window, so the url stays the same and cacheable within a window.
+func URL(key string) string {
+	if options.PublicBaseUrl != "" {
+		return options.PublicBaseUrl + "/" + escapeKey(key)
+	}
+
+	window := int64(options.SignedUrlExpire / time.Second)
+	expires := (time.Now().Unix()/window + 2) * window
+	return options.SignBaseUrl + "/" + escapeKey(key) +
+		"?expires=" + strconv.FormatInt(expires, 10) + "&signature=" + sign(key, expires)
+}
+
+// VerifySignature checks the signed url parameters of the file endpoint
+func VerifySignature(key, expires, signature string) error {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || expiresAt < time.Now().Unix() {
+		return SignatureInvalidErr
+	}
+	if !hmac.Equal([]byte(sign(key, expiresAt)), []byte(signature)) {
+		return SignatureInvalidErr
+	}
+	return nil
+}
+
+// CacheMaxAge how long the file of a verified signed url may be cached, never past the expiry of the url
+func CacheMaxAge(expires string) time.Duration {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return 0
+	}
+	left := time.Until(time.Unix(expiresAt, 0)).Truncate(time.Second)
+	return max(min(left, maxCacheAge), 0)
+}
+
+// Open the object body must be closed by the caller
+func Open(ctx context.Context, key string) (*storage.Object, error) {
+	if store == nil {
+		return nil, ClientUnInitErr
+	}
+	return store.Get(ctx, key)
+}
+
+func sign(key string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(options.SignSecret))
+	mac.Write([]byte(key + "\n" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func escapeKey(key string) string {
+	parts := strings.Split(key, "/")
+	for i := range parts {
+		parts[i] = url.PathEscape(parts[i])
+	}
+	return strings.Join(parts, "/")
+}
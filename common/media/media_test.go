@@ -0,0 +1,116 @@
+package media
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setUpSigning(t *testing.T) {
+	t.Helper()
+
+	previous := options
+	SetUp(nil, Options{SignBaseUrl: "https://bytes.test/api/v1/files/", SignSecret: "secret", SignedUrlExpire: time.Hour})
+	t.Cleanup(func() {
+		options = previous
+	})
+}
+
+// signedQuery the key, expires and signature of a signed url
+func signedQuery(t *testing.T, signed string) (string, string, string) {
+	t.Helper()
+
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatalf("parse %s: %v", signed, err)
+	}
+	key, err := url.PathUnescape(strings.TrimPrefix(u.EscapedPath(), "/api/v1/files/"))
+	if err != nil {
+		t.Fatalf("unescape %s: %v", u.EscapedPath(), err)
+	}
+	return key, u.Query().Get("expires"), u.Query().Get("signature")
+}
+
+func TestURLIsSigned(t *testing.T) {
+	setUpSigning(t)
+	key := "merchants/1/images/a b.jpg"
+
+	signed := URL(key)
+	if !strings.HasPrefix(signed, "https://bytes.test/api/v1/files/merchants/1/images/a%20b.jpg?") {
+		t.Fatalf("unexpected signed url %s", signed)
+	}
+	if again := URL(key); again != signed {
+		t.Fatalf("got %s then %s within a window", signed, again)
+	}
+
+	gotKey, expires, signature := signedQuery(t, signed)
+	if gotKey != key {
+		t.Fatalf("got key %s, want %s", gotKey, key)
+	}
+	expiresAt, _ := strconv.ParseInt(expires, 10, 64)
+	if left := time.Until(time.Unix(expiresAt, 0)); left <= time.Hour || left > 2*time.Hour {
+		t.Fatalf("the url expires in %s, want within the next window", left)
+	}
+
+	past := strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)
+	cases := []struct {
+		name      string
+		key       string
+		expires   string
+		signature string
+		valid     bool
+	}{
+		{"signed url", key, expires, signature, true},
+		{"another key", "merchants/2/images/a b.jpg", expires, signature, false},
+		{"extended expiry", key, strconv.FormatInt(expiresAt+3600, 10), signature, false},
+		{"expired", key, past, sign(key, time.Now().Add(-time.Minute).Unix()), false},
+		{"no expiry", key, "", signature, false},
+		{"no signature", key, expires, "", false},
+	}
+	for _, c := range cases {
+		err := VerifySignature(c.key, c.expires, c.signature)
+		if c.valid && err != nil {
+			t.Fatalf("%s: got %v", c.name, err)
+		}
+		if !c.valid && err != SignatureInvalidErr {
+			t.Fatalf("%s: got %v, want %v", c.name, err, SignatureInvalidErr)
+		}
+	}
+}
+
+func TestPublicURLIsNotSigned(t *testing.T) {
+	previous := options
+	SetUp(nil, Options{PublicBaseUrl: "https://cdn.bytes.test/"})
+	t.Cleanup(func() {
+		options = previous
+	})
+
+	if got := URL("merchants/1/logo.png"); got != "https://cdn.bytes.test/merchants/1/logo.png" {
+		t.Fatalf("got %s", got)
+	}
+	if image := ImageOf("https://example.com/logo.png"); image.Url != "https://example.com/logo.png" || len(image.Thumbnails) != 0 {
+		t.Fatalf("got %+v for an external image", image)
+	}
+}
+
+func TestCacheMaxAge(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		name    string
+		expires string
+		min     time.Duration
+		max     time.Duration
+	}{
+		{"expires in a minute", strconv.FormatInt(now.Add(time.Minute).Unix(), 10), 58 * time.Second, time.Minute},
+		{"expires in two days", strconv.FormatInt(now.Add(48*time.Hour).Unix(), 10), maxCacheAge, maxCacheAge},
+		{"expired", strconv.FormatInt(now.Add(-time.Minute).Unix(), 10), 0, 0},
+		{"invalid", "soon", 0, 0},
+	}
+	for _, c := range cases {
+		if got := CacheMaxAge(c.expires); got < c.min || got > c.max {
+			t.Fatalf("%s: got %s, want between %s and %s", c.name, got, c.min, c.max)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+// Package openhours evaluates the weekly opening hours of a store with the special days and pauses
+package openhours
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	DateLayout = "2006-01-02"
+	TimeLayout = "15:04"
+
+	minutesPerDay = 24 * 60
+	// lookAheadDays NextOpening gives up after this many days without any shift
+	lookAheadDays = 14
+)
+
+// Shift opens and closes in minutes of the day, a close not after the open runs past midnight into the next day
+type Shift struct {
+	Open  int
+	Close int
+}
+
+// SpecialDay replaces the weekly shifts of the date, closed or with its own shifts
+type SpecialDay struct {
+	Closed bool
+	Shifts []Shift
+}
+
+type Schedule struct {
+	Location *time.Location
+	Weekly   map[time.Weekday][]Shift
+	// Special the key is the local date YYYY-MM-DD
+	Special map[string]SpecialDay
+	// PausedUntil the store is busy and closed until then
+	PausedUntil *time.Time
+}
+
+func NewSchedule(timezone string) (*Schedule, error) {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, errors.Wrapf(err, ">>NewSchedule, unknown timezone %s", timezone)
+	}
+
+	return &Schedule{
+		Location: location,
+		Weekly:   make(map[time.Weekday][]Shift),
+		Special:  make(map[string]SpecialDay),
+	}, nil
+}
+
+// ParseShift parses HH:MM times, 24:00 is accepted as the close of the day
+func ParseShift(open, close string) (Shift, error) {
+	openMinute, err := parseMinute(open)
+	if err != nil {
+		return Shift{}, err
+	}
+	closeMinute, err := parseMinute(close)
+	if err != nil {
+		return Shift{}, err
+	}
+	if openMinute == minutesPerDay {
+		return Shift{}, errors.Errorf("open time %s is out of the day", open)
+	}
+	if openMinute == closeMinute {
+		return Shift{}, errors.Errorf("open and close time are both %s", open)
+	}
+	return Shift{Open: openMinute, Close: closeMinute}, nil
+}
+
+func parseMinute(value string) (int, error) {
+	if value == "24:00" {
+		return minutesPerDay, nil
+	}
+	t, err := time.Parse(TimeLayout, value)
+	if err != nil {
+		return 0, errors.Errorf("time %s must be in HH:MM format", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func (s Shift) overnight() bool {
+	return s.Close <= s.Open
+}
+
+func (s Shift) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", s.Open/60, s.Open%60, s.Close/60, s.Close%60)
+}
+
+// ValidateShifts the shifts of a day must not overlap, including an overnight shift into the next day
+func ValidateShifts(shifts []Shift) error {
+	sorted := append([]Shift(nil), shifts...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Open < sorted[j].Open })
+
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i-1].overnight() || sorted[i].Open < sorted[i-1].Close {
+			return errors.Errorf("shift %s overlaps %s", sorted[i], sorted[i-1])
+		}
+	}
+	return nil
+}
+
+// ShiftsOn the shifts starting on the local date, special days win over the weekly hours
+func (s *Schedule) ShiftsOn(date time.Time) []Shift {
+	if special, ok := s.Special[date.Format(DateLayout)]; ok {
+		if special.Closed {
+			return nil
+		}
+		return special.Shifts
+	}
+	return s.Weekly[date.Weekday()]
+}
+
+// IsOpenAt reports whether the store takes orders at the time, a pause closes it whatever the hours
+func (s *Schedule) IsOpenAt(t time.Time) bool {
+	if s.PausedUntil != nil && t.Before(*s.PausedUntil) {
+		return false
+	}
+	return s.inShift(t)
+}
+
+func (s *Schedule) inShift(t time.Time) bool {
+	local := t.In(s.Location)
+	today := startOfDay(local)
+	minute := local.Hour()*60 + local.Minute()
+
+	for _, shift := range s.ShiftsOn(today) {
+		if minute >= shift.Open && (shift.overnight() || minute < shift.Close) {
+			return true
+		}
+	}
+
+	//an overnight shift of yesterday runs into today
+	for _, shift := range s.ShiftsOn(today.AddDate(0, 0, -1)) {
+		if shift.overnight() && minute < shift.Close {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOpening the time the store is open at or after t, false if it has no shift in the coming two weeks
+func (s *Schedule) NextOpening(t time.Time) (time.Time, bool) {
+	from := t
+	if s.PausedUntil != nil && from.Before(*s.PausedUntil) {
+		from = *s.PausedUntil
+	}
+	if s.inShift(from) {
+		return from, true
+	}
+
+	day := startOfDay(from.In(s.Location))
+	for i := 0; i <= lookAheadDays; i++ {
+		shifts := append([]Shift(nil), s.ShiftsOn(day)...)
+		sort.Slice(shifts, func(i, j int) bool { return shifts[i].Open < shifts[j].Open })
+
+		for _, shift := range shifts {
+			opening := day.Add(time.Duration(shift.Open) * time.Minute)
+			if opening.After(from) {
+				return opening, true
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+
+	return time.Time{}, false
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,216 @@
+package openhours
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func mustShift(t *testing.T, open, close string) Shift {
+	t.Helper()
+
+	shift, err := ParseShift(open, close)
+	if err != nil {
+		t.Fatalf("parse shift %s-%s: %v", open, close, err)
+	}
+	return shift
+}
+
+func mustSchedule(t *testing.T, timezone string) *Schedule {
+	t.Helper()
+
+	schedule, err := NewSchedule(timezone)
+	if err != nil {
+		t.Fatalf("new schedule: %v", err)
+	}
+	return schedule
+}
+
+// at the local time of the schedule, 2026-10-19 is a monday
+func at(schedule *Schedule, day, hour, minute int) time.Time {
+	return time.Date(2026, 10, day, hour, minute, 0, 0, schedule.Location)
+}
+
+func TestParseShift(t *testing.T) {
+	if shift := mustShift(t, "09:30", "24:00"); shift.Open != 570 || shift.Close != minutesPerDay {
+		t.Fatalf("unexpected shift %+v", shift)
+	}
+	for _, c := range [][2]string{{"24:00", "02:00"}, {"10:00", "10:00"}, {"9am", "17:00"}, {"10:00", "25:00"}} {
+		if _, err := ParseShift(c[0], c[1]); err == nil {
+			t.Fatalf("parse shift %s-%s: want an error", c[0], c[1])
+		}
+	}
+}
+
+func TestValidateShifts(t *testing.T) {
+	if err := ValidateShifts([]Shift{mustShift(t, "18:00", "23:00"), mustShift(t, "08:00", "12:00")}); err != nil {
+		t.Fatalf("validate shifts: %v", err)
+	}
+	if err := ValidateShifts([]Shift{mustShift(t, "08:00", "12:00"), mustShift(t, "11:00", "14:00")}); err == nil {
+		t.Fatal("validate shifts: want an overlap error")
+	}
+	// nothing can follow a shift running past midnight on the same day
+	if err := ValidateShifts([]Shift{mustShift(t, "08:00", "02:00"), mustShift(t, "20:00", "23:00")}); err == nil {
+		t.Fatal("validate shifts: want an overlap error for the overnight shift")
+	}
+}
+
+func TestIsOpenAtWithShifts(t *testing.T) {
+	schedule := mustSchedule(t, "Asia/Muscat")
+	schedule.Weekly[time.Monday] = []Shift{mustShift(t, "08:00", "12:00"), mustShift(t, "18:00", "23:00")}
+
+	cases := []struct {
+		hour, minute int
+		open         bool
+	}{
+		{7, 59, false},
+		{8, 0, true},
+		{11, 59, true},
+		{12, 0, false},
+		{15, 0, false},
+		{18, 0, true},
+		{23, 0, false},
+	}
+	for _, c := range cases {
+		if got := schedule.IsOpenAt(at(schedule, 19, c.hour, c.minute)); got != c.open {
+			t.Fatalf("is open at %02d:%02d: got %t, want %t", c.hour, c.minute, got, c.open)
+		}
+	}
+	if schedule.IsOpenAt(at(schedule, 20, 9, 0)) {
+		t.Fatal("is open on tuesday without shifts")
+	}
+}
+
+func TestIsOpenAtOvernightShift(t *testing.T) {
+	schedule := mustSchedule(t, "Asia/Muscat")
+	schedule.Weekly[time.Friday] = []Shift{mustShift(t, "20:00", "02:00")}
+
+	// friday is 2026-10-23, the shift runs into saturday
+	cases := []struct {
+		day, hour int
+		open      bool
+	}{
+		{23, 19, false},
+		{23, 20, true},
+		{23, 23, true},
+		{24, 0, true},
+		{24, 1, true},
+		{24, 2, false},
+		{24, 20, false},
+	}
+	for _, c := range cases {
+		if got := schedule.IsOpenAt(at(schedule, c.day, c.hour, 0)); got != c.open {
+			t.Fatalf("is open on %d at %02d:00: got %t, want %t", c.day, c.hour, got, c.open)
+		}
+	}
+
+	// a closed friday also ends the night on saturday
+	schedule.Special["2026-10-23"] = SpecialDay{Closed: true}
+	if schedule.IsOpenAt(at(schedule, 24, 1, 0)) {
+		t.Fatal("is open after a closed friday")
+	}
+}
+
+func TestSpecialDayOverridesWeeklyHours(t *testing.T) {
+	schedule := mustSchedule(t, "Asia/Muscat")
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		schedule.Weekly[day] = []Shift{mustShift(t, "09:00", "17:00")}
+	}
+	schedule.Special["2026-10-19"] = SpecialDay{Closed: true}
+	schedule.Special["2026-10-20"] = SpecialDay{Shifts: []Shift{mustShift(t, "12:00", "14:00")}}
+
+	if schedule.IsOpenAt(at(schedule, 19, 10, 0)) {
+		t.Fatal("is open on the closed day")
+	}
+	if schedule.IsOpenAt(at(schedule, 20, 10, 0)) {
+		t.Fatal("is open out of the special hours")
+	}
+	if !schedule.IsOpenAt(at(schedule, 20, 13, 0)) {
+		t.Fatal("is closed within the special hours")
+	}
+	if !schedule.IsOpenAt(at(schedule, 21, 10, 0)) {
+		t.Fatal("is closed within the weekly hours after the special days")
+	}
+}
+
+func TestPause(t *testing.T) {
+	schedule := mustSchedule(t, "Asia/Muscat")
+	schedule.Weekly[time.Monday] = []Shift{mustShift(t, "08:00", "22:00")}
+	pausedUntil := at(schedule, 19, 12, 30)
+	schedule.PausedUntil = &pausedUntil
+
+	if schedule.IsOpenAt(at(schedule, 19, 12, 0)) {
+		t.Fatal("is open while paused")
+	}
+	if !schedule.IsOpenAt(pausedUntil) {
+		t.Fatal("is closed when the pause ends")
+	}
+	if next, ok := schedule.NextOpening(at(schedule, 19, 12, 0)); !ok || !next.Equal(pausedUntil) {
+		t.Fatalf("next opening: got %s %t, want the end of the pause %s", next, ok, pausedUntil)
+	}
+
+	// a pause ending after the hours opens on the next shift
+	pausedUntil = at(schedule, 19, 23, 0)
+	if next, ok := schedule.NextOpening(at(schedule, 19, 12, 0)); !ok || !next.Equal(at(schedule, 26, 8, 0)) {
+		t.Fatalf("next opening: got %s %t, want next monday 08:00", next, ok)
+	}
+}
+
+func TestTimezone(t *testing.T) {
+	muscat := mustSchedule(t, "Asia/Muscat")
+	muscat.Weekly[time.Monday] = []Shift{mustShift(t, "09:00", "17:00")}
+	london := mustSchedule(t, "Europe/London")
+	london.Weekly[time.Monday] = []Shift{mustShift(t, "09:00", "17:00")}
+
+	// 06:00 UTC is 10:00 in Muscat and 07:00 in London, which is on summer time until 2026-10-25
+	instant := time.Date(2026, 10, 19, 6, 0, 0, 0, time.UTC)
+	if !muscat.IsOpenAt(instant) {
+		t.Fatal("muscat is closed at 10:00 local time")
+	}
+	if london.IsOpenAt(instant) {
+		t.Fatal("london is open at 07:00 local time")
+	}
+
+	// the special day is the local date of the store, 22:00 UTC on sunday is already monday in Muscat
+	muscat.Special["2026-10-19"] = SpecialDay{Shifts: []Shift{mustShift(t, "00:00", "04:00")}}
+	if !muscat.IsOpenAt(time.Date(2026, 10, 18, 22, 0, 0, 0, time.UTC)) {
+		t.Fatal("muscat is closed at 02:00 local time of the special day")
+	}
+
+	if _, err := NewSchedule("Mars/Olympus"); err == nil {
+		t.Fatal("new schedule: want an error for an unknown timezone")
+	}
+}
+
+func TestNextOpening(t *testing.T) {
+	schedule := mustSchedule(t, "Asia/Muscat")
+	schedule.Weekly[time.Monday] = []Shift{mustShift(t, "18:00", "23:00"), mustShift(t, "08:00", "12:00")}
+	schedule.Weekly[time.Wednesday] = []Shift{mustShift(t, "10:00", "14:00")}
+
+	cases := []struct {
+		name string
+		from time.Time
+		want time.Time
+	}{
+		{"open now", at(schedule, 19, 9, 0), at(schedule, 19, 9, 0)},
+		{"before the first shift", at(schedule, 19, 6, 0), at(schedule, 19, 8, 0)},
+		{"between the shifts", at(schedule, 19, 13, 0), at(schedule, 19, 18, 0)},
+		{"after the last shift", at(schedule, 19, 23, 30), at(schedule, 21, 10, 0)},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if next, ok := schedule.NextOpening(c.from); !ok || !next.Equal(c.want) {
+				t.Fatalf("next opening: got %s %t, want %s", next, ok, c.want)
+			}
+		})
+	}
+
+	schedule.Special["2026-10-21"] = SpecialDay{Closed: true}
+	if next, ok := schedule.NextOpening(at(schedule, 19, 23, 30)); !ok || !next.Equal(at(schedule, 26, 8, 0)) {
+		t.Fatalf("next opening after the closed wednesday: got %s %t", next, ok)
+	}
+
+	if _, ok := mustSchedule(t, "Asia/Muscat").NextOpening(at(schedule, 19, 9, 0)); ok {
+		t.Fatal("next opening: want none without any shift")
+	}
+}
@@ -0,0 +1,199 @@
+package semantic
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/tespkg/bytes-be/internal/ingredient"
+	"math"
+	"sort"
+	"sync"
+)
+
+var ClientUnInitErr = errors.New("embedding client not init")
+
+var analysis ingredient.Analysis
+
+var index = NewIndex()
+
+// SetUp sets the analysis producing the embeddings, nil disables the semantic search
+func SetUp(a ingredient.Analysis) {
+	analysis = a
+}
+
+func Enabled() bool {
+	return analysis != nil
+}
+
+// Model the embedding model of the ingredient analysis, the vectors of another model aren't comparable
+func Model() string {
+	if analysis == nil {
+		return ""
+	}
+	return analysis.EmbeddingModel()
+}
+
+// Embed the vector by text, a text the api failed to embed is missing.
+// trim drops the parenthesized parts of the menu names before embedding
+func Embed(ctx context.Context, texts []string, trim bool) (map[string][]float32, error) {
+	if analysis == nil {
+		return nil, ClientUnInitErr
+	}
+
+	raw, err := analysis.Embedding(ctx, texts, trim)
+	if err != nil {
+		return nil, errors.Wrap(err, "analysis.Embedding fail")
+	}
+
+	vectors := make(map[string][]float32, len(raw))
+	for text, embedding := range raw {
+		var vector []float32
+		if err = json.Unmarshal([]byte(embedding), &vector); err != nil {
+			return nil, errors.Wrap(err, "json.Unmarshal embedding fail")
+		}
+		vectors[text] = vector
+	}
+	return vectors, nil
+}
+
+// Default the index shared by the server
+func Default() *Index {
+	return index
+}
+
+type Hit struct {
+	Id         int64
+	MerchantId int64
+	Score      float32 // cosine similarity
+}
+
+type entry struct {
+	merchantId int64
+	vector     []float32 // unit length
+}
+
+// Index an in-memory exact nearest neighbour index by cosine similarity
+type Index struct {
+	mu      sync.RWMutex
+	entries map[int64]entry
+}
+
+func NewIndex() *Index {
+	return &Index{entries: make(map[int64]entry)}
+}
+
+// Put adds or replaces the vector of the id, a zero vector is ignored
+func (i *Index) Put(id, merchantId int64, vector []float32) {
+	unit := normalize(vector)
+	if unit == nil {
+		return
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.entries[id] = entry{merchantId: merchantId, vector: unit}
+}
+
+func (i *Index) Remove(id int64) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	delete(i.entries, id)
+}
+
+func (i *Index) Len() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return len(i.entries)
+}
+
+// Search the limit nearest vectors to the query, merchantIds empty means all the merchants.
+// The hits are ordered by score then id descending
+func (i *Index) Search(query []float32, limit int, merchantIds []int64) []Hit {
+	unit := normalize(query)
+	if unit == nil || limit <= 0 {
+		return nil
+	}
+
+	var merchants map[int64]bool
+	if len(merchantIds) > 0 {
+		merchants = make(map[int64]bool, len(merchantIds))
+		for _, id := range merchantIds {
+			merchants[id] = true
+		}
+	}
+
+	i.mu.RLock()
+	hits := make([]Hit, 0, len(i.entries))
+	for id, e := range i.entries {
+		if merchants != nil && !merchants[e.merchantId] {
+			continue
+		}
+		if score, ok := dot(unit, e.vector); ok {
+			hits = append(hits, Hit{Id: id, MerchantId: e.merchantId, Score: score})
+		}
+	}
+	i.mu.RUnlock()
+
+	sort.Slice(hits, func(a, b int) bool {
+		if hits[a].Score != hits[b].Score {
+			return hits[a].Score > hits[b].Score
+		}
+		return hits[a].Id > hits[b].Id
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// Similarity the cosine similarity of the query to the indexed ids, the ids not indexed are missing
+func (i *Index) Similarity(query []float32, ids []int64) map[int64]float32 {
+	scores := make(map[int64]float32, len(ids))
+	unit := normalize(query)
+	if unit == nil {
+		return scores
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	for _, id := range ids {
+		e, ok := i.entries[id]
+		if !ok {
+			continue
+		}
+		if score, ok := dot(unit, e.vector); ok {
+			scores[id] = score
+		}
+	}
+	return scores
+}
+
+func normalize(vector []float32) []float32 {
+	var sum float64
+	for _, v := range vector {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return nil
+	}
+
+	norm := math.Sqrt(sum)
+	unit := make([]float32, len(vector))
+	for i, v := range vector {
+		unit[i] = float32(float64(v) / norm)
+	}
+	return unit
+}
+
+// dot false if the dimensions differ
+func dot(a, b []float32) (float32, bool) {
+	if len(a) != len(b) {
+		return 0, false
+	}
+
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum, true
+}
@@ -0,0 +1,84 @@
+package semantic
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func hitIds(hits []Hit) []int64 {
+	var ids []int64
+	for _, hit := range hits {
+		ids = append(ids, hit.Id)
+	}
+	return ids
+}
+
+func TestIndexSearch(t *testing.T) {
+	index := NewIndex()
+	index.Put(1, 10, []float32{1, 0})
+	index.Put(2, 10, []float32{3, 4})
+	index.Put(3, 20, []float32{0, 2})
+	index.Put(4, 20, []float32{2, 0}) // the same direction as 1
+	index.Put(5, 20, []float32{0, 0}) // ignored
+	index.Put(6, 30, []float32{1, 0, 0})
+
+	cases := []struct {
+		name        string
+		query       []float32
+		limit       int
+		merchantIds []int64
+		ids         []int64
+	}{
+		{"ranked by similarity then id", []float32{5, 0}, 10, nil, []int64{4, 1, 2, 3}},
+		{"limited", []float32{1, 0}, 2, nil, []int64{4, 1}},
+		{"by merchant", []float32{1, 0}, 10, []int64{20}, []int64{4, 3}},
+		{"other dimensions", []float32{1, 0, 0}, 10, nil, []int64{6}},
+		{"zero query", []float32{0, 0}, 10, nil, nil},
+		{"zero limit", []float32{1, 0}, 0, nil, nil},
+	}
+	for _, c := range cases {
+		if got := hitIds(index.Search(c.query, c.limit, c.merchantIds)); !reflect.DeepEqual(got, c.ids) {
+			t.Fatalf("%s: got %v, want %v", c.name, got, c.ids)
+		}
+	}
+
+	hits := index.Search([]float32{0, 1}, 1, nil)
+	if len(hits) != 1 || hits[0].Id != 3 || hits[0].MerchantId != 20 || math.Abs(float64(hits[0].Score)-1) > 1e-6 {
+		t.Fatalf("unexpected hits %+v", hits)
+	}
+}
+
+func TestIndexPutAndRemove(t *testing.T) {
+	index := NewIndex()
+	index.Put(1, 10, []float32{1, 0})
+	index.Put(1, 20, []float32{0, 1})
+	index.Put(2, 10, []float32{0, 0})
+	if index.Len() != 1 {
+		t.Fatalf("got %d entries, want 1", index.Len())
+	}
+	if hits := index.Search([]float32{0, 1}, 1, []int64{20}); len(hits) != 1 || hits[0].Id != 1 {
+		t.Fatalf("got %+v, want the replaced vector", hits)
+	}
+
+	index.Remove(1)
+	index.Remove(3)
+	if index.Len() != 0 {
+		t.Fatalf("got %d entries, want none", index.Len())
+	}
+}
+
+func TestIndexSimilarity(t *testing.T) {
+	index := NewIndex()
+	index.Put(1, 10, []float32{1, 0})
+	index.Put(2, 10, []float32{1, 1})
+	index.Put(3, 10, []float32{1, 0, 0})
+
+	scores := index.Similarity([]float32{2, 0}, []int64{1, 2, 3, 4})
+	if len(scores) != 2 || math.Abs(float64(scores[1])-1) > 1e-6 || math.Abs(float64(scores[2])-math.Sqrt2/2) > 1e-6 {
+		t.Fatalf("unexpected scores %v", scores)
+	}
+	if scores = index.Similarity([]float32{0, 0}, []int64{1}); len(scores) != 0 {
+		t.Fatalf("got %v for a zero query, want none", scores)
+	}
+}
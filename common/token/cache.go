@@ -67,6 +67,9 @@ func GetUserSecret(dto *GenTokenDto) (string, error) {
 
 	if !global.GlobalClientSets.EnableSingleLogin {
 		if user == nil || user.Id == 0 {
+			if global.GlobalClientSets.JwtSignedSecret != "" {
+				return global.GlobalClientSets.JwtSignedSecret, nil
+			}
 			return jwtSecret, nil
 		}
 
@@ -0,0 +1,191 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/pkg/errors"
+)
+
+var KeyNotFoundError = errors.New("signing key not found")
+
+// KeyFile a PEM encoded RSA or Ed25519 key, keys with only a public key file can verify but not sign
+type KeyFile struct {
+	Kid            string
+	PrivateKeyFile string
+	PublicKeyFile  string
+}
+
+type signingKey struct {
+	kid     string
+	method  jwt.SigningMethod
+	private crypto.PrivateKey
+	public  crypto.PublicKey
+}
+
+// KeySet holds the active signing key and the previous keys which are still accepted,
+// so tokens signed before a rotation stay valid until they expire
+type KeySet struct {
+	active *signingKey
+	keys   map[string]*signingKey
+}
+
+var keySet *KeySet
+
+// SetUpKeySet switches token signing from the legacy HS256 user secret to the asymmetric keys
+func SetUpKeySet(ks *KeySet) {
+	keySet = ks
+}
+
+// LoadKeySet loads the key files, the first one is the active key and must have a private key
+func LoadKeySet(files []KeyFile) (*KeySet, error) {
+	if len(files) == 0 {
+		return nil, errors.New(">>LoadKeySet, no key configured")
+	}
+
+	ks := &KeySet{keys: make(map[string]*signingKey)}
+	for idx, file := range files {
+		if file.Kid == "" {
+			return nil, errors.Errorf(">>LoadKeySet, kid of key %d is empty", idx)
+		}
+		if _, ok := ks.keys[file.Kid]; ok {
+			return nil, errors.Errorf(">>LoadKeySet, duplicated kid %s", file.Kid)
+		}
+
+		key, err := loadKey(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, ">>LoadKeySet, load key %s fail", file.Kid)
+		}
+		if idx == 0 && key.private == nil {
+			return nil, errors.Errorf(">>LoadKeySet, active key %s has no private key", file.Kid)
+		}
+
+		ks.keys[file.Kid] = key
+		if idx == 0 {
+			ks.active = key
+		}
+	}
+
+	return ks, nil
+}
+
+func loadKey(file KeyFile) (*signingKey, error) {
+	key := &signingKey{kid: file.Kid}
+
+	if file.PrivateKeyFile != "" {
+		pem, err := os.ReadFile(file.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		if rsaKey, err := jwt.ParseRSAPrivateKeyFromPEM(pem); err == nil {
+			key.method, key.private, key.public = jwt.SigningMethodRS256, rsaKey, &rsaKey.PublicKey
+			return key, nil
+		}
+
+		edKey, err := jwt.ParseEdPrivateKeyFromPEM(pem)
+		if err != nil {
+			return nil, errors.New("the private key is neither RSA nor Ed25519")
+		}
+		key.method, key.private, key.public = jwt.SigningMethodEdDSA, edKey, edKey.(ed25519.PrivateKey).Public()
+		return key, nil
+	}
+
+	if file.PublicKeyFile == "" {
+		return nil, errors.New("private or public key file is required")
+	}
+
+	pem, err := os.ReadFile(file.PublicKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if rsaKey, err := jwt.ParseRSAPublicKeyFromPEM(pem); err == nil {
+		key.method, key.public = jwt.SigningMethodRS256, rsaKey
+		return key, nil
+	}
+
+	edKey, err := jwt.ParseEdPublicKeyFromPEM(pem)
+	if err != nil {
+		return nil, errors.New("the public key is neither RSA nor Ed25519")
+	}
+	key.method, key.public = jwt.SigningMethodEdDSA, edKey
+	return key, nil
+}
+
+func (ks *KeySet) sign(claims UserClaims) (string, error) {
+	token := jwt.NewWithClaims(ks.active.method, claims)
+	token.Header["kid"] = ks.active.kid
+	return token.SignedString(ks.active.private)
+}
+
+// verifyKey finds the public key by the kid header, the algorithm must match the key
+func (ks *KeySet) verifyKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, KeyNotFoundError
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, SignatureInvalidError
+	}
+	return key.public, nil
+}
+
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// JWKS publishes the public keys of the active and previous keys, empty with the legacy HS256 signing
+func JWKS() JSONWebKeySet {
+	jwks := JSONWebKeySet{Keys: []JSONWebKey{}}
+	if keySet == nil {
+		return jwks
+	}
+
+	appendKey := func(key *signingKey) {
+		jwk := JSONWebKey{Kid: key.kid, Use: "sig", Alg: key.method.Alg()}
+		switch public := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			return
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	//sorted by kid, the set is the same in every response
+	kids := make([]string, 0, len(keySet.keys))
+	for kid := range keySet.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+	for _, kid := range kids {
+		appendKey(keySet.keys[kid])
+	}
+
+	return jwks
+}
@@ -0,0 +1,37 @@
+package token
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJWKSIsSortedByKid(t *testing.T) {
+	ks, err := LoadKeySet([]KeyFile{
+		{Kid: "2026-10", PrivateKeyFile: writeTestKey(t)},
+		{Kid: "2026-04", PrivateKeyFile: writeTestKey(t)},
+		{Kid: "2026-07", PrivateKeyFile: writeTestKey(t)},
+	})
+	if err != nil {
+		t.Fatalf("load key set: %v", err)
+	}
+	previous := keySet
+	SetUpKeySet(ks)
+	t.Cleanup(func() {
+		SetUpKeySet(previous)
+	})
+
+	first := JWKS()
+	var kids []string
+	for _, key := range first.Keys {
+		kids = append(kids, key.Kid)
+	}
+	if want := []string{"2026-04", "2026-07", "2026-10"}; !reflect.DeepEqual(kids, want) {
+		t.Fatalf("got kids %v, want %v", kids, want)
+	}
+
+	for i := 0; i < 10; i++ {
+		if again := JWKS(); !reflect.DeepEqual(again, first) {
+			t.Fatalf("got %+v, want the same set %+v", again, first)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package token
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/tespkg/bytes-be/common/global"
+	"github.com/tespkg/bytes-be/svc/staff/model/dao"
+	"gorm.io/gorm"
+	"strconv"
+	"time"
+)
+
+var RefreshTokenInvalidError = errors.New("invalid refresh token")
+var RefreshTokenExpiredError = errors.New("expired refresh token")
+var RefreshTokenReusedError = errors.New("refresh token reused, the session has been revoked")
+
+type TokenPair struct {
+	AccessToken           string
+	AccessTokenExpiresAt  time.Time
+	RefreshToken          string
+	RefreshTokenExpiresAt time.Time
+}
+
+// RefreshTokenExpireDuration refresh tokens live as long as login_token_expire_duration
+func RefreshTokenExpireDuration() time.Duration {
+	return time.Hour * time.Duration(global.GlobalClientSets.TokenExpireDurationHour)
+}
+
+// GenTokenPair issues an access token and an opaque refresh token starting a new family
+func GenTokenPair(dto *GenTokenDto) (*TokenPair, error) {
+	userId, err := strconv.ParseInt(dto.UserId, 10, 64)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>GenTokenPair, userId strconv.ParseInt fail")
+	}
+
+	sessionDto := *dto
+	sessionDto.SessionId = uuid.NewString()
+
+	accessToken, claims, err := genAccessToken(&sessionDto, global.GlobalClientSets.EnableSingleLogin)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>GenTokenPair ")
+	}
+
+	//single login keeps only the new session, otherwise the others could still refresh
+	if global.GlobalClientSets.EnableSingleLogin {
+		if err = revokeUserSessions(context.Background(), dto.Session, userId, sessionDto.SessionId); err != nil {
+			return nil, errors.Wrap(err, ">>GenTokenPair ")
+		}
+	}
+
+	refreshToken, row, err := newRefreshToken(userId, sessionDto.SessionId, claims, &sessionDto)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>GenTokenPair ")
+	}
+	if err = row.Save(dto.Session); err != nil {
+		return nil, errors.Wrap(err, ">>GenTokenPair, refresh token save fail")
+	}
+
+	return &TokenPair{
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  claims.ExpiresAt.Time,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: row.ExpiresAt,
+	}, nil
+}
+
+// RotateRefreshToken exchanges a refresh token for a new pair, the old one can't be used anymore.
+// Presenting an already rotated token means it leaked, the whole family is revoked.
+func RotateRefreshToken(ctx context.Context, session *gorm.DB, refreshToken string) (*TokenPair, error) {
+	now := time.Now()
+
+	tx := session.Begin()
+	if err := tx.Error; err != nil {
+		return nil, errors.Wrap(err, ">>RotateRefreshToken, transaction begin fail")
+	}
+	defer tx.Rollback()
+
+	old, err := dao.GetRefreshTokenByHashForUpdate(tx, hashRefreshToken(refreshToken))
+	if err != nil {
+		return nil, errors.Wrap(err, ">>RotateRefreshToken, dao.GetRefreshTokenByHashForUpdate fail")
+	}
+	if old == nil || old.Id == 0 {
+		return nil, RefreshTokenInvalidError
+	}
+
+	if old.RevokedAt != nil {
+		if err = revokeFamily(ctx, tx, old.FamilyId, now); err != nil {
+			return nil, errors.Wrap(err, ">>RotateRefreshToken ")
+		}
+		if err = tx.Commit().Error; err != nil {
+			return nil, errors.Wrap(err, ">>RotateRefreshToken, transaction commit fail")
+		}
+		return nil, RefreshTokenReusedError
+	}
+
+	if now.After(old.ExpiresAt) {
+		return nil, RefreshTokenExpiredError
+	}
+
+	user, err := dao.GetUserById(tx, old.UserId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>RotateRefreshToken, dao.GetUserById fail")
+	}
+	if user == nil || user.Id == 0 || !user.IsEnabled {
+		if err = revokeFamily(ctx, tx, old.FamilyId, now); err != nil {
+			return nil, errors.Wrap(err, ">>RotateRefreshToken ")
+		}
+		if err = tx.Commit().Error; err != nil {
+			return nil, errors.Wrap(err, ">>RotateRefreshToken, transaction commit fail")
+		}
+		return nil, RefreshTokenInvalidError
+	}
+
+	dto := &GenTokenDto{
+		Session:       session,
+		UserId:        strconv.FormatInt(old.UserId, 10),
+		Platform:      old.Platform,
+		Imei:          old.Imei,
+		ClientVersion: old.ClientVersion,
+		Model:         old.Model,
+		SystemVersion: old.SystemVersion,
+		SessionId:     old.FamilyId,
+		Roles:         user.RoleNames(),
+	}
+
+	//the user secret must not be renewed here, otherwise single login kicks the refreshing device itself
+	accessToken, claims, err := genAccessToken(dto, false)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>RotateRefreshToken ")
+	}
+
+	newRefreshToken, row, err := newRefreshToken(old.UserId, old.FamilyId, claims, dto)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>RotateRefreshToken ")
+	}
+	if err = row.Save(tx); err != nil {
+		return nil, errors.Wrap(err, ">>RotateRefreshToken, refresh token save fail")
+	}
+
+	old.RevokedAt = &now
+	old.ReplacedById = &row.Id
+	old.UpdatedAt = &now
+	if err = old.Save(tx); err != nil {
+		return nil, errors.Wrap(err, ">>RotateRefreshToken, old refresh token save fail")
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		return nil, errors.Wrap(err, ">>RotateRefreshToken, transaction commit fail")
+	}
+
+	return &TokenPair{
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  claims.ExpiresAt.Time,
+		RefreshToken:          newRefreshToken,
+		RefreshTokenExpiresAt: row.ExpiresAt,
+	}, nil
+}
+
+// revokeFamily revokes the refresh tokens of the family and the access tokens issued with them
+func revokeFamily(ctx context.Context, tx *gorm.DB, familyId string, now time.Time) error {
+	revoked, err := dao.RevokeRefreshTokenFamily(tx, familyId, now)
+	if err != nil {
+		return errors.Wrap(err, ">>revokeFamily, dao.RevokeRefreshTokenFamily fail")
+	}
+
+	for _, refreshToken := range revoked {
+		if err = RevokeJti(ctx, refreshToken.AccessJti, AccessTokenExpireDuration()); err != nil {
+			return errors.Wrap(err, ">>revokeFamily ")
+		}
+	}
+
+	return nil
+}
+
+func newRefreshToken(userId int64, familyId string, claims *UserClaims, dto *GenTokenDto) (string, *dao.RefreshToken, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, errors.Wrap(err, ">>newRefreshToken, rand.Read fail")
+	}
+	refreshToken := base64.RawURLEncoding.EncodeToString(buf)
+
+	return refreshToken, &dao.RefreshToken{
+		UserId:        userId,
+		FamilyId:      familyId,
+		TokenHash:     hashRefreshToken(refreshToken),
+		AccessJti:     claims.ID,
+		Platform:      dto.Platform,
+		Imei:          dto.Imei,
+		ClientVersion: dto.ClientVersion,
+		Model:         dto.Model,
+		SystemVersion: dto.SystemVersion,
+		ExpiresAt:     time.Now().Add(RefreshTokenExpireDuration()),
+	}, nil
+}
+
+func hashRefreshToken(refreshToken string) string {
+	sum := sha256.Sum256([]byte(refreshToken))
+	return hex.EncodeToString(sum[:])
+}
+
+func revokedJtiKey(jti string) string {
+	return fmt.Sprintf("%s-revoked-jti-%s", redisKeyPrefix, jti)
+}
+
+// RevokeJti puts an access token into the revocation list, ttl should cover the rest of its lifetime
+func RevokeJti(ctx context.Context, jti string, ttl time.Duration) error {
+	if global.GlobalClientSets.RedisClient == nil {
+		return RedisUnInitErr
+	}
+
+	if err := global.GlobalClientSets.RedisClient.Set(ctx, revokedJtiKey(jti), 1, ttl).Err(); err != nil {
+		return errors.Wrap(err, ">>RevokeJti, redis set fail")
+	}
+	return nil
+}
+
+func IsJtiRevoked(ctx context.Context, jti string) (bool, error) {
+	if global.GlobalClientSets.RedisClient == nil {
+		return false, RedisUnInitErr
+	}
+
+	count, err := global.GlobalClientSets.RedisClient.Exists(ctx, revokedJtiKey(jti)).Result()
+	if err != nil {
+		return false, errors.Wrap(err, ">>IsJtiRevoked, redis exists fail")
+	}
+	return count > 0, nil
+}
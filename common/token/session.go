@@ -0,0 +1,165 @@
+package token
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"github.com/tespkg/bytes-be/common/global"
+	"github.com/tespkg/bytes-be/svc/staff/model/dao"
+	"gorm.io/gorm"
+	"strconv"
+	"time"
+)
+
+var SessionNotFoundError = errors.New("session not found")
+
+// sessionTouchInterval limits the last seen updates to one write per session per interval
+const sessionTouchInterval = 5 * time.Minute
+
+func ListSessions(session *gorm.DB, userId int64) ([]dao.RefreshToken, error) {
+	refreshTokens, err := dao.ListActiveRefreshTokensByUserId(session, userId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>ListSessions, dao.ListActiveRefreshTokensByUserId fail")
+	}
+	return refreshTokens, nil
+}
+
+// TouchSession records the claims' session as seen now, at most once per interval. The database only skips
+// the writes without redis
+func TouchSession(ctx context.Context, session *gorm.DB, claims *UserClaims) error {
+	if claims.SessionId == "" {
+		return nil
+	}
+
+	if client := global.GlobalClientSets.RedisClient; client != nil {
+		due, err := client.SetNX(ctx, sessionTouchKey(claims.SessionId), 1, sessionTouchInterval).Result()
+		if err != nil {
+			return errors.Wrap(err, ">>TouchSession, redis SetNX fail")
+		}
+		if !due {
+			return nil
+		}
+	}
+
+	if err := dao.TouchRefreshTokenFamily(session, claims.SessionId, time.Now(), sessionTouchInterval); err != nil {
+		return errors.Wrap(err, ">>TouchSession, dao.TouchRefreshTokenFamily fail")
+	}
+	return nil
+}
+
+// RevokeSession logs out one session of the user, the access token issued with it is revoked as well
+func RevokeSession(ctx context.Context, session *gorm.DB, userId int64, sessionId string) error {
+	refreshToken, err := dao.GetActiveRefreshTokenByFamilyId(session, sessionId)
+	if err != nil {
+		return errors.Wrap(err, ">>RevokeSession, dao.GetActiveRefreshTokenByFamilyId fail")
+	}
+	if refreshToken == nil || refreshToken.Id == 0 || refreshToken.UserId != userId {
+		return SessionNotFoundError
+	}
+
+	if err = revokeFamily(ctx, session, sessionId, time.Now()); err != nil {
+		return errors.Wrap(err, ">>RevokeSession ")
+	}
+	return nil
+}
+
+// RevokeCurrentSession logs out the session of the claims, tokens without session only revoke themselves
+func RevokeCurrentSession(ctx context.Context, session *gorm.DB, claims *UserClaims) error {
+	if err := RevokeJti(ctx, claims.ID, AccessTokenExpireDuration()); err != nil {
+		return errors.Wrap(err, ">>RevokeCurrentSession ")
+	}
+
+	if claims.SessionId == "" {
+		return nil
+	}
+
+	if err := revokeFamily(ctx, session, claims.SessionId, time.Now()); err != nil {
+		return errors.Wrap(err, ">>RevokeCurrentSession ")
+	}
+	return nil
+}
+
+// RevokeAllSessions logs out every device of the user.
+// The session generation of the user is bumped, so every access token issued before is rejected whatever key
+// signed it, even one issued in the same second. With single login the user secret is renewed too.
+func RevokeAllSessions(ctx context.Context, session *gorm.DB, userId int64) error {
+	if err := revokeUserSessions(ctx, session, userId, ""); err != nil {
+		return errors.Wrap(err, ">>RevokeAllSessions ")
+	}
+
+	if err := bumpSessionGeneration(ctx, strconv.FormatInt(userId, 10)); err != nil {
+		return errors.Wrap(err, ">>RevokeAllSessions ")
+	}
+
+	if global.GlobalClientSets.EnableSingleLogin {
+		if _, err := RefreshUserSecret(&GenTokenDto{Session: session, UserId: strconv.FormatInt(userId, 10)}); err != nil {
+			return errors.Wrap(err, ">>RevokeAllSessions ")
+		}
+	}
+
+	return nil
+}
+
+// revokeUserSessions revokes every session of the user except the kept one
+func revokeUserSessions(ctx context.Context, session *gorm.DB, userId int64, keepSessionId string) error {
+	revoked, err := dao.RevokeRefreshTokensByUserId(session, userId, keepSessionId, time.Now())
+	if err != nil {
+		return errors.Wrap(err, ">>revokeUserSessions, dao.RevokeRefreshTokensByUserId fail")
+	}
+
+	for _, refreshToken := range revoked {
+		if err = RevokeJti(ctx, refreshToken.AccessJti, AccessTokenExpireDuration()); err != nil {
+			return errors.Wrap(err, ">>revokeUserSessions ")
+		}
+	}
+
+	return nil
+}
+
+func sessionTouchKey(sessionId string) string {
+	return fmt.Sprintf("%s-session-touch-%s", redisKeyPrefix, sessionId)
+}
+
+func sessionGenerationKey(userId string) string {
+	return fmt.Sprintf("%s-user-session-gen-%s", redisKeyPrefix, userId)
+}
+
+// bumpSessionGeneration rejects every access token of the user issued so far
+func bumpSessionGeneration(ctx context.Context, userId string) error {
+	if global.GlobalClientSets.RedisClient == nil {
+		return RedisUnInitErr
+	}
+
+	// the key never expires, a generation starting over would accept the tokens of the old ones again
+	if err := global.GlobalClientSets.RedisClient.Incr(ctx, sessionGenerationKey(userId)).Err(); err != nil {
+		return errors.Wrap(err, ">>bumpSessionGeneration, redis incr fail")
+	}
+	return nil
+}
+
+// sessionGeneration the count of the times the user logged out all sessions, carried by the access tokens
+func sessionGeneration(ctx context.Context, userId string) (int64, error) {
+	if global.GlobalClientSets.RedisClient == nil {
+		return 0, RedisUnInitErr
+	}
+
+	generation, err := global.GlobalClientSets.RedisClient.Get(ctx, sessionGenerationKey(userId)).Int64()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return 0, nil
+		}
+		return 0, errors.Wrap(err, ">>sessionGeneration, redis get fail")
+	}
+	return generation, nil
+}
+
+// isRevokedByUser checks the token is issued before the user logged out all sessions
+func isRevokedByUser(ctx context.Context, claims *UserClaims) (bool, error) {
+	generation, err := sessionGeneration(ctx, claims.Subject)
+	if err != nil {
+		return false, errors.Wrap(err, ">>isRevokedByUser ")
+	}
+
+	return claims.Generation < generation, nil
+}
@@ -0,0 +1,235 @@
+package token
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/tespkg/bytes-be/common/global"
+	"github.com/tespkg/bytes-be/internal/testenv"
+	"github.com/tespkg/bytes-be/svc/staff/model/dao"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// writeTestKey a new Ed25519 private key file
+func writeTestKey(t *testing.T) string {
+	t.Helper()
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	file := filepath.Join(t.TempDir(), "key.pem")
+	if err = os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+	return file
+}
+
+// useTestKeySet signs the tokens of the test with a new Ed25519 key
+func useTestKeySet(t *testing.T) {
+	t.Helper()
+
+	ks, err := LoadKeySet([]KeyFile{{Kid: "test", PrivateKeyFile: writeTestKey(t)}})
+	if err != nil {
+		t.Fatalf("load key set: %v", err)
+	}
+
+	previous := keySet
+	SetUpKeySet(ks)
+	t.Cleanup(func() {
+		SetUpKeySet(previous)
+	})
+}
+
+func setUpTokenEnv(t *testing.T, singleLogin bool) {
+	t.Helper()
+
+	client, _ := testenv.Redis(t)
+	testenv.Global(t, global.ClientSet{
+		RedisClient:             client,
+		EnableSingleLogin:       singleLogin,
+		TokenExpireDurationHour: 24,
+		JwtSignedSecret:         "test-secret",
+	})
+}
+
+func createTestUser(t *testing.T, db *gorm.DB) int64 {
+	t.Helper()
+
+	email := uuid.NewString() + "@example.com"
+	user := dao.User{Uuid: uuid.NewString(), Email: &email, Password: []byte("password-hash"), IsEnabled: true}
+	if err := user.Save(db); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return user.Id
+}
+
+func assertVerify(t *testing.T, db *gorm.DB, accessToken string, want error) {
+	t.Helper()
+
+	_, err := VerifyToken(context.Background(), db, accessToken)
+	if !errors.Is(err, want) {
+		t.Fatalf("verify token: got %v, want %v", err, want)
+	}
+}
+
+func TestSessionGenerationRejectsTokensOfTheSameSecond(t *testing.T) {
+	useTestKeySet(t)
+	setUpTokenEnv(t, false)
+	ctx := context.Background()
+
+	before, err := GenToken(&GenTokenDto{UserId: "42"})
+	if err != nil {
+		t.Fatalf("gen token: %v", err)
+	}
+	other, err := GenToken(&GenTokenDto{UserId: "43"})
+	if err != nil {
+		t.Fatalf("gen token: %v", err)
+	}
+	assertVerify(t, nil, before, nil)
+
+	if err = bumpSessionGeneration(ctx, "42"); err != nil {
+		t.Fatalf("bump session generation: %v", err)
+	}
+
+	// issued in the same second as the revocation, only the generation tells them apart
+	assertVerify(t, nil, before, RevokedError)
+	after, err := GenToken(&GenTokenDto{UserId: "42"})
+	if err != nil {
+		t.Fatalf("gen token: %v", err)
+	}
+	assertVerify(t, nil, after, nil)
+	assertVerify(t, nil, other, nil)
+}
+
+func TestRevokeSession(t *testing.T) {
+	for _, singleLogin := range []bool{false, true} {
+		t.Run("single_login="+strconv.FormatBool(singleLogin), func(t *testing.T) {
+			db := testenv.Postgres(t)
+			setUpTokenEnv(t, singleLogin)
+			ctx := context.Background()
+			userId := createTestUser(t, db)
+			dto := &GenTokenDto{Session: db, UserId: strconv.FormatInt(userId, 10)}
+
+			first, err := GenTokenPair(dto)
+			if err != nil {
+				t.Fatalf("gen token pair: %v", err)
+			}
+			second, err := GenTokenPair(dto)
+			if err != nil {
+				t.Fatalf("gen token pair: %v", err)
+			}
+
+			firstCtx, err := VerifyToken(ctx, db, first.AccessToken)
+			if singleLogin {
+				// the second login already logged the first device out
+				if err == nil {
+					t.Fatal("verify token: the first session is still valid after the second login")
+				}
+				if _, err = RotateRefreshToken(ctx, db, first.RefreshToken); err == nil {
+					t.Fatal("rotate refresh token: the first session can still refresh after the second login")
+				}
+				assertVerify(t, db, second.AccessToken, nil)
+				return
+			}
+			if err != nil {
+				t.Fatalf("verify token: %v", err)
+			}
+
+			sessionId := firstCtx.Value(ClaimsCtx).(*UserClaims).SessionId
+			if err = RevokeSession(ctx, db, userId, sessionId); err != nil {
+				t.Fatalf("revoke session: %v", err)
+			}
+
+			assertVerify(t, db, first.AccessToken, RevokedError)
+			if _, err = RotateRefreshToken(ctx, db, first.RefreshToken); err == nil {
+				t.Fatal("rotate refresh token: the revoked session can still refresh")
+			}
+			assertVerify(t, db, second.AccessToken, nil)
+			if _, err = RotateRefreshToken(ctx, db, second.RefreshToken); err != nil {
+				t.Fatalf("rotate refresh token: %v", err)
+			}
+		})
+	}
+}
+
+func TestRevokeAllSessions(t *testing.T) {
+	for _, singleLogin := range []bool{false, true} {
+		t.Run("single_login="+strconv.FormatBool(singleLogin), func(t *testing.T) {
+			db := testenv.Postgres(t)
+			setUpTokenEnv(t, singleLogin)
+			ctx := context.Background()
+			userId := createTestUser(t, db)
+			dto := &GenTokenDto{Session: db, UserId: strconv.FormatInt(userId, 10)}
+
+			pair, err := GenTokenPair(dto)
+			if err != nil {
+				t.Fatalf("gen token pair: %v", err)
+			}
+			assertVerify(t, db, pair.AccessToken, nil)
+
+			if err = RevokeAllSessions(ctx, db, userId); err != nil {
+				t.Fatalf("revoke all sessions: %v", err)
+			}
+
+			if _, err = VerifyToken(ctx, db, pair.AccessToken); err == nil {
+				t.Fatal("verify token: the token is still valid after revoking every session")
+			}
+			if _, err = RotateRefreshToken(ctx, db, pair.RefreshToken); err == nil {
+				t.Fatal("rotate refresh token: the session can still refresh after revoking every session")
+			}
+
+			fresh, err := GenTokenPair(dto)
+			if err != nil {
+				t.Fatalf("gen token pair: %v", err)
+			}
+			assertVerify(t, db, fresh.AccessToken, nil)
+		})
+	}
+}
+
+func TestTouchSessionIsThrottled(t *testing.T) {
+	setUpTokenEnv(t, false)
+	ctx := context.Background()
+
+	// the statements are counted, not sent
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, SkipDefaultTransaction: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("open dry run db: %v", err)
+	}
+	updates := 0
+	if err = db.Callback().Update().After("gorm:update").Register("test:count", func(*gorm.DB) { updates++ }); err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+
+	claims := &UserClaims{SessionId: uuid.NewString()}
+	for i := 0; i < 3; i++ {
+		if err = TouchSession(ctx, db, claims); err != nil {
+			t.Fatalf("touch session: %v", err)
+		}
+	}
+	if updates != 1 {
+		t.Fatalf("got %d updates, want 1", updates)
+	}
+
+	if err = TouchSession(ctx, db, &UserClaims{SessionId: uuid.NewString()}); err != nil {
+		t.Fatalf("touch session: %v", err)
+	}
+	if updates != 2 {
+		t.Fatalf("got %d updates for another session, want 2", updates)
+	}
+}
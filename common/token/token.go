@@ -13,24 +13,27 @@ import (
 const (
 	Issuer    = "bytes-be"
 	jwtSecret = "z4kP5aDMcR#o[dgV"
+
+	defaultAccessTokenExpireSecond = 15 * 60
 )
 
 type UserClaims struct {
-	Platform      string `json:"platform,omitempty"` //ios, android
-	IMEI          string `json:"imei,omitempty"`
-	ClientVersion string `json:"clientVersion,omitempty"` // client version客户端版本
-	Model         string `json:"model,omitempty"`         // phone model手机型号
-	SystemVersion string `json:"systemVersion,omitempty"` // phone system version手机操作系统版本
+	Platform      string   `json:"platform,omitempty"` //ios, android
+	IMEI          string   `json:"imei,omitempty"`
+	ClientVersion string   `json:"clientVersion,omitempty"` // client version客户端版本
+	Model         string   `json:"model,omitempty"`         // phone model手机型号
+	SystemVersion string   `json:"systemVersion,omitempty"` // phone system version手机操作系统版本
+	SessionId     string   `json:"sid,omitempty"`           // refresh token family, empty for tokens without session
+	Roles         []string `json:"roles,omitempty"`         // role names, checked by middle.RequireRole
+	Generation    int64    `json:"gen,omitempty"`           // session generation of the user when issued, see RevokeAllSessions
 	jwt.RegisteredClaims
 }
 
 func GenClaims(dto *GenTokenDto) UserClaims {
-	defaultTokenExpireDuration := global.GlobalClientSets.TokenExpireDurationHour
-
 	return UserClaims{
 		RegisteredClaims: jwt.RegisteredClaims{
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * time.Duration(defaultTokenExpireDuration))),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenExpireDuration())),
 			Issuer:    Issuer,
 			ID:        uuid.NewString(),
 			Subject:   dto.UserId,
@@ -40,6 +43,8 @@ func GenClaims(dto *GenTokenDto) UserClaims {
 		ClientVersion: dto.ClientVersion,
 		Model:         dto.Model,
 		SystemVersion: dto.SystemVersion,
+		SessionId:     dto.SessionId,
+		Roles:         dto.Roles,
 	}
 }
 
@@ -51,31 +56,63 @@ type GenTokenDto struct {
 	ClientVersion string
 	Model         string
 	SystemVersion string
+	SessionId     string
+	Roles         []string
+}
+
+// AccessTokenExpireDuration access tokens are short-lived, they are renewed with refresh tokens
+func AccessTokenExpireDuration() time.Duration {
+	seconds := global.GlobalClientSets.AccessTokenExpireSecond
+	if seconds <= 0 {
+		seconds = defaultAccessTokenExpireSecond
+	}
+	return time.Second * time.Duration(seconds)
 }
 
 func GenToken(dto *GenTokenDto) (string, error) {
+	signedToken, _, err := genAccessToken(dto, global.GlobalClientSets.EnableSingleLogin)
+	return signedToken, err
+}
+
+// genAccessToken signs a new access token with the active key of the key set,
+// without key set it falls back to HS256 with the user secret, refreshSecret renews it for single login
+func genAccessToken(dto *GenTokenDto, refreshSecret bool) (string, *UserClaims, error) {
 	claims := GenClaims(dto)
+
+	generation, err := sessionGeneration(context.Background(), dto.UserId)
+	if err != nil {
+		return "", nil, errors.Wrap(err, ">>GenToken ")
+	}
+	claims.Generation = generation
+
+	if keySet != nil {
+		signedToken, err := keySet.sign(claims)
+		if err != nil {
+			return "", nil, errors.Wrap(err, ">>GenToken ")
+		}
+		return signedToken, &claims, nil
+	}
+
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
 	var secret string
-	var err error
-	if global.GlobalClientSets.EnableSingleLogin {
+	if refreshSecret {
 		secret, err = RefreshUserSecret(dto)
 		if err != nil {
-			return "", errors.Wrap(err, ">>GenToken ")
+			return "", nil, errors.Wrap(err, ">>GenToken ")
 		}
 	} else {
 		secret, err = GetUserSecret(dto)
 		if err != nil {
-			return "", errors.Wrap(err, ">>GenToken ")
+			return "", nil, errors.Wrap(err, ">>GenToken ")
 		}
 	}
 
 	signedToken, err := token.SignedString([]byte(secret))
 	if err != nil {
-		return "", errors.Wrap(err, ">>GenToken ")
+		return "", nil, errors.Wrap(err, ">>GenToken ")
 	}
-	return signedToken, nil
+	return signedToken, &claims, nil
 }
 
 var FormatError = errors.New("token format error")
@@ -84,9 +121,28 @@ var ExpiredError = errors.New("expired token")
 var UnknownError = errors.New("unknown token error")
 var ClaimsError = errors.New("token claims error")
 var SignatureInvalidError = errors.New("token invalid signature error")
+var RevokedError = errors.New("revoked token")
+
+// HasRole checks the role is carried by the claims
+func (c *UserClaims) HasRole(role string) bool {
+	for _, r := range c.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
 
 func VerifyToken(ctx context.Context, session *gorm.DB, tokenString string) (context.Context, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &UserClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if keySet != nil {
+			return keySet.verifyKey(token)
+		}
+
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, SignatureInvalidError
+		}
+
 		if claims, ok := token.Claims.(*UserClaims); !ok {
 			return nil, ClaimsError
 		} else {
@@ -125,6 +181,22 @@ func VerifyToken(ctx context.Context, session *gorm.DB, tokenString string) (con
 
 	if claims, ok := token.Claims.(*UserClaims); ok {
 		// fmt.Printf("claims %+v\n", claims)
+		revoked, err := IsJtiRevoked(ctx, claims.ID)
+		if err != nil {
+			return ctx, err
+		}
+		if revoked {
+			return ctx, RevokedError
+		}
+
+		revoked, err = isRevokedByUser(ctx, claims)
+		if err != nil {
+			return ctx, err
+		}
+		if revoked {
+			return ctx, RevokedError
+		}
+
 		ctx = context.WithValue(ctx, ClaimsCtx, claims)
 		return ctx, nil
 	} else {
@@ -0,0 +1,297 @@
+package verifycode
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"github.com/tespkg/bytes-be/common/global"
+	"github.com/tespkg/bytes-be/common/xerr"
+)
+
+const (
+	PurposeRegister      = "register"
+	PurposeLogin         = "login"
+	PurposeResetPassword = "reset_password"
+	PurposeChangePhone   = "change_phone"
+	PurposeChangeEmail   = "change_email"
+)
+
+const (
+	ChannelPhone = "phone"
+	ChannelEmail = "email"
+)
+
+var RedisUnInitErr = errors.New("redis not init")
+
+var redisKeyPrefix = "bytes_be:verify_code"
+
+type Options struct {
+	Length         int
+	Expire         time.Duration
+	MaxAttempts    int
+	ResendInterval time.Duration
+	// MaxSendPerHour limits the codes sent to one phone or email
+	MaxSendPerHour int
+	// MaxSendPerHourPerIp limits the codes requested from one client ip
+	MaxSendPerHourPerIp int
+}
+
+var DefaultOptions = Options{
+	Length:              6,
+	Expire:              5 * time.Minute,
+	MaxAttempts:         5,
+	ResendInterval:      time.Minute,
+	MaxSendPerHour:      10,
+	MaxSendPerHourPerIp: 30,
+}
+
+var options = DefaultOptions
+
+// SetUp overrides the default options, zero values keep the defaults
+func SetUp(opts Options) {
+	if opts.Length > 0 {
+		options.Length = opts.Length
+	}
+	if opts.Expire > 0 {
+		options.Expire = opts.Expire
+	}
+	if opts.MaxAttempts > 0 {
+		options.MaxAttempts = opts.MaxAttempts
+	}
+	if opts.ResendInterval > 0 {
+		options.ResendInterval = opts.ResendInterval
+	}
+	if opts.MaxSendPerHour > 0 {
+		options.MaxSendPerHour = opts.MaxSendPerHour
+	}
+	if opts.MaxSendPerHourPerIp > 0 {
+		options.MaxSendPerHourPerIp = opts.MaxSendPerHourPerIp
+	}
+}
+
+func IsValidPurpose(purpose string) bool {
+	switch purpose {
+	case PurposeRegister, PurposeLogin, PurposeResetPassword, PurposeChangePhone, PurposeChangeEmail:
+		return true
+	}
+	return false
+}
+
+// Target picks the channel of a phone or email pair, the phone is preferred when both are given
+func Target(phone, email string) (string, string) {
+	if phone != "" {
+		return ChannelPhone, phone
+	}
+	return ChannelEmail, email
+}
+
+type Code struct {
+	Purpose   string
+	Channel   string
+	Target    string
+	Value     string
+	ExpiresAt time.Time
+}
+
+// Issue generates a code for the purpose, the previous one of the same purpose and target is replaced.
+// It fails when the target is locked out or the resend limits are hit.
+func Issue(ctx context.Context, purpose, channel, target, ip string) (*Code, error) {
+	rdb := global.GlobalClientSets.RedisClient
+	if rdb == nil {
+		return nil, RedisUnInitErr
+	}
+	target = normalizeTarget(channel, target)
+
+	locked, err := rdb.Exists(ctx, lockKey(purpose, channel, target)).Result()
+	if err != nil {
+		return nil, errors.Wrap(err, ">>Issue, redis exists fail")
+	}
+	if locked > 0 {
+		return nil, xerr.NewErrCode(xerr.VerifyCodeTooManyAttempts)
+	}
+
+	if err = throttle(ctx, rdb, channel, target, ip); err != nil {
+		return nil, err
+	}
+
+	value, err := generate(options.Length)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>Issue ")
+	}
+
+	key := codeKey(purpose, channel, target)
+	pipe := rdb.TxPipeline()
+	pipe.Del(ctx, key)
+	pipe.HSet(ctx, key, "hash", hashCode(key, value), "attempts", 0)
+	pipe.Expire(ctx, key, options.Expire)
+	if _, err = pipe.Exec(ctx); err != nil {
+		return nil, errors.Wrap(err, ">>Issue, redis pipeline fail")
+	}
+
+	return &Code{
+		Purpose:   purpose,
+		Channel:   channel,
+		Target:    target,
+		Value:     value,
+		ExpiresAt: time.Now().Add(options.Expire),
+	}, nil
+}
+
+// Revoke drops an issued code, e.g. when it couldn't be delivered
+func Revoke(ctx context.Context, code *Code) {
+	if rdb := global.GlobalClientSets.RedisClient; rdb != nil {
+		rdb.Del(ctx, codeKey(code.Purpose, code.Channel, code.Target))
+	}
+}
+
+// Verify checks the code and consumes it on success.
+// Every wrong attempt counts, the code is dropped and the target locked out after MaxAttempts.
+func Verify(ctx context.Context, purpose, channel, target, value string) error {
+	rdb := global.GlobalClientSets.RedisClient
+	if rdb == nil {
+		return RedisUnInitErr
+	}
+	target = normalizeTarget(channel, target)
+	key := codeKey(purpose, channel, target)
+
+	values, err := attemptScript.Run(ctx, rdb, []string{key}).Slice()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return xerr.NewErrCode(xerr.VerifyCodeInvalid)
+		}
+		return errors.Wrap(err, ">>Verify, redis attempt script fail")
+	}
+	hash, _ := values[0].(string)
+	attempts, _ := values[1].(int64)
+	if attempts > int64(options.MaxAttempts) {
+		return lockOut(ctx, rdb, purpose, channel, target)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(hashCode(key, value))) != 1 {
+		if attempts == int64(options.MaxAttempts) {
+			return lockOut(ctx, rdb, purpose, channel, target)
+		}
+		return xerr.NewErrCode(xerr.VerifyCodeInvalid)
+	}
+
+	//only one of the concurrent verifications can consume the code
+	deleted, err := rdb.Del(ctx, key).Result()
+	if err != nil {
+		return errors.Wrap(err, ">>Verify, redis del fail")
+	}
+	if deleted == 0 {
+		return xerr.NewErrCode(xerr.VerifyCodeInvalid)
+	}
+
+	return nil
+}
+
+// attemptScript counts an attempt on an existing code and returns its hash with the attempts. An expired code
+// isn't created again without a ttl, nil is returned instead
+var attemptScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return false
+end
+local attempts = redis.call("HINCRBY", KEYS[1], "attempts", 1)
+return {redis.call("HGET", KEYS[1], "hash"), attempts}
+`)
+
+func lockOut(ctx context.Context, rdb *redis.Client, purpose, channel, target string) error {
+	pipe := rdb.TxPipeline()
+	pipe.Del(ctx, codeKey(purpose, channel, target))
+	pipe.Set(ctx, lockKey(purpose, channel, target), 1, options.Expire)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return errors.Wrap(err, ">>lockOut, redis pipeline fail")
+	}
+	return xerr.NewErrCode(xerr.VerifyCodeTooManyAttempts)
+}
+
+// throttle applies the resend cooldown and the hourly limits per target and per ip
+func throttle(ctx context.Context, rdb *redis.Client, channel, target, ip string) error {
+	ok, err := rdb.SetNX(ctx, fmt.Sprintf("%s:cooldown:%s:%s", redisKeyPrefix, channel, target), 1, options.ResendInterval).Result()
+	if err != nil {
+		return errors.Wrap(err, ">>throttle, redis setnx fail")
+	}
+	if !ok {
+		return xerr.NewErrCode(xerr.VerifyCodeTooFrequent)
+	}
+
+	exceeded, err := incrHourly(ctx, rdb, fmt.Sprintf("%s:hourly:%s:%s", redisKeyPrefix, channel, target), options.MaxSendPerHour)
+	if err != nil {
+		return err
+	}
+	if exceeded {
+		return xerr.NewErrCode(xerr.VerifyCodeTooFrequent)
+	}
+
+	if ip == "" {
+		return nil
+	}
+
+	exceeded, err = incrHourly(ctx, rdb, fmt.Sprintf("%s:hourly:ip:%s", redisKeyPrefix, ip), options.MaxSendPerHourPerIp)
+	if err != nil {
+		return err
+	}
+	if exceeded {
+		return xerr.NewErrCode(xerr.VerifyCodeTooFrequent)
+	}
+
+	return nil
+}
+
+func incrHourly(ctx context.Context, rdb *redis.Client, key string, limit int) (bool, error) {
+	count, err := rdb.Incr(ctx, key).Result()
+	if err != nil {
+		return false, errors.Wrap(err, ">>incrHourly, redis incr fail")
+	}
+	if count == 1 {
+		if err = rdb.Expire(ctx, key, time.Hour).Err(); err != nil {
+			return false, errors.Wrap(err, ">>incrHourly, redis expire fail")
+		}
+	}
+	return count > int64(limit), nil
+}
+
+func generate(length int) (string, error) {
+	var sb strings.Builder
+	ten := big.NewInt(10)
+	for i := 0; i < length; i++ {
+		n, err := rand.Int(rand.Reader, ten)
+		if err != nil {
+			return "", errors.Wrap(err, ">>generate, rand.Int fail")
+		}
+		sb.WriteByte(byte('0' + n.Int64()))
+	}
+	return sb.String(), nil
+}
+
+// hashCode binds the code to its key, so the stored value is useless for other purposes or targets
+func hashCode(key, value string) string {
+	sum := sha256.Sum256([]byte(key + ":" + value))
+	return hex.EncodeToString(sum[:])
+}
+
+func normalizeTarget(channel, target string) string {
+	target = strings.TrimSpace(target)
+	if channel == ChannelEmail {
+		return strings.ToLower(target)
+	}
+	return target
+}
+
+func codeKey(purpose, channel, target string) string {
+	return fmt.Sprintf("%s:%s:%s:%s", redisKeyPrefix, purpose, channel, target)
+}
+
+func lockKey(purpose, channel, target string) string {
+	return fmt.Sprintf("%s:lock:%s:%s:%s", redisKeyPrefix, purpose, channel, target)
+}
@@ -0,0 +1,122 @@
+package verifycode
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/tespkg/bytes-be/common/global"
+	"github.com/tespkg/bytes-be/common/xerr"
+	"github.com/tespkg/bytes-be/internal/testenv"
+)
+
+func setUpRedis(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+
+	client, server := testenv.Redis(t)
+	testenv.Global(t, global.ClientSet{RedisClient: client})
+	return server
+}
+
+func mustIssue(t *testing.T, target string) *Code {
+	t.Helper()
+
+	code, err := Issue(context.Background(), PurposeLogin, ChannelEmail, target, "")
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	return code
+}
+
+func isErrCode(err error, code uint32) bool {
+	var codeErr *xerr.CodeError
+	return errors.As(err, &codeErr) && codeErr.GetErrCode() == code
+}
+
+func TestVerify(t *testing.T) {
+	setUpRedis(t)
+	ctx := context.Background()
+	code := mustIssue(t, "Someone@Example.com ")
+
+	cases := []struct {
+		name  string
+		value string
+		want  uint32
+	}{
+		{"wrong code", "wrong", xerr.VerifyCodeInvalid},
+		{"right code", code.Value, 0},
+		{"consumed code", code.Value, xerr.VerifyCodeInvalid},
+	}
+	for _, c := range cases {
+		err := Verify(ctx, PurposeLogin, ChannelEmail, "someone@example.com", c.value)
+		if (c.want == 0 && err != nil) || (c.want != 0 && !isErrCode(err, c.want)) {
+			t.Fatalf("%s: got %v, want code %d", c.name, err, c.want)
+		}
+	}
+}
+
+func TestVerifyAttemptLimit(t *testing.T) {
+	setUpRedis(t)
+	ctx := context.Background()
+	code := mustIssue(t, "limit@example.com")
+
+	for i := 1; i <= options.MaxAttempts; i++ {
+		want := uint32(xerr.VerifyCodeInvalid)
+		if i == options.MaxAttempts {
+			want = xerr.VerifyCodeTooManyAttempts
+		}
+		if err := Verify(ctx, PurposeLogin, ChannelEmail, code.Target, "wrong"); !isErrCode(err, want) {
+			t.Fatalf("attempt %d: got %v, want code %d", i, err, want)
+		}
+	}
+
+	// the code is dropped and the target locked out
+	if err := Verify(ctx, PurposeLogin, ChannelEmail, code.Target, code.Value); !isErrCode(err, xerr.VerifyCodeInvalid) {
+		t.Fatalf("verify after the lock out: got %v", err)
+	}
+	if _, err := Issue(ctx, PurposeLogin, ChannelEmail, code.Target, ""); !isErrCode(err, xerr.VerifyCodeTooManyAttempts) {
+		t.Fatalf("issue after the lock out: got %v", err)
+	}
+}
+
+func TestVerifyConcurrentGuesses(t *testing.T) {
+	server := setUpRedis(t)
+	ctx := context.Background()
+	code := mustIssue(t, "race@example.com")
+	key := codeKey(PurposeLogin, ChannelEmail, code.Target)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4*options.MaxAttempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = Verify(ctx, PurposeLogin, ChannelEmail, code.Target, "wrong")
+		}()
+	}
+	wg.Wait()
+
+	// no late guess brings the dropped code back without a ttl
+	if server.Exists(key) {
+		t.Fatalf("the code is kept with ttl %s after the lock out", server.TTL(key))
+	}
+	if _, err := Issue(ctx, PurposeLogin, ChannelEmail, code.Target, ""); !isErrCode(err, xerr.VerifyCodeTooManyAttempts) {
+		t.Fatalf("issue after the concurrent guesses: got %v", err)
+	}
+}
+
+func TestVerifyExpiredCode(t *testing.T) {
+	server := setUpRedis(t)
+	code := mustIssue(t, "expired@example.com")
+	key := codeKey(PurposeLogin, ChannelEmail, code.Target)
+
+	server.FastForward(options.Expire + time.Second)
+	if err := Verify(context.Background(), PurposeLogin, ChannelEmail, code.Target, code.Value); !isErrCode(err, xerr.VerifyCodeInvalid) {
+		t.Fatalf("verify an expired code: got %v", err)
+	}
+	if server.Exists(key) {
+		t.Fatal("the expired code is created again")
+	}
+}
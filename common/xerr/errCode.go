@@ -12,12 +12,42 @@ const DbError uint32 = 100005
 const DeliveryTimeOutOperatingHours uint32 = 40
 
 const (
-	UserNotExist           = 100006
-	UserPasswordInvalid    = 100007
-	TraveICAuthTokenError  = 100008
-	OrderNotExist          = 100009
-	TicketNotExist         = 100010
-	AccountSettingNotExist = 100011
-	EmailRegistered        = 100012
-	ThirdPartyLoginFail    = 100013
+	UserNotExist              = 100006
+	UserPasswordInvalid       = 100007
+	TraveICAuthTokenError     = 100008
+	OrderNotExist             = 100009
+	TicketNotExist            = 100010
+	AccountSettingNotExist    = 100011
+	EmailRegistered           = 100012
+	ThirdPartyLoginFail       = 100013
+	UserDisabled              = 100014
+	VerifyCodeInvalid         = 100015
+	VerifyCodeTooManyAttempts = 100016
+	VerifyCodeTooFrequent     = 100017
+	PhoneRegistered           = 100018
+	CustomerAddressNotExist   = 100019
+	MerchantNotExist          = 100020
+	MerchantRegistered        = 100021
+	MerchantCrNumberExist     = 100022
+	MerchantStatusInvalid     = 100023
+	MerchantDocumentNotExist  = 100024
+	CategoryNotExist          = 100025
+	ProductNotExist           = 100026
+	ProductSkuExist           = 100027
+	ProductUnavailable        = 100028
+	ProductSelectionInvalid   = 100029
+	FileNotExist              = 100030
+	InventoryNotExist         = 100031
+	StockInsufficient         = 100032
+	StockReserved             = 100033
+	StockReservationExist     = 100034
+	DeliveryZoneNotExist      = 100035
+	DeliveryOutOfZone         = 100036
+	DeliveryBelowMinOrder     = 100037
+	SemanticSearchDisabled    = 100038
+	ProductIngredientNotExist = 100039
+	IngredientAnalysisOff     = 100040
+	StockReservationReleased  = 100041
+	OrderStatusInvalid        = 100042
+	OrderExpired              = 100043
 )
@@ -9,6 +9,42 @@ func init() {
 	message[RequestParamError] = "Parameter error"
 	message[TokenExpireError] = "The token is invalid, please log in again"
 	message[TokenGenerateError] = "Failed to generate token"
+	message[DeliveryTimeOutOperatingHours] = "The delivery time is out of the operating hours"
+	message[UserNotExist] = "The user does not exist"
+	message[UserPasswordInvalid] = "The account or password is incorrect"
+	message[EmailRegistered] = "The email is registered"
+	message[ThirdPartyLoginFail] = "Third party login failed"
+	message[UserDisabled] = "The user has been disabled"
+	message[VerifyCodeInvalid] = "The verify code is invalid or expired"
+	message[VerifyCodeTooManyAttempts] = "Too many wrong verify codes, please try again later"
+	message[VerifyCodeTooFrequent] = "Verify codes are requested too frequently, please try again later"
+	message[PhoneRegistered] = "The phone is registered"
+	message[CustomerAddressNotExist] = "The address does not exist"
+	message[MerchantNotExist] = "The merchant does not exist"
+	message[MerchantRegistered] = "The user is registered as a merchant"
+	message[MerchantCrNumberExist] = "The commercial registration number is registered"
+	message[MerchantStatusInvalid] = "The operation is not allowed in the current merchant status"
+	message[MerchantDocumentNotExist] = "The merchant document does not exist"
+	message[CategoryNotExist] = "The category does not exist"
+	message[ProductNotExist] = "The product does not exist"
+	message[ProductSkuExist] = "The SKU is used by another variant"
+	message[ProductUnavailable] = "The product is unavailable"
+	message[ProductSelectionInvalid] = "The selected variant or options are invalid"
+	message[FileNotExist] = "The file does not exist"
+	message[InventoryNotExist] = "The inventory does not exist"
+	message[StockInsufficient] = "The stock is insufficient"
+	message[StockReserved] = "The stock is reserved by pending orders"
+	message[StockReservationExist] = "The stock of the order is already reserved"
+	message[DeliveryZoneNotExist] = "The delivery zone does not exist"
+	message[DeliveryOutOfZone] = "The store does not deliver to the address"
+	message[DeliveryBelowMinOrder] = "The order is below the minimum of the delivery zone"
+	message[SemanticSearchDisabled] = "The semantic search is not enabled"
+	message[ProductIngredientNotExist] = "The ingredients of the product are not analyzed yet"
+	message[IngredientAnalysisOff] = "The ingredient analysis is not enabled"
+	message[StockReservationReleased] = "The stock of the order was released"
+	message[OrderNotExist] = "The order does not exist"
+	message[OrderStatusInvalid] = "The order can't be changed in its status"
+	message[OrderExpired] = "The order was not accepted in time"
 }
 
 func MapErrMsg(errcode uint32) string {
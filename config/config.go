@@ -2,8 +2,12 @@ package config
 
 import (
 	"github.com/knadh/koanf"
+	"github.com/pkg/errors"
 	"github.com/knadh/koanf/parsers/yaml"
 	"github.com/knadh/koanf/providers/file"
+	"github.com/tespkg/bytes-be/internal/geocode"
+	"github.com/tespkg/bytes-be/internal/ingredient"
+	"github.com/tespkg/bytes-be/internal/oidc"
 )
 
 type Config struct {
@@ -21,10 +25,14 @@ type Config struct {
 	NominatimAddr string `koanf:"nominatim_addr"`
 	SessmsAddr    string `koanf:"sessms_addr"`
 
+	Geo Geo `koanf:"geo"`
+
 	GoroutinePoolMax int `koanf:"goroutine_pool_max"`
 
 	Meerastorage Meerastorage `koanf:"meerastorage"`
 
+	Storage Storage `koanf:"storage"`
+
 	GoogleAnalytics GoogleAnalytics `koanf:"google_analytics"`
 
 	SmartPay string `koanf:"smart_pay"`
@@ -32,9 +40,16 @@ type Config struct {
 
 	JwtSignedSecret string `koanf:"jwt_signed_secret"`
 
+	Jwt Jwt `koanf:"jwt"`
+
+	Oidc Oidc `koanf:"oidc"`
+
 	BytesMatch BytesMatch `koanf:"bytes_match"`
 
-	EnableIngredientAnalysis bool `koanf:"enable_ingredient_analysis"`
+	EnableIngredientAnalysis bool              `koanf:"enable_ingredient_analysis"`
+	Ingredient               ingredient.Config `koanf:"ingredient"`
+
+	VerifyCode VerifyCode `koanf:"verify_code"`
 }
 
 type ServerREST struct {
@@ -46,13 +61,14 @@ type ServerREST struct {
 }
 
 type ServiceBasicConfig struct {
-	EnableSwaggerDocs        bool   `koanf:"enable_swagger_docs"`
-	DomainAddr               string `koanf:"domain_addr"`
-	RedirectDomain           string `koanf:"redirect_domain"`
-	SingleLogin              bool   `koanf:"single_login"`
-	LoginTokenExpireDuration int    `koanf:"login_token_expire_duration"`
-	EmailSender              string `koanf:"email_sender"`
-	BytesEnv                 string `koanf:"bytes_env"`
+	EnableSwaggerDocs         bool   `koanf:"enable_swagger_docs"`
+	DomainAddr                string `koanf:"domain_addr"`
+	RedirectDomain            string `koanf:"redirect_domain"`
+	SingleLogin               bool   `koanf:"single_login"`
+	LoginTokenExpireDuration  int    `koanf:"login_token_expire_duration"`
+	AccessTokenExpireDuration int    `koanf:"access_token_expire_duration"`
+	EmailSender               string `koanf:"email_sender"`
+	BytesEnv                  string `koanf:"bytes_env"`
 }
 
 type Postgres struct {
@@ -72,8 +88,20 @@ type Redis struct {
 }
 
 type Meerastorage struct {
-	EndPoint string `koanf:"endpoint"`
-	Bucket   string `koanf:"bucket"`
+	EndPoint       string `koanf:"endpoint"`
+	Bucket         string `koanf:"bucket"`
+	TimeoutSeconds int    `koanf:"timeout_seconds"`
+}
+
+type Storage struct {
+	// Backend meerastorage or local, the local backend keeps the files in local_dir for development
+	Backend  string `koanf:"backend"`
+	LocalDir string `koanf:"local_dir"`
+	// PublicBaseUrl serves the files directly when the bucket is public, e.g. through a CDN, empty signs the urls
+	PublicBaseUrl string `koanf:"public_base_url"`
+	// SignSecret signs the file urls, it is required
+	SignSecret             string `koanf:"sign_secret"`
+	SignedUrlExpireSeconds int    `koanf:"signed_url_expire_seconds"`
 }
 
 type GoogleAnalytics struct {
@@ -86,6 +114,47 @@ type BytesMatch struct {
 	ExpireDuration int    `koanf:"expire_duration"`
 }
 
+type Jwt struct {
+	// Keys the first one signs the tokens, the others are previous keys still accepted for verification
+	Keys []JwtKey `koanf:"keys"`
+}
+
+type JwtKey struct {
+	Kid            string `koanf:"kid"`
+	PrivateKeyFile string `koanf:"private_key_file"`
+	PublicKeyFile  string `koanf:"public_key_file"`
+}
+
+type Oidc struct {
+	Providers []oidc.ProviderConfig `koanf:"providers"`
+}
+
+type Geo struct {
+	// Nominatim addr defaults to nominatim_addr
+	Nominatim          geocode.NominatimConfig `koanf:"nominatim"`
+	Market             Market                  `koanf:"market"`
+	CacheExpireSeconds int                     `koanf:"cache_expire_seconds"`
+	// RateLimitPerSecond is shared by every instance through redis, the public nominatim allows 1 request per second
+	RateLimitPerSecond int `koanf:"rate_limit_per_second"`
+}
+
+// Market the search results are biased to the view box, [min lon, min lat, max lon, max lat]
+type Market struct {
+	Name           string    `koanf:"name"`
+	ViewBox        []float64 `koanf:"view_box"`
+	CountryCodes   string    `koanf:"country_codes"`
+	AcceptLanguage string    `koanf:"accept_language"`
+}
+
+type VerifyCode struct {
+	Length                int `koanf:"length"`
+	ExpireSeconds         int `koanf:"expire_seconds"`
+	MaxAttempts           int `koanf:"max_attempts"`
+	ResendIntervalSeconds int `koanf:"resend_interval_seconds"`
+	MaxSendPerHour        int `koanf:"max_send_per_hour"`
+	MaxSendPerHourPerIp   int `koanf:"max_send_per_hour_per_ip"`
+}
+
 var DefaultConfig = Config{
 	Version: "0.0.0",
 }
@@ -103,5 +172,17 @@ func LoadWithDefault(configPath string) (Config, error) {
 		return cfg, err
 	}
 
+	if err := cfg.validate(); err != nil {
+		return cfg, err
+	}
+
 	return cfg, nil
 }
+
+// validate rejects the settings the server can't run safely without
+func (c *Config) validate() error {
+	if c.Storage.SignSecret == "" {
+		return errors.New("storage.sign_secret is required")
+	}
+	return nil
+}
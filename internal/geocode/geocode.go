@@ -0,0 +1,112 @@
+// Package geocode resolves addresses and pins through a geocoding service, nominatim by default
+package geocode
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+var (
+	// ErrorNotFound the pin can't be resolved to a place
+	ErrorNotFound = errors.New("place not found")
+	// ErrorBadRequest the service rejected the request, retrying won't help
+	ErrorBadRequest = errors.New("geocoding bad request")
+	// ErrorRateLimited the service asks to slow down
+	ErrorRateLimited = errors.New("geocoding rate limited")
+	// ErrorUnavailable the service can't be reached, timed out or failed on its side, it is retried
+	ErrorUnavailable = errors.New("geocoding service unavailable")
+	// ErrorInvalidResponse the response can't be decoded
+	ErrorInvalidResponse = errors.New("geocoding invalid response")
+)
+
+type Geocoder interface {
+	Search(ctx context.Context, query string, opts SearchOptions) (*FeatureCollection, error)
+	Reverse(ctx context.Context, lat, lon float64, language string) (*FeatureCollection, error)
+}
+
+// ViewBox min/max longitude and latitude
+type ViewBox struct {
+	MinLon float64
+	MinLat float64
+	MaxLon float64
+	MaxLat float64
+}
+
+func (b *ViewBox) Contains(lon, lat float64) bool {
+	return lon >= b.MinLon && lon <= b.MaxLon && lat >= b.MinLat && lat <= b.MaxLat
+}
+
+type SearchOptions struct {
+	ViewBox *ViewBox
+	// Bounded restricts the results to the view box instead of only preferring it
+	Bounded        bool
+	CountryCodes   string
+	AcceptLanguage string
+	Limit          int
+}
+
+// FeatureCollection the GeoJSON output of nominatim
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+type Feature struct {
+	Type       string    `json:"type"`
+	Properties Place     `json:"properties"`
+	Bbox       []float64 `json:"bbox"`
+	Geometry   Geometry  `json:"geometry"`
+}
+
+// Geometry coordinates are [lon, lat] for points
+type Geometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+type Place struct {
+	PlaceId     int64         `json:"place_id"`
+	OsmType     string        `json:"osm_type"`
+	OsmId       int64         `json:"osm_id"`
+	Category    string        `json:"category"`
+	Type        string        `json:"type"`
+	Name        string        `json:"name"`
+	DisplayName string        `json:"display_name"`
+	Address     *PlaceAddress `json:"address"`
+}
+
+type PlaceAddress struct {
+	HouseNumber   string `json:"house_number"`
+	Road          string `json:"road"`
+	Pedestrian    string `json:"pedestrian"`
+	Neighbourhood string `json:"neighbourhood"`
+	Suburb        string `json:"suburb"`
+	Village       string `json:"village"`
+	Town          string `json:"town"`
+	City          string `json:"city"`
+	Province      string `json:"province"`
+	Region        string `json:"region"`
+	State         string `json:"state"`
+	Postcode      string `json:"postcode"`
+	Country       string `json:"country"`
+	CountryCode   string `json:"country_code"`
+}
+
+// NewPoint builds a point geometry
+func NewPoint(lon, lat float64) Geometry {
+	coordinates, _ := json.Marshal([]float64{lon, lat})
+	return Geometry{Type: "Point", Coordinates: coordinates}
+}
+
+// Point the [lon, lat] of a point geometry
+func (g Geometry) Point() (lon, lat float64, ok bool) {
+	if g.Type != "Point" {
+		return 0, 0, false
+	}
+	var coordinates []float64
+	if err := json.Unmarshal(g.Coordinates, &coordinates); err != nil || len(coordinates) < 2 {
+		return 0, 0, false
+	}
+	return coordinates[0], coordinates[1], true
+}
@@ -0,0 +1,117 @@
+// Package geocodetest provides an in-memory geocoder to exercise the geocoding users without a nominatim server
+package geocodetest
+
+import (
+	"context"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/tespkg/bytes-be/internal/geocode"
+)
+
+// maxReverseDistance pins farther than this from every place, in degrees, are not found
+const maxReverseDistance = 0.01
+
+// Fake matches the search query against the name and display name of the added places,
+// and reverses a pin to the nearest place
+type Fake struct {
+	// Err is returned by every call when set
+	Err error
+
+	lock         sync.Mutex
+	places       []geocode.Feature
+	searchCalls  int
+	reverseCalls int
+}
+
+var _ geocode.Geocoder = (*Fake)(nil)
+
+func NewFake() *Fake {
+	return &Fake{}
+}
+
+// AddPlace adds a place at the pin
+func (f *Fake) AddPlace(lat, lon float64, place geocode.Place) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.places = append(f.places, geocode.Feature{
+		Type:       "Feature",
+		Properties: place,
+		Geometry:   geocode.NewPoint(lon, lat),
+	})
+}
+
+// Calls the number of search and reverse calls
+func (f *Fake) Calls() (search, reverse int) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.searchCalls, f.reverseCalls
+}
+
+func (f *Fake) Search(ctx context.Context, query string, opts geocode.SearchOptions) (*geocode.FeatureCollection, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.searchCalls++
+
+	if err := f.check(ctx); err != nil {
+		return nil, err
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = geocode.DefaultSearchLimit
+	}
+	query = strings.ToLower(query)
+
+	fc := &geocode.FeatureCollection{Type: "FeatureCollection", Features: []geocode.Feature{}}
+	for _, feature := range f.places {
+		if len(fc.Features) >= limit {
+			break
+		}
+		if !strings.Contains(strings.ToLower(feature.Properties.Name), query) &&
+			!strings.Contains(strings.ToLower(feature.Properties.DisplayName), query) {
+			continue
+		}
+		if opts.ViewBox != nil && opts.Bounded {
+			lon, lat, _ := feature.Geometry.Point()
+			if !opts.ViewBox.Contains(lon, lat) {
+				continue
+			}
+		}
+		fc.Features = append(fc.Features, feature)
+	}
+	return fc, nil
+}
+
+func (f *Fake) Reverse(ctx context.Context, lat, lon float64, language string) (*geocode.FeatureCollection, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.reverseCalls++
+
+	if err := f.check(ctx); err != nil {
+		return nil, err
+	}
+
+	var nearest *geocode.Feature
+	nearestDistance := maxReverseDistance
+	for i, feature := range f.places {
+		placeLon, placeLat, _ := feature.Geometry.Point()
+		if distance := math.Hypot(placeLon-lon, placeLat-lat); distance <= nearestDistance {
+			nearest, nearestDistance = &f.places[i], distance
+		}
+	}
+	if nearest == nil {
+		return nil, geocode.ErrorNotFound
+	}
+
+	return &geocode.FeatureCollection{Type: "FeatureCollection", Features: []geocode.Feature{*nearest}}, nil
+}
+
+func (f *Fake) check(ctx context.Context) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	return ctx.Err()
+}
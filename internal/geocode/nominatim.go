@@ -0,0 +1,219 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+const (
+	DefaultScheme         = "https"
+	DefaultTimeoutSeconds = 5
+	DefaultRetries        = 2
+	DefaultUserAgent      = "bytes-be"
+	DefaultSearchLimit    = 6
+
+	retryBackoff = 200 * time.Millisecond
+	// maxBodySize nominatim results are small, anything bigger is not a result
+	maxBodySize = 1 << 20
+)
+
+type NominatimConfig struct {
+	// Addr host[:port] of the nominatim server
+	Addr   string `koanf:"addr"`
+	Scheme string `koanf:"scheme"`
+	// TimeoutSeconds of each attempt
+	TimeoutSeconds int `koanf:"timeout_seconds"`
+	// Retries on network errors and 5xx responses
+	Retries int `koanf:"retries"`
+	// UserAgent identifies the application, the nominatim usage policy requires it
+	UserAgent string `koanf:"user_agent"`
+}
+
+type nominatim struct {
+	baseUrl    string
+	userAgent  string
+	retries    int
+	httpClient *http.Client
+	limit      func(ctx context.Context) error
+}
+
+type Option func(n *nominatim) error
+
+func WithHTTPClient(client *http.Client) Option {
+	return func(n *nominatim) error {
+		n.httpClient = client
+		return nil
+	}
+}
+
+// WithLimiter is called before every request, retries included, its error fails the request as is
+func WithLimiter(limit func(ctx context.Context) error) Option {
+	return func(n *nominatim) error {
+		n.limit = limit
+		return nil
+	}
+}
+
+func NewNominatim(config NominatimConfig, options ...Option) (Geocoder, error) {
+	if config.Addr == "" {
+		return nil, errors.New("nominatim addr is required")
+	}
+	if config.Scheme == "" {
+		config.Scheme = DefaultScheme
+	}
+	if config.Scheme != "http" && config.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported nominatim scheme %s", config.Scheme)
+	}
+	if config.TimeoutSeconds <= 0 {
+		config.TimeoutSeconds = DefaultTimeoutSeconds
+	}
+	if config.Retries < 0 {
+		config.Retries = 0
+	}
+	if config.UserAgent == "" {
+		config.UserAgent = DefaultUserAgent
+	}
+
+	instance := &nominatim{
+		baseUrl:    fmt.Sprintf("%s://%s", config.Scheme, config.Addr),
+		userAgent:  config.UserAgent,
+		retries:    config.Retries,
+		httpClient: &http.Client{Timeout: time.Duration(config.TimeoutSeconds) * time.Second},
+	}
+	for _, option := range options {
+		if err := option(instance); err != nil {
+			return nil, err
+		}
+	}
+
+	return instance, nil
+}
+
+func (n *nominatim) Search(ctx context.Context, query string, opts SearchOptions) (*FeatureCollection, error) {
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("format", "geojson")
+	params.Set("addressdetails", "1")
+	if opts.ViewBox != nil {
+		params.Set("viewbox", fmt.Sprintf("%v,%v,%v,%v", opts.ViewBox.MinLon, opts.ViewBox.MinLat, opts.ViewBox.MaxLon, opts.ViewBox.MaxLat))
+		if opts.Bounded {
+			params.Set("bounded", "1")
+		}
+	}
+	if opts.CountryCodes != "" {
+		params.Set("countrycodes", opts.CountryCodes)
+	}
+	if opts.AcceptLanguage != "" {
+		params.Set("accept-language", opts.AcceptLanguage)
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	params.Set("limit", strconv.Itoa(limit))
+
+	return n.get(ctx, "/search", params)
+}
+
+func (n *nominatim) Reverse(ctx context.Context, lat, lon float64, language string) (*FeatureCollection, error) {
+	params := url.Values{}
+	params.Set("lat", strconv.FormatFloat(lat, 'f', -1, 64))
+	params.Set("lon", strconv.FormatFloat(lon, 'f', -1, 64))
+	params.Set("format", "geojson")
+	params.Set("addressdetails", "1")
+	params.Set("zoom", "18")
+	if language != "" {
+		params.Set("accept-language", language)
+	}
+
+	return n.get(ctx, "/reverse", params)
+}
+
+// get retries the unavailable errors with an exponential backoff, as long as the context allows
+func (n *nominatim) get(ctx context.Context, path string, params url.Values) (*FeatureCollection, error) {
+	var err error
+	for attempt := 0; attempt <= n.retries; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(retryBackoff << (attempt - 1))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, fmt.Errorf("%w: %v", ErrorUnavailable, ctx.Err())
+			case <-timer.C:
+			}
+		}
+
+		if n.limit != nil {
+			if err = n.limit(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		var fc *FeatureCollection
+		fc, err = n.do(ctx, path, params)
+		if err == nil {
+			return fc, nil
+		}
+		if !errors.Is(err, ErrorUnavailable) || ctx.Err() != nil {
+			return nil, err
+		}
+		log.Printf("[geocode] nominatim %s attempt %d failed: %v", path, attempt+1, err)
+	}
+
+	return nil, err
+}
+
+func (n *nominatim) do(ctx context.Context, path string, params url.Values) (*FeatureCollection, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, n.baseUrl+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorBadRequest, err)
+	}
+	req.Header.Set("User-Agent", n.userAgent)
+	req.Header.Set("Accept", "application/geo+json, application/json")
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorUnavailable, err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return nil, ErrorRateLimited
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return nil, fmt.Errorf("%w: status %d", ErrorUnavailable, resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("%w: status %d", ErrorBadRequest, resp.StatusCode)
+	}
+
+	// reverse answers 200 with an error object when nothing is at the pin
+	var failure struct {
+		Error interface{} `json:"error"`
+	}
+	if err = jsoniter.Unmarshal(body, &failure); err == nil && failure.Error != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorNotFound, failure.Error)
+	}
+
+	var fc FeatureCollection
+	if err = jsoniter.Unmarshal(body, &fc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorInvalidResponse, err)
+	}
+	if fc.Features == nil {
+		fc.Features = []Feature{}
+	}
+	return &fc, nil
+}
@@ -0,0 +1,189 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testFeatureCollection = `{"type":"FeatureCollection","features":[{"type":"Feature","properties":{"place_id":1,"name":"Souq Waqif"},"geometry":{"type":"Point","coordinates":[51.53,25.29]}}]}`
+
+// newTestNominatim serves every request with the handler and counts the requests
+func newTestNominatim(t *testing.T, retries int, handler http.HandlerFunc, options ...Option) (Geocoder, *int32) {
+	t.Helper()
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	geocoder, err := NewNominatim(NominatimConfig{
+		Addr:    strings.TrimPrefix(server.URL, "http://"),
+		Scheme:  "http",
+		Retries: retries,
+	}, options...)
+	if err != nil {
+		t.Fatalf("new nominatim: %v", err)
+	}
+	return geocoder, &calls
+}
+
+func respond(status int, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}
+}
+
+func TestNominatimSearch(t *testing.T) {
+	geocoder, _ := newTestNominatim(t, 0, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/search" || query.Get("q") != "souq" || query.Get("format") != "geojson" || query.Get("bounded") != "1" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		if r.Header.Get("User-Agent") != DefaultUserAgent {
+			t.Errorf("unexpected user agent %s", r.Header.Get("User-Agent"))
+		}
+		_, _ = w.Write([]byte(testFeatureCollection))
+	})
+
+	fc, err := geocoder.Search(context.Background(), "souq", SearchOptions{
+		ViewBox: &ViewBox{MinLon: 50, MinLat: 24, MaxLon: 52, MaxLat: 27},
+		Bounded: true,
+	})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(fc.Features) != 1 || fc.Features[0].Properties.Name != "Souq Waqif" {
+		t.Fatalf("unexpected features %+v", fc.Features)
+	}
+	if lon, lat, ok := fc.Features[0].Geometry.Point(); !ok || lon != 51.53 || lat != 25.29 {
+		t.Fatalf("unexpected point %v %v", lon, lat)
+	}
+}
+
+func TestNominatimErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    error
+		calls   int32
+	}{
+		{"nothing at the pin", respond(http.StatusOK, `{"error":"Unable to geocode"}`), ErrorNotFound, 1},
+		{"bad request", respond(http.StatusBadRequest, `{}`), ErrorBadRequest, 1},
+		{"not found status", respond(http.StatusNotFound, ``), ErrorBadRequest, 1},
+		{"rate limited", respond(http.StatusTooManyRequests, ``), ErrorRateLimited, 1},
+		{"server error", respond(http.StatusBadGateway, ``), ErrorUnavailable, 3},
+		{"invalid response", respond(http.StatusOK, `<html>`), ErrorInvalidResponse, 1},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			geocoder, calls := newTestNominatim(t, 2, c.handler)
+
+			if _, err := geocoder.Reverse(context.Background(), 25.29, 51.53, ""); !errors.Is(err, c.want) {
+				t.Fatalf("reverse: got %v, want %v", err, c.want)
+			}
+			// only the unavailable errors are retried
+			if got := atomic.LoadInt32(calls); got != c.calls {
+				t.Fatalf("got %d requests, want %d", got, c.calls)
+			}
+		})
+	}
+}
+
+func TestNominatimRetriesUntilAvailable(t *testing.T) {
+	var attempts int32
+	geocoder, calls := newTestNominatim(t, 2, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(testFeatureCollection))
+	})
+
+	fc, err := geocoder.Reverse(context.Background(), 25.29, 51.53, "en")
+	if err != nil {
+		t.Fatalf("reverse: %v", err)
+	}
+	if len(fc.Features) != 1 {
+		t.Fatalf("unexpected features %+v", fc.Features)
+	}
+	if got := atomic.LoadInt32(calls); got != 2 {
+		t.Fatalf("got %d requests, want 2", got)
+	}
+}
+
+func TestNominatimLimitsEveryAttempt(t *testing.T) {
+	var waits int32
+	limiter := WithLimiter(func(ctx context.Context) error {
+		if atomic.AddInt32(&waits, 1) > 3 {
+			return ErrorRateLimited
+		}
+		return nil
+	})
+	geocoder, calls := newTestNominatim(t, 2, respond(http.StatusServiceUnavailable, ""), limiter)
+
+	// the retries wait for the limiter too
+	if _, err := geocoder.Search(context.Background(), "souq", SearchOptions{}); !errors.Is(err, ErrorUnavailable) {
+		t.Fatalf("search: got %v, want %v", err, ErrorUnavailable)
+	}
+	if got, waited := atomic.LoadInt32(calls), atomic.LoadInt32(&waits); got != 3 || waited != 3 {
+		t.Fatalf("got %d requests after %d waits, want 3 of each", got, waited)
+	}
+
+	// a request refused by the limiter isn't sent
+	if _, err := geocoder.Search(context.Background(), "souq", SearchOptions{}); !errors.Is(err, ErrorRateLimited) {
+		t.Fatalf("search: got %v, want %v", err, ErrorRateLimited)
+	}
+	if got := atomic.LoadInt32(calls); got != 3 {
+		t.Fatalf("got %d requests, want 3", got)
+	}
+}
+
+func TestNominatimContextCancel(t *testing.T) {
+	release := make(chan struct{})
+	geocoder, calls := newTestNominatim(t, 2, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := geocoder.Search(ctx, "souq", SearchOptions{}); !errors.Is(err, ErrorUnavailable) {
+		t.Fatalf("search: got %v, want %v", err, ErrorUnavailable)
+	}
+	// the canceled request is neither waited for nor retried
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("search returned after %s", elapsed)
+	}
+	if got := atomic.LoadInt32(calls); got != 1 {
+		t.Fatalf("got %d requests, want 1", got)
+	}
+}
+
+func TestNominatimCanceledDuringBackoff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	geocoder, calls := newTestNominatim(t, 2, func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	if _, err := geocoder.Search(ctx, "souq", SearchOptions{}); !errors.Is(err, ErrorUnavailable) {
+		t.Fatalf("search: got %v, want %v", err, ErrorUnavailable)
+	}
+	if got := atomic.LoadInt32(calls); got != 1 {
+		t.Fatalf("got %d requests, want 1", got)
+	}
+}
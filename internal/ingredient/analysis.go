@@ -2,23 +2,19 @@ package ingredient
 
 import (
 	"context"
+	"crypto/sha256"
 	_ "embed"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"log"
-	"os"
 	"regexp"
 	"strings"
 	"time"
 
-	"github.com/dtm-labs/rockscache"
 	jsoniter "github.com/json-iterator/go"
-	"github.com/knadh/koanf"
-	"github.com/knadh/koanf/parsers/yaml"
-	"github.com/knadh/koanf/providers/file"
 	"github.com/redis/go-redis/v9"
 	"github.com/samber/lo"
-	"github.com/sashabaranov/go-openai"
-	"github.com/spf13/cast"
 	"github.com/tidwall/gjson"
 )
 
@@ -28,10 +24,7 @@ var PromptSystem string
 //go:embed user.txt
 var PromptUser string
 
-var (
-	ErrorMissingKey      = errors.New("key is required")
-	ErrorMissingRedisDsn = errors.New("redis dsn is required")
-)
+var ErrorMissingKey = errors.New("key is required")
 
 const (
 	DefaultCacheExpireMinutes = 60 * 24 * 7
@@ -39,26 +32,65 @@ const (
 
 	DefaultEmbeddingCacheExpireMinutes = 60 * 24 * 7 * 30
 	CacheEmbeddingRedisPrefix          = "bytes:embedding:cache:"
+
+	DefaultTimeoutSeconds = 30
 )
 
 type Config struct {
-	Endpoint     string `koanf:"endpoint"`
-	Key          string `koanf:"key"`
-	Model        string `koanf:"model"`
-	RedisDsn     string `koanf:"redis_dsn"`
-	CacheMinutes int    `koanf:"cache_minutes"`
+	// Provider openai for an OpenAI-compatible api, the default which requires a key, or stub for the
+	// deterministic local one which must be selected explicitly
+	Provider       string `koanf:"provider"`
+	Endpoint       string `koanf:"endpoint"`
+	Key            string `koanf:"key"`
+	Model          string `koanf:"model"`
+	EmbeddingModel string `koanf:"embedding_model"`
+	// TimeoutSeconds of each call to the provider
+	TimeoutSeconds int `koanf:"timeout_seconds"`
+	CacheMinutes   int `koanf:"cache_minutes"`
+}
+
+const (
+	SourceLLM      = "llm"
+	SourceStub     = "stub" // the local stub provider, not a real analysis
+	SourceMerchant = "merchant"
+	SourceAdmin    = "admin"
+)
+
+// Result the ingredients of a dish and where they come from
+type Result struct {
+	Ingredients []string
+	Confidence  *float64 // reported by the model between 0 and 1, nil if not reported or overridden
+	Source      string
+}
+
+// Override an ingredient list confirmed by a merchant or an admin, it wins over the model
+type Override struct {
+	Ingredients []string
+	Source      string
+}
+
+// OverrideStore looks up the confirmed lists by the DishKey of the dishes, the own ones of the merchant go before
+// the ones of the admins. merchantId 0 only looks up the ones of the admins
+type OverrideStore interface {
+	Overrides(ctx context.Context, merchantId int64, keys []string) (map[string]Override, error)
 }
 
 type Analysis interface {
-	Analyze(dishes []string) ([]string, error)
-	Embedding(inputs []string, trim bool) (map[string]string, error)
+	Analyze(ctx context.Context, dishes []string) ([]string, error)
+	// AnalyzeDishes the overrides of the merchant apply, merchantId 0 only applies the ones of the admins
+	AnalyzeDishes(ctx context.Context, merchantId int64, dishes []string) (map[string]Result, error)
+	Embedding(ctx context.Context, inputs []string, trim bool) (map[string]string, error)
+	// EmbeddingModel the model of the embeddings, the vectors of another model aren't comparable
+	EmbeddingModel() string
 }
 
 type analysisImpl struct {
 	Config
 
-	AiClient *openai.Client
-	rcClient *rockscache.Client
+	provider  Provider
+	redis     redis.UniversalClient
+	cache     cache
+	overrides OverrideStore
 }
 
 type Option func(analysis *analysisImpl) error
@@ -70,18 +102,26 @@ func WithConfig(config Config) Option {
 	}
 }
 
-func WithConfigFile(filename string) Option {
+// WithRedis shares the cache of the results between the instances, the cache is kept by the process without it
+func WithRedis(client redis.UniversalClient) Option {
 	return func(analysis *analysisImpl) error {
-		var k = koanf.New(".")
-		if err := k.Load(file.Provider(filename), yaml.Parser()); err != nil {
-			return err
-		}
+		analysis.redis = client
+		return nil
+	}
+}
 
-		var config Config
-		if err := k.Unmarshal("ingredient", &config); err != nil {
-			return err
-		}
+// WithProvider replaces the provider of the config
+func WithProvider(provider Provider) Option {
+	return func(analysis *analysisImpl) error {
+		analysis.provider = provider
+		return nil
+	}
+}
 
+// WithOverrideStore the confirmed lists returned instead of the model ones
+func WithOverrideStore(store OverrideStore) Option {
+	return func(analysis *analysisImpl) error {
+		analysis.overrides = store
 		return nil
 	}
 }
@@ -94,91 +134,95 @@ func New(options ...Option) (Analysis, error) {
 		}
 	}
 
-	if instance.Endpoint == "" {
-		envEndpoint, envEndpointExist := os.LookupEnv("INGREDIENT_ENDPOINT")
-		if envEndpointExist {
-			instance.Endpoint = envEndpoint
-		}
-
-		if instance.Endpoint == "" {
-			instance.Endpoint = "https://api.openai.com/v1"
-		}
+	if instance.TimeoutSeconds <= 0 {
+		instance.TimeoutSeconds = DefaultTimeoutSeconds
 	}
-
-	if instance.Key == "" {
-		envKey, envKeyExist := os.LookupEnv("INGREDIENT_KEY")
-		if envKeyExist {
-			instance.Key = envKey
-		}
-
-		if instance.Key == "" {
-			log.Printf("[ingredient] key is required")
-			return nil, ErrorMissingKey
-		}
+	if instance.CacheMinutes <= 0 {
+		instance.CacheMinutes = DefaultCacheExpireMinutes
 	}
 
-	if instance.Model == "" {
-		envModel, envModelExist := os.LookupEnv("INGREDIENT_MODEL")
-		if envModelExist {
-			instance.Model = envModel
+	if instance.provider == nil {
+		provider := instance.Provider
+		if provider == "" {
+			provider = ProviderOpenAI
 		}
 
-		if instance.Model == "" {
-			instance.Model = openai.GPT4o
+		switch provider {
+		case ProviderOpenAI:
+			p, err := NewOpenAI(instance.Config)
+			if err != nil {
+				return nil, err
+			}
+			instance.provider = p
+		case ProviderStub:
+			instance.provider = NewStub()
+		default:
+			return nil, fmt.Errorf("unsupported ingredient provider %s", provider)
 		}
 	}
 
-	if instance.RedisDsn == "" {
-		envRedisDsn, envRedisDsnExist := os.LookupEnv("INGREDIENT_REDIS_DSN")
-		if envRedisDsnExist {
-			instance.RedisDsn = envRedisDsn
-		}
-
-		if instance.RedisDsn == "" {
-			log.Printf("[ingredient] redis dsn is required")
-			return nil, ErrorMissingRedisDsn
-		}
+	// the stub is cheap and its results must not mix with the ones of a real model in the shared cache
+	if instance.redis != nil && !instance.isStub() {
+		instance.cache = newRedisCache(instance.redis)
+	} else {
+		instance.cache = newMemoryCache(memoryCacheMaxEntries)
 	}
 
-	if instance.CacheMinutes == 0 {
-		envCacheMinutes, envCacheMinutesExist := os.LookupEnv("INGREDIENT_CACHE_MINUTES")
-		if envCacheMinutesExist {
-			instance.CacheMinutes = cast.ToInt(envCacheMinutes)
-		}
+	return instance, nil
+}
 
-		if instance.CacheMinutes == 0 {
-			instance.CacheMinutes = DefaultCacheExpireMinutes
-		}
-	}
+func (a *analysisImpl) isStub() bool {
+	_, stub := a.provider.(*stubProvider)
+	return stub
+}
 
-	redisOptions, err := redis.ParseURL(instance.RedisDsn)
+// Analyze the ingredients of all the dishes without duplicates
+func (a *analysisImpl) Analyze(ctx context.Context, dishes []string) ([]string, error) {
+	results, err := a.AnalyzeDishes(ctx, 0, dishes)
 	if err != nil {
 		return nil, err
 	}
 
-	redisClient := redis.NewClient(redisOptions)
-	if redisClient.Ping(context.Background()).Err() != nil {
-		log.Printf("[ingredient] failed to connect to redis: %v", err)
-		return nil, err
+	var allIngredients []string
+	for _, dish := range dishes {
+		allIngredients = append(allIngredients, results[dish].Ingredients...)
 	}
-	instance.rcClient = rockscache.NewClient(redisClient, rockscache.NewDefaultOptions())
-
-	aiConfig := openai.DefaultConfig(instance.Key)
-	aiConfig.BaseURL = instance.Endpoint
-	instance.AiClient = openai.NewClientWithConfig(aiConfig)
 
-	return instance, nil
+	return lo.Uniq(allIngredients), nil
 }
 
-func (a *analysisImpl) Analyze(dishes []string) ([]string, error) {
-	var allIngredients []string
+// AnalyzeDishes the ingredients by dish, a confirmed override always wins over the cached model result
+func (a *analysisImpl) AnalyzeDishes(ctx context.Context, merchantId int64, dishes []string) (map[string]Result, error) {
+	results := make(map[string]Result, len(dishes))
+
+	overrides := make(map[string]Override)
+	if a.overrides != nil && len(dishes) > 0 {
+		found, err := a.overrides.Overrides(ctx, merchantId, lo.Uniq(lo.Map(dishes, func(dish string, _ int) string {
+			return DishKey(dish)
+		})))
+		if err != nil {
+			log.Printf("[ingredient] failed to fetch overrides: %v", err)
+			return nil, err
+		}
+		overrides = found
+	}
 
 	for _, dish := range dishes {
-		rawIngredients, err := a.rcClient.Fetch(
+		if _, ok := results[dish]; ok {
+			continue
+		}
+
+		if override, ok := overrides[DishKey(dish)]; ok {
+			results[dish] = Result{Ingredients: override.Ingredients, Source: override.Source}
+			continue
+		}
+
+		rawIngredients, err := a.cache.Fetch(
+			ctx,
 			CacheRedisPrefix+dish,
 			time.Duration(a.CacheMinutes)*time.Minute,
 			func() (string, error) {
-				rawJson, err := a.doAnalysis(dish)
+				rawJson, err := a.doAnalysis(ctx, dish)
 				if err != nil {
 					return "", err
 				}
@@ -192,21 +236,33 @@ func (a *analysisImpl) Analyze(dishes []string) ([]string, error) {
 			return nil, err
 		}
 
+		result := Result{Ingredients: []string{}, Source: SourceLLM}
+		if a.isStub() {
+			result.Source = SourceStub
+		}
 		jsonIngredients := gjson.Get(rawIngredients, "ingredients")
 		if jsonIngredients.Exists() && jsonIngredients.IsArray() {
 			lo.ForEach(jsonIngredients.Array(), func(value gjson.Result, _ int) {
 				elem := value.String()
 				if elem != "" {
-					allIngredients = append(allIngredients, elem)
+					result.Ingredients = append(result.Ingredients, elem)
 				}
 			})
 		}
+
+		jsonConfidence := gjson.Get(rawIngredients, "confidence")
+		if jsonConfidence.Exists() && jsonConfidence.Type == gjson.Number {
+			confidence := lo.Clamp(jsonConfidence.Float(), 0, 1)
+			result.Confidence = &confidence
+		}
+
+		results[dish] = result
 	}
 
-	return lo.Uniq(allIngredients), nil
+	return results, nil
 }
 
-func (a *analysisImpl) Embedding(inputs []string, trim bool) (map[string]string, error) {
+func (a *analysisImpl) Embedding(ctx context.Context, inputs []string, trim bool) (map[string]string, error) {
 	embeddingMap := make(map[string]string)
 
 	if len(inputs) == 0 {
@@ -214,11 +270,20 @@ func (a *analysisImpl) Embedding(inputs []string, trim bool) (map[string]string,
 	}
 
 	inputs = lo.Uniq(inputs)
-	keys := lo.Map(inputs, func(value string, _ int) string {
-		return CacheEmbeddingRedisPrefix + value
+	texts := lo.Map(inputs, func(value string, _ int) string {
+		if trim {
+			return trimMenuName(value)
+		}
+
+		return value
+	})
+	model := a.EmbeddingModel()
+	keys := lo.Map(texts, func(text string, _ int) string {
+		return embeddingCacheKey(model, text)
 	})
 
-	strEmbeddings, err := a.rcClient.FetchBatch(
+	strEmbeddings, err := a.cache.FetchBatch(
+		ctx,
 		keys,
 		time.Duration(DefaultEmbeddingCacheExpireMinutes)*time.Minute,
 		func(missingIdxs []int) (map[int]string, error) {
@@ -227,34 +292,26 @@ func (a *analysisImpl) Embedding(inputs []string, trim bool) (map[string]string,
 			missingInputs := lo.Map(
 				missingIdxs,
 				func(inputIdx int, _ int) string {
-					if trim {
-						return trimMenuName(inputs[inputIdx])
-					}
-
-					return inputs[inputIdx]
+					return texts[inputIdx]
 				},
 			)
 
-			resp, err := a.AiClient.CreateEmbeddings(
-				context.Background(),
-				openai.EmbeddingRequestStrings{
-					Model: openai.SmallEmbedding3,
-					Input: missingInputs,
-				},
-			)
+			callCtx, cancel := context.WithTimeout(ctx, a.timeout())
+			defer cancel()
 
+			vectors, err := a.provider.Embed(callCtx, missingInputs)
 			if err != nil {
 				log.Printf("[ingredient] failed to create embedding: %v", err)
 				return nil, err
 			}
 
-			for _, data := range resp.Data {
-				if data.Index < 0 || data.Index >= len(missingIdxs) {
+			for i, vector := range vectors {
+				if vector == nil || i >= len(missingIdxs) {
 					continue
 				}
 
-				sourceIdx := missingIdxs[data.Index]
-				if strEmbedding, err := jsoniter.MarshalToString(data.Embedding); err != nil {
+				sourceIdx := missingIdxs[i]
+				if strEmbedding, err := jsoniter.MarshalToString(vector); err != nil {
 					log.Printf("[ingredient] failed to marshal embedding: %v", err)
 					continue
 				} else {
@@ -282,37 +339,30 @@ func (a *analysisImpl) Embedding(inputs []string, trim bool) (map[string]string,
 	return embeddingMap, nil
 }
 
-func (a *analysisImpl) doAnalysis(dish string) (string, error) {
+func (a *analysisImpl) EmbeddingModel() string {
+	return a.provider.EmbeddingModel()
+}
+
+// embeddingCacheKey by the model and the hash of the text embedded, the vectors of two models never mix
+func embeddingCacheKey(model, text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return CacheEmbeddingRedisPrefix + model + ":" + hex.EncodeToString(sum[:])
+}
+
+func (a *analysisImpl) doAnalysis(ctx context.Context, dish string) (string, error) {
 	if dish == "" {
 		return "", nil
 	}
 
-	resp, err := a.AiClient.CreateChatCompletion(
-		context.Background(),
-		openai.ChatCompletionRequest{
-			Model: a.Model,
-			ResponseFormat: &openai.ChatCompletionResponseFormat{
-				Type: openai.ChatCompletionResponseFormatTypeJSONObject,
-			},
-			Messages: []openai.ChatCompletionMessage{
-				{
-					Role:    openai.ChatMessageRoleSystem,
-					Content: PromptSystem,
-				},
-				{
-					Role:    openai.ChatMessageRoleUser,
-					Content: PromptUser + dish,
-				},
-			},
-		},
-	)
+	callCtx, cancel := context.WithTimeout(ctx, a.timeout())
+	defer cancel()
 
+	rawIngredients, err := a.provider.Complete(callCtx, PromptSystem, PromptUser+dish)
 	if err != nil {
 		log.Printf("[ingredient] failed to create chat completion: %v", err)
 		return "", err
 	}
 
-	rawIngredients := resp.Choices[0].Message.Content
 	jsonIngredients := gjson.Get(rawIngredients, "ingredients")
 	if jsonIngredients.Exists() && jsonIngredients.IsArray() {
 		return rawIngredients, nil
@@ -321,6 +371,15 @@ func (a *analysisImpl) doAnalysis(dish string) (string, error) {
 	return "", nil
 }
 
+func (a *analysisImpl) timeout() time.Duration {
+	return time.Duration(a.TimeoutSeconds) * time.Second
+}
+
+// DishKey the dish name in lower case with single spaces, the key of the overrides
+func DishKey(dish string) string {
+	return strings.Join(strings.Fields(strings.ToLower(dish)), " ")
+}
+
 func trimMenuName(name string) string {
 	menuNameRe := regexp.MustCompile(`\(.*\)`)
 	return strings.TrimSpace(menuNameRe.ReplaceAllString(name, ""))
@@ -0,0 +1,175 @@
+package ingredient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+// fakeOverrideStore the overrides by merchant id, 0 for the ones of the admins
+type fakeOverrideStore map[int64]map[string]Override
+
+func (s fakeOverrideStore) Overrides(ctx context.Context, merchantId int64, keys []string) (map[string]Override, error) {
+	found := make(map[string]Override)
+	for _, key := range keys {
+		if override, ok := s[0][key]; ok {
+			found[key] = override
+		}
+		if override, ok := s[merchantId][key]; ok && merchantId != 0 {
+			found[key] = override
+		}
+	}
+	return found, nil
+}
+
+func newStubAnalysis(t *testing.T, options ...Option) Analysis {
+	t.Helper()
+
+	analysis, err := New(append([]Option{WithConfig(Config{Provider: ProviderStub})}, options...)...)
+	if err != nil {
+		t.Fatalf("new stub analysis: %v", err)
+	}
+	return analysis
+}
+
+func TestNewRequiresKey(t *testing.T) {
+	for _, provider := range []string{"", ProviderOpenAI} {
+		if _, err := New(WithConfig(Config{Provider: provider})); !errors.Is(err, ErrorMissingKey) {
+			t.Fatalf("new with provider %q: got %v, want %v", provider, err, ErrorMissingKey)
+		}
+	}
+	if _, err := New(WithConfig(Config{Provider: "unknown"})); err == nil {
+		t.Fatal("new with an unknown provider: want an error")
+	}
+	newStubAnalysis(t)
+}
+
+func TestAnalyzeDishesWithStub(t *testing.T) {
+	analysis := newStubAnalysis(t)
+	dish := "Chicken with Rice (large)"
+
+	results, err := analysis.AnalyzeDishes(context.Background(), 1, []string{dish, ""})
+	if err != nil {
+		t.Fatalf("analyze dishes: %v", err)
+	}
+
+	result := results[dish]
+	if result.Source != SourceStub {
+		t.Fatalf("got source %s, want %s", result.Source, SourceStub)
+	}
+	if !reflect.DeepEqual(result.Ingredients, []string{"chicken", "rice"}) {
+		t.Fatalf("got ingredients %v", result.Ingredients)
+	}
+	if empty := results[""]; len(empty.Ingredients) != 0 || empty.Source != SourceStub {
+		t.Fatalf("got %+v for the empty dish", empty)
+	}
+}
+
+func TestAnalyzeDishesOverridesByMerchant(t *testing.T) {
+	key := DishKey("Shuwa")
+	store := fakeOverrideStore{
+		0: {key: {Ingredients: []string{"lamb", "spices"}, Source: SourceAdmin}},
+		1: {key: {Ingredients: []string{"goat"}, Source: SourceMerchant}},
+	}
+	analysis := newStubAnalysis(t, WithOverrideStore(store))
+
+	cases := []struct {
+		merchantId int64
+		source     string
+	}{
+		{1, SourceMerchant},
+		{2, SourceAdmin},
+		{0, SourceAdmin},
+	}
+	for _, c := range cases {
+		results, err := analysis.AnalyzeDishes(context.Background(), c.merchantId, []string{" shuwa "})
+		if err != nil {
+			t.Fatalf("analyze dishes: %v", err)
+		}
+		if result := results[" shuwa "]; result.Source != c.source || result.Confidence != nil {
+			t.Fatalf("merchant %d: got %+v, want the override of source %s", c.merchantId, result, c.source)
+		}
+	}
+}
+
+func TestMemoryCacheIsBounded(t *testing.T) {
+	c := newMemoryCache(2).(*memoryCache)
+	ctx := context.Background()
+	calls := 0
+	fetch := func(key string, expire time.Duration) string {
+		value, err := c.Fetch(ctx, key, expire, func() (string, error) {
+			calls++
+			return fmt.Sprintf("%s-%d", key, calls), nil
+		})
+		if err != nil {
+			t.Fatalf("fetch %s: %v", key, err)
+		}
+		return value
+	}
+
+	first := fetch("a", time.Minute)
+	fetch("b", time.Hour)
+	if again := fetch("a", time.Minute); again != first || calls != 2 {
+		t.Fatalf("got %s after %d calls, want the cached %s", again, calls, first)
+	}
+
+	// a full cache drops the entry expiring first
+	fetch("c", time.Hour)
+	if len(c.entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(c.entries))
+	}
+	if _, ok := c.entries["a"]; ok {
+		t.Fatal("the entry expiring first is kept")
+	}
+
+	// the expired entries go before the live ones
+	c.entries["b"] = memoryEntry{value: "b", expireAt: time.Now().Add(-time.Second)}
+	fetch("d", time.Hour)
+	if _, ok := c.entries["c"]; !ok || len(c.entries) != 2 {
+		t.Fatalf("unexpected entries %+v", c.entries)
+	}
+}
+
+func TestEmbeddingCacheKey(t *testing.T) {
+	key := embeddingCacheKey("text-embedding-3-small", "Shuwa")
+	cases := []struct {
+		name  string
+		model string
+		text  string
+		same  bool
+	}{
+		{"same model and text", "text-embedding-3-small", "Shuwa", true},
+		{"another model", "text-embedding-3-large", "Shuwa", false},
+		{"another text", "text-embedding-3-small", "Harees", false},
+		{"another case", "text-embedding-3-small", "shuwa", false},
+	}
+	for _, c := range cases {
+		if got := embeddingCacheKey(c.model, c.text); (got == key) != c.same {
+			t.Fatalf("%s: got %s, the key of Shuwa is %s", c.name, got, key)
+		}
+	}
+
+	long := embeddingCacheKey("text-embedding-3-small", strings.Repeat("Chicken Machboos ", 100))
+	if len(long) != len(key) || !strings.HasPrefix(long, CacheEmbeddingRedisPrefix+"text-embedding-3-small:") {
+		t.Fatalf("unexpected key %s of a long text", long)
+	}
+}
+
+func TestEmbeddingIsCachedByTrimmedText(t *testing.T) {
+	analysis := newStubAnalysis(t)
+	entries := analysis.(*analysisImpl).cache.(*memoryCache).entries
+
+	vectors, err := analysis.Embedding(context.Background(), []string{"Shuwa (large)"}, true)
+	if err != nil || vectors["Shuwa (large)"] == "" {
+		t.Fatalf("embedding: %v %v", err, vectors)
+	}
+	if _, ok := entries[embeddingCacheKey(StubEmbeddingModel, "Shuwa")]; !ok || len(entries) != 1 {
+		t.Fatalf("got cache keys %v, want the one of the trimmed text", lo.Keys(entries))
+	}
+}
@@ -0,0 +1,136 @@
+package ingredient
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/dtm-labs/rockscache"
+	"github.com/redis/go-redis/v9"
+)
+
+// cache the results of the provider, shared through redis or kept by the process
+type cache interface {
+	Fetch(ctx context.Context, key string, expire time.Duration, fn func() (string, error)) (string, error)
+	FetchBatch(ctx context.Context, keys []string, expire time.Duration, fn func(idxs []int) (map[int]string, error)) (map[int]string, error)
+}
+
+type redisCache struct {
+	client *rockscache.Client
+}
+
+func newRedisCache(rdb redis.UniversalClient) cache {
+	return &redisCache{client: rockscache.NewClient(rdb, rockscache.NewDefaultOptions())}
+}
+
+func (c *redisCache) Fetch(ctx context.Context, key string, expire time.Duration, fn func() (string, error)) (string, error) {
+	return c.client.Fetch2(ctx, key, expire, fn)
+}
+
+func (c *redisCache) FetchBatch(ctx context.Context, keys []string, expire time.Duration, fn func(idxs []int) (map[int]string, error)) (map[int]string, error) {
+	return c.client.FetchBatch2(ctx, keys, expire, fn)
+}
+
+// memoryCacheMaxEntries the entries kept by the process, about a few MB of results
+const memoryCacheMaxEntries = 10000
+
+type memoryEntry struct {
+	value    string
+	expireAt time.Time
+}
+
+// memoryCache without redis, the expired entries are dropped when read. Once full, the expired entries
+// are dropped then the one expiring first
+type memoryCache struct {
+	lock       sync.Mutex
+	entries    map[string]memoryEntry
+	maxEntries int
+}
+
+func newMemoryCache(maxEntries int) cache {
+	return &memoryCache{entries: make(map[string]memoryEntry), maxEntries: maxEntries}
+}
+
+func (c *memoryCache) Fetch(ctx context.Context, key string, expire time.Duration, fn func() (string, error)) (string, error) {
+	values, err := c.FetchBatch(ctx, []string{key}, expire, func(idxs []int) (map[int]string, error) {
+		value, err := fn()
+		if err != nil {
+			return nil, err
+		}
+		return map[int]string{0: value}, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return values[0], nil
+}
+
+func (c *memoryCache) FetchBatch(ctx context.Context, keys []string, expire time.Duration, fn func(idxs []int) (map[int]string, error)) (map[int]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	values := make(map[int]string, len(keys))
+	var missingIdxs []int
+	now := time.Now()
+
+	c.lock.Lock()
+	for idx, key := range keys {
+		entry, ok := c.entries[key]
+		if ok && now.Before(entry.expireAt) {
+			values[idx] = entry.value
+			continue
+		}
+		delete(c.entries, key)
+		missingIdxs = append(missingIdxs, idx)
+	}
+	c.lock.Unlock()
+
+	if len(missingIdxs) == 0 {
+		return values, nil
+	}
+
+	missing, err := fn(missingIdxs)
+	if err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, idx := range missingIdxs {
+		value, ok := missing[idx]
+		if !ok || value == "" {
+			// not answered this time, asked again by the next fetch
+			continue
+		}
+		values[idx] = value
+		if _, ok = c.entries[keys[idx]]; !ok && len(c.entries) >= c.maxEntries {
+			c.evict(now)
+		}
+		c.entries[keys[idx]] = memoryEntry{value: value, expireAt: now.Add(expire)}
+	}
+
+	return values, nil
+}
+
+// evict makes room for an entry, it must be called with the lock held
+func (c *memoryCache) evict(now time.Time) {
+	for key, entry := range c.entries {
+		if !now.Before(entry.expireAt) {
+			delete(c.entries, key)
+		}
+	}
+	if len(c.entries) < c.maxEntries {
+		return
+	}
+
+	var firstKey string
+	var firstExpireAt time.Time
+	for key, entry := range c.entries {
+		if firstKey == "" || entry.expireAt.Before(firstExpireAt) {
+			firstKey, firstExpireAt = key, entry.expireAt
+		}
+	}
+	delete(c.entries, firstKey)
+}
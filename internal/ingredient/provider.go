@@ -0,0 +1,118 @@
+package ingredient
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	ProviderOpenAI = "openai"
+	ProviderStub   = "stub"
+
+	DefaultEndpoint       = "https://api.openai.com/v1"
+	DefaultModel          = openai.GPT4o
+	DefaultEmbeddingModel = string(openai.SmallEmbedding3)
+)
+
+var ErrorEmptyCompletion = errors.New("completion has no choice")
+
+// Provider the language model behind the analysis, for the chat completion and the embeddings
+type Provider interface {
+	// Complete the answer of the model to the prompts as a JSON object
+	Complete(ctx context.Context, system, user string) (string, error)
+	// Embed the vectors of the inputs in their order, an input not embedded is nil
+	Embed(ctx context.Context, inputs []string) ([][]float32, error)
+	// EmbeddingModel the vectors of another model aren't comparable
+	EmbeddingModel() string
+}
+
+type openaiProvider struct {
+	client         *openai.Client
+	model          string
+	embeddingModel string
+}
+
+// NewOpenAI a provider for any OpenAI-compatible api
+func NewOpenAI(config Config) (Provider, error) {
+	if config.Key == "" {
+		log.Printf("[ingredient] key is required")
+		return nil, ErrorMissingKey
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = DefaultEndpoint
+	}
+	if config.Model == "" {
+		config.Model = DefaultModel
+	}
+	if config.EmbeddingModel == "" {
+		config.EmbeddingModel = DefaultEmbeddingModel
+	}
+
+	aiConfig := openai.DefaultConfig(config.Key)
+	aiConfig.BaseURL = config.Endpoint
+	return &openaiProvider{
+		client:         openai.NewClientWithConfig(aiConfig),
+		model:          config.Model,
+		embeddingModel: config.EmbeddingModel,
+	}, nil
+}
+
+func (p *openaiProvider) Complete(ctx context.Context, system, user string) (string, error) {
+	resp, err := p.client.CreateChatCompletion(
+		ctx,
+		openai.ChatCompletionRequest{
+			Model: p.model,
+			ResponseFormat: &openai.ChatCompletionResponseFormat{
+				Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+			},
+			Messages: []openai.ChatCompletionMessage{
+				{
+					Role:    openai.ChatMessageRoleSystem,
+					Content: system,
+				},
+				{
+					Role:    openai.ChatMessageRoleUser,
+					Content: user,
+				},
+			},
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", ErrorEmptyCompletion
+	}
+
+	return resp.Choices[0].Message.Content, nil
+}
+
+func (p *openaiProvider) Embed(ctx context.Context, inputs []string) ([][]float32, error) {
+	resp, err := p.client.CreateEmbeddings(
+		ctx,
+		openai.EmbeddingRequestStrings{
+			Model: openai.EmbeddingModel(p.embeddingModel),
+			Input: inputs,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	vectors := make([][]float32, len(inputs))
+	for _, data := range resp.Data {
+		if data.Index < 0 || data.Index >= len(inputs) {
+			continue
+		}
+		vectors[data.Index] = data.Embedding
+	}
+
+	return vectors, nil
+}
+
+func (p *openaiProvider) EmbeddingModel() string {
+	return p.embeddingModel
+}
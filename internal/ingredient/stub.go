@@ -0,0 +1,101 @@
+package ingredient
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/samber/lo"
+)
+
+const (
+	StubEmbeddingModel = "stub-hash-256"
+
+	stubDimensions = 256
+	stubConfidence = 0.8
+)
+
+// stubStopWords the words of a menu name which aren't ingredients
+var stubStopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "the": true, "of": true, "with": true, "in": true, "on": true, "or": true,
+	"style": true, "special": true, "fresh": true, "homemade": true, "classic": true, "small": true, "medium": true,
+	"large": true, "regular": true, "combo": true, "meal": true, "plate": true, "box": true,
+}
+
+type stubProvider struct{}
+
+// NewStub a deterministic local provider for the tests and the offline development, no api is called.
+// The ingredients of a dish are the words of its name, the embeddings hash the words of the input
+func NewStub() Provider {
+	return &stubProvider{}
+}
+
+// Complete answers the dish on the last line of the user prompt
+func (p *stubProvider) Complete(ctx context.Context, system, user string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	lines := strings.Split(strings.TrimSpace(user), "\n")
+	ingredients := lo.Uniq(lo.Filter(stubWords(trimMenuName(lines[len(lines)-1])), func(word string, _ int) bool {
+		return !stubStopWords[word]
+	}))
+
+	confidence := 0.0
+	if len(ingredients) > 0 {
+		confidence = stubConfidence
+	}
+	return jsoniter.MarshalToString(map[string]interface{}{
+		"ingredients": ingredients,
+		"confidence":  confidence,
+	})
+}
+
+func (p *stubProvider) Embed(ctx context.Context, inputs []string) ([][]float32, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	vectors := make([][]float32, len(inputs))
+	for i, input := range inputs {
+		vector := make([]float32, stubDimensions)
+		for _, word := range stubWords(input) {
+			hash := fnv.New32a()
+			_, _ = hash.Write([]byte(word))
+			sum := hash.Sum32()
+			// the sign bit spreads the words so unrelated inputs stay near orthogonal
+			if sum>>31 == 0 {
+				vector[sum%stubDimensions]++
+			} else {
+				vector[sum%stubDimensions]--
+			}
+		}
+
+		var norm float64
+		for _, v := range vector {
+			norm += float64(v * v)
+		}
+		if norm > 0 {
+			scale := float32(1 / math.Sqrt(norm))
+			for j := range vector {
+				vector[j] *= scale
+			}
+		}
+		vectors[i] = vector
+	}
+
+	return vectors, nil
+}
+
+func (p *stubProvider) EmbeddingModel() string {
+	return StubEmbeddingModel
+}
+
+func stubWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+}
@@ -0,0 +1,292 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/spf13/cast"
+)
+
+var (
+	ErrorUnknownProvider = errors.New("unknown oidc provider")
+	ErrorMissingIssuer   = errors.New("issuer is required")
+	ErrorMissingClientId = errors.New("client id is required")
+	ErrorUnknownKey      = errors.New("unknown signing key")
+	ErrorInvalidToken    = errors.New("invalid id token")
+)
+
+const (
+	DefaultKeysCacheMinutes = 60
+	// keysRefreshInterval an unknown kid triggers at most one refresh per interval
+	keysRefreshInterval = time.Minute
+)
+
+type ProviderConfig struct {
+	Name      string   `koanf:"name"`
+	Issuer    string   `koanf:"issuer"`
+	ClientIds []string `koanf:"client_ids"`
+	// JwksUrl is discovered from the issuer when empty
+	JwksUrl string `koanf:"jwks_url"`
+}
+
+// Identity the verified claims of an id token
+type Identity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type Verifier interface {
+	Verify(ctx context.Context, provider, idToken, nonce string) (*Identity, error)
+}
+
+type idTokenClaims struct {
+	Email         string      `json:"email"`
+	EmailVerified interface{} `json:"email_verified"` // apple sends it as a string
+	Name          string      `json:"name"`
+	Nonce         string      `json:"nonce"`
+	jwt.RegisteredClaims
+}
+
+type provider struct {
+	ProviderConfig
+
+	lock        sync.Mutex
+	keys        map[string]interface{}
+	keysFetched time.Time
+}
+
+type verifierImpl struct {
+	providers  map[string]*provider
+	httpClient *http.Client
+}
+
+type Option func(verifier *verifierImpl) error
+
+func WithHTTPClient(client *http.Client) Option {
+	return func(verifier *verifierImpl) error {
+		verifier.httpClient = client
+		return nil
+	}
+}
+
+func New(providers []ProviderConfig, options ...Option) (Verifier, error) {
+	instance := &verifierImpl{
+		providers:  make(map[string]*provider),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, option := range options {
+		if err := option(instance); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, config := range providers {
+		if config.Issuer == "" {
+			return nil, ErrorMissingIssuer
+		}
+		if len(config.ClientIds) == 0 {
+			return nil, ErrorMissingClientId
+		}
+		instance.providers[config.Name] = &provider{ProviderConfig: config}
+	}
+
+	return instance, nil
+}
+
+func (v *verifierImpl) Verify(ctx context.Context, providerName, idToken, nonce string) (*Identity, error) {
+	p, ok := v.providers[providerName]
+	if !ok {
+		return nil, ErrorUnknownProvider
+	}
+
+	claims := &idTokenClaims{}
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{"RS256", "ES256"}))
+	_, err := parser.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return v.getKey(ctx, p, kid)
+	})
+	if err != nil {
+		log.Printf("[oidc] failed to parse id token of %s: %v", providerName, err)
+		return nil, ErrorInvalidToken
+	}
+
+	if !claims.VerifyIssuer(p.Issuer, true) {
+		return nil, fmt.Errorf("%w: unexpected issuer %s", ErrorInvalidToken, claims.Issuer)
+	}
+	audienceOk := false
+	for _, clientId := range p.ClientIds {
+		if claims.VerifyAudience(clientId, true) {
+			audienceOk = true
+			break
+		}
+	}
+	if !audienceOk {
+		return nil, fmt.Errorf("%w: unexpected audience", ErrorInvalidToken)
+	}
+	if claims.ExpiresAt == nil {
+		return nil, fmt.Errorf("%w: missing exp", ErrorInvalidToken)
+	}
+	if nonce != "" && claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrorInvalidToken)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing sub", ErrorInvalidToken)
+	}
+
+	return &Identity{
+		Provider:      providerName,
+		Subject:       claims.Subject,
+		Email:         strings.ToLower(claims.Email),
+		EmailVerified: cast.ToBool(claims.EmailVerified),
+		Name:          claims.Name,
+	}, nil
+}
+
+// getKey returns the cached key, the keys are fetched again when expired or when the kid is unknown
+func (v *verifierImpl) getKey(ctx context.Context, p *provider, kid string) (interface{}, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	expired := time.Since(p.keysFetched) > DefaultKeysCacheMinutes*time.Minute
+	key, ok := p.keys[kid]
+	if ok && !expired {
+		return key, nil
+	}
+
+	if expired || time.Since(p.keysFetched) > keysRefreshInterval {
+		keys, err := v.fetchKeys(ctx, p)
+		if err != nil {
+			log.Printf("[oidc] failed to fetch keys of %s: %v", p.Name, err)
+			return nil, err
+		}
+		p.keys = keys
+		p.keysFetched = time.Now()
+	}
+
+	if key, ok = p.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, ErrorUnknownKey
+}
+
+func (v *verifierImpl) fetchKeys(ctx context.Context, p *provider) (map[string]interface{}, error) {
+	jwksUrl := p.JwksUrl
+	if jwksUrl == "" {
+		var discovery struct {
+			Issuer  string `json:"issuer"`
+			JwksUri string `json:"jwks_uri"`
+		}
+		if err := v.getJson(ctx, strings.TrimSuffix(p.Issuer, "/")+"/.well-known/openid-configuration", &discovery); err != nil {
+			return nil, err
+		}
+		if discovery.JwksUri == "" {
+			return nil, errors.New("jwks_uri is missing in discovery document")
+		}
+		jwksUrl = discovery.JwksUri
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := v.getJson(ctx, jwksUrl, &jwks); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]interface{})
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			log.Printf("[oidc] skip key %s of %s: %v", jwk.Kid, p.Name, err)
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	return keys, nil
+}
+
+func (v *verifierImpl) getJson(ctx context.Context, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request %s failed with code: %d", url, resp.StatusCode)
+	}
+
+	return jsoniter.NewDecoder(resp.Body).Decode(out)
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+}
@@ -0,0 +1,118 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tespkg/bytes-be/internal/oidc/oidctest"
+)
+
+const (
+	testProvider = "test"
+	testClientId = "bytes-app"
+)
+
+func newTestVerifier(t *testing.T) (*verifierImpl, *oidctest.Issuer) {
+	t.Helper()
+
+	issuer, err := oidctest.NewIssuer()
+	if err != nil {
+		t.Fatalf("new issuer: %v", err)
+	}
+	t.Cleanup(issuer.Close)
+
+	verifier, err := New([]ProviderConfig{{Name: testProvider, Issuer: issuer.URL, ClientIds: []string{testClientId}}})
+	if err != nil {
+		t.Fatalf("new verifier: %v", err)
+	}
+	return verifier.(*verifierImpl), issuer
+}
+
+func signTestToken(t *testing.T, issuer *oidctest.Issuer, clientId string, extra map[string]interface{}) string {
+	t.Helper()
+
+	idToken, err := issuer.IDToken(clientId, "subject", "Someone@Example.com", extra)
+	if err != nil {
+		t.Fatalf("sign id token: %v", err)
+	}
+	return idToken
+}
+
+func TestVerify(t *testing.T) {
+	verifier, issuer := newTestVerifier(t)
+
+	identity, err := verifier.Verify(context.Background(), testProvider, signTestToken(t, issuer, testClientId, map[string]interface{}{"nonce": "n"}), "n")
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if identity.Subject != "subject" || identity.Email != "someone@example.com" || !identity.EmailVerified {
+		t.Fatalf("unexpected identity %+v", identity)
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	verifier, issuer := newTestVerifier(t)
+
+	// another issuer signs with another key under the same kid
+	forger, err := oidctest.NewIssuer()
+	if err != nil {
+		t.Fatalf("new issuer: %v", err)
+	}
+	defer forger.Close()
+
+	cases := []struct {
+		name    string
+		idToken string
+		nonce   string
+	}{
+		{"bad signature", signTestToken(t, forger, testClientId, map[string]interface{}{"iss": issuer.URL}), ""},
+		{"wrong audience", signTestToken(t, issuer, "another-app", nil), ""},
+		{"expired", signTestToken(t, issuer, testClientId, map[string]interface{}{"exp": time.Now().Add(-time.Minute).Unix()}), ""},
+		{"wrong issuer", signTestToken(t, issuer, testClientId, map[string]interface{}{"iss": "https://evil.example.com"}), ""},
+		{"nonce mismatch", signTestToken(t, issuer, testClientId, map[string]interface{}{"nonce": "other"}), "n"},
+		{"missing subject", signTestToken(t, issuer, testClientId, map[string]interface{}{"sub": ""}), ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := verifier.Verify(context.Background(), testProvider, c.idToken, c.nonce); !errors.Is(err, ErrorInvalidToken) {
+				t.Fatalf("verify: got %v, want %v", err, ErrorInvalidToken)
+			}
+		})
+	}
+
+	if _, err = verifier.Verify(context.Background(), "unknown", signTestToken(t, issuer, testClientId, nil), ""); !errors.Is(err, ErrorUnknownProvider) {
+		t.Fatalf("verify: got %v, want %v", err, ErrorUnknownProvider)
+	}
+}
+
+func TestVerifyAfterKeyRotation(t *testing.T) {
+	verifier, issuer := newTestVerifier(t)
+	ctx := context.Background()
+
+	before := signTestToken(t, issuer, testClientId, nil)
+	if _, err := verifier.Verify(ctx, testProvider, before, ""); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	if err := issuer.Rotate(); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	after := signTestToken(t, issuer, testClientId, nil)
+
+	// an unknown kid refreshes the keys at most once per interval
+	if _, err := verifier.Verify(ctx, testProvider, after, ""); !errors.Is(err, ErrorInvalidToken) {
+		t.Fatalf("verify within the refresh interval: got %v, want %v", err, ErrorInvalidToken)
+	}
+
+	verifier.providers[testProvider].keysFetched = time.Now().Add(-2 * keysRefreshInterval)
+	if _, err := verifier.Verify(ctx, testProvider, after, ""); err != nil {
+		t.Fatalf("verify after the refresh interval: %v", err)
+	}
+
+	// the old key is no longer published
+	if _, err := verifier.Verify(ctx, testProvider, before, ""); !errors.Is(err, ErrorInvalidToken) {
+		t.Fatalf("verify the token of the old key: got %v, want %v", err, ErrorInvalidToken)
+	}
+}
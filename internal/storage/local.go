@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+)
+
+type local struct {
+	dir string
+}
+
+// NewLocal keeps the objects under dir, for development and tests,
+// the content type is derived from the key extension
+func NewLocal(dir string) (Storage, error) {
+	if dir == "" {
+		return nil, errors.New("local storage dir is required")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create local storage dir fail: %w", err)
+	}
+	return &local{dir: dir}, nil
+}
+
+// Put writes a temp file first, so readers never see a partial object
+func (l *local) Put(_ context.Context, key, _ string, content []byte) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+
+	name := l.path(key)
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+func (l *local) Get(_ context.Context, key string) (*Object, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(l.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrorNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return &Object{ContentType: contentType, Size: info.Size(), Body: file}, nil
+}
+
+func (l *local) Delete(_ context.Context, key string) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+
+	if err := os.Remove(l.path(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (l *local) path(key string) string {
+	return filepath.Join(l.dir, filepath.FromSlash(key))
+}
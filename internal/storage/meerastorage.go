@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DefaultTimeoutSeconds = 30
+
+type MeerastorageConfig struct {
+	// Endpoint base url of the meerastorage server, e.g. http://meerastorage:9090
+	Endpoint string
+	Bucket   string
+	// TimeoutSeconds of each request
+	TimeoutSeconds int
+}
+
+type meerastorage struct {
+	baseUrl    string
+	httpClient *http.Client
+}
+
+type Option func(m *meerastorage) error
+
+func WithHTTPClient(client *http.Client) Option {
+	return func(m *meerastorage) error {
+		m.httpClient = client
+		return nil
+	}
+}
+
+// NewMeerastorage stores the objects in a meerastorage bucket through its object api, {endpoint}/{bucket}/{key}
+func NewMeerastorage(config MeerastorageConfig, options ...Option) (Storage, error) {
+	if config.Endpoint == "" {
+		return nil, errors.New("meerastorage endpoint is required")
+	}
+	if config.Bucket == "" {
+		return nil, errors.New("meerastorage bucket is required")
+	}
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid meerastorage endpoint %s", config.Endpoint)
+	}
+	if config.TimeoutSeconds <= 0 {
+		config.TimeoutSeconds = DefaultTimeoutSeconds
+	}
+
+	instance := &meerastorage{
+		baseUrl:    strings.TrimRight(config.Endpoint, "/") + "/" + url.PathEscape(config.Bucket),
+		httpClient: &http.Client{Timeout: time.Duration(config.TimeoutSeconds) * time.Second},
+	}
+	for _, option := range options {
+		if err := option(instance); err != nil {
+			return nil, err
+		}
+	}
+
+	return instance, nil
+}
+
+func (m *meerastorage) Put(ctx context.Context, key, contentType string, content []byte) error {
+	req, err := m.newRequest(ctx, http.MethodPut, key, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = int64(len(content))
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrorUnavailable, err)
+	}
+	defer drain(resp.Body)
+
+	return checkStatus(resp)
+}
+
+func (m *meerastorage) Get(ctx context.Context, key string) (*Object, error) {
+	req, err := m.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrorUnavailable, err)
+	}
+	if err = checkStatus(resp); err != nil {
+		drain(resp.Body)
+		return nil, err
+	}
+
+	size, _ := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+	return &Object{ContentType: resp.Header.Get("Content-Type"), Size: size, Body: resp.Body}, nil
+}
+
+// Delete a missing object isn't an error
+func (m *meerastorage) Delete(ctx context.Context, key string) error {
+	req, err := m.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrorUnavailable, err)
+	}
+	defer drain(resp.Body)
+
+	if err = checkStatus(resp); err != nil && !errors.Is(err, ErrorNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (m *meerastorage) newRequest(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(key, "/")
+	for i := range parts {
+		parts[i] = url.PathEscape(parts[i])
+	}
+	return http.NewRequestWithContext(ctx, method, m.baseUrl+"/"+strings.Join(parts, "/"), body)
+}
+
+func checkStatus(resp *http.Response) error {
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrorNotFound
+	case resp.StatusCode >= 500:
+		return fmt.Errorf("%w: status %d", ErrorUnavailable, resp.StatusCode)
+	default:
+		return fmt.Errorf("storage: unexpected status %d", resp.StatusCode)
+	}
+}
+
+// drain lets the connection be reused
+func drain(body io.ReadCloser) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(body, 64<<10))
+	_ = body.Close()
+}
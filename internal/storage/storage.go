@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+)
+
+var (
+	ErrorNotFound    = errors.New("storage: object not found")
+	ErrorInvalidKey  = errors.New("storage: invalid object key")
+	ErrorUnavailable = errors.New("storage: service unavailable")
+)
+
+// Object the body must be closed by the caller
+type Object struct {
+	ContentType string
+	Size        int64
+	Body        io.ReadCloser
+}
+
+// Storage keeps objects by key, keys are slash separated relative paths like merchants/1/images/x.jpg
+type Storage interface {
+	Put(ctx context.Context, key, contentType string, content []byte) error
+	Get(ctx context.Context, key string) (*Object, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// ValidateKey rejects the keys escaping the root, absolute or not clean
+func ValidateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") || path.Clean(key) != key {
+		return ErrorInvalidKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == ".." || part == "." {
+			return ErrorInvalidKey
+		}
+	}
+	return nil
+}
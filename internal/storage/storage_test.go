@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateKey(t *testing.T) {
+	cases := []struct {
+		key   string
+		valid bool
+	}{
+		{"merchants/1/images/a.jpg", true},
+		{"a.jpg", true},
+		{"merchants/1/images/a..b.jpg", true},
+		{"", false},
+		{"/etc/passwd", false},
+		{"../secret", false},
+		{"merchants/../../secret", false},
+		{"merchants/./a.jpg", false},
+		{"merchants//a.jpg", false},
+		{"merchants/1/", false},
+		{".", false},
+		{"..", false},
+		{`merchants\..\secret`, false},
+	}
+	for _, c := range cases {
+		err := ValidateKey(c.key)
+		if c.valid && err != nil {
+			t.Fatalf("key %q: got %v", c.key, err)
+		}
+		if !c.valid && !errors.Is(err, ErrorInvalidKey) {
+			t.Fatalf("key %q: got %v, want %v", c.key, err, ErrorInvalidKey)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Package testenv provides the redis and postgres of the tests. Redis runs in memory, postgres is the server of
+// TEST_POSTGRES_DSN with a fresh schema migrated for each test, the tests needing it are skipped without it
+package testenv
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/redis/go-redis/v9"
+	"github.com/tespkg/bytes-be/common/global"
+	"github.com/tespkg/bytes-be/res_embed"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const PostgresDsnEnv = "TEST_POSTGRES_DSN"
+
+// Redis an in-memory redis closed with the test
+func Redis(t testing.TB) (*redis.Client, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+	return client, server
+}
+
+// Global sets the global client set for the test and restores it after
+func Global(t testing.TB, clientSet global.ClientSet) {
+	t.Helper()
+
+	previous := global.GlobalClientSets
+	global.GlobalClientSets = clientSet
+	t.Cleanup(func() {
+		global.GlobalClientSets = previous
+	})
+}
+
+// Postgres a session on a fresh schema with every migration applied, dropped with the test
+func Postgres(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv(PostgresDsnEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", PostgresDsnEnv)
+	}
+
+	admin, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open postgres: %v", err)
+	}
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	if err = admin.Exec(fmt.Sprintf(`CREATE SCHEMA "%s"`, schema)).Error; err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = admin.Exec(fmt.Sprintf(`DROP SCHEMA "%s" CASCADE`, schema)).Error
+		if sqlDb, err := admin.DB(); err == nil {
+			_ = sqlDb.Close()
+		}
+	})
+
+	schemaDsn, err := withSearchPath(dsn, schema)
+	if err != nil {
+		t.Fatalf("parse %s: %v", PostgresDsnEnv, err)
+	}
+
+	fs, err := iofs.New(res_embed.PgMigrationFiles, "migration/pg")
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	m, err := migrate.NewWithSourceInstance("iofs", fs, schemaDsn)
+	if err != nil {
+		t.Fatalf("init migrate: %v", err)
+	}
+	if err = m.Up(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	_, _ = m.Close()
+
+	db, err := gorm.Open(postgres.Open(schemaDsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open postgres: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDb, err := db.DB(); err == nil {
+			_ = sqlDb.Close()
+		}
+	})
+	return db
+}
+
+func withSearchPath(dsn, schema string) (string, error) {
+	parsedUrl, err := url.Parse(dsn)
+	if err != nil {
+		return "", err
+	}
+
+	query := parsedUrl.Query()
+	query.Set("search_path", schema)
+	parsedUrl.RawQuery = query.Encode()
+	return parsedUrl.String(), nil
+}
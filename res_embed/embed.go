@@ -4,3 +4,6 @@ import "embed"
 
 //go:embed migration/pg/*.sql
 var PgMigrationFiles embed.FS
+
+//go:embed template/email/*
+var EmailTemplateFiles embed.FS
@@ -0,0 +1,22 @@
+create table if not exists refresh_tokens
+(
+    "id"                            bigserial                   primary key not null,
+    "user_id"                       bigint                      not null references users(id),
+    "family_id"                     text                        not null, -- all rotated tokens of one login share the family
+    "token_hash"                    text                        not null, -- sha256 of the opaque token
+    "access_jti"                    text                        not null, -- jti of the access token issued together
+    "platform"                      text                        not null default '',
+    "imei"                          text                        not null default '',
+    "client_version"                text                        not null default '',
+    "model"                         text                        not null default '',
+    "system_version"                text                        not null default '',
+    "expires_at"                    timestamp with time zone    not null,
+    "revoked_at"                    timestamp with time zone    default null,
+    "replaced_by_id"                bigint                      default null,
+    "created_at"                    timestamp with time zone    not null default now(),
+    "updated_at"                    timestamp with time zone    not null default now()
+);
+
+create unique index if not exists uidx_refresh_tokens_token_hash on refresh_tokens(token_hash);
+create index if not exists idx_refresh_tokens_family_id on refresh_tokens(family_id);
+create index if not exists idx_refresh_tokens_user_id on refresh_tokens(user_id);
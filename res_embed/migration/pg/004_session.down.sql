@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_refresh_tokens_user_id_active;
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS last_seen_at;
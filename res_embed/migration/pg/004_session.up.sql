@@ -0,0 +1,3 @@
+alter table refresh_tokens add column if not exists "last_seen_at" timestamp with time zone default null;
+
+create index if not exists idx_refresh_tokens_user_id_active on refresh_tokens(user_id) WHERE revoked_at IS NULL;
@@ -0,0 +1,14 @@
+create table if not exists user_identities
+(
+    "id"                            bigserial                   primary key not null,
+    "user_id"                       bigint                      not null references users(id),
+    "provider"                      text                        not null, -- google, apple
+    "subject"                       text                        not null, -- sub claim of the id token
+    "email"                         text                        default null,
+    "created_at"                    timestamp with time zone    not null default now(),
+    "updated_at"                    timestamp with time zone    not null default now(),
+    "deleted_at"                    timestamp with time zone    default null
+);
+
+create unique index if not exists uidx_user_identities_provider_subject on user_identities(provider, subject) WHERE deleted_at IS NULL;
+create index if not exists idx_user_identities_user_id on user_identities(user_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS uidx_customer_addresses_customer_id_default;
+ALTER TABLE customer_addresses DROP COLUMN IF EXISTS is_default;
+ALTER TABLE customer_addresses DROP COLUMN IF EXISTS label;
@@ -0,0 +1,4 @@
+alter table customer_addresses add column if not exists "label" text default null; -- home, work, other
+alter table customer_addresses add column if not exists "is_default" boolean not null default false;
+
+create unique index if not exists uidx_customer_addresses_customer_id_default on customer_addresses(customer_id) WHERE is_default AND deleted_at IS NULL;
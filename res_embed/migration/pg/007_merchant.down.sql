@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS merchant_status_histories;
+DROP TABLE IF EXISTS merchant_documents;
+DROP TABLE IF EXISTS merchants;
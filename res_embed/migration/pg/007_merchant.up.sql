@@ -0,0 +1,56 @@
+create table if not exists merchants
+(
+    "id"                            bigserial                   primary key not null,
+    "user_id"                       bigint                      not null references users(id),
+    "legal_name"                    text                        not null,
+    "trade_name"                    text                        default null,
+    "cr_number"                     text                        not null, -- commercial registration number
+    "contact_name"                  text                        not null,
+    "contact_phone"                 text                        not null,
+    "contact_email"                 text                        default null,
+    "logo"                          text                        default null,
+    "address"                       text                        not null,
+    "longitude"                     numeric(20,10)              not null,
+    "latitude"                      numeric(20,10)              not null,
+    "status"                        text                        not null default 'pending', -- pending, approved, rejected, suspended
+    "status_reason"                 text                        default null,
+    "approved_at"                   timestamp with time zone    default null,
+    "created_at"                    timestamp with time zone    not null default now(),
+    "updated_at"                    timestamp with time zone    not null default now(),
+    "deleted_at"                    timestamp with time zone    default null
+);
+
+create unique index if not exists uidx_merchants_user_id on merchants(user_id) WHERE deleted_at IS NULL;
+create unique index if not exists uidx_merchants_cr_number on merchants(cr_number) WHERE deleted_at IS NULL;
+create index if not exists idx_merchants_status on merchants(status);
+
+
+create table if not exists merchant_documents
+(
+    "id"                            bigserial                   primary key not null,
+    "merchant_id"                   bigint                      not null references merchants(id),
+    "type"                          text                        not null, -- cr_certificate, owner_id, food_license, other
+    "file_name"                     text                        not null,
+    "content_type"                  text                        not null,
+    "size"                          bigint                      not null,
+    "content"                       bytea                       not null,
+    "created_at"                    timestamp with time zone    not null default now(),
+    "updated_at"                    timestamp with time zone    not null default now(),
+    "deleted_at"                    timestamp with time zone    default null
+);
+
+create index if not exists idx_merchant_documents_merchant_id on merchant_documents(merchant_id);
+
+
+create table if not exists merchant_status_histories
+(
+    "id"                            bigserial                   primary key not null,
+    "merchant_id"                   bigint                      not null references merchants(id),
+    "from_status"                   text                        default null, -- null when registered
+    "to_status"                     text                        not null,
+    "reason"                        text                        default null,
+    "operator_id"                   bigint                      not null references users(id),
+    "created_at"                    timestamp with time zone    not null default now()
+);
+
+create index if not exists idx_merchant_status_histories_merchant_id on merchant_status_histories(merchant_id);
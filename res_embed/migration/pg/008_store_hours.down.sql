@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS merchant_special_days;
+DROP TABLE IF EXISTS merchant_hours;
+ALTER TABLE merchants DROP COLUMN IF EXISTS pause_reason;
+ALTER TABLE merchants DROP COLUMN IF EXISTS paused_until;
+ALTER TABLE merchants DROP COLUMN IF EXISTS timezone;
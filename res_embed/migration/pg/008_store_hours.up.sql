@@ -0,0 +1,34 @@
+alter table merchants add column if not exists "timezone" text not null default 'Asia/Muscat';
+alter table merchants add column if not exists "paused_until" timestamp with time zone default null;
+alter table merchants add column if not exists "pause_reason" text default null;
+
+
+create table if not exists merchant_hours
+(
+    "id"                            bigserial                   primary key not null,
+    "merchant_id"                   bigint                      not null references merchants(id),
+    "weekday"                       int                         not null, -- 0:sunday ... 6:saturday
+    "open_time"                     text                        not null, -- HH:MM in the merchant timezone
+    "close_time"                    text                        not null, -- HH:MM, not after open_time means the next day
+    "created_at"                    timestamp with time zone    not null default now(),
+    "updated_at"                    timestamp with time zone    not null default now(),
+    "deleted_at"                    timestamp with time zone    default null
+);
+
+create index if not exists idx_merchant_hours_merchant_id on merchant_hours(merchant_id);
+
+
+create table if not exists merchant_special_days
+(
+    "id"                            bigserial                   primary key not null,
+    "merchant_id"                   bigint                      not null references merchants(id),
+    "date"                          text                        not null, -- YYYY-MM-DD in the merchant timezone
+    "closed"                        bool                        not null default false,
+    "shifts"                        jsonb                       not null default '[]', -- [{"openTime":"10:00","closeTime":"14:00"}]
+    "note"                          text                        default null, -- e.g. Eid holiday
+    "created_at"                    timestamp with time zone    not null default now(),
+    "updated_at"                    timestamp with time zone    not null default now(),
+    "deleted_at"                    timestamp with time zone    default null
+);
+
+create unique index if not exists uidx_merchant_special_days_merchant_id_date on merchant_special_days(merchant_id, date) WHERE deleted_at IS NULL;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS modifier_options;
+DROP TABLE IF EXISTS modifier_groups;
+DROP TABLE IF EXISTS product_variants;
+DROP TABLE IF EXISTS products;
+DROP TABLE IF EXISTS categories;
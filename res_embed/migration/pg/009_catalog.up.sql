@@ -0,0 +1,88 @@
+create table if not exists categories
+(
+    "id"                            bigserial                   primary key not null,
+    "merchant_id"                   bigint                      not null references merchants(id),
+    "name"                          text                        not null,
+    "description"                   text                        default null,
+    "sort_order"                    int                         not null default 0,
+    "is_available"                  bool                        not null default true,
+    "created_at"                    timestamp with time zone    not null default now(),
+    "updated_at"                    timestamp with time zone    not null default now(),
+    "deleted_at"                    timestamp with time zone    default null
+);
+
+create index if not exists idx_categories_merchant_id on categories(merchant_id);
+
+
+create table if not exists products
+(
+    "id"                            bigserial                   primary key not null,
+    "merchant_id"                   bigint                      not null references merchants(id),
+    "category_id"                   bigint                      default null references categories(id),
+    "kind"                          text                        not null, -- food, clothing
+    "name"                          text                        not null,
+    "description"                   text                        default null,
+    "image"                         text                        default null,
+    "price"                         bigint                      not null, -- minor units of the currency, e.g. baisa
+    "currency"                      text                        not null default 'OMR',
+    "sort_order"                    int                         not null default 0,
+    "is_available"                  bool                        not null default true,
+    "created_at"                    timestamp with time zone    not null default now(),
+    "updated_at"                    timestamp with time zone    not null default now(),
+    "deleted_at"                    timestamp with time zone    default null
+);
+
+create index if not exists idx_products_merchant_id on products(merchant_id);
+create index if not exists idx_products_category_id on products(category_id);
+
+
+create table if not exists product_variants
+(
+    "id"                            bigserial                   primary key not null,
+    "merchant_id"                   bigint                      not null references merchants(id),
+    "product_id"                    bigint                      not null references products(id),
+    "sku"                           text                        not null,
+    "name"                          text                        not null, -- e.g. M / Red
+    "attributes"                    jsonb                       not null default '{}', -- {"size":"M","color":"red"}
+    "price"                         bigint                      default null, -- null means the product price
+    "sort_order"                    int                         not null default 0,
+    "is_available"                  bool                        not null default true,
+    "created_at"                    timestamp with time zone    not null default now(),
+    "updated_at"                    timestamp with time zone    not null default now(),
+    "deleted_at"                    timestamp with time zone    default null
+);
+
+create index if not exists idx_product_variants_product_id on product_variants(product_id);
+create unique index if not exists uidx_product_variants_merchant_id_sku on product_variants(merchant_id, sku) WHERE deleted_at IS NULL;
+
+
+create table if not exists modifier_groups
+(
+    "id"                            bigserial                   primary key not null,
+    "product_id"                    bigint                      not null references products(id),
+    "name"                          text                        not null, -- e.g. Extras
+    "min_select"                    int                         not null default 0,
+    "max_select"                    int                         not null default 1,
+    "sort_order"                    int                         not null default 0,
+    "created_at"                    timestamp with time zone    not null default now(),
+    "updated_at"                    timestamp with time zone    not null default now(),
+    "deleted_at"                    timestamp with time zone    default null
+);
+
+create index if not exists idx_modifier_groups_product_id on modifier_groups(product_id);
+
+
+create table if not exists modifier_options
+(
+    "id"                            bigserial                   primary key not null,
+    "group_id"                      bigint                      not null references modifier_groups(id),
+    "name"                          text                        not null, -- e.g. Extra cheese
+    "price"                         bigint                      not null default 0, -- added to the item price
+    "sort_order"                    int                         not null default 0,
+    "is_available"                  bool                        not null default true,
+    "created_at"                    timestamp with time zone    not null default now(),
+    "updated_at"                    timestamp with time zone    not null default now(),
+    "deleted_at"                    timestamp with time zone    default null
+);
+
+create index if not exists idx_modifier_options_group_id on modifier_options(group_id);
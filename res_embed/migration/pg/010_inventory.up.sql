@@ -0,0 +1,40 @@
+create table if not exists inventories
+(
+    "id"                            bigserial                   primary key not null,
+    "merchant_id"                   bigint                      not null references merchants(id),
+    "product_id"                    bigint                      not null references products(id),
+    "variant_id"                    bigint                      default null references product_variants(id), -- null for a product without variants
+    "quantity"                      int                         not null default 0, -- on hand, including the reserved
+    "reserved"                      int                         not null default 0 check (reserved >= 0),
+    "low_stock_threshold"           int                         not null default 0,
+    "low_stock_notified"            bool                        not null default false,
+    "daily_quantity"                int                         default null, -- the quantity is reset to it every day, e.g. limited dishes
+    "stock_date"                    text                        default null, -- YYYY-MM-DD of the last daily reset in the merchant timezone
+    "created_at"                    timestamp with time zone    not null default now(),
+    "updated_at"                    timestamp with time zone    not null default now(),
+    "deleted_at"                    timestamp with time zone    default null
+);
+
+create index if not exists idx_inventories_merchant_id on inventories(merchant_id);
+create unique index if not exists uidx_inventories_product_id_variant_id on inventories(product_id, coalesce(variant_id, 0)) WHERE deleted_at IS NULL;
+
+
+create table if not exists stock_reservations
+(
+    "id"                            bigserial                   primary key not null,
+    "inventory_id"                  bigint                      not null references inventories(id),
+    "merchant_id"                   bigint                      not null references merchants(id),
+    "order_ref"                     text                        not null,
+    "quantity"                      int                         not null check (quantity > 0),
+    "status"                        text                        not null default 'reserved', -- reserved, committed, released
+    "expires_at"                    timestamp with time zone    not null, -- released by the sweeper when the payment times out
+    "release_reason"                text                        default null,
+    "created_at"                    timestamp with time zone    not null default now(),
+    "updated_at"                    timestamp with time zone    not null default now(),
+    "deleted_at"                    timestamp with time zone    default null
+);
+
+create index if not exists idx_stock_reservations_order_ref on stock_reservations(order_ref);
+create index if not exists idx_stock_reservations_status_expires_at on stock_reservations(status, expires_at);
+-- an order holds one reservation per inventory item, i.e. per product and variant, concurrent retries can't double it
+create unique index if not exists uidx_stock_reservations_order_ref_inventory_id on stock_reservations(order_ref, inventory_id) WHERE status = 'reserved' AND deleted_at IS NULL;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS uidx_products_merchant_id_sku;
+ALTER TABLE products DROP COLUMN IF EXISTS sku;
@@ -0,0 +1,5 @@
+alter table products add column if not exists "sku" text default null; -- unique per merchant, the key of the catalog import
+
+update products set sku = 'P' || id where sku is null;
+
+create unique index if not exists uidx_products_merchant_id_sku on products(merchant_id, sku) WHERE deleted_at IS NULL;
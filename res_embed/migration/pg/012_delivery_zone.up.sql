@@ -0,0 +1,20 @@
+create table if not exists delivery_zones
+(
+    "id"                            bigserial                   primary key not null,
+    "merchant_id"                   bigint                      not null references merchants(id),
+    "name"                          text                        not null,
+    "area"                          jsonb                       not null, -- GeoJSON Polygon or MultiPolygon, [lon, lat] in WGS84
+    "min_lon"                       double precision            not null, -- bounding box of the area to prefilter the points
+    "min_lat"                       double precision            not null,
+    "max_lon"                       double precision            not null,
+    "max_lat"                       double precision            not null,
+    "min_order"                     bigint                      not null default 0, -- minor units of the currency
+    "delivery_fee"                  bigint                      not null default 0, -- minor units of the currency
+    "is_active"                     bool                        not null default true,
+    "created_at"                    timestamp with time zone    not null default now(),
+    "updated_at"                    timestamp with time zone    not null default now(),
+    "deleted_at"                    timestamp with time zone    default null
+);
+
+create index if not exists idx_delivery_zones_merchant_id on delivery_zones(merchant_id);
+create index if not exists idx_delivery_zones_bbox on delivery_zones(min_lat, max_lat) WHERE deleted_at IS NULL AND is_active;
@@ -0,0 +1,10 @@
+DROP TRIGGER IF EXISTS trg_merchants_search ON merchants;
+DROP TRIGGER IF EXISTS trg_categories_search ON categories;
+DROP TRIGGER IF EXISTS trg_products_search ON products;
+DROP FUNCTION IF EXISTS merchants_search_update();
+DROP FUNCTION IF EXISTS categories_search_update();
+DROP FUNCTION IF EXISTS products_search_update();
+ALTER TABLE merchants DROP COLUMN IF EXISTS search_ar;
+ALTER TABLE merchants DROP COLUMN IF EXISTS search_en;
+ALTER TABLE products DROP COLUMN IF EXISTS search_ar;
+ALTER TABLE products DROP COLUMN IF EXISTS search_en;
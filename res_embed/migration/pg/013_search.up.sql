@@ -0,0 +1,69 @@
+alter table products add column if not exists "search_en" tsvector default null;
+alter table products add column if not exists "search_ar" tsvector default null;
+alter table merchants add column if not exists "search_en" tsvector default null;
+alter table merchants add column if not exists "search_ar" tsvector default null;
+
+
+-- the name weighs the most, then the category, then the description
+create or replace function products_search_update() returns trigger as $$
+declare
+    category_name text;
+begin
+    select name into category_name from categories where id = new.category_id;
+
+    new.search_en :=
+        setweight(to_tsvector('english', coalesce(new.name, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(category_name, '')), 'B') ||
+        setweight(to_tsvector('english', coalesce(new.description, '')), 'C');
+    new.search_ar :=
+        setweight(to_tsvector('arabic', coalesce(new.name, '')), 'A') ||
+        setweight(to_tsvector('arabic', coalesce(category_name, '')), 'B') ||
+        setweight(to_tsvector('arabic', coalesce(new.description, '')), 'C');
+    return new;
+end
+$$ language plpgsql;
+
+drop trigger if exists trg_products_search on products;
+create trigger trg_products_search before insert or update of name, description, category_id on products
+    for each row execute procedure products_search_update();
+
+
+-- a renamed category refreshes its products
+create or replace function categories_search_update() returns trigger as $$
+begin
+    update products set name = name where category_id = new.id;
+    return null;
+end
+$$ language plpgsql;
+
+drop trigger if exists trg_categories_search on categories;
+create trigger trg_categories_search after update of name on categories
+    for each row when (old.name is distinct from new.name) execute procedure categories_search_update();
+
+
+create or replace function merchants_search_update() returns trigger as $$
+begin
+    new.search_en :=
+        setweight(to_tsvector('english', coalesce(new.trade_name, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(new.legal_name, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(new.address, '')), 'C');
+    new.search_ar :=
+        setweight(to_tsvector('arabic', coalesce(new.trade_name, '')), 'A') ||
+        setweight(to_tsvector('arabic', coalesce(new.legal_name, '')), 'A') ||
+        setweight(to_tsvector('arabic', coalesce(new.address, '')), 'C');
+    return new;
+end
+$$ language plpgsql;
+
+drop trigger if exists trg_merchants_search on merchants;
+create trigger trg_merchants_search before insert or update of legal_name, trade_name, address on merchants
+    for each row execute procedure merchants_search_update();
+
+
+update products set name = name;
+update merchants set legal_name = legal_name;
+
+create index if not exists idx_products_search_en on products using gin(search_en);
+create index if not exists idx_products_search_ar on products using gin(search_ar);
+create index if not exists idx_merchants_search_en on merchants using gin(search_en);
+create index if not exists idx_merchants_search_ar on merchants using gin(search_ar);
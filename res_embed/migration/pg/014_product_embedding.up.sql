@@ -0,0 +1,13 @@
+create table if not exists product_embeddings
+(
+    "product_id"                    bigint                      primary key not null references products(id),
+    "merchant_id"                   bigint                      not null references merchants(id),
+    "model"                         text                        not null,
+    "content_hash"                  text                        not null, -- sha1 of the model and the embedded text, the product is re-embedded when it changes
+    "embedding"                     jsonb                       not null, -- array of float
+    "created_at"                    timestamp with time zone    not null default now(),
+    "updated_at"                    timestamp with time zone    not null default now(),
+    "deleted_at"                    timestamp with time zone    default null
+);
+
+create index if not exists idx_product_embeddings_updated_at on product_embeddings(updated_at);
@@ -0,0 +1,18 @@
+-- status failed: the analysis of a food without any list failed, it is retried and never matches the allergen filters.
+-- status reconfirm: a confirmed list of a renamed food, kept until the merchant confirms it again
+create table if not exists product_ingredients
+(
+    "product_id"                    bigint                      primary key not null references products(id),
+    "merchant_id"                   bigint                      not null references merchants(id),
+    "ingredients"                   jsonb                       not null default '[]',
+    "allergens"                     jsonb                       not null default '[]', -- codes of the 14 major allergens
+    "status"                        text                        not null, -- generated, confirmed, failed, reconfirm
+    "analyzed_name"                 text                        default null, -- the product name the generated list is for
+    "confirmed_at"                  timestamp with time zone    default null,
+    "analysis_error"                text                        default null, -- the last analysis failure, cleared by the next success
+    "analysis_failed_at"            timestamp with time zone    default null,
+    "created_at"                    timestamp with time zone    not null default now(),
+    "updated_at"                    timestamp with time zone    not null default now()
+);
+
+create index if not exists idx_product_ingredients_merchant_id on product_ingredients(merchant_id);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS ingredient_overrides;
+DROP TABLE IF EXISTS product_ingredient_audits;
+DROP INDEX IF EXISTS idx_product_ingredients_status;
+ALTER TABLE product_ingredients DROP COLUMN IF EXISTS confirmed_by;
+ALTER TABLE product_ingredients DROP COLUMN IF EXISTS confidence;
+ALTER TABLE product_ingredients DROP COLUMN IF EXISTS source;
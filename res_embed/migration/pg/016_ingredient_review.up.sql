@@ -0,0 +1,38 @@
+alter table product_ingredients add column if not exists "source" text not null default 'llm'; -- llm, merchant, admin
+alter table product_ingredients add column if not exists "confidence" real default null; -- reported by the model between 0 and 1
+alter table product_ingredients add column if not exists "confirmed_by" bigint default null references users(id);
+
+create index if not exists idx_product_ingredients_status on product_ingredients(status, updated_at);
+
+create table if not exists product_ingredient_audits
+(
+    "id"                            bigserial                   primary key not null,
+    "product_id"                    bigint                      not null references products(id),
+    "merchant_id"                   bigint                      not null references merchants(id),
+    "action"                        text                        not null, -- analyze, update, confirm, admin_update, admin_approve
+    "source"                        text                        not null, -- llm, merchant, admin
+    "status"                        text                        not null, -- generated, flagged, confirmed
+    "ingredients"                   jsonb                       not null default '[]',
+    "allergens"                     jsonb                       not null default '[]',
+    "confidence"                    real                        default null,
+    "operator_id"                   bigint                      default null references users(id), -- null for the analysis
+    "note"                          text                        default null,
+    "created_at"                    timestamp with time zone    not null default now()
+);
+
+create index if not exists idx_product_ingredient_audits_product_id on product_ingredient_audits(product_id);
+
+create table if not exists ingredient_overrides
+(
+    "id"                            bigserial                   primary key not null,
+    "dish"                          text                        not null, -- the dish name in lower case with single spaces
+    "ingredients"                   jsonb                       not null default '[]',
+    "source"                        text                        not null, -- merchant, admin
+    "merchant_id"                   bigint                      default null references merchants(id), -- null for the admin reviews, which apply to every merchant
+    "product_id"                    bigint                      default null references products(id), -- the product confirmed last
+    "operator_id"                   bigint                      not null references users(id),
+    "created_at"                    timestamp with time zone    not null default now(),
+    "updated_at"                    timestamp with time zone    not null default now()
+);
+
+create unique index if not exists uidx_ingredient_overrides_dish_merchant_id on ingredient_overrides(dish, coalesce(merchant_id, 0));
@@ -0,0 +1,21 @@
+create table if not exists orders
+(
+    "id"                            bigserial                   primary key not null,
+    "order_ref"                     text                        not null, -- the stock reservations of the order go by it
+    "user_id"                       bigint                      not null references users(id),
+    "merchant_id"                   bigint                      not null references merchants(id),
+    "status"                        text                        not null default 'placed', -- placed, accepted, cancelled
+    "items"                         jsonb                       not null, -- the lines priced when the order is placed
+    "total"                         bigint                      not null, -- minor units of the currency
+    "currency"                      text                        not null,
+    "expires_at"                    timestamp with time zone    not null, -- cancelled with its stock released if not accepted by then
+    "cancel_reason"                 text                        default null,
+    "created_at"                    timestamp with time zone    not null default now(),
+    "updated_at"                    timestamp with time zone    not null default now(),
+    "deleted_at"                    timestamp with time zone    default null
+);
+
+create unique index if not exists uidx_orders_order_ref on orders(order_ref);
+create index if not exists idx_orders_user_id on orders(user_id);
+create index if not exists idx_orders_merchant_id_status on orders(merchant_id, status);
+create index if not exists idx_orders_status_expires_at on orders(status, expires_at);
@@ -0,0 +1,291 @@
+package logic
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/tespkg/bytes-be/common/geo"
+	"github.com/tespkg/bytes-be/common/xerr"
+	"github.com/tespkg/bytes-be/internal/geocode"
+	"github.com/tespkg/bytes-be/svc/staff/model/dao"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+	"gorm.io/gorm"
+	"strings"
+)
+
+func ListCustomerAddresses(session *gorm.DB, user *dao.User) (*dto.ListCustomerAddressResp, error) {
+	customer, err := getCustomer(session, user.Id)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>ListCustomerAddresses ")
+	}
+
+	addresses, err := dao.ListCustomerAddresses(session, customer.Id)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>ListCustomerAddresses, dao.ListCustomerAddresses fail")
+	}
+
+	resp := &dto.ListCustomerAddressResp{Addresses: make([]dto.CustomerAddressResp, 0, len(addresses))}
+	for i := range addresses {
+		resp.Addresses = append(resp.Addresses, *newCustomerAddressResp(&addresses[i]))
+	}
+	return resp, nil
+}
+
+func GetCustomerAddress(session *gorm.DB, user *dao.User, addressId int64) (*dto.CustomerAddressResp, error) {
+	customer, err := getCustomer(session, user.Id)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>GetCustomerAddress ")
+	}
+
+	address, err := getCustomerAddress(session, customer.Id, addressId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>GetCustomerAddress ")
+	}
+	return newCustomerAddressResp(address), nil
+}
+
+// CreateCustomerAddress the first address of a customer is always the default one
+func CreateCustomerAddress(ctx context.Context, session *gorm.DB, user *dao.User, req *dto.CustomerAddressReq) (*dto.CustomerAddressResp, error) {
+	customer, err := getCustomer(session, user.Id)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>CreateCustomerAddress ")
+	}
+
+	address := &dao.CustomerAddress{CustomerId: customer.Id}
+	if err = fillCustomerAddress(ctx, address, req); err != nil {
+		return nil, errors.Wrap(err, ">>CreateCustomerAddress ")
+	}
+
+	tx := session.Begin()
+	if err = tx.Error; err != nil {
+		return nil, errors.Wrap(err, ">>CreateCustomerAddress, transaction begin fail")
+	}
+	defer tx.Rollback()
+
+	count, err := dao.CountCustomerAddresses(tx, customer.Id)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>CreateCustomerAddress, dao.CountCustomerAddresses fail")
+	}
+	address.IsDefault = req.IsDefault || count == 0
+	if address.IsDefault {
+		if err = dao.ClearDefaultCustomerAddress(tx, customer.Id); err != nil {
+			return nil, errors.Wrap(err, ">>CreateCustomerAddress, dao.ClearDefaultCustomerAddress fail")
+		}
+	}
+
+	if err = address.Save(tx); err != nil {
+		return nil, errors.Wrap(err, ">>CreateCustomerAddress, address.Save fail")
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		return nil, errors.Wrap(err, ">>CreateCustomerAddress, transaction commit fail")
+	}
+
+	return newCustomerAddressResp(address), nil
+}
+
+// UpdateCustomerAddress replaces the address, unsetting the default flag is ignored because a default must remain
+func UpdateCustomerAddress(ctx context.Context, session *gorm.DB, user *dao.User, addressId int64, req *dto.CustomerAddressReq) (*dto.CustomerAddressResp, error) {
+	customer, err := getCustomer(session, user.Id)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>UpdateCustomerAddress ")
+	}
+
+	address, err := getCustomerAddress(session, customer.Id, addressId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>UpdateCustomerAddress ")
+	}
+	if err = fillCustomerAddress(ctx, address, req); err != nil {
+		return nil, errors.Wrap(err, ">>UpdateCustomerAddress ")
+	}
+
+	tx := session.Begin()
+	if err = tx.Error; err != nil {
+		return nil, errors.Wrap(err, ">>UpdateCustomerAddress, transaction begin fail")
+	}
+	defer tx.Rollback()
+
+	if req.IsDefault && !address.IsDefault {
+		if err = dao.ClearDefaultCustomerAddress(tx, customer.Id); err != nil {
+			return nil, errors.Wrap(err, ">>UpdateCustomerAddress, dao.ClearDefaultCustomerAddress fail")
+		}
+		address.IsDefault = true
+	}
+
+	if err = address.Save(tx); err != nil {
+		return nil, errors.Wrap(err, ">>UpdateCustomerAddress, address.Save fail")
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		return nil, errors.Wrap(err, ">>UpdateCustomerAddress, transaction commit fail")
+	}
+
+	return newCustomerAddressResp(address), nil
+}
+
+func SetDefaultCustomerAddress(session *gorm.DB, user *dao.User, addressId int64) (*dto.CustomerAddressResp, error) {
+	customer, err := getCustomer(session, user.Id)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>SetDefaultCustomerAddress ")
+	}
+
+	address, err := getCustomerAddress(session, customer.Id, addressId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>SetDefaultCustomerAddress ")
+	}
+	if address.IsDefault {
+		return newCustomerAddressResp(address), nil
+	}
+
+	tx := session.Begin()
+	if err = tx.Error; err != nil {
+		return nil, errors.Wrap(err, ">>SetDefaultCustomerAddress, transaction begin fail")
+	}
+	defer tx.Rollback()
+
+	if err = dao.ClearDefaultCustomerAddress(tx, customer.Id); err != nil {
+		return nil, errors.Wrap(err, ">>SetDefaultCustomerAddress, dao.ClearDefaultCustomerAddress fail")
+	}
+	address.IsDefault = true
+	if err = address.Save(tx); err != nil {
+		return nil, errors.Wrap(err, ">>SetDefaultCustomerAddress, address.Save fail")
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		return nil, errors.Wrap(err, ">>SetDefaultCustomerAddress, transaction commit fail")
+	}
+
+	return newCustomerAddressResp(address), nil
+}
+
+// DeleteCustomerAddress deleting the default address makes the latest remaining one the default
+func DeleteCustomerAddress(session *gorm.DB, user *dao.User, addressId int64) error {
+	customer, err := getCustomer(session, user.Id)
+	if err != nil {
+		return errors.Wrap(err, ">>DeleteCustomerAddress ")
+	}
+
+	address, err := getCustomerAddress(session, customer.Id, addressId)
+	if err != nil {
+		return errors.Wrap(err, ">>DeleteCustomerAddress ")
+	}
+
+	tx := session.Begin()
+	if err = tx.Error; err != nil {
+		return errors.Wrap(err, ">>DeleteCustomerAddress, transaction begin fail")
+	}
+	defer tx.Rollback()
+
+	if err = dao.DeleteCustomerAddress(tx, customer.Id, address.Id); err != nil {
+		return errors.Wrap(err, ">>DeleteCustomerAddress, dao.DeleteCustomerAddress fail")
+	}
+	if address.IsDefault {
+		if err = dao.SetDefaultCustomerAddressToLatest(tx, customer.Id); err != nil {
+			return errors.Wrap(err, ">>DeleteCustomerAddress, dao.SetDefaultCustomerAddressToLatest fail")
+		}
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		return errors.Wrap(err, ">>DeleteCustomerAddress, transaction commit fail")
+	}
+	return nil
+}
+
+func ValidateCustomerAddress(req *dto.CustomerAddressReq) error {
+	switch req.Label {
+	case "", dao.CustomerAddressLabelHome, dao.CustomerAddressLabelWork, dao.CustomerAddressLabelOther:
+		return nil
+	}
+	return errors.New("label must be home, work or other")
+}
+
+// fillCustomerAddress copies the request into the address, the empty fields are filled by reverse geocoding the pin.
+// A geocoding failure isn't fatal as long as the app sent the address text.
+func fillCustomerAddress(ctx context.Context, address *dao.CustomerAddress, req *dto.CustomerAddressReq) error {
+	address.Label = nullableString(req.Label)
+	address.Country = nullableString(req.Country)
+	address.State = nullableString(req.State)
+	address.City = nullableString(req.City)
+	address.Street = nullableString(req.Street)
+	address.ZipCode = nullableString(req.ZipCode)
+	address.Address = strings.TrimSpace(req.Address)
+	address.Latitude = *req.Latitude
+	address.Longitude = *req.Longitude
+
+	if needReverseGeocoding(address) {
+		place, err := geo.Reverse(ctx, address.Latitude, address.Longitude, "")
+		if err != nil {
+			logrus.Errorf("reverse geocoding %v,%v fail: %s", address.Latitude, address.Longitude, err)
+		} else {
+			autofillCustomerAddress(address, place)
+		}
+	}
+
+	if address.Address == "" {
+		return xerr.NewErrCodeMsg(xerr.RequestParamError, "address is required, the location can't be resolved")
+	}
+	return nil
+}
+
+func needReverseGeocoding(address *dao.CustomerAddress) bool {
+	return address.Address == "" || address.Country == nil || address.State == nil ||
+		address.City == nil || address.Street == nil || address.ZipCode == nil
+}
+
+// autofillCustomerAddress reads the first feature of the reverse geocoding result
+func autofillCustomerAddress(address *dao.CustomerAddress, place *geocode.FeatureCollection) {
+	if len(place.Features) == 0 {
+		return
+	}
+	properties := place.Features[0].Properties
+	details := properties.Address
+	if details == nil {
+		details = &geocode.PlaceAddress{}
+	}
+
+	if address.Country == nil {
+		address.Country = nullableString(details.Country)
+	}
+	if address.State == nil {
+		address.State = nullableString(firstNonEmpty(details.State, details.Province, details.Region))
+	}
+	if address.City == nil {
+		address.City = nullableString(firstNonEmpty(details.City, details.Town, details.Village, details.Suburb))
+	}
+	if address.Street == nil {
+		address.Street = nullableString(firstNonEmpty(details.Road, details.Pedestrian, details.Neighbourhood))
+	}
+	if address.ZipCode == nil {
+		address.ZipCode = nullableString(details.Postcode)
+	}
+	if address.Address == "" {
+		address.Address = properties.DisplayName
+	}
+}
+
+func getCustomerAddress(session *gorm.DB, customerId, addressId int64) (*dao.CustomerAddress, error) {
+	address, err := dao.GetCustomerAddress(session, customerId, addressId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>getCustomerAddress, dao.GetCustomerAddress fail")
+	}
+	if address == nil || address.Id == 0 {
+		return nil, xerr.NewErrCode(xerr.CustomerAddressNotExist)
+	}
+	return address, nil
+}
+
+func newCustomerAddressResp(address *dao.CustomerAddress) *dto.CustomerAddressResp {
+	return &dto.CustomerAddressResp{
+		Id:        address.Id,
+		Label:     address.Label,
+		IsDefault: address.IsDefault,
+		Country:   address.Country,
+		State:     address.State,
+		City:      address.City,
+		Street:    address.Street,
+		ZipCode:   address.ZipCode,
+		Address:   address.Address,
+		Latitude:  address.Latitude,
+		Longitude: address.Longitude,
+	}
+}
@@ -0,0 +1,35 @@
+package logic
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/tespkg/bytes-be/common/token"
+	"github.com/tespkg/bytes-be/common/xerr"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+	"gorm.io/gorm"
+	"net/http"
+)
+
+func RefreshToken(ctx context.Context, session *gorm.DB, req *dto.RefreshTokenReq) (*dto.TokenResp, error) {
+	pair, err := token.RotateRefreshToken(ctx, session, req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, token.RefreshTokenInvalidError) ||
+			errors.Is(err, token.RefreshTokenExpiredError) ||
+			errors.Is(err, token.RefreshTokenReusedError) {
+			return nil, xerr.NewHttpError(http.StatusUnauthorized, err.Error())
+		}
+		return nil, errors.Wrap(err, ">>RefreshToken, token.RotateRefreshToken fail")
+	}
+
+	resp := newTokenResp(pair)
+	return &resp, nil
+}
+
+func newTokenResp(pair *token.TokenPair) dto.TokenResp {
+	return dto.TokenResp{
+		LoginToken:            pair.AccessToken,
+		LoginTokenExpiresAt:   pair.AccessTokenExpiresAt.Unix(),
+		RefreshToken:          pair.RefreshToken,
+		RefreshTokenExpiresAt: pair.RefreshTokenExpiresAt.Unix(),
+	}
+}
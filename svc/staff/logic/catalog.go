@@ -0,0 +1,715 @@
+package logic
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/tespkg/bytes-be/common/xerr"
+	"github.com/tespkg/bytes-be/svc/staff/model/dao"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+	"gorm.io/gorm"
+	"strings"
+)
+
+func ListCategories(session *gorm.DB, merchant *dao.Merchant) (*dto.ListCategoryResp, error) {
+	categories, err := dao.ListCategories(session, merchant.Id, false)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>ListCategories, dao.ListCategories fail")
+	}
+
+	resp := &dto.ListCategoryResp{Categories: make([]dto.CategoryResp, 0, len(categories))}
+	for i := range categories {
+		resp.Categories = append(resp.Categories, newCategoryResp(&categories[i]))
+	}
+	return resp, nil
+}
+
+func CreateCategory(session *gorm.DB, merchant *dao.Merchant, req *dto.CategoryReq) (*dto.CategoryResp, error) {
+	category := &dao.Category{MerchantId: merchant.Id}
+	fillCategory(category, req)
+	if err := category.Save(session); err != nil {
+		return nil, errors.Wrap(err, ">>CreateCategory, category.Save fail")
+	}
+
+	resp := newCategoryResp(category)
+	return &resp, nil
+}
+
+func UpdateCategory(session *gorm.DB, merchant *dao.Merchant, categoryId int64, req *dto.CategoryReq) (*dto.CategoryResp, error) {
+	category, err := getCategory(session, merchant.Id, categoryId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>UpdateCategory ")
+	}
+
+	fillCategory(category, req)
+	if err = category.Save(session); err != nil {
+		return nil, errors.Wrap(err, ">>UpdateCategory, category.Save fail")
+	}
+
+	resp := newCategoryResp(category)
+	return &resp, nil
+}
+
+// DeleteCategory the products of the category are kept without category
+func DeleteCategory(session *gorm.DB, merchant *dao.Merchant, categoryId int64) error {
+	if _, err := getCategory(session, merchant.Id, categoryId); err != nil {
+		return errors.Wrap(err, ">>DeleteCategory ")
+	}
+
+	tx := session.Begin()
+	if err := tx.Error; err != nil {
+		return errors.Wrap(err, ">>DeleteCategory, transaction begin fail")
+	}
+	defer tx.Rollback()
+
+	if err := dao.DeleteCategory(tx, merchant.Id, categoryId); err != nil {
+		return errors.Wrap(err, ">>DeleteCategory, dao.DeleteCategory fail")
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return errors.Wrap(err, ">>DeleteCategory, transaction commit fail")
+	}
+	return nil
+}
+
+func ListProducts(session *gorm.DB, merchant *dao.Merchant, req *dto.ListProductReq) (*dto.ListProductResp, error) {
+	products, err := dao.ListProducts(session, merchant.Id, req.CategoryId, false)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>ListProducts, dao.ListProducts fail")
+	}
+	stocks, err := loadProductStocks(session, merchant.Timezone, products)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>ListProducts ")
+	}
+	ingredients, err := loadProductIngredients(session, products)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>ListProducts ")
+	}
+
+	resp := &dto.ListProductResp{Products: make([]dto.ProductResp, 0, len(products))}
+	for i := range products {
+		resp.Products = append(resp.Products, newProductResp(&products[i], stocks, ingredients))
+	}
+	return resp, nil
+}
+
+func GetProduct(session *gorm.DB, merchant *dao.Merchant, productId int64) (*dto.ProductResp, error) {
+	product, err := getProduct(session, merchant.Id, productId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>GetProduct ")
+	}
+	stocks, err := loadProductStocks(session, merchant.Timezone, []dao.Product{*product})
+	if err != nil {
+		return nil, errors.Wrap(err, ">>GetProduct ")
+	}
+	ingredients, err := loadProductIngredients(session, []dao.Product{*product})
+	if err != nil {
+		return nil, errors.Wrap(err, ">>GetProduct ")
+	}
+
+	resp := newProductResp(product, stocks, ingredients)
+	return &resp, nil
+}
+
+func CreateProduct(session *gorm.DB, merchant *dao.Merchant, req *dto.ProductReq) (*dto.ProductResp, error) {
+	product := &dao.Product{MerchantId: merchant.Id, Currency: dao.CurrencyOMR}
+	if err := saveProduct(session, product, req); err != nil {
+		return nil, errors.Wrap(err, ">>CreateProduct ")
+	}
+
+	return GetProduct(session, merchant, product.Id)
+}
+
+// UpdateProduct replaces the product, the variants and modifiers are matched by id so their ids stay stable
+func UpdateProduct(session *gorm.DB, merchant *dao.Merchant, productId int64, req *dto.ProductReq) (*dto.ProductResp, error) {
+	product, err := getProduct(session, merchant.Id, productId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>UpdateProduct ")
+	}
+
+	if err = saveProduct(session, product, req); err != nil {
+		return nil, errors.Wrap(err, ">>UpdateProduct ")
+	}
+
+	return GetProduct(session, merchant, product.Id)
+}
+
+// UpdateProductAvailability marks the product sold out or back, without touching the rest
+func UpdateProductAvailability(session *gorm.DB, merchant *dao.Merchant, productId int64, isAvailable bool) (*dto.ProductResp, error) {
+	if _, err := getProduct(session, merchant.Id, productId); err != nil {
+		return nil, errors.Wrap(err, ">>UpdateProductAvailability ")
+	}
+
+	if err := dao.UpdateProductAvailability(session, merchant.Id, productId, isAvailable); err != nil {
+		return nil, errors.Wrap(err, ">>UpdateProductAvailability, dao.UpdateProductAvailability fail")
+	}
+
+	return GetProduct(session, merchant, productId)
+}
+
+func DeleteProduct(session *gorm.DB, merchant *dao.Merchant, productId int64) error {
+	if _, err := getProduct(session, merchant.Id, productId); err != nil {
+		return errors.Wrap(err, ">>DeleteProduct ")
+	}
+
+	tx := session.Begin()
+	if err := tx.Error; err != nil {
+		return errors.Wrap(err, ">>DeleteProduct, transaction begin fail")
+	}
+	defer tx.Rollback()
+
+	if err := dao.DeleteProduct(tx, merchant.Id, productId); err != nil {
+		return errors.Wrap(err, ">>DeleteProduct, dao.DeleteProduct fail")
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return errors.Wrap(err, ">>DeleteProduct, transaction commit fail")
+	}
+	return nil
+}
+
+// GetStoreCatalog the available categories and products of an approved store for customers
+func GetStoreCatalog(session *gorm.DB, storeId int64, req *dto.StoreCatalogReq) (*dto.StoreCatalogResp, error) {
+	excluded, err := parseAllergenFilter(&req.AllergenFilterReq)
+	if err != nil {
+		return nil, err
+	}
+	store, err := GetStore(session, storeId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>GetStoreCatalog ")
+	}
+
+	categories, err := dao.ListCategories(session, storeId, true)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>GetStoreCatalog, dao.ListCategories fail")
+	}
+	products, err := dao.ListProducts(session, storeId, 0, true)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>GetStoreCatalog, dao.ListProducts fail")
+	}
+	stocks, err := loadProductStocks(session, store.Timezone, products)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>GetStoreCatalog ")
+	}
+	ingredients, err := loadProductIngredients(session, products)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>GetStoreCatalog ")
+	}
+
+	resp := &dto.StoreCatalogResp{
+		Store:      *store,
+		Categories: make([]dto.CatalogCategoryResp, 0, len(categories)),
+		Others:     []dto.ProductResp{},
+	}
+	index := make(map[int64]int, len(categories))
+	for i := range categories {
+		index[categories[i].Id] = i
+		resp.Categories = append(resp.Categories, dto.CatalogCategoryResp{
+			CategoryResp: newCategoryResp(&categories[i]),
+			Products:     []dto.ProductResp{},
+		})
+	}
+
+	for i := range products {
+		product := &products[i]
+		if !allergenFree(product, ingredients[product.Id], excluded) {
+			continue
+		}
+		if product.CategoryId == nil {
+			resp.Others = append(resp.Others, newProductResp(product, stocks, ingredients))
+			continue
+		}
+		//the products of an unavailable category are hidden with it
+		if idx, ok := index[*product.CategoryId]; ok {
+			resp.Categories[idx].Products = append(resp.Categories[idx].Products, newProductResp(product, stocks, ingredients))
+		}
+	}
+
+	return resp, nil
+}
+
+func GetStoreProduct(session *gorm.DB, storeId, productId int64) (*dto.ProductResp, error) {
+	store, product, err := getStoreProduct(session, storeId, productId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>GetStoreProduct ")
+	}
+	stocks, err := loadProductStocks(session, store.Timezone, []dao.Product{*product})
+	if err != nil {
+		return nil, errors.Wrap(err, ">>GetStoreProduct ")
+	}
+	ingredients, err := loadProductIngredients(session, []dao.Product{*product})
+	if err != nil {
+		return nil, errors.Wrap(err, ">>GetStoreProduct ")
+	}
+
+	resp := newProductResp(product, stocks, ingredients)
+	return &resp, nil
+}
+
+// PriceStoreProduct the unit price of the variant and options picked by the customer on a product of the store
+func PriceStoreProduct(session *gorm.DB, storeId int64, req *dto.ProductSelectionReq) (*dto.ProductPriceResp, error) {
+	_, product, err := getStoreProduct(session, storeId, req.ProductId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>PriceStoreProduct ")
+	}
+	price, err := PriceProductSelection(product, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.ProductPriceResp{
+		ProductId: product.Id,
+		VariantId: req.VariantId,
+		OptionIds: req.OptionIds,
+		UnitPrice: price,
+		Currency:  product.Currency,
+	}, nil
+}
+
+// PriceProductSelection checks the variant and the modifier options picked by the customer,
+// and returns the unit price in minor units, the variants and modifiers must be preloaded
+func PriceProductSelection(product *dao.Product, req *dto.ProductSelectionReq) (int64, error) {
+	if !product.IsAvailable {
+		return 0, xerr.NewErrCode(xerr.ProductUnavailable)
+	}
+
+	price := product.Price
+	if len(product.Variants) > 0 {
+		variant := product.Variant(req.VariantId)
+		if variant == nil {
+			return 0, xerr.NewErrCodeMsg(xerr.ProductSelectionInvalid, "a variant of the product is required")
+		}
+		if !variant.IsAvailable {
+			return 0, xerr.NewErrCode(xerr.ProductUnavailable)
+		}
+		if variant.Price != nil {
+			price = *variant.Price
+		}
+	} else if req.VariantId != 0 {
+		return 0, xerr.NewErrCodeMsg(xerr.ProductSelectionInvalid, "the product has no variant")
+	}
+
+	selected := make(map[int64]bool, len(req.OptionIds))
+	for _, optionId := range req.OptionIds {
+		if selected[optionId] {
+			return 0, xerr.NewErrCodeMsg(xerr.ProductSelectionInvalid, "an option is selected twice")
+		}
+		selected[optionId] = true
+	}
+
+	matched := 0
+	for _, group := range product.ModifierGroups {
+		count := 0
+		for _, option := range group.Options {
+			if !selected[option.Id] {
+				continue
+			}
+			if !option.IsAvailable {
+				return 0, xerr.NewErrCodeMsg(xerr.ProductUnavailable, option.Name+" is unavailable")
+			}
+			count++
+			price += option.Price
+		}
+		if count < group.MinSelect || count > group.MaxSelect {
+			return 0, xerr.NewErrCodeMsg(xerr.ProductSelectionInvalid,
+				fmt.Sprintf("%s requires %d to %d options", group.Name, group.MinSelect, group.MaxSelect))
+		}
+		matched += count
+	}
+	if matched != len(selected) {
+		return 0, xerr.NewErrCodeMsg(xerr.ProductSelectionInvalid, "an option is not of the product")
+	}
+
+	return price, nil
+}
+
+// saveProduct saves the product with its variants and modifiers in a transaction, the product is updated if it has an id
+func saveProduct(session *gorm.DB, product *dao.Product, req *dto.ProductReq) error {
+	if err := validateProduct(session, product, req); err != nil {
+		return err
+	}
+
+	product.CategoryId = nil
+	if req.CategoryId != nil && *req.CategoryId > 0 {
+		product.CategoryId = req.CategoryId
+	}
+	if sku := strings.TrimSpace(req.Sku); sku != "" {
+		product.Sku = &sku
+	}
+	product.Kind = req.Kind
+	product.Name = strings.TrimSpace(req.Name)
+	product.Description = nullableString(req.Description)
+	product.Image = nullableString(req.Image)
+	product.Price = *req.Price
+	product.SortOrder = req.SortOrder
+	product.IsAvailable = boolOrDefault(req.IsAvailable, true)
+
+	tx := session.Begin()
+	if err := tx.Error; err != nil {
+		return errors.Wrap(err, ">>saveProduct, transaction begin fail")
+	}
+	defer tx.Rollback()
+
+	if err := product.Save(tx); err != nil {
+		return errors.Wrap(err, ">>saveProduct, product.Save fail")
+	}
+	if err := ensureProductSku(tx, product); err != nil {
+		return errors.Wrap(err, ">>saveProduct ")
+	}
+	if err := reconfirmProductIngredient(tx, product); err != nil {
+		return errors.Wrap(err, ">>saveProduct ")
+	}
+	if err := saveProductVariants(tx, product, req.Variants); err != nil {
+		return errors.Wrap(err, ">>saveProduct ")
+	}
+	if err := saveModifierGroups(tx, product, req.ModifierGroups); err != nil {
+		return errors.Wrap(err, ">>saveProduct ")
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return errors.Wrap(err, ">>saveProduct, transaction commit fail")
+	}
+	return nil
+}
+
+func validateProduct(session *gorm.DB, product *dao.Product, req *dto.ProductReq) error {
+	if req.CategoryId != nil && *req.CategoryId > 0 {
+		if _, err := getCategory(session, product.MerchantId, *req.CategoryId); err != nil {
+			return err
+		}
+	}
+
+	if err := validateMerchantImage(product.MerchantId, req.Image); err != nil {
+		return err
+	}
+
+	if sku := strings.TrimSpace(req.Sku); sku != "" {
+		owner, err := dao.GetProductBySku(session, product.MerchantId, sku)
+		if err != nil {
+			return errors.Wrap(err, ">>validateProduct, dao.GetProductBySku fail")
+		}
+		if owner != nil && owner.Id > 0 && owner.Id != product.Id {
+			return xerr.NewErrCodeMsg(xerr.ProductSkuExist, "the SKU "+sku+" is used by another product")
+		}
+	}
+
+	if req.Kind == dao.ProductKindClothing && len(req.Variants) == 0 {
+		return xerr.NewErrCodeMsg(xerr.RequestParamError, "a clothing product requires variants")
+	}
+
+	skus := make(map[string]bool, len(req.Variants))
+	for _, item := range req.Variants {
+		if item.Id != 0 && product.Variant(item.Id) == nil {
+			return xerr.NewErrCodeMsg(xerr.RequestParamError, fmt.Sprintf("variant %d is not of the product", item.Id))
+		}
+
+		sku := strings.TrimSpace(item.Sku)
+		if sku == "" || skus[sku] {
+			return xerr.NewErrCodeMsg(xerr.RequestParamError, "the SKUs must be unique and not empty")
+		}
+		skus[sku] = true
+
+		variant, err := dao.GetProductVariantBySku(session, product.MerchantId, sku)
+		if err != nil {
+			return errors.Wrap(err, ">>validateProduct, dao.GetProductVariantBySku fail")
+		}
+		if variant != nil && variant.Id > 0 && variant.ProductId != product.Id {
+			return xerr.NewErrCodeMsg(xerr.ProductSkuExist, "the SKU "+sku+" is used by another product")
+		}
+	}
+
+	groups := make(map[int64]*dao.ModifierGroup, len(product.ModifierGroups))
+	for i := range product.ModifierGroups {
+		groups[product.ModifierGroups[i].Id] = &product.ModifierGroups[i]
+	}
+	for _, item := range req.ModifierGroups {
+		if item.MinSelect > item.MaxSelect || item.MaxSelect > len(item.Options) {
+			return xerr.NewErrCodeMsg(xerr.RequestParamError,
+				item.Name+": minSelect must not exceed maxSelect, and maxSelect must not exceed the options")
+		}
+
+		if item.Id == 0 {
+			for _, option := range item.Options {
+				if option.Id != 0 {
+					return xerr.NewErrCodeMsg(xerr.RequestParamError, fmt.Sprintf("option %d is not of the group", option.Id))
+				}
+			}
+			continue
+		}
+
+		group, ok := groups[item.Id]
+		if !ok {
+			return xerr.NewErrCodeMsg(xerr.RequestParamError, fmt.Sprintf("modifier group %d is not of the product", item.Id))
+		}
+		for _, option := range item.Options {
+			if option.Id != 0 && group.Option(option.Id) == nil {
+				return xerr.NewErrCodeMsg(xerr.RequestParamError, fmt.Sprintf("option %d is not of the group", option.Id))
+			}
+		}
+	}
+
+	return nil
+}
+
+// ensureProductSku generates the SKU of a product created without one, the product must be saved
+func ensureProductSku(tx *gorm.DB, product *dao.Product) error {
+	if product.Sku != nil {
+		return nil
+	}
+
+	sku := fmt.Sprintf("P%d", product.Id)
+	for i := 1; ; i++ {
+		owner, err := dao.GetProductBySku(tx, product.MerchantId, sku)
+		if err != nil {
+			return errors.Wrap(err, ">>ensureProductSku, dao.GetProductBySku fail")
+		}
+		if owner == nil || owner.Id == 0 {
+			break
+		}
+		sku = fmt.Sprintf("P%d-%d", product.Id, i)
+	}
+
+	product.Sku = &sku
+	if err := product.Save(tx); err != nil {
+		return errors.Wrap(err, ">>ensureProductSku, product.Save fail")
+	}
+	return nil
+}
+
+// saveProductVariants the variants missing in the request are deleted first, so their SKUs can be reused
+func saveProductVariants(tx *gorm.DB, product *dao.Product, items []dto.ProductVariantReq) error {
+	keepIds := make([]int64, 0, len(items))
+	for _, item := range items {
+		if item.Id != 0 {
+			keepIds = append(keepIds, item.Id)
+		}
+	}
+	if err := dao.DeleteProductVariants(tx, product.Id, keepIds); err != nil {
+		return errors.Wrap(err, ">>saveProductVariants, dao.DeleteProductVariants fail")
+	}
+
+	for _, item := range items {
+		variant := &dao.ProductVariant{}
+		if existing := product.Variant(item.Id); existing != nil {
+			variant = existing
+		}
+		variant.MerchantId = product.MerchantId
+		variant.ProductId = product.Id
+		variant.Sku = strings.TrimSpace(item.Sku)
+		variant.Name = strings.TrimSpace(item.Name)
+		variant.Attributes = item.Attributes
+		if variant.Attributes == nil {
+			variant.Attributes = map[string]string{}
+		}
+		variant.Price = item.Price
+		variant.SortOrder = item.SortOrder
+		variant.IsAvailable = boolOrDefault(item.IsAvailable, true)
+		if err := variant.Save(tx); err != nil {
+			return errors.Wrap(err, ">>saveProductVariants, variant.Save fail")
+		}
+	}
+	return nil
+}
+
+func saveModifierGroups(tx *gorm.DB, product *dao.Product, items []dto.ModifierGroupReq) error {
+	keepIds := make([]int64, 0, len(items))
+	for _, item := range items {
+		if item.Id != 0 {
+			keepIds = append(keepIds, item.Id)
+		}
+	}
+	if err := dao.DeleteModifierGroups(tx, product.Id, keepIds); err != nil {
+		return errors.Wrap(err, ">>saveModifierGroups, dao.DeleteModifierGroups fail")
+	}
+
+	groups := make(map[int64]*dao.ModifierGroup, len(product.ModifierGroups))
+	for i := range product.ModifierGroups {
+		groups[product.ModifierGroups[i].Id] = &product.ModifierGroups[i]
+	}
+
+	for _, item := range items {
+		group := &dao.ModifierGroup{}
+		if existing, ok := groups[item.Id]; ok {
+			group = existing
+		}
+		group.ProductId = product.Id
+		group.Name = strings.TrimSpace(item.Name)
+		group.MinSelect = item.MinSelect
+		group.MaxSelect = item.MaxSelect
+		group.SortOrder = item.SortOrder
+		if err := group.Save(tx); err != nil {
+			return errors.Wrap(err, ">>saveModifierGroups, group.Save fail")
+		}
+
+		optionIds := make([]int64, 0, len(item.Options))
+		for _, option := range item.Options {
+			if option.Id != 0 {
+				optionIds = append(optionIds, option.Id)
+			}
+		}
+		if err := dao.DeleteModifierOptions(tx, group.Id, optionIds); err != nil {
+			return errors.Wrap(err, ">>saveModifierGroups, dao.DeleteModifierOptions fail")
+		}
+
+		for _, optionItem := range item.Options {
+			option := &dao.ModifierOption{}
+			if existing := group.Option(optionItem.Id); existing != nil {
+				option = existing
+			}
+			option.GroupId = group.Id
+			option.Name = strings.TrimSpace(optionItem.Name)
+			option.Price = optionItem.Price
+			option.SortOrder = optionItem.SortOrder
+			option.IsAvailable = boolOrDefault(optionItem.IsAvailable, true)
+			if err := option.Save(tx); err != nil {
+				return errors.Wrap(err, ">>saveModifierGroups, option.Save fail")
+			}
+		}
+	}
+	return nil
+}
+
+func getCategory(session *gorm.DB, merchantId, categoryId int64) (*dao.Category, error) {
+	category, err := dao.GetCategory(session, merchantId, categoryId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>getCategory, dao.GetCategory fail")
+	}
+	if category == nil || category.Id == 0 {
+		return nil, xerr.NewErrCode(xerr.CategoryNotExist)
+	}
+	return category, nil
+}
+
+func getProduct(session *gorm.DB, merchantId, productId int64) (*dao.Product, error) {
+	product, err := dao.GetProduct(session, merchantId, productId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>getProduct, dao.GetProduct fail")
+	}
+	if product == nil || product.Id == 0 {
+		return nil, xerr.NewErrCode(xerr.ProductNotExist)
+	}
+	return product, nil
+}
+
+// getStoreProduct an available product of an approved store, hidden with its category as in the catalog
+func getStoreProduct(session *gorm.DB, storeId, productId int64) (*dto.StoreResp, *dao.Product, error) {
+	store, err := GetStore(session, storeId)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, ">>getStoreProduct ")
+	}
+
+	product, err := getProduct(session, storeId, productId)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, ">>getStoreProduct ")
+	}
+	if !product.IsAvailable {
+		return nil, nil, xerr.NewErrCode(xerr.ProductUnavailable)
+	}
+	if product.CategoryId != nil {
+		category, err := dao.GetCategory(session, storeId, *product.CategoryId)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, ">>getStoreProduct, dao.GetCategory fail")
+		}
+		if category == nil || !category.IsAvailable {
+			return nil, nil, xerr.NewErrCode(xerr.ProductUnavailable)
+		}
+	}
+	return store, product, nil
+}
+
+func fillCategory(category *dao.Category, req *dto.CategoryReq) {
+	category.Name = strings.TrimSpace(req.Name)
+	category.Description = nullableString(req.Description)
+	category.SortOrder = req.SortOrder
+	category.IsAvailable = boolOrDefault(req.IsAvailable, true)
+}
+
+func boolOrDefault(b *bool, def bool) bool {
+	if b == nil {
+		return def
+	}
+	return *b
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func newCategoryResp(category *dao.Category) dto.CategoryResp {
+	return dto.CategoryResp{
+		Id:          category.Id,
+		Name:        category.Name,
+		Description: category.Description,
+		SortOrder:   category.SortOrder,
+		IsAvailable: category.IsAvailable,
+	}
+}
+
+// newProductResp stocks are the available stocks by stockKey, the items missing aren't tracked.
+// ingredients are by product id, the foods missing aren't analyzed yet
+func newProductResp(product *dao.Product, stocks map[string]int, ingredients map[int64]*dao.ProductIngredient) dto.ProductResp {
+	resp := dto.ProductResp{
+		Id:             product.Id,
+		CategoryId:     product.CategoryId,
+		Sku:            stringValue(product.Sku),
+		Kind:           product.Kind,
+		Name:           product.Name,
+		Description:    product.Description,
+		Image:          newImageResp(product.Image),
+		Price:          product.Price,
+		Currency:       product.Currency,
+		SortOrder:      product.SortOrder,
+		IsAvailable:    product.IsAvailable,
+		Variants:       make([]dto.ProductVariantResp, 0, len(product.Variants)),
+		ModifierGroups: make([]dto.ModifierGroupResp, 0, len(product.ModifierGroups)),
+	}
+	if stock, ok := stocks[stockKey(product.Id, nil)]; ok {
+		resp.Stock = &stock
+	}
+	resp.Ingredients = newProductIngredientResp(ingredients[product.Id])
+
+	for _, variant := range product.Variants {
+		price := product.Price
+		if variant.Price != nil {
+			price = *variant.Price
+		}
+		variantResp := dto.ProductVariantResp{
+			Id:          variant.Id,
+			Sku:         variant.Sku,
+			Name:        variant.Name,
+			Attributes:  variant.Attributes,
+			Price:       price,
+			SortOrder:   variant.SortOrder,
+			IsAvailable: variant.IsAvailable,
+		}
+		if stock, ok := stocks[stockKey(product.Id, &variant.Id)]; ok {
+			variantResp.Stock = &stock
+		}
+		resp.Variants = append(resp.Variants, variantResp)
+	}
+
+	for _, group := range product.ModifierGroups {
+		groupResp := dto.ModifierGroupResp{
+			Id:        group.Id,
+			Name:      group.Name,
+			MinSelect: group.MinSelect,
+			MaxSelect: group.MaxSelect,
+			SortOrder: group.SortOrder,
+			Options:   make([]dto.ModifierOptionResp, 0, len(group.Options)),
+		}
+		for _, option := range group.Options {
+			groupResp.Options = append(groupResp.Options, dto.ModifierOptionResp{
+				Id:          option.Id,
+				Name:        option.Name,
+				Price:       option.Price,
+				SortOrder:   option.SortOrder,
+				IsAvailable: option.IsAvailable,
+			})
+		}
+		resp.ModifierGroups = append(resp.ModifierGroups, groupResp)
+	}
+
+	return resp
+}
@@ -0,0 +1,544 @@
+package logic
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/tespkg/bytes-be/common/xerr"
+	"github.com/tespkg/bytes-be/svc/staff/model/dao"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+	"gorm.io/gorm"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	CatalogFormatCSV  = "csv"
+	CatalogFormatJSON = "json"
+
+	MaxCatalogFileSize = 5 << 20
+	MaxCatalogRows     = 5000
+)
+
+// catalogColumns the csv header of the export, the import accepts the columns in any order
+var catalogColumns = []string{
+	"productSku", "sku", "name", "kind", "category", "description", "image",
+	"price", "variantName", "variantPrice", "attributes", "isAvailable",
+}
+
+var requiredCatalogColumns = []string{"productSku", "name", "kind", "price"}
+
+// catalogProduct the rows of a product, the product fields are taken from the first row
+type catalogProduct struct {
+	product  dto.CatalogRow
+	plain    bool
+	variants []dto.CatalogRow
+}
+
+// CatalogFormatOf the format by the extension of the file name, empty if unknown
+func CatalogFormatOf(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return CatalogFormatCSV
+	case ".json":
+		return CatalogFormatJSON
+	}
+	return ""
+}
+
+// ImportCatalog upserts the products by the product SKU and the variants by the SKU in one transaction.
+// Every row is validated first, nothing is saved if any row fails or in dry run, the products and
+// variants missing in the file are kept, and the modifiers of the products aren't touched
+func ImportCatalog(session *gorm.DB, merchant *dao.Merchant, format string, r io.Reader, dryRun bool) (*dto.CatalogImportResp, error) {
+	resp := &dto.CatalogImportResp{DryRun: dryRun, Errors: []dto.CatalogImportError{}}
+
+	rows, err := parseCatalog(format, r, resp)
+	if err != nil {
+		return nil, err
+	}
+	resp.Rows = len(rows) + len(resp.Errors)
+	if resp.Rows == 0 {
+		return nil, xerr.NewErrCodeMsg(xerr.RequestParamError, "the catalog has no rows")
+	}
+
+	products := groupCatalogRows(merchant.Id, rows, resp)
+	if len(resp.Errors) > 0 {
+		sortCatalogImportErrors(resp)
+		return resp, nil
+	}
+
+	tx := session.Begin()
+	if err = tx.Error; err != nil {
+		return nil, errors.Wrap(err, ">>ImportCatalog, transaction begin fail")
+	}
+	defer tx.Rollback()
+
+	categories, err := dao.ListCategories(tx, merchant.Id, false)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>ImportCatalog, dao.ListCategories fail")
+	}
+	categoryByName := make(map[string]*dao.Category, len(categories))
+	for i := range categories {
+		categoryByName[strings.ToLower(categories[i].Name)] = &categories[i]
+	}
+
+	for _, item := range products {
+		if err = importCatalogProduct(tx, merchant.Id, item, categoryByName, resp); err != nil {
+			return nil, errors.Wrap(err, ">>ImportCatalog ")
+		}
+	}
+
+	//the changes are rolled back, the counts show what the import does
+	if len(resp.Errors) > 0 || dryRun {
+		sortCatalogImportErrors(resp)
+		return resp, nil
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		return nil, errors.Wrap(err, ">>ImportCatalog, transaction commit fail")
+	}
+	resp.Imported = true
+	return resp, nil
+}
+
+// ExportCatalog writes the products in the format of the import, one row per variant
+func ExportCatalog(session *gorm.DB, merchant *dao.Merchant, format string, w io.Writer) error {
+	categories, err := dao.ListCategories(session, merchant.Id, false)
+	if err != nil {
+		return errors.Wrap(err, ">>ExportCatalog, dao.ListCategories fail")
+	}
+	categoryNames := make(map[int64]string, len(categories))
+	for _, category := range categories {
+		categoryNames[category.Id] = category.Name
+	}
+
+	products, err := dao.ListProducts(session, merchant.Id, 0, false)
+	if err != nil {
+		return errors.Wrap(err, ">>ExportCatalog, dao.ListProducts fail")
+	}
+
+	rows := make([]dto.CatalogRow, 0, len(products))
+	for i := range products {
+		product := &products[i]
+		price := product.Price
+		row := dto.CatalogRow{
+			ProductSku:  stringValue(product.Sku),
+			Name:        product.Name,
+			Kind:        product.Kind,
+			Description: stringValue(product.Description),
+			Image:       stringValue(product.Image),
+			Price:       &price,
+		}
+		if product.CategoryId != nil {
+			row.Category = categoryNames[*product.CategoryId]
+		}
+
+		if len(product.Variants) == 0 {
+			isAvailable := product.IsAvailable
+			row.IsAvailable = &isAvailable
+			rows = append(rows, row)
+			continue
+		}
+		for _, variant := range product.Variants {
+			variantRow := row
+			variantRow.Sku = variant.Sku
+			variantRow.VariantName = variant.Name
+			variantRow.VariantPrice = variant.Price
+			variantRow.Attributes = variant.Attributes
+			//the flag of the variant itself, the import keeps the one of the product
+			isAvailable := variant.IsAvailable
+			variantRow.IsAvailable = &isAvailable
+			rows = append(rows, variantRow)
+		}
+	}
+
+	switch format {
+	case CatalogFormatCSV:
+		err = writeCatalogCSV(w, rows)
+	case CatalogFormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(rows)
+	default:
+		return xerr.NewErrCodeMsg(xerr.RequestParamError, "format must be csv or json")
+	}
+	if err != nil {
+		return errors.Wrap(err, ">>ExportCatalog, write fail")
+	}
+	return nil
+}
+
+// parseCatalog the rows with invalid values are reported in resp and skipped, an unreadable file is an error
+func parseCatalog(format string, r io.Reader, resp *dto.CatalogImportResp) ([]dto.CatalogRow, error) {
+	switch format {
+	case CatalogFormatCSV:
+		return parseCatalogCSV(r, resp)
+	case CatalogFormatJSON:
+		return parseCatalogJSON(r)
+	}
+	return nil, xerr.NewErrCodeMsg(xerr.RequestParamError, "format must be csv or json")
+}
+
+func parseCatalogCSV(r io.Reader, resp *dto.CatalogImportResp) ([]dto.CatalogRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, xerr.NewErrCodeMsg(xerr.RequestParamError, "the csv header can't be read")
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		//the byte order mark of the files saved by excel
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		if _, ok := columns[name]; ok {
+			return nil, xerr.NewErrCodeMsg(xerr.RequestParamError, "the column "+name+" is repeated")
+		}
+		columns[name] = i
+	}
+	for name := range columns {
+		if !isCatalogColumn(name) {
+			return nil, xerr.NewErrCodeMsg(xerr.RequestParamError, "unknown column "+name)
+		}
+	}
+	for _, name := range requiredCatalogColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, xerr.NewErrCodeMsg(xerr.RequestParamError, "the column "+name+" is required")
+		}
+	}
+
+	var rows []dto.CatalogRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, xerr.NewErrCodeMsg(xerr.RequestParamError, "the csv can't be parsed: "+err.Error())
+		}
+		if len(rows)+len(resp.Errors) >= MaxCatalogRows {
+			return nil, xerr.NewErrCodeMsg(xerr.RequestParamError, fmt.Sprintf("the catalog exceeds %d rows", MaxCatalogRows))
+		}
+
+		line, _ := reader.FieldPos(0)
+		field := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row := dto.CatalogRow{
+			Row:         line,
+			ProductSku:  field("productSku"),
+			Sku:         field("sku"),
+			Name:        field("name"),
+			Kind:        field("kind"),
+			Category:    field("category"),
+			Description: field("description"),
+			Image:       field("image"),
+			VariantName: field("variantName"),
+		}
+		if message := parseCatalogFields(&row, field); message != "" {
+			addCatalogImportError(resp, &row, message)
+			continue
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func parseCatalogFields(row *dto.CatalogRow, field func(name string) string) string {
+	if s := field("price"); s != "" {
+		price, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return "price must be an integer in minor units"
+		}
+		row.Price = &price
+	}
+	if s := field("variantPrice"); s != "" {
+		price, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return "variantPrice must be an integer in minor units"
+		}
+		row.VariantPrice = &price
+	}
+	if s := field("isAvailable"); s != "" {
+		isAvailable, err := strconv.ParseBool(s)
+		if err != nil {
+			return "isAvailable must be true or false"
+		}
+		row.IsAvailable = &isAvailable
+	}
+	if s := field("attributes"); s != "" {
+		row.Attributes = map[string]string{}
+		for _, pair := range strings.Split(s, ";") {
+			key, value, ok := strings.Cut(pair, "=")
+			key = strings.TrimSpace(key)
+			if !ok || key == "" {
+				return "attributes must be like size=M;color=red"
+			}
+			row.Attributes[key] = strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+func parseCatalogJSON(r io.Reader) ([]dto.CatalogRow, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+
+	var rows []dto.CatalogRow
+	if err := decoder.Decode(&rows); err != nil {
+		return nil, xerr.NewErrCodeMsg(xerr.RequestParamError, "the json can't be parsed: "+err.Error())
+	}
+	if len(rows) > MaxCatalogRows {
+		return nil, xerr.NewErrCodeMsg(xerr.RequestParamError, fmt.Sprintf("the catalog exceeds %d rows", MaxCatalogRows))
+	}
+
+	for i := range rows {
+		row := &rows[i]
+		row.Row = i + 1
+		row.ProductSku = strings.TrimSpace(row.ProductSku)
+		row.Sku = strings.TrimSpace(row.Sku)
+		row.Name = strings.TrimSpace(row.Name)
+		row.Kind = strings.TrimSpace(row.Kind)
+		row.Category = strings.TrimSpace(row.Category)
+		row.Description = strings.TrimSpace(row.Description)
+		row.Image = strings.TrimSpace(row.Image)
+		row.VariantName = strings.TrimSpace(row.VariantName)
+	}
+	return rows, nil
+}
+
+func isCatalogColumn(name string) bool {
+	for _, column := range catalogColumns {
+		if column == name {
+			return true
+		}
+	}
+	return false
+}
+
+// groupCatalogRows validates the rows and groups them by the product SKU in the order of the file
+func groupCatalogRows(merchantId int64, rows []dto.CatalogRow, resp *dto.CatalogImportResp) []*catalogProduct {
+	var products []*catalogProduct
+	index := make(map[string]*catalogProduct)
+	skuRows := make(map[string]int)
+
+	for i := range rows {
+		row := &rows[i]
+		if message := validateCatalogRow(merchantId, row); message != "" {
+			addCatalogImportError(resp, row, message)
+			continue
+		}
+
+		product, ok := index[row.ProductSku]
+		if !ok {
+			product = &catalogProduct{product: *row}
+			index[row.ProductSku] = product
+			products = append(products, product)
+		} else if !sameCatalogProduct(&product.product, row) {
+			addCatalogImportError(resp, row, fmt.Sprintf("the product fields differ from row %d", product.product.Row))
+			continue
+		}
+
+		if row.Sku == "" {
+			if product.plain || len(product.variants) > 0 {
+				addCatalogImportError(resp, row, "the product is repeated, a variant requires the sku")
+				continue
+			}
+			product.plain = true
+			continue
+		}
+
+		if product.plain {
+			addCatalogImportError(resp, row, "the product has a row without sku, a product has either variants or one row")
+			continue
+		}
+		if line, ok := skuRows[row.Sku]; ok {
+			addCatalogImportError(resp, row, fmt.Sprintf("the sku is repeated from row %d", line))
+			continue
+		}
+		skuRows[row.Sku] = row.Row
+		product.variants = append(product.variants, *row)
+	}
+
+	return products
+}
+
+func validateCatalogRow(merchantId int64, row *dto.CatalogRow) string {
+	switch {
+	case row.ProductSku == "":
+		return "productSku is required"
+	case row.Name == "":
+		return "name is required"
+	case row.Kind != dao.ProductKindFood && row.Kind != dao.ProductKindClothing:
+		return "kind must be food or clothing"
+	case row.Price == nil:
+		return "price is required"
+	case *row.Price < 0:
+		return "price must not be negative"
+	case row.VariantPrice != nil && *row.VariantPrice < 0:
+		return "variantPrice must not be negative"
+	case row.Sku != "" && row.VariantName == "":
+		return "variantName is required for a variant"
+	case row.Sku == "" && (row.VariantName != "" || row.VariantPrice != nil || len(row.Attributes) > 0):
+		return "sku is required for a variant"
+	case row.Sku == "" && row.Kind == dao.ProductKindClothing:
+		return "a clothing product requires variants, sku is required"
+	case validateMerchantImage(merchantId, row.Image) != nil:
+		return "the image must be uploaded first"
+	}
+	return ""
+}
+
+func sameCatalogProduct(first, row *dto.CatalogRow) bool {
+	return first.Name == row.Name &&
+		first.Kind == row.Kind &&
+		strings.EqualFold(first.Category, row.Category) &&
+		first.Description == row.Description &&
+		first.Image == row.Image &&
+		*first.Price == *row.Price
+}
+
+// importCatalogProduct the rows failing against the saved catalog are reported in resp
+func importCatalogProduct(tx *gorm.DB, merchantId int64, item *catalogProduct, categories map[string]*dao.Category, resp *dto.CatalogImportResp) error {
+	first := &item.product
+	product, err := dao.GetProductBySku(tx, merchantId, first.ProductSku)
+	if err != nil {
+		return errors.Wrap(err, ">>importCatalogProduct, dao.GetProductBySku fail")
+	}
+	if product == nil || product.Id == 0 {
+		sku := first.ProductSku
+		product = &dao.Product{MerchantId: merchantId, Sku: &sku, Currency: dao.CurrencyOMR, IsAvailable: true}
+		resp.ProductsCreated++
+	} else {
+		if len(item.variants) == 0 && len(product.Variants) > 0 {
+			addCatalogImportError(resp, first, "the product has variants, sku is required")
+			return nil
+		}
+		resp.ProductsUpdated++
+	}
+
+	product.CategoryId = nil
+	if first.Category != "" {
+		category, ok := categories[strings.ToLower(first.Category)]
+		if !ok {
+			category = &dao.Category{MerchantId: merchantId, Name: first.Category, IsAvailable: true}
+			if err = category.Save(tx); err != nil {
+				return errors.Wrap(err, ">>importCatalogProduct, category.Save fail")
+			}
+			categories[strings.ToLower(first.Category)] = category
+			resp.CategoriesAdded++
+		}
+		product.CategoryId = &category.Id
+	}
+	product.Kind = first.Kind
+	product.Name = first.Name
+	product.Description = nullableString(first.Description)
+	product.Image = nullableString(first.Image)
+	product.Price = *first.Price
+	if len(item.variants) == 0 {
+		product.IsAvailable = boolOrDefault(first.IsAvailable, true)
+	}
+	if err = product.Save(tx); err != nil {
+		return errors.Wrap(err, ">>importCatalogProduct, product.Save fail")
+	}
+	if err = reconfirmProductIngredient(tx, product); err != nil {
+		return errors.Wrap(err, ">>importCatalogProduct ")
+	}
+
+	for i := range item.variants {
+		row := &item.variants[i]
+		variant, err := dao.GetProductVariantBySku(tx, merchantId, row.Sku)
+		if err != nil {
+			return errors.Wrap(err, ">>importCatalogProduct, dao.GetProductVariantBySku fail")
+		}
+		if variant != nil && variant.Id > 0 {
+			if variant.ProductId != product.Id {
+				addCatalogImportError(resp, row, "the sku is used by another product")
+				continue
+			}
+			resp.VariantsUpdated++
+		} else {
+			variant = &dao.ProductVariant{MerchantId: merchantId, ProductId: product.Id}
+			resp.VariantsCreated++
+		}
+
+		variant.Sku = row.Sku
+		variant.Name = row.VariantName
+		variant.Attributes = row.Attributes
+		if variant.Attributes == nil {
+			variant.Attributes = map[string]string{}
+		}
+		variant.Price = row.VariantPrice
+		variant.IsAvailable = boolOrDefault(row.IsAvailable, true)
+		if err = variant.Save(tx); err != nil {
+			return errors.Wrap(err, ">>importCatalogProduct, variant.Save fail")
+		}
+	}
+	return nil
+}
+
+func writeCatalogCSV(w io.Writer, rows []dto.CatalogRow) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(catalogColumns); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		record := []string{
+			row.ProductSku, row.Sku, row.Name, row.Kind, row.Category, row.Description, row.Image,
+			formatCatalogPrice(row.Price), row.VariantName, formatCatalogPrice(row.VariantPrice),
+			formatCatalogAttributes(row.Attributes), strconv.FormatBool(boolOrDefault(row.IsAvailable, true)),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatCatalogPrice(price *int64) string {
+	if price == nil {
+		return ""
+	}
+	return strconv.FormatInt(*price, 10)
+}
+
+// formatCatalogAttributes sorted by the key so the exports are stable
+func formatCatalogAttributes(attributes map[string]string) string {
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+attributes[key])
+	}
+	return strings.Join(pairs, ";")
+}
+
+func addCatalogImportError(resp *dto.CatalogImportResp, row *dto.CatalogRow, message string) {
+	sku := row.Sku
+	if sku == "" {
+		sku = row.ProductSku
+	}
+	resp.Errors = append(resp.Errors, dto.CatalogImportError{Row: row.Row, Sku: sku, Message: message})
+}
+
+// sortCatalogImportErrors in the order of the file, the values are checked before the rows
+func sortCatalogImportErrors(resp *dto.CatalogImportResp) {
+	sort.SliceStable(resp.Errors, func(i, j int) bool {
+		return resp.Errors[i].Row < resp.Errors[j].Row
+	})
+}
@@ -0,0 +1,130 @@
+package logic
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tespkg/bytes-be/common/xerr"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+)
+
+func TestParseCatalogCSV(t *testing.T) {
+	cases := []struct {
+		name   string
+		csv    string
+		rows   int
+		errors []string
+		code   uint32
+	}{
+		{
+			name: "columns in any order with a byte order mark",
+			csv:  "\ufeffprice,name,kind,productSku\n1500,Shuwa,food,SHW\n",
+			rows: 1,
+		},
+		{
+			name: "variants with attributes",
+			csv: "productSku,sku,name,kind,price,variantName,variantPrice,attributes,isAvailable\n" +
+				"TEE,TEE-M,Tee,clothing,3000,M,,size=M;color=red,true\n" +
+				"TEE,TEE-XL,Tee,clothing,3000,XL,3500,size=XL,false\n",
+			rows: 2,
+		},
+		{
+			name: "invalid values are reported by row",
+			csv: "productSku,name,kind,price,isAvailable,attributes\n" +
+				"A,Harees,food,abc,,\n" +
+				"B,Madrouba,food,1000,maybe,\n" +
+				"C,Mashuai,food,1000,,size\n" +
+				"D,Luqaimat,food,500,,\n",
+			rows: 1,
+			errors: []string{
+				"price must be an integer in minor units",
+				"isAvailable must be true or false",
+				"attributes must be like size=M;color=red",
+			},
+		},
+		{name: "missing required column", csv: "productSku,name,kind\nA,Harees,food\n", code: xerr.RequestParamError},
+		{name: "unknown column", csv: "productSku,name,kind,price,color\nA,Harees,food,1,red\n", code: xerr.RequestParamError},
+		{name: "repeated column", csv: "productSku,name,kind,price,price\nA,Harees,food,1,1\n", code: xerr.RequestParamError},
+		{name: "empty file", csv: "", code: xerr.RequestParamError},
+	}
+	for _, c := range cases {
+		resp := &dto.CatalogImportResp{}
+		rows, err := parseCatalog(CatalogFormatCSV, strings.NewReader(c.csv), resp)
+		if c.code != 0 {
+			if !isErrCode(err, c.code) {
+				t.Fatalf("%s: got %v, want code %d", c.name, err, c.code)
+			}
+			continue
+		}
+		if err != nil || len(rows) != c.rows || len(resp.Errors) != len(c.errors) {
+			t.Fatalf("%s: got %d rows, errors %+v, %v", c.name, len(rows), resp.Errors, err)
+		}
+		for i, message := range c.errors {
+			if resp.Errors[i].Message != message || resp.Errors[i].Row != i+2 {
+				t.Fatalf("%s: got error %+v, want %q on row %d", c.name, resp.Errors[i], message, i+2)
+			}
+		}
+	}
+}
+
+func TestParseCatalogCSVFields(t *testing.T) {
+	csv := "productSku,sku,name,kind,category,price,variantName,variantPrice,attributes,isAvailable\n" +
+		" TEE , TEE-XL , Tee ,clothing, Shirts ,3000, XL ,3500,size = XL; color=red,false\n"
+
+	rows, err := parseCatalog(CatalogFormatCSV, strings.NewReader(csv), &dto.CatalogImportResp{})
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("parse catalog: %v %+v", err, rows)
+	}
+	row := rows[0]
+	if row.Row != 2 || row.ProductSku != "TEE" || row.Sku != "TEE-XL" || row.Name != "Tee" || row.Category != "Shirts" || row.VariantName != "XL" {
+		t.Fatalf("unexpected row %+v", row)
+	}
+	if *row.Price != 3000 || *row.VariantPrice != 3500 || *row.IsAvailable {
+		t.Fatalf("unexpected values %d %d %t", *row.Price, *row.VariantPrice, *row.IsAvailable)
+	}
+	if want := map[string]string{"size": "XL", "color": "red"}; !reflect.DeepEqual(row.Attributes, want) {
+		t.Fatalf("got attributes %v, want %v", row.Attributes, want)
+	}
+}
+
+func TestParseCatalogJSON(t *testing.T) {
+	cases := []struct {
+		name string
+		json string
+		rows int
+		code uint32
+	}{
+		{name: "rows", json: `[{"productSku":" SHW ","name":"Shuwa","kind":"food","price":1500},{"productSku":"TEE","sku":"TEE-M","name":"Tee","kind":"clothing","price":3000,"variantName":"M","attributes":{"size":"M"},"isAvailable":false}]`, rows: 2},
+		{name: "empty list", json: `[]`, rows: 0},
+		{name: "unknown field", json: `[{"productSku":"SHW","color":"red"}]`, code: xerr.RequestParamError},
+		{name: "price as a string", json: `[{"productSku":"SHW","price":"1.500"}]`, code: xerr.RequestParamError},
+		{name: "not a list", json: `{"productSku":"SHW"}`, code: xerr.RequestParamError},
+	}
+	for _, c := range cases {
+		rows, err := parseCatalog(CatalogFormatJSON, strings.NewReader(c.json), &dto.CatalogImportResp{})
+		if c.code != 0 {
+			if !isErrCode(err, c.code) {
+				t.Fatalf("%s: got %v, want code %d", c.name, err, c.code)
+			}
+			continue
+		}
+		if err != nil || len(rows) != c.rows {
+			t.Fatalf("%s: got %d rows, %v", c.name, len(rows), err)
+		}
+		for i, row := range rows {
+			if row.Row != i+1 || row.ProductSku != strings.TrimSpace(row.ProductSku) {
+				t.Fatalf("%s: unexpected row %+v", c.name, row)
+			}
+		}
+	}
+}
+
+func TestParseCatalogUnknownFormat(t *testing.T) {
+	if _, err := parseCatalog("xlsx", strings.NewReader(""), &dto.CatalogImportResp{}); !isErrCode(err, xerr.RequestParamError) {
+		t.Fatalf("got %v, want code %d", err, xerr.RequestParamError)
+	}
+	if format := CatalogFormatOf("menu.JSON"); format != CatalogFormatJSON {
+		t.Fatalf("got format %q of menu.JSON", format)
+	}
+}
@@ -0,0 +1,106 @@
+package logic
+
+import (
+	"testing"
+
+	"github.com/tespkg/bytes-be/common/xerr"
+	"github.com/tespkg/bytes-be/svc/staff/model/dao"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+)
+
+func TestPriceProductSelection(t *testing.T) {
+	large := int64(1800)
+	shirt := &dao.Product{
+		Price:       1500,
+		IsAvailable: true,
+		Variants: []dao.ProductVariant{
+			{Id: 1, Name: "M", IsAvailable: true},
+			{Id: 2, Name: "XL", Price: &large, IsAvailable: true},
+			{Id: 3, Name: "XXL", IsAvailable: false},
+		},
+	}
+	dish := &dao.Product{
+		Price:       2000,
+		IsAvailable: true,
+		ModifierGroups: []dao.ModifierGroup{
+			{Id: 1, Name: "Rice", MinSelect: 1, MaxSelect: 1, Options: []dao.ModifierOption{
+				{Id: 10, Name: "White", IsAvailable: true},
+				{Id: 11, Name: "Saffron", Price: 300, IsAvailable: true},
+			}},
+			{Id: 2, Name: "Extras", MinSelect: 0, MaxSelect: 2, Options: []dao.ModifierOption{
+				{Id: 20, Name: "Salad", Price: 500, IsAvailable: true},
+				{Id: 21, Name: "Laban", Price: 250, IsAvailable: true},
+				{Id: 22, Name: "Halwa", Price: 400, IsAvailable: false},
+			}},
+		},
+	}
+
+	cases := []struct {
+		name    string
+		product *dao.Product
+		req     dto.ProductSelectionReq
+		price   int64
+		code    uint32
+	}{
+		{"variant of the base price", shirt, dto.ProductSelectionReq{VariantId: 1}, 1500, 0},
+		{"variant with its own price", shirt, dto.ProductSelectionReq{VariantId: 2}, 1800, 0},
+		{"missing variant", shirt, dto.ProductSelectionReq{}, 0, xerr.ProductSelectionInvalid},
+		{"variant of another product", shirt, dto.ProductSelectionReq{VariantId: 9}, 0, xerr.ProductSelectionInvalid},
+		{"unavailable variant", shirt, dto.ProductSelectionReq{VariantId: 3}, 0, xerr.ProductUnavailable},
+		{"variant on a product without", dish, dto.ProductSelectionReq{VariantId: 1, OptionIds: []int64{10}}, 0, xerr.ProductSelectionInvalid},
+		{"required option", dish, dto.ProductSelectionReq{OptionIds: []int64{10}}, 2000, 0},
+		{"options add up", dish, dto.ProductSelectionReq{OptionIds: []int64{11, 20, 21}}, 3050, 0},
+		{"below the min", dish, dto.ProductSelectionReq{OptionIds: []int64{20}}, 0, xerr.ProductSelectionInvalid},
+		{"above the max", dish, dto.ProductSelectionReq{OptionIds: []int64{10, 11}}, 0, xerr.ProductSelectionInvalid},
+		{"option selected twice", dish, dto.ProductSelectionReq{OptionIds: []int64{10, 20, 20}}, 0, xerr.ProductSelectionInvalid},
+		{"option of another product", dish, dto.ProductSelectionReq{OptionIds: []int64{10, 99}}, 0, xerr.ProductSelectionInvalid},
+		{"unavailable option", dish, dto.ProductSelectionReq{OptionIds: []int64{10, 22}}, 0, xerr.ProductUnavailable},
+		{"unavailable product", &dao.Product{Price: 100}, dto.ProductSelectionReq{}, 0, xerr.ProductUnavailable},
+	}
+	for _, c := range cases {
+		price, err := PriceProductSelection(c.product, &c.req)
+		if c.code != 0 {
+			if !isErrCode(err, c.code) {
+				t.Fatalf("%s: got %v, want code %d", c.name, err, c.code)
+			}
+			continue
+		}
+		if err != nil || price != c.price {
+			t.Fatalf("%s: got %d %v, want %d", c.name, price, err, c.price)
+		}
+	}
+}
+
+func TestStoreProductIsHiddenWithItsCategory(t *testing.T) {
+	db := setUpLogicEnv(t, false)
+	merchant := createTestMerchant(t, db)
+	product := createTestProduct(t, db, merchant, "Kabuli", 0)
+
+	category := dao.Category{MerchantId: merchant.Id, Name: "Rice", IsAvailable: true}
+	if err := category.Save(db); err != nil {
+		t.Fatalf("create category: %v", err)
+	}
+	product.CategoryId = &category.Id
+	if err := product.Save(db); err != nil {
+		t.Fatalf("save product: %v", err)
+	}
+
+	if _, err := GetStoreProduct(db, merchant.Id, product.Id); err != nil {
+		t.Fatalf("get store product: %v", err)
+	}
+	resp, err := PriceStoreProduct(db, merchant.Id, &dto.ProductSelectionReq{ProductId: product.Id})
+	if err != nil || resp.UnitPrice != product.Price {
+		t.Fatalf("price store product: got %+v %v", resp, err)
+	}
+
+	category.IsAvailable = false
+	if err = category.Save(db); err != nil {
+		t.Fatalf("save category: %v", err)
+	}
+	if _, err = GetStoreProduct(db, merchant.Id, product.Id); !isErrCode(err, xerr.ProductUnavailable) {
+		t.Fatalf("get store product of an unavailable category: got %v", err)
+	}
+	if _, err = PriceStoreProduct(db, merchant.Id, &dto.ProductSelectionReq{ProductId: product.Id}); !isErrCode(err, xerr.ProductUnavailable) {
+		t.Fatalf("price store product of an unavailable category: got %v", err)
+	}
+}
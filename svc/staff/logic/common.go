@@ -6,59 +6,68 @@ import (
 	"github.com/pkg/errors"
 	"github.com/samber/lo"
 	"github.com/tespkg/bytes-be/common/global"
+	"github.com/tespkg/bytes-be/common/verifycode"
 	"github.com/tespkg/bytes-be/svc/staff/model/dto"
-	"math/rand"
-	"strings"
+	"math"
 	"tespkg.in/go-genproto/sespb"
 	"tespkg.in/go-genproto/smspb"
 	"time"
 )
 
-func SendVerifyCode(ctx context.Context, req *dto.SendVerifyCodeReq) error {
-	code := GetCode()
-
-	key := fmt.Sprintf("bytes_be:verify_code:phone:%s", req.Phone)
-	if err := global.GlobalClientSets.RedisClient.Set(ctx, key, code, time.Duration(3)*time.Minute).Err(); err != nil {
-		return errors.Wrap(err, ">>SendVerifyCode, redis set fail")
-	}
-
+// SendVerifyCode issues a code of the purpose for every given target and delivers it
+func SendVerifyCode(ctx context.Context, req *dto.SendVerifyCodeReq, ip string) error {
 	if req.Phone != "" {
-		if err := SendMessage(ctx,
+		code, err := verifycode.Issue(ctx, req.Purpose, verifycode.ChannelPhone, req.Phone, ip)
+		if err != nil {
+			return errors.Wrap(err, ">>SendVerifyCode, verifycode.Issue fail")
+		}
+
+		if err = SendMessage(ctx,
 			global.GlobalClientSets.SMSClient,
 			global.GlobalClientSets.SESClient,
 			&smspb.SM{
 				PhoneNumber: req.Phone,
-				Message:     fmt.Sprintf(`Your OTP number is %s please do not share or reply to this message.`, code),
+				Message:     fmt.Sprintf(`Your OTP number is %s please do not share or reply to this message.`, code.Value),
 			},
 			&sespb.Email{},
 		); err != nil {
-			global.GlobalClientSets.RedisClient.Del(ctx, key)
+			verifycode.Revoke(ctx, code)
 			return errors.Wrap(err, ">>UserSendCodeToMobile ")
 		}
 	}
 
 	if req.Email != "" {
-		//TODO if send verify code to email, please provide the template from the front end.
-	}
-
-	return nil
-}
+		code, err := verifycode.Issue(ctx, req.Purpose, verifycode.ChannelEmail, req.Email, ip)
+		if err != nil {
+			return errors.Wrap(err, ">>SendVerifyCode, verifycode.Issue fail")
+		}
 
-func GetCode() string {
-	numeric := [9]byte{1, 2, 3, 4, 5, 6, 7, 8, 9}
-	r := len(numeric)
-	rand.Seed(time.Now().UnixNano())
+		email, err := NewVerifyCodeEmail(req.Email, code.Value, int(math.Ceil(time.Until(code.ExpiresAt).Minutes())))
+		if err != nil {
+			verifycode.Revoke(ctx, code)
+			return errors.Wrap(err, ">>SendVerifyCode ")
+		}
 
-	var sb strings.Builder
-	for i := 0; i < 4; i++ {
-		fmt.Fprintf(&sb, "%d", numeric[rand.Intn(r)])
+		if err = SendMessage(ctx,
+			global.GlobalClientSets.SMSClient,
+			global.GlobalClientSets.SESClient,
+			&smspb.SM{},
+			email,
+		); err != nil {
+			verifycode.Revoke(ctx, code)
+			return errors.Wrap(err, ">>UserSendCodeToEmail ")
+		}
 	}
-	return sb.String()
+
+	return nil
 }
 
 func SendMessage(ctx context.Context, smsCli smspb.SMSClient, sesCli sespb.SESClient, note *smspb.SM, email *sespb.Email) error {
 	//send verify code to phone
 	if note.PhoneNumber != "" {
+		if smsCli == nil {
+			return errors.New(">>SendMessage, sms client is not configured")
+		}
 		_, err := smsCli.Send(ctx, &smspb.SendRequest{
 			ShortMsg: note,
 		})
@@ -69,6 +78,9 @@ func SendMessage(ctx context.Context, smsCli smspb.SMSClient, sesCli sespb.SESCl
 
 	//send verify code to email
 	if email.To != nil && len(email.To) > 0 && !lo.Contains(email.To, "") {
+		if sesCli == nil {
+			return errors.New(">>SendMessage, ses client is not configured")
+		}
 		_, err := sesCli.Send(ctx, &sespb.SendRequest{
 			Email: email,
 		})
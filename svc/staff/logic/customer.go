@@ -1,10 +1,13 @@
 package logic
 
 import (
+	"context"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"github.com/tespkg/bytes-be/common/token"
+	"github.com/tespkg/bytes-be/common/verifycode"
+	"github.com/tespkg/bytes-be/common/xerr"
 	"github.com/tespkg/bytes-be/svc/staff/model/dao"
 	"github.com/tespkg/bytes-be/svc/staff/model/dto"
 	"golang.org/x/crypto/bcrypt"
@@ -31,8 +34,8 @@ func CustomerRegister(session *gorm.DB, req *dto.CustomerRegisterReq) (*dto.Cust
 	var userId int64
 	user := dao.User{
 		Uuid:      uuid.NewString(),
-		Email:     &(req.Email),
-		Phone:     &(req.Phone),
+		Email:     nullableString(req.Email),
+		Phone:     nullableString(req.Phone),
 		Password:  nil,
 		IsEnabled: true,
 	}
@@ -68,7 +71,7 @@ func CustomerRegister(session *gorm.DB, req *dto.CustomerRegisterReq) (*dto.Cust
 	}
 
 	//general login token
-	tokenStr, err := token.GenToken(&token.GenTokenDto{
+	pair, err := token.GenTokenPair(&token.GenTokenDto{
 		Session:       session,
 		UserId:        fmt.Sprintf("%d", userId),
 		Platform:      "",
@@ -76,12 +79,70 @@ func CustomerRegister(session *gorm.DB, req *dto.CustomerRegisterReq) (*dto.Cust
 		ClientVersion: "",
 		Model:         "",
 		SystemVersion: "",
+		Roles:         []string{dao.RoleCustomer},
 	})
 	if err != nil {
-		return nil, errors.Wrap(err, ">>CustomerRegister, token.GenToken fail")
+		return nil, errors.Wrap(err, ">>CustomerRegister, token.GenTokenPair fail")
 	}
 
 	return &dto.CustomerRegisterResp{
-		LoginToken: tokenStr,
+		TokenResp: newTokenResp(pair),
 	}, nil
 }
+
+func CustomerLogin(ctx context.Context, session *gorm.DB, req *dto.CustomerLoginReq) (*dto.CustomerLoginResp, error) {
+	//get user by phone or email
+	user, err := getCustomerUser(session, req.Phone, req.Email)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>CustomerLogin ")
+	}
+	if user == nil || user.Id == 0 {
+		return nil, xerr.NewErrCode(xerr.UserNotExist)
+	}
+	if !user.IsEnabled {
+		return nil, xerr.NewErrCode(xerr.UserDisabled)
+	}
+
+	//check password or verify code
+	if err = checkLoginCredential(ctx, user, req.Phone, req.Email, req.Password, req.Code); err != nil {
+		return nil, errors.Wrap(err, ">>CustomerLogin ")
+	}
+
+	//general login token
+	pair, err := token.GenTokenPair(&token.GenTokenDto{
+		Session:       session,
+		UserId:        fmt.Sprintf("%d", user.Id),
+		Platform:      req.Platform,
+		Imei:          req.Imei,
+		ClientVersion: req.ClientVersion,
+		Model:         req.Model,
+		SystemVersion: req.SystemVersion,
+		Roles:         user.RoleNames(),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, ">>CustomerLogin, token.GenTokenPair fail")
+	}
+
+	return &dto.CustomerLoginResp{
+		TokenResp: newTokenResp(pair),
+	}, nil
+}
+
+// checkLoginCredential checks the password, or the login verify code sent to the phone or email without password
+func checkLoginCredential(ctx context.Context, user *dao.User, phone, email, password, code string) error {
+	if password != "" {
+		if user.Password == nil {
+			return xerr.NewErrCode(xerr.UserPasswordInvalid)
+		}
+		if err := bcrypt.CompareHashAndPassword(user.Password, []byte(password)); err != nil {
+			return xerr.NewErrCode(xerr.UserPasswordInvalid)
+		}
+		return nil
+	}
+
+	channel, target := verifycode.Target(phone, email)
+	if err := verifycode.Verify(ctx, verifycode.PurposeLogin, channel, target, code); err != nil {
+		return errors.Wrap(err, ">>checkLoginCredential, verifycode.Verify fail")
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package logic
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/tespkg/bytes-be/common/geofence"
+	"github.com/tespkg/bytes-be/common/token"
+	"github.com/tespkg/bytes-be/common/xerr"
+	"github.com/tespkg/bytes-be/svc/staff/model/dao"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+	"gorm.io/gorm"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func ListDeliveryZones(session *gorm.DB, merchant *dao.Merchant) (*dto.ListDeliveryZoneResp, error) {
+	zones, err := dao.ListDeliveryZones(session, merchant.Id)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>ListDeliveryZones, dao.ListDeliveryZones fail")
+	}
+
+	resp := &dto.ListDeliveryZoneResp{Zones: make([]dto.DeliveryZoneResp, 0, len(zones))}
+	for i := range zones {
+		resp.Zones = append(resp.Zones, newDeliveryZoneResp(&zones[i]))
+	}
+	return resp, nil
+}
+
+func CreateDeliveryZone(session *gorm.DB, merchant *dao.Merchant, req *dto.DeliveryZoneReq) (*dto.DeliveryZoneResp, error) {
+	zone := &dao.DeliveryZone{MerchantId: merchant.Id}
+	if err := fillDeliveryZone(zone, req); err != nil {
+		return nil, err
+	}
+	if err := zone.Save(session); err != nil {
+		return nil, errors.Wrap(err, ">>CreateDeliveryZone, zone.Save fail")
+	}
+
+	resp := newDeliveryZoneResp(zone)
+	return &resp, nil
+}
+
+func UpdateDeliveryZone(session *gorm.DB, merchant *dao.Merchant, zoneId int64, req *dto.DeliveryZoneReq) (*dto.DeliveryZoneResp, error) {
+	zone, err := getDeliveryZone(session, merchant.Id, zoneId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>UpdateDeliveryZone ")
+	}
+
+	if err = fillDeliveryZone(zone, req); err != nil {
+		return nil, err
+	}
+	if err = zone.Save(session); err != nil {
+		return nil, errors.Wrap(err, ">>UpdateDeliveryZone, zone.Save fail")
+	}
+
+	resp := newDeliveryZoneResp(zone)
+	return &resp, nil
+}
+
+func DeleteDeliveryZone(session *gorm.DB, merchant *dao.Merchant, zoneId int64) error {
+	if _, err := getDeliveryZone(session, merchant.Id, zoneId); err != nil {
+		return errors.Wrap(err, ">>DeleteDeliveryZone ")
+	}
+
+	if err := dao.DeleteDeliveryZone(session, merchant.Id, zoneId); err != nil {
+		return errors.Wrap(err, ">>DeleteDeliveryZone, dao.DeleteDeliveryZone fail")
+	}
+	return nil
+}
+
+// GetStoreDeliveryQuote the zone of the store covering the location, the subtotal is checked like the checkout does
+func GetStoreDeliveryQuote(session *gorm.DB, claims *token.UserClaims, storeId int64, req *dto.DeliveryQuoteReq) (*dto.StoreDeliveryResp, error) {
+	if _, err := GetStore(session, storeId); err != nil {
+		return nil, errors.Wrap(err, ">>GetStoreDeliveryQuote ")
+	}
+
+	lat, lon, located, err := resolveDeliveryLocation(session, claims, &req.DeliveryLocationReq)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>GetStoreDeliveryQuote ")
+	}
+	if !located {
+		return nil, xerr.NewErrCodeMsg(xerr.RequestParamError, "addressId or lat and lon are required")
+	}
+
+	zone, err := EnsureDeliverable(session, storeId, lat, lon, req.Subtotal)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>GetStoreDeliveryQuote ")
+	}
+
+	//without a delivery time the order is delivered now
+	deliverAt := time.Now()
+	if req.DeliverAt != nil {
+		deliverAt = time.Unix(*req.DeliverAt, 0)
+	}
+	if err = EnsureStoreOpenAt(session, storeId, deliverAt); err != nil {
+		return nil, errors.Wrap(err, ">>GetStoreDeliveryQuote ")
+	}
+	return newStoreDeliveryResp(zone), nil
+}
+
+// EnsureDeliverable the zone of the merchant covering the point, for the checkout.
+// subtotal is in minor units, nil skips the minimum order
+func EnsureDeliverable(session *gorm.DB, merchantId int64, lat, lon float64, subtotal *int64) (*dao.DeliveryZone, error) {
+	zones, err := findDeliveryZones(session, []int64{merchantId}, lat, lon)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>EnsureDeliverable ")
+	}
+
+	zone, ok := zones[merchantId]
+	if !ok {
+		return nil, xerr.NewErrCode(xerr.DeliveryOutOfZone)
+	}
+	if subtotal != nil && *subtotal < zone.MinOrder {
+		return nil, xerr.NewErrCodeMsg(xerr.DeliveryBelowMinOrder,
+			fmt.Sprintf("The minimum order of the delivery zone is %d", zone.MinOrder))
+	}
+	return zone, nil
+}
+
+// findDeliveryZones the zone covering the point by merchant, merchantIds empty means all the merchants.
+// The cheapest delivery wins if the zones of a merchant overlap
+func findDeliveryZones(session *gorm.DB, merchantIds []int64, lat, lon float64) (map[int64]*dao.DeliveryZone, error) {
+	zones, err := dao.ListDeliveryZonesAround(session, merchantIds, lon, lat)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>findDeliveryZones, dao.ListDeliveryZonesAround fail")
+	}
+
+	found := make(map[int64]*dao.DeliveryZone)
+	for i := range zones {
+		zone := &zones[i]
+		area, err := geofence.Parse(zone.Area)
+		if err != nil {
+			return nil, errors.Wrapf(err, ">>findDeliveryZones, geofence.Parse zone %d fail", zone.Id)
+		}
+		if !area.Contains(lon, lat) {
+			continue
+		}
+
+		best, ok := found[zone.MerchantId]
+		if !ok || zone.DeliveryFee < best.DeliveryFee ||
+			(zone.DeliveryFee == best.DeliveryFee && zone.MinOrder < best.MinOrder) {
+			found[zone.MerchantId] = zone
+		}
+	}
+	return found, nil
+}
+
+// resolveDeliveryLocation the address of the customer goes before the point, located is false without either
+func resolveDeliveryLocation(session *gorm.DB, claims *token.UserClaims, req *dto.DeliveryLocationReq) (lat, lon float64, located bool, err error) {
+	if req.AddressId != 0 {
+		userId, err := strconv.ParseInt(claims.Subject, 10, 64)
+		if err != nil {
+			return 0, 0, false, errors.Wrap(err, ">>resolveDeliveryLocation, strconv.ParseInt fail")
+		}
+		customer, err := getCustomer(session, userId)
+		if err != nil {
+			return 0, 0, false, errors.Wrap(err, ">>resolveDeliveryLocation ")
+		}
+		address, err := getCustomerAddress(session, customer.Id, req.AddressId)
+		if err != nil {
+			return 0, 0, false, errors.Wrap(err, ">>resolveDeliveryLocation ")
+		}
+		return address.Latitude, address.Longitude, true, nil
+	}
+
+	if (req.Latitude == nil) != (req.Longitude == nil) {
+		return 0, 0, false, xerr.NewErrCodeMsg(xerr.RequestParamError, "lat and lon must be given together")
+	}
+	if req.Latitude == nil {
+		return 0, 0, false, nil
+	}
+	return *req.Latitude, *req.Longitude, true, nil
+}
+
+func fillDeliveryZone(zone *dao.DeliveryZone, req *dto.DeliveryZoneReq) error {
+	area, err := geofence.Parse(req.Area)
+	if err != nil {
+		return xerr.NewErrCodeMsg(xerr.RequestParamError, err.Error())
+	}
+
+	zone.Name = strings.TrimSpace(req.Name)
+	zone.Area = req.Area
+	zone.MinLon, zone.MinLat, zone.MaxLon, zone.MaxLat = area.Bbox[0], area.Bbox[1], area.Bbox[2], area.Bbox[3]
+	zone.MinOrder = req.MinOrder
+	zone.DeliveryFee = req.DeliveryFee
+	zone.IsActive = boolOrDefault(req.IsActive, true)
+	return nil
+}
+
+func getDeliveryZone(session *gorm.DB, merchantId, zoneId int64) (*dao.DeliveryZone, error) {
+	zone, err := dao.GetDeliveryZone(session, merchantId, zoneId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>getDeliveryZone, dao.GetDeliveryZone fail")
+	}
+	if zone == nil || zone.Id == 0 {
+		return nil, xerr.NewErrCode(xerr.DeliveryZoneNotExist)
+	}
+	return zone, nil
+}
+
+func newDeliveryZoneResp(zone *dao.DeliveryZone) dto.DeliveryZoneResp {
+	return dto.DeliveryZoneResp{
+		Id:          zone.Id,
+		Name:        zone.Name,
+		Area:        zone.Area,
+		MinOrder:    zone.MinOrder,
+		DeliveryFee: zone.DeliveryFee,
+		Currency:    dao.CurrencyOMR,
+		IsActive:    zone.IsActive,
+	}
+}
+
+func newStoreDeliveryResp(zone *dao.DeliveryZone) *dto.StoreDeliveryResp {
+	return &dto.StoreDeliveryResp{
+		ZoneId:      zone.Id,
+		ZoneName:    zone.Name,
+		MinOrder:    zone.MinOrder,
+		DeliveryFee: zone.DeliveryFee,
+		Currency:    dao.CurrencyOMR,
+	}
+}
@@ -0,0 +1,54 @@
+package logic
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+
+	"github.com/pkg/errors"
+	"github.com/tespkg/bytes-be/common/global"
+	"github.com/tespkg/bytes-be/res_embed"
+	"tespkg.in/go-genproto/sespb"
+)
+
+const verifyCodeEmailSubject = "Your Bytes verification code"
+
+var (
+	verifyCodeHtmlTemplate = htmltemplate.Must(htmltemplate.ParseFS(res_embed.EmailTemplateFiles, "template/email/verify_code.html"))
+	verifyCodeTextTemplate = texttemplate.Must(texttemplate.ParseFS(res_embed.EmailTemplateFiles, "template/email/verify_code.txt"))
+)
+
+type verifyCodeEmailData struct {
+	Code          string
+	ExpireMinutes int
+}
+
+// NewVerifyCodeEmail renders the embedded verify code templates, sent from service_basic_config.email_sender
+func NewVerifyCodeEmail(to, code string, expireMinutes int) (*sespb.Email, error) {
+	if global.GlobalClientSets.EmailSender == "" {
+		return nil, errors.New(">>NewVerifyCodeEmail, email sender is not configured")
+	}
+
+	data := verifyCodeEmailData{
+		Code:          code,
+		ExpireMinutes: expireMinutes,
+	}
+
+	var html bytes.Buffer
+	if err := verifyCodeHtmlTemplate.Execute(&html, data); err != nil {
+		return nil, errors.Wrap(err, ">>NewVerifyCodeEmail, html template execute fail")
+	}
+
+	var text bytes.Buffer
+	if err := verifyCodeTextTemplate.Execute(&text, data); err != nil {
+		return nil, errors.Wrap(err, ">>NewVerifyCodeEmail, text template execute fail")
+	}
+
+	return &sespb.Email{
+		From:     global.GlobalClientSets.EmailSender,
+		To:       []string{to},
+		Subject:  verifyCodeEmailSubject,
+		TextBody: text.String(),
+		HtmlBody: html.String(),
+	}, nil
+}
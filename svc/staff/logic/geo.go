@@ -0,0 +1,77 @@
+package logic
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/tespkg/bytes-be/common/geo"
+	"github.com/tespkg/bytes-be/internal/geocode"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+)
+
+func GeoSearch(ctx context.Context, req *dto.GeoSearchReq) (*dto.GeoFeatureCollection, error) {
+	fc, err := geo.Search(ctx, geo.SearchReq{
+		Query:    req.Query,
+		Limit:    req.Limit,
+		Bounded:  req.Bounded,
+		Language: req.Language,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, ">>GeoSearch, geo.Search fail")
+	}
+	return newGeoFeatureCollection(fc), nil
+}
+
+func GeoReverse(ctx context.Context, req *dto.GeoReverseReq) (*dto.GeoFeatureCollection, error) {
+	fc, err := geo.Reverse(ctx, *req.Latitude, *req.Longitude, req.Language)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>GeoReverse, geo.Reverse fail")
+	}
+	return newGeoFeatureCollection(fc), nil
+}
+
+// newGeoFeatureCollection keeps the point features only, every nominatim result has a point geometry by default
+func newGeoFeatureCollection(fc *geocode.FeatureCollection) *dto.GeoFeatureCollection {
+	resp := &dto.GeoFeatureCollection{Type: "FeatureCollection", Features: make([]dto.GeoFeature, 0, len(fc.Features))}
+	for _, feature := range fc.Features {
+		lon, lat, ok := feature.Geometry.Point()
+		if !ok {
+			continue
+		}
+
+		place := dto.GeoPlace{
+			PlaceId:     feature.Properties.PlaceId,
+			OsmType:     feature.Properties.OsmType,
+			OsmId:       feature.Properties.OsmId,
+			Category:    feature.Properties.Category,
+			Type:        feature.Properties.Type,
+			Name:        feature.Properties.Name,
+			DisplayName: feature.Properties.DisplayName,
+		}
+		if address := feature.Properties.Address; address != nil {
+			place.Country = address.Country
+			place.CountryCode = address.CountryCode
+			place.State = firstNonEmpty(address.State, address.Province, address.Region)
+			place.City = firstNonEmpty(address.City, address.Town, address.Village, address.Suburb)
+			place.Street = firstNonEmpty(address.Road, address.Pedestrian, address.Neighbourhood)
+			place.HouseNumber = address.HouseNumber
+			place.ZipCode = address.Postcode
+		}
+
+		resp.Features = append(resp.Features, dto.GeoFeature{
+			Type:       "Feature",
+			Geometry:   dto.GeoGeometry{Type: "Point", Coordinates: []float64{lon, lat}},
+			Bbox:       feature.Bbox,
+			Properties: place,
+		})
+	}
+	return resp
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,94 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/tespkg/bytes-be/common/media"
+	"github.com/tespkg/bytes-be/common/xerr"
+	"github.com/tespkg/bytes-be/internal/storage"
+	"github.com/tespkg/bytes-be/svc/staff/model/dao"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+	"strings"
+)
+
+// MaxImageSize product photos and logos, bigger ones should be resized by the app
+const MaxImageSize = 5 << 20
+
+const (
+	ImageTypeProduct = "product"
+	ImageTypeLogo    = "logo"
+)
+
+// ValidateImage the type is checked again from the content when the image is decoded
+func ValidateImage(imageType string, content []byte) error {
+	if imageType != ImageTypeProduct && imageType != ImageTypeLogo {
+		return errors.New("type must be product or logo")
+	}
+
+	if len(content) == 0 {
+		return errors.New("the file is empty")
+	}
+	if len(content) > MaxImageSize {
+		return errors.Errorf("the file exceeds %d bytes", MaxImageSize)
+	}
+
+	if _, err := media.DetectImageType(content); err != nil {
+		return err
+	}
+	return nil
+}
+
+// UploadMerchantImage stores the image with its thumbnails, the key is then set as the product image or the logo
+func UploadMerchantImage(ctx context.Context, merchant *dao.Merchant, imageType string, content []byte) (*dto.ImageResp, error) {
+	image, err := media.UploadImage(ctx, fmt.Sprintf("%s%ss", merchantImagePrefix(merchant.Id), imageType), content)
+	if errors.Is(err, media.UnsupportedImageErr) || errors.Is(err, media.ImageTooLargeErr) {
+		return nil, xerr.NewErrCodeMsg(xerr.RequestParamError, err.Error())
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, ">>UploadMerchantImage, media.UploadImage fail")
+	}
+
+	return &dto.ImageResp{Key: image.Key, Url: image.Url, Thumbnails: image.Thumbnails}, nil
+}
+
+// OpenFile serves the signed file urls, the object body must be closed by the caller
+func OpenFile(ctx context.Context, key, expires, signature string) (*storage.Object, error) {
+	if err := media.VerifySignature(key, expires, signature); err != nil {
+		return nil, xerr.NewErrCodeMsg(xerr.RequestParamError, err.Error())
+	}
+
+	object, err := media.Open(ctx, key)
+	if errors.Is(err, storage.ErrorNotFound) || errors.Is(err, storage.ErrorInvalidKey) {
+		return nil, xerr.NewErrCode(xerr.FileNotExist)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, ">>OpenFile, media.Open fail")
+	}
+	return object, nil
+}
+
+// validateMerchantImage the image must be uploaded by the merchant, absolute urls are kept for the existing data
+func validateMerchantImage(merchantId int64, image string) error {
+	image = strings.TrimSpace(image)
+	if image == "" || media.IsExternal(image) {
+		return nil
+	}
+	if merchantId == 0 || !strings.HasPrefix(image, merchantImagePrefix(merchantId)) || strings.Contains(image, "..") {
+		return xerr.NewErrCodeMsg(xerr.RequestParamError, "the image must be uploaded first")
+	}
+	return nil
+}
+
+func merchantImagePrefix(merchantId int64) string {
+	return fmt.Sprintf("merchants/%d/", merchantId)
+}
+
+func newImageResp(key *string) *dto.ImageResp {
+	if key == nil || *key == "" {
+		return nil
+	}
+
+	image := media.ImageOf(*key)
+	return &dto.ImageResp{Key: image.Key, Url: image.Url, Thumbnails: image.Thumbnails}
+}
@@ -0,0 +1,639 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/tespkg/bytes-be/common/allergen"
+	"github.com/tespkg/bytes-be/common/xerr"
+	"github.com/tespkg/bytes-be/internal/ingredient"
+	"github.com/tespkg/bytes-be/svc/staff/model/dao"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+// minIngredientConfidence a model result below goes to the review queue of the admins, like an empty one
+const minIngredientConfidence = 0.6
+
+// ingredientRetryInterval a food whose analysis failed is left out of the batches for a while
+const ingredientRetryInterval = time.Hour
+
+func GetProductIngredient(session *gorm.DB, merchant *dao.Merchant, productId int64) (*dto.ProductIngredientResp, error) {
+	if _, err := getProduct(session, merchant.Id, productId); err != nil {
+		return nil, errors.Wrap(err, ">>GetProductIngredient ")
+	}
+
+	record, err := getProductIngredient(session, productId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>GetProductIngredient ")
+	}
+	return newProductIngredientResp(record), nil
+}
+
+// ListProductIngredientAudits the audit trail of the ingredient list of a food, the oldest first
+func ListProductIngredientAudits(session *gorm.DB, merchant *dao.Merchant, productId int64) (*dto.ListIngredientAuditResp, error) {
+	if _, err := getProduct(session, merchant.Id, productId); err != nil {
+		return nil, errors.Wrap(err, ">>ListProductIngredientAudits ")
+	}
+
+	audits, err := listIngredientAudits(session, productId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>ListProductIngredientAudits ")
+	}
+	return &dto.ListIngredientAuditResp{Audits: audits}, nil
+}
+
+// UpdateProductIngredient saves the list corrected by the merchant as confirmed
+func UpdateProductIngredient(session *gorm.DB, merchant *dao.Merchant, productId int64, req *dto.ProductIngredientReq) (*dto.ProductIngredientResp, error) {
+	product, err := getFood(session, merchant.Id, productId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>UpdateProductIngredient ")
+	}
+
+	record, err := correctProductIngredient(session, product, req, ingredient.SourceMerchant, merchant.UserId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>UpdateProductIngredient ")
+	}
+	return newProductIngredientResp(record), nil
+}
+
+// ConfirmProductIngredient confirms the generated list as is
+func ConfirmProductIngredient(session *gorm.DB, merchant *dao.Merchant, productId int64) (*dto.ProductIngredientResp, error) {
+	product, err := getFood(session, merchant.Id, productId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>ConfirmProductIngredient ")
+	}
+
+	record, err := getProductIngredient(session, productId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>ConfirmProductIngredient ")
+	}
+	if record.Status == dao.IngredientStatusConfirmed {
+		return newProductIngredientResp(record), nil
+	}
+
+	if err = approveProductIngredient(session, product, record, dao.IngredientActionConfirm,
+		ingredient.SourceMerchant, merchant.UserId, ""); err != nil {
+		return nil, errors.Wrap(err, ">>ConfirmProductIngredient ")
+	}
+	return newProductIngredientResp(record), nil
+}
+
+// AnalyzeProductIngredient generates the list again, replacing a confirmed one
+func AnalyzeProductIngredient(ctx context.Context, session *gorm.DB, analysis ingredient.Analysis, merchant *dao.Merchant, productId int64) (*dto.ProductIngredientResp, error) {
+	if analysis == nil {
+		return nil, xerr.NewErrCode(xerr.IngredientAnalysisOff)
+	}
+	product, err := getFood(session, merchant.Id, productId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>AnalyzeProductIngredient ")
+	}
+
+	record, err := analyzeProduct(ctx, session, analysis, product, &merchant.UserId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>AnalyzeProductIngredient ")
+	}
+	return newProductIngredientResp(record), nil
+}
+
+// AnalyzeProducts generates the lists of up to limit foods never analyzed or renamed since, the count analyzed is returned.
+// A failed food is logged and recorded on its list, the next ones are still analyzed
+func AnalyzeProducts(ctx context.Context, session *gorm.DB, analysis ingredient.Analysis, limit int) (int, error) {
+	if analysis == nil {
+		return 0, nil
+	}
+
+	products, err := dao.ListProductsToAnalyze(session, time.Now().Add(-ingredientRetryInterval), limit)
+	if err != nil {
+		return 0, errors.Wrap(err, ">>AnalyzeProducts, dao.ListProductsToAnalyze fail")
+	}
+
+	analyzed := 0
+	for i := range products {
+		product := &products[i]
+		if _, err = analyzeProduct(ctx, session, analysis, product, nil); err != nil {
+			logrus.Errorf("analyze the ingredients of product %d fail: %s", product.Id, err)
+			if err = recordAnalysisFailure(session, product, err); err != nil {
+				logrus.Errorf("record the analysis failure of product %d fail: %s", product.Id, err)
+			}
+			continue
+		}
+		analyzed++
+	}
+	return analyzed, nil
+}
+
+// ListIngredientReviews the review queue of the admins, the oldest change first
+func ListIngredientReviews(session *gorm.DB, req *dto.ListIngredientReviewReq) (*dto.ListIngredientReviewResp, error) {
+	status := req.Status
+	if status == "" {
+		status = dao.IngredientStatusFlagged
+	}
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	reviews, total, err := dao.ListProductIngredientReviews(session, status, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>ListIngredientReviews, dao.ListProductIngredientReviews fail")
+	}
+
+	resp := &dto.ListIngredientReviewResp{Total: total, Reviews: make([]dto.IngredientReviewResp, 0, len(reviews))}
+	for i := range reviews {
+		resp.Reviews = append(resp.Reviews, newIngredientReviewResp(&reviews[i].ProductIngredient,
+			reviews[i].ProductName, reviews[i].StoreName))
+	}
+	return resp, nil
+}
+
+// GetIngredientReview the list of a food with its audit trail for the admins
+func GetIngredientReview(session *gorm.DB, productId int64) (*dto.IngredientReviewDetailResp, error) {
+	product, record, err := getReviewedFood(session, productId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>GetIngredientReview ")
+	}
+	merchant, err := getMerchant(session, product.MerchantId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>GetIngredientReview ")
+	}
+
+	audits, err := listIngredientAudits(session, productId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>GetIngredientReview ")
+	}
+	return &dto.IngredientReviewDetailResp{
+		IngredientReviewResp: newIngredientReviewResp(record, product.Name, merchantStoreName(merchant)),
+		Audits:               audits,
+	}, nil
+}
+
+// ReviewProductIngredient saves the list corrected by an admin as confirmed, it overrides the analysis of the dish
+func ReviewProductIngredient(session *gorm.DB, operator *dao.User, productId int64, req *dto.ProductIngredientReq) (*dto.ProductIngredientResp, error) {
+	product, _, err := getReviewedFood(session, productId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>ReviewProductIngredient ")
+	}
+
+	record, err := correctProductIngredient(session, product, req, ingredient.SourceAdmin, operator.Id)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>ReviewProductIngredient ")
+	}
+	return newProductIngredientResp(record), nil
+}
+
+// ApproveProductIngredient confirms the list as is by an admin, it overrides the analysis of the dish
+func ApproveProductIngredient(session *gorm.DB, operator *dao.User, productId int64, req *dto.IngredientApproveReq) (*dto.ProductIngredientResp, error) {
+	product, record, err := getReviewedFood(session, productId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>ApproveProductIngredient ")
+	}
+
+	if err = approveProductIngredient(session, product, record, dao.IngredientActionAdminApprove,
+		ingredient.SourceAdmin, operator.Id, req.Note); err != nil {
+		return nil, errors.Wrap(err, ">>ApproveProductIngredient ")
+	}
+	return newProductIngredientResp(record), nil
+}
+
+// IngredientOverrideStore the confirmed lists of the database, for the ingredient analysis
+type IngredientOverrideStore struct {
+	session *gorm.DB
+}
+
+func NewIngredientOverrideStore(session *gorm.DB) *IngredientOverrideStore {
+	return &IngredientOverrideStore{session: session}
+}
+
+// Overrides the own override of the merchant wins over the one of the admins
+func (s *IngredientOverrideStore) Overrides(ctx context.Context, merchantId int64, keys []string) (map[string]ingredient.Override, error) {
+	overrides, err := dao.ListIngredientOverrides(s.session.WithContext(ctx), merchantId, keys)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>IngredientOverrideStore.Overrides, dao.ListIngredientOverrides fail")
+	}
+
+	found := make(map[string]ingredient.Override, len(overrides))
+	for _, override := range overrides {
+		if _, ok := found[override.Dish]; ok && override.MerchantId == nil {
+			continue
+		}
+		found[override.Dish] = ingredient.Override{Ingredients: override.Ingredients, Source: override.Source}
+	}
+	return found, nil
+}
+
+// analyzeProduct generates the list of the food, an empty or low confidence model result and a stub one are flagged
+// for the admins.
+// operatorId is nil for the sweeper
+func analyzeProduct(ctx context.Context, session *gorm.DB, analysis ingredient.Analysis, product *dao.Product, operatorId *int64) (*dao.ProductIngredient, error) {
+	results, err := analysis.AnalyzeDishes(ctx, product.MerchantId, []string{product.Name})
+	if err != nil {
+		return nil, errors.Wrapf(err, ">>analyzeProduct, analysis.AnalyzeDishes product %d fail", product.Id)
+	}
+	result := results[product.Name]
+
+	record, err := dao.GetProductIngredient(session, product.Id)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>analyzeProduct, dao.GetProductIngredient fail")
+	}
+	if record == nil {
+		record = &dao.ProductIngredient{ProductId: product.Id, MerchantId: product.MerchantId}
+	}
+
+	record.Ingredients = cleanIngredients(result.Ingredients)
+	record.Allergens = allergen.Detect(record.Ingredients)
+	record.Status = dao.IngredientStatusGenerated
+	if result.Source == ingredient.SourceStub || (result.Source == ingredient.SourceLLM &&
+		(len(record.Ingredients) == 0 || (result.Confidence != nil && *result.Confidence < minIngredientConfidence))) {
+		record.Status = dao.IngredientStatusFlagged
+	}
+	record.Source = result.Source
+	record.Confidence = result.Confidence
+	record.AnalyzedName = &product.Name
+	record.AnalysisError = nil
+	record.AnalysisFailedAt = nil
+	record.ConfirmedBy = nil
+	record.ConfirmedAt = nil
+
+	tx := session.Begin()
+	if err = tx.Error; err != nil {
+		return nil, errors.Wrap(err, ">>analyzeProduct, transaction begin fail")
+	}
+	defer tx.Rollback()
+
+	if err = saveProductIngredient(tx, record, dao.IngredientActionAnalyze, operatorId, ""); err != nil {
+		return nil, errors.Wrap(err, ">>analyzeProduct ")
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		return nil, errors.Wrap(err, ">>analyzeProduct, transaction commit fail")
+	}
+	return record, nil
+}
+
+// recordAnalysisFailure the list of the food is kept with the failure, a food without any list gets a failed one
+func recordAnalysisFailure(session *gorm.DB, product *dao.Product, cause error) error {
+	record, err := dao.GetProductIngredient(session, product.Id)
+	if err != nil {
+		return errors.Wrap(err, ">>recordAnalysisFailure, dao.GetProductIngredient fail")
+	}
+	if record == nil {
+		record = &dao.ProductIngredient{
+			ProductId:   product.Id,
+			MerchantId:  product.MerchantId,
+			Ingredients: []string{},
+			Allergens:   []string{},
+			Status:      dao.IngredientStatusFailed,
+			Source:      ingredient.SourceLLM,
+		}
+	}
+
+	now := time.Now()
+	message := errors.Cause(cause).Error()
+	record.AnalysisError = &message
+	record.AnalysisFailedAt = &now
+	if err = record.Save(session); err != nil {
+		return errors.Wrap(err, ">>recordAnalysisFailure, record.Save fail")
+	}
+	return nil
+}
+
+// reconfirmProductIngredient a confirmed list is for the former name of the food, the merchant confirms it again
+// for the new one. It must be called in a transaction
+func reconfirmProductIngredient(tx *gorm.DB, product *dao.Product) error {
+	if product.Kind != dao.ProductKindFood {
+		return nil
+	}
+	record, err := dao.GetProductIngredient(tx, product.Id)
+	if err != nil {
+		return errors.Wrap(err, ">>reconfirmProductIngredient, dao.GetProductIngredient fail")
+	}
+	if record == nil || record.Status != dao.IngredientStatusConfirmed ||
+		(record.AnalyzedName != nil && *record.AnalyzedName == product.Name) {
+		return nil
+	}
+
+	record.Status = dao.IngredientStatusReconfirm
+	record.AnalyzedName = &product.Name
+	if err = saveProductIngredient(tx, record, dao.IngredientActionRename, nil, ""); err != nil {
+		return errors.Wrap(err, ">>reconfirmProductIngredient ")
+	}
+	return nil
+}
+
+// correctProductIngredient saves the list of the merchant or the admin as confirmed, with the override of the dish
+func correctProductIngredient(session *gorm.DB, product *dao.Product, req *dto.ProductIngredientReq, source string, operatorId int64) (*dao.ProductIngredient, error) {
+	ingredients := cleanIngredients(req.Ingredients)
+	allergens := allergen.Detect(ingredients)
+	if req.Allergens != nil {
+		var err error
+		if allergens, err = normalizeAllergens(*req.Allergens); err != nil {
+			return nil, err
+		}
+	}
+
+	tx := session.Begin()
+	if err := tx.Error; err != nil {
+		return nil, errors.Wrap(err, ">>correctProductIngredient, transaction begin fail")
+	}
+	defer tx.Rollback()
+
+	record, err := dao.GetProductIngredient(tx, product.Id)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>correctProductIngredient, dao.GetProductIngredient fail")
+	}
+	if record == nil {
+		record = &dao.ProductIngredient{ProductId: product.Id, MerchantId: product.MerchantId}
+	}
+
+	action := dao.IngredientActionUpdate
+	if source == ingredient.SourceAdmin {
+		action = dao.IngredientActionAdminUpdate
+	}
+	now := time.Now()
+	record.Ingredients = ingredients
+	record.Allergens = allergens
+	record.Status = dao.IngredientStatusConfirmed
+	record.Source = source
+	record.Confidence = nil
+	record.AnalyzedName = &product.Name
+	record.AnalysisError = nil
+	record.AnalysisFailedAt = nil
+	record.ConfirmedBy = &operatorId
+	record.ConfirmedAt = &now
+	if err = saveProductIngredient(tx, record, action, &operatorId, req.Note); err != nil {
+		return nil, errors.Wrap(err, ">>correctProductIngredient ")
+	}
+	if err = saveIngredientOverride(tx, product, ingredients, source, operatorId); err != nil {
+		return nil, errors.Wrap(err, ">>correctProductIngredient ")
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		return nil, errors.Wrap(err, ">>correctProductIngredient, transaction commit fail")
+	}
+	return record, nil
+}
+
+// approveProductIngredient confirms the list as is, the source of the list is kept and the one of the reviewer overrides the dish
+func approveProductIngredient(session *gorm.DB, product *dao.Product, record *dao.ProductIngredient, action, source string, operatorId int64, note string) error {
+	if record.Status == dao.IngredientStatusFailed {
+		return xerr.NewErrCodeMsg(xerr.RequestParamError, "the analysis of the food failed, the list must be corrected")
+	}
+
+	tx := session.Begin()
+	if err := tx.Error; err != nil {
+		return errors.Wrap(err, ">>approveProductIngredient, transaction begin fail")
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	record.Status = dao.IngredientStatusConfirmed
+	record.ConfirmedBy = &operatorId
+	record.ConfirmedAt = &now
+	if err := saveProductIngredient(tx, record, action, &operatorId, note); err != nil {
+		return errors.Wrap(err, ">>approveProductIngredient ")
+	}
+	if err := saveIngredientOverride(tx, product, record.Ingredients, source, operatorId); err != nil {
+		return errors.Wrap(err, ">>approveProductIngredient ")
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return errors.Wrap(err, ">>approveProductIngredient, transaction commit fail")
+	}
+	return nil
+}
+
+// saveProductIngredient saves the list with its audit, it must be called in a transaction
+func saveProductIngredient(tx *gorm.DB, record *dao.ProductIngredient, action string, operatorId *int64, note string) error {
+	if err := record.Save(tx); err != nil {
+		return errors.Wrap(err, ">>saveProductIngredient, record.Save fail")
+	}
+
+	audit := dao.ProductIngredientAudit{
+		ProductId:   record.ProductId,
+		MerchantId:  record.MerchantId,
+		Action:      action,
+		Source:      record.Source,
+		Status:      record.Status,
+		Ingredients: record.Ingredients,
+		Allergens:   record.Allergens,
+		Confidence:  record.Confidence,
+		OperatorId:  operatorId,
+		Note:        nullableString(strings.TrimSpace(note)),
+	}
+	if err := audit.Save(tx); err != nil {
+		return errors.Wrap(err, ">>saveProductIngredient, audit.Save fail")
+	}
+	return nil
+}
+
+// saveIngredientOverride the confirmed list wins over the analysis of the dish name. The list of a merchant only applies
+// to its own foods, the one of an admin applies to every merchant and replaces the own one of the merchant reviewed.
+// It must be called in a transaction
+func saveIngredientOverride(tx *gorm.DB, product *dao.Product, ingredients []string, source string, operatorId int64) error {
+	dish := ingredient.DishKey(product.Name)
+	merchantId := &product.MerchantId
+	if source == ingredient.SourceAdmin {
+		merchantId = nil
+		if err := dao.DeleteIngredientOverride(tx, dish, product.MerchantId); err != nil {
+			return errors.Wrap(err, ">>saveIngredientOverride, dao.DeleteIngredientOverride fail")
+		}
+	}
+
+	override, err := dao.GetIngredientOverride(tx, dish, merchantId)
+	if err != nil {
+		return errors.Wrap(err, ">>saveIngredientOverride, dao.GetIngredientOverride fail")
+	}
+	if override == nil {
+		override = &dao.IngredientOverride{Dish: dish, MerchantId: merchantId}
+	}
+
+	override.Ingredients = ingredients
+	override.Source = source
+	override.ProductId = &product.Id
+	override.OperatorId = operatorId
+	if err = override.Save(tx); err != nil {
+		return errors.Wrap(err, ">>saveIngredientOverride, override.Save fail")
+	}
+	return nil
+}
+
+func listIngredientAudits(session *gorm.DB, productId int64) ([]dto.IngredientAuditResp, error) {
+	audits, err := dao.ListProductIngredientAudits(session, productId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>listIngredientAudits, dao.ListProductIngredientAudits fail")
+	}
+
+	resp := make([]dto.IngredientAuditResp, 0, len(audits))
+	for _, audit := range audits {
+		item := dto.IngredientAuditResp{
+			Action:      audit.Action,
+			Source:      audit.Source,
+			Status:      audit.Status,
+			Ingredients: audit.Ingredients,
+			Allergens:   audit.Allergens,
+			Confidence:  audit.Confidence,
+			OperatorId:  audit.OperatorId,
+			Note:        audit.Note,
+		}
+		if audit.CreatedAt != nil {
+			item.CreatedAt = audit.CreatedAt.Unix()
+		}
+		resp = append(resp, item)
+	}
+	return resp, nil
+}
+
+// parseAllergenFilter the codes of the allergens to exclude
+func parseAllergenFilter(req *dto.AllergenFilterReq) ([]string, error) {
+	var names []string
+	for _, value := range req.ExcludeAllergens {
+		names = append(names, strings.Split(value, ",")...)
+	}
+	return normalizeAllergens(names)
+}
+
+func normalizeAllergens(names []string) ([]string, error) {
+	codes := make([]string, 0, len(names))
+	for _, name := range names {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		code, ok := allergen.Normalize(name)
+		if !ok {
+			return nil, xerr.NewErrCodeMsg(xerr.RequestParamError, fmt.Sprintf("unknown allergen %s", name))
+		}
+		codes = append(codes, code)
+	}
+	return allergen.Sort(codes), nil
+}
+
+// allergenFree false if the food has any of the allergens or has no reviewed list, a stub list isn't an analysis
+func allergenFree(product *dao.Product, record *dao.ProductIngredient, excluded []string) bool {
+	if len(excluded) == 0 || product.Kind != dao.ProductKindFood {
+		return true
+	}
+	if record == nil || record.Source == ingredient.SourceStub || len(record.Ingredients) == 0 {
+		return false
+	}
+	reviewed := false
+	for _, status := range dao.IngredientStatusesReviewed {
+		reviewed = reviewed || record.Status == status
+	}
+	if !reviewed {
+		return false
+	}
+	for _, code := range record.Allergens {
+		for _, ex := range excluded {
+			if code == ex {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// cleanIngredients trimmed, without the empty ones and the duplicates ignoring the case
+func cleanIngredients(ingredients []string) []string {
+	seen := make(map[string]bool, len(ingredients))
+	cleaned := make([]string, 0, len(ingredients))
+	for _, name := range ingredients {
+		name = strings.Join(strings.Fields(name), " ")
+		key := strings.ToLower(name)
+		if name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		cleaned = append(cleaned, name)
+	}
+	return cleaned
+}
+
+func loadProductIngredients(session *gorm.DB, products []dao.Product) (map[int64]*dao.ProductIngredient, error) {
+	ids := make([]int64, 0, len(products))
+	for i := range products {
+		if products[i].Kind == dao.ProductKindFood {
+			ids = append(ids, products[i].Id)
+		}
+	}
+
+	records, err := dao.GetProductIngredients(session, ids)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>loadProductIngredients, dao.GetProductIngredients fail")
+	}
+	return records, nil
+}
+
+func getProductIngredient(session *gorm.DB, productId int64) (*dao.ProductIngredient, error) {
+	record, err := dao.GetProductIngredient(session, productId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>getProductIngredient, dao.GetProductIngredient fail")
+	}
+	if record == nil {
+		return nil, xerr.NewErrCode(xerr.ProductIngredientNotExist)
+	}
+	return record, nil
+}
+
+// getReviewedFood the food of any merchant with its list, for the admins
+func getReviewedFood(session *gorm.DB, productId int64) (*dao.Product, *dao.ProductIngredient, error) {
+	record, err := getProductIngredient(session, productId)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, ">>getReviewedFood ")
+	}
+	product, err := getFood(session, record.MerchantId, productId)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, ">>getReviewedFood ")
+	}
+	return product, record, nil
+}
+
+// getFood only the foods have ingredients
+func getFood(session *gorm.DB, merchantId, productId int64) (*dao.Product, error) {
+	product, err := getProduct(session, merchantId, productId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>getFood ")
+	}
+	if product.Kind != dao.ProductKindFood {
+		return nil, xerr.NewErrCodeMsg(xerr.RequestParamError, "only a food has ingredients")
+	}
+	return product, nil
+}
+
+func merchantStoreName(merchant *dao.Merchant) string {
+	if merchant.TradeName != nil {
+		return *merchant.TradeName
+	}
+	return merchant.LegalName
+}
+
+func newIngredientReviewResp(record *dao.ProductIngredient, productName, storeName string) dto.IngredientReviewResp {
+	return dto.IngredientReviewResp{
+		ProductId:             record.ProductId,
+		ProductName:           productName,
+		StoreId:               record.MerchantId,
+		StoreName:             storeName,
+		ProductIngredientResp: *newProductIngredientResp(record),
+	}
+}
+
+func newProductIngredientResp(record *dao.ProductIngredient) *dto.ProductIngredientResp {
+	if record == nil {
+		return nil
+	}
+	return &dto.ProductIngredientResp{
+		Ingredients:   record.Ingredients,
+		Allergens:     record.Allergens,
+		Status:        record.Status,
+		Source:        record.Source,
+		Confidence:    record.Confidence,
+		AnalysisError: record.AnalysisError,
+		ConfirmedAt:   record.ConfirmedAt,
+	}
+}
@@ -0,0 +1,263 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/tespkg/bytes-be/internal/ingredient"
+	"github.com/tespkg/bytes-be/svc/staff/model/dao"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+	"gorm.io/gorm"
+)
+
+// fakeAnalysis the dishes of fail return an error, the others one ingredient
+type fakeAnalysis struct {
+	lock  sync.Mutex
+	fail  map[string]bool
+	calls map[string]int
+}
+
+func newFakeAnalysis(fail ...string) *fakeAnalysis {
+	f := &fakeAnalysis{fail: make(map[string]bool), calls: make(map[string]int)}
+	for _, dish := range fail {
+		f.fail[dish] = true
+	}
+	return f
+}
+
+func (f *fakeAnalysis) Analyze(ctx context.Context, dishes []string) ([]string, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f *fakeAnalysis) AnalyzeDishes(ctx context.Context, merchantId int64, dishes []string) (map[string]ingredient.Result, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	confidence := 0.9
+	results := make(map[string]ingredient.Result, len(dishes))
+	for _, dish := range dishes {
+		f.calls[dish]++
+		if f.fail[dish] {
+			return nil, errors.New("provider unavailable")
+		}
+		results[dish] = ingredient.Result{Ingredients: []string{"rice"}, Confidence: &confidence, Source: ingredient.SourceLLM}
+	}
+	return results, nil
+}
+
+func (f *fakeAnalysis) Embedding(ctx context.Context, inputs []string, trim bool) (map[string]string, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f *fakeAnalysis) EmbeddingModel() string {
+	return "fake"
+}
+
+func (f *fakeAnalysis) callsOf(dish string) int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.calls[dish]
+}
+
+func mustProductIngredient(t *testing.T, db *gorm.DB, productId int64) *dao.ProductIngredient {
+	t.Helper()
+
+	record, err := dao.GetProductIngredient(db, productId)
+	if err != nil || record == nil {
+		t.Fatalf("get product ingredient %d: %v %+v", productId, err, record)
+	}
+	return record
+}
+
+func TestAnalyzeProductsContinuesPastFailures(t *testing.T) {
+	db := setUpLogicEnv(t, false)
+	merchant := createTestMerchant(t, db)
+	failing := createTestProduct(t, db, merchant, "Shuwa", 0)
+	analyzed := createTestProduct(t, db, merchant, "Harees", 0)
+	analysis := newFakeAnalysis(failing.Name)
+
+	if _, err := AnalyzeProducts(context.Background(), db, analysis, 1000); err != nil {
+		t.Fatalf("analyze products: %v", err)
+	}
+
+	if record := mustProductIngredient(t, db, analyzed.Id); record.Status != dao.IngredientStatusGenerated {
+		t.Fatalf("got status %s, want %s", record.Status, dao.IngredientStatusGenerated)
+	}
+	record := mustProductIngredient(t, db, failing.Id)
+	if record.Status != dao.IngredientStatusFailed || record.AnalysisError == nil || record.AnalysisFailedAt == nil {
+		t.Fatalf("unexpected failed record %+v", record)
+	}
+	if allergenFree(failing, record, []string{"gluten"}) {
+		t.Fatal("a failed food passes the allergen filter")
+	}
+
+	// the failed food waits for the retry interval
+	if _, err := AnalyzeProducts(context.Background(), db, analysis, 1000); err != nil {
+		t.Fatalf("analyze products again: %v", err)
+	}
+	if calls := analysis.callsOf(failing.Name); calls != 1 {
+		t.Fatalf("got %d analyses of the failed food, want 1", calls)
+	}
+
+	// a success clears the failure
+	analysis.fail = nil
+	if _, err := AnalyzeProductIngredient(context.Background(), db, analysis, merchant, failing.Id); err != nil {
+		t.Fatalf("analyze product ingredient: %v", err)
+	}
+	record = mustProductIngredient(t, db, failing.Id)
+	if record.Status != dao.IngredientStatusGenerated || record.AnalysisError != nil || record.AnalysisFailedAt != nil {
+		t.Fatalf("unexpected record after the success %+v", record)
+	}
+}
+
+func TestRenameAsksToReconfirm(t *testing.T) {
+	db := setUpLogicEnv(t, false)
+	merchant := createTestMerchant(t, db)
+	product := createTestProduct(t, db, merchant, "Majboos", 0)
+
+	if _, err := UpdateProductIngredient(db, merchant, product.Id, &dto.ProductIngredientReq{Ingredients: []string{"rice", "chicken"}}); err != nil {
+		t.Fatalf("update product ingredient: %v", err)
+	}
+
+	price := product.Price
+	req := &dto.ProductReq{Kind: dao.ProductKindFood, Name: "Majboos", Price: &price}
+	if _, err := UpdateProduct(db, merchant, product.Id, req); err != nil {
+		t.Fatalf("update product: %v", err)
+	}
+	if record := mustProductIngredient(t, db, product.Id); record.Status != dao.IngredientStatusConfirmed {
+		t.Fatalf("got status %s without a rename, want %s", record.Status, dao.IngredientStatusConfirmed)
+	}
+
+	req.Name = "Majboos Laham"
+	if _, err := UpdateProduct(db, merchant, product.Id, req); err != nil {
+		t.Fatalf("rename product: %v", err)
+	}
+	record := mustProductIngredient(t, db, product.Id)
+	if record.Status != dao.IngredientStatusReconfirm || record.AnalyzedName == nil || *record.AnalyzedName != req.Name {
+		t.Fatalf("unexpected record after the rename %+v", record)
+	}
+
+	if _, err := ConfirmProductIngredient(db, merchant, product.Id); err != nil {
+		t.Fatalf("confirm product ingredient: %v", err)
+	}
+	if record = mustProductIngredient(t, db, product.Id); record.Status != dao.IngredientStatusConfirmed {
+		t.Fatalf("got status %s after the confirmation, want %s", record.Status, dao.IngredientStatusConfirmed)
+	}
+}
+
+func TestOverridesByMerchant(t *testing.T) {
+	db := setUpLogicEnv(t, false)
+	store := NewIngredientOverrideStore(db)
+	own := createTestMerchant(t, db)
+	other := createTestMerchant(t, db)
+	dish := "Mashuai " + own.CrNumber
+	product := createTestProduct(t, db, own, dish, 0)
+	keys := []string{ingredient.DishKey(dish)}
+
+	overridesOf := func(merchant *dao.Merchant) map[string]ingredient.Override {
+		found, err := store.Overrides(context.Background(), merchant.Id, keys)
+		if err != nil {
+			t.Fatalf("overrides: %v", err)
+		}
+		return found
+	}
+
+	// the list confirmed by a merchant only applies to its own foods
+	if _, err := UpdateProductIngredient(db, own, product.Id, &dto.ProductIngredientReq{Ingredients: []string{"kingfish"}}); err != nil {
+		t.Fatalf("update product ingredient: %v", err)
+	}
+	if override, ok := overridesOf(own)[keys[0]]; !ok || override.Source != ingredient.SourceMerchant {
+		t.Fatalf("got %+v %t, want the override of the merchant", override, ok)
+	}
+	if override, ok := overridesOf(other)[keys[0]]; ok {
+		t.Fatalf("got the override %+v of another merchant", override)
+	}
+
+	// the list of an admin applies to every merchant
+	operator := &dao.User{Id: own.UserId}
+	if _, err := ReviewProductIngredient(db, operator, product.Id, &dto.ProductIngredientReq{Ingredients: []string{"kingfish", "rice"}}); err != nil {
+		t.Fatalf("review product ingredient: %v", err)
+	}
+	for _, merchant := range []*dao.Merchant{own, other} {
+		if override, ok := overridesOf(merchant)[keys[0]]; !ok || override.Source != ingredient.SourceAdmin {
+			t.Fatalf("got %+v %t for merchant %d, want the override of the admin", override, ok, merchant.Id)
+		}
+	}
+
+	// the own list of the merchant goes before the one of the admin
+	if _, err := UpdateProductIngredient(db, own, product.Id, &dto.ProductIngredientReq{Ingredients: []string{"kingfish", "lime"}}); err != nil {
+		t.Fatalf("update product ingredient again: %v", err)
+	}
+	if override := overridesOf(own)[keys[0]]; override.Source != ingredient.SourceMerchant {
+		t.Fatalf("got %+v, want the override of the merchant", override)
+	}
+	if override := overridesOf(other)[keys[0]]; override.Source != ingredient.SourceAdmin {
+		t.Fatalf("got %+v, want the override of the admin", override)
+	}
+}
+
+func TestAnalyzeProductsWithStub(t *testing.T) {
+	db := setUpLogicEnv(t, false)
+	merchant := createTestMerchant(t, db)
+	product := createTestProduct(t, db, merchant, "Chicken Machboos", 0)
+	analysis, err := ingredient.New(
+		ingredient.WithConfig(ingredient.Config{Provider: ingredient.ProviderStub}),
+		ingredient.WithOverrideStore(NewIngredientOverrideStore(db)),
+	)
+	if err != nil {
+		t.Fatalf("new stub analysis: %v", err)
+	}
+
+	if _, err = AnalyzeProducts(context.Background(), db, analysis, 1000); err != nil {
+		t.Fatalf("analyze products: %v", err)
+	}
+
+	// a stub list always waits for a review and never passes the allergen filters
+	record := mustProductIngredient(t, db, product.Id)
+	if record.Source != ingredient.SourceStub || record.Status != dao.IngredientStatusFlagged {
+		t.Fatalf("unexpected stub record %+v", record)
+	}
+	if len(record.Ingredients) == 0 {
+		t.Fatal("got no ingredient from the stub")
+	}
+	if allergenFree(product, record, []string{"gluten"}) {
+		t.Fatal("a stub list passes the allergen filter")
+	}
+}
+
+func TestAllergenFree(t *testing.T) {
+	food := &dao.Product{Kind: dao.ProductKindFood}
+	list := func(status, source string, ingredients []string, allergens ...string) *dao.ProductIngredient {
+		return &dao.ProductIngredient{Status: status, Source: source, Ingredients: ingredients, Allergens: allergens}
+	}
+	rice := []string{"rice"}
+
+	cases := []struct {
+		name    string
+		product *dao.Product
+		record  *dao.ProductIngredient
+		free    bool
+	}{
+		{"generated", food, list(dao.IngredientStatusGenerated, ingredient.SourceLLM, rice), true},
+		{"confirmed", food, list(dao.IngredientStatusConfirmed, ingredient.SourceMerchant, rice), true},
+		{"renamed", food, list(dao.IngredientStatusReconfirm, ingredient.SourceMerchant, rice), true},
+		{"with the allergen", food, list(dao.IngredientStatusConfirmed, ingredient.SourceMerchant, []string{"bread"}, "gluten"), false},
+		{"with another allergen", food, list(dao.IngredientStatusConfirmed, ingredient.SourceMerchant, []string{"laban"}, "milk"), true},
+		{"empty list", food, list(dao.IngredientStatusGenerated, ingredient.SourceLLM, []string{}), false},
+		{"flagged", food, list(dao.IngredientStatusFlagged, ingredient.SourceLLM, rice), false},
+		{"failed", food, list(dao.IngredientStatusFailed, ingredient.SourceLLM, nil), false},
+		{"stub", food, list(dao.IngredientStatusGenerated, ingredient.SourceStub, rice), false},
+		{"not analyzed", food, nil, false},
+		{"not a food", &dao.Product{Kind: dao.ProductKindClothing}, nil, true},
+	}
+	for _, c := range cases {
+		if got := allergenFree(c.product, c.record, []string{"gluten"}); got != c.free {
+			t.Fatalf("%s: got %t, want %t", c.name, got, c.free)
+		}
+	}
+	if !allergenFree(food, nil, nil) {
+		t.Fatal("a food is filtered without any allergen excluded")
+	}
+}
@@ -0,0 +1,531 @@
+package logic
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/tespkg/bytes-be/common/global"
+	"github.com/tespkg/bytes-be/common/openhours"
+	"github.com/tespkg/bytes-be/common/xerr"
+	"github.com/tespkg/bytes-be/svc/staff/model/dao"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+	"gorm.io/gorm"
+	"sort"
+	"time"
+)
+
+// EventInventoryLowStock is emitted to the merchant when an item falls to its low stock threshold
+const EventInventoryLowStock = "inventory_low_stock"
+
+// DefaultStockReservationExpire the payment timeout, the sweeper releases the reservations past it
+const DefaultStockReservationExpire = 15 * time.Minute
+
+const releaseReasonPaymentTimeout = "payment timeout"
+
+func ListInventories(session *gorm.DB, merchant *dao.Merchant) (*dto.ListInventoryResp, error) {
+	inventories, err := dao.ListInventories(session, merchant.Id)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>ListInventories, dao.ListInventories fail")
+	}
+	products, err := dao.ListProducts(session, merchant.Id, 0, false)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>ListInventories, dao.ListProducts fail")
+	}
+
+	index := make(map[int64]*dao.Product, len(products))
+	for i := range products {
+		index[products[i].Id] = &products[i]
+	}
+
+	today := storeToday(merchant.Timezone)
+	resp := &dto.ListInventoryResp{Inventories: make([]dto.InventoryResp, 0, len(inventories))}
+	for i := range inventories {
+		product, ok := index[inventories[i].ProductId]
+		if !ok {
+			continue
+		}
+		resetDailyStock(&inventories[i], today)
+		resp.Inventories = append(resp.Inventories, newInventoryResp(&inventories[i], product))
+	}
+	return resp, nil
+}
+
+// SaveInventory starts tracking the stock of an item or overwrites it, the reserved stock is kept
+func SaveInventory(session *gorm.DB, merchant *dao.Merchant, req *dto.InventoryReq) (*dto.InventoryResp, error) {
+	product, err := getProduct(session, merchant.Id, req.ProductId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>SaveInventory ")
+	}
+	if err = validateStockItem(product, req.VariantId); err != nil {
+		return nil, err
+	}
+
+	tx := session.Begin()
+	if err = tx.Error; err != nil {
+		return nil, errors.Wrap(err, ">>SaveInventory, transaction begin fail")
+	}
+	defer tx.Rollback()
+
+	inventory, err := dao.GetInventoryByItemForUpdate(tx, product.Id, req.VariantId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>SaveInventory, dao.GetInventoryByItemForUpdate fail")
+	}
+	if inventory == nil || inventory.Id == 0 {
+		inventory = &dao.Inventory{MerchantId: merchant.Id, ProductId: product.Id, VariantId: req.VariantId}
+	}
+	inventory.Quantity = *req.Quantity
+	inventory.LowStockThreshold = req.LowStockThreshold
+	inventory.DailyQuantity = req.DailyQuantity
+	inventory.StockDate = nil
+	if req.DailyQuantity != nil {
+		//the quantity given is the one of today, the daily quantity applies from tomorrow
+		today := storeToday(merchant.Timezone)
+		inventory.StockDate = &today
+	}
+	lowStock := checkLowStock(inventory)
+
+	if err = inventory.Save(tx); err != nil {
+		return nil, errors.Wrap(err, ">>SaveInventory, inventory.Save fail")
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		return nil, errors.Wrap(err, ">>SaveInventory, transaction commit fail")
+	}
+
+	if lowStock {
+		notifyLowStock(merchant.Id, []dao.Inventory{*inventory})
+	}
+	resp := newInventoryResp(inventory, product)
+	return &resp, nil
+}
+
+// AdjustInventory adds the delta to the quantity under the row lock, so it can't lose a concurrent reservation
+func AdjustInventory(session *gorm.DB, merchant *dao.Merchant, inventoryId int64, delta int) (*dto.InventoryResp, error) {
+	tx := session.Begin()
+	if err := tx.Error; err != nil {
+		return nil, errors.Wrap(err, ">>AdjustInventory, transaction begin fail")
+	}
+	defer tx.Rollback()
+
+	inventory, err := dao.GetInventoryForUpdate(tx, merchant.Id, inventoryId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>AdjustInventory, dao.GetInventoryForUpdate fail")
+	}
+	if inventory == nil || inventory.Id == 0 {
+		return nil, xerr.NewErrCode(xerr.InventoryNotExist)
+	}
+
+	resetDailyStock(inventory, storeToday(merchant.Timezone))
+	if inventory.Quantity+delta < 0 {
+		return nil, xerr.NewErrCodeMsg(xerr.RequestParamError, fmt.Sprintf("only %d in stock", inventory.Quantity))
+	}
+	inventory.Quantity += delta
+	lowStock := checkLowStock(inventory)
+
+	if err = inventory.Save(tx); err != nil {
+		return nil, errors.Wrap(err, ">>AdjustInventory, inventory.Save fail")
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		return nil, errors.Wrap(err, ">>AdjustInventory, transaction commit fail")
+	}
+
+	if lowStock {
+		notifyLowStock(merchant.Id, []dao.Inventory{*inventory})
+	}
+
+	product, err := getProduct(session, merchant.Id, inventory.ProductId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>AdjustInventory ")
+	}
+	resp := newInventoryResp(inventory, product)
+	return &resp, nil
+}
+
+// DeleteInventory stops tracking the stock, the item becomes unlimited
+func DeleteInventory(session *gorm.DB, merchant *dao.Merchant, inventoryId int64) error {
+	tx := session.Begin()
+	if err := tx.Error; err != nil {
+		return errors.Wrap(err, ">>DeleteInventory, transaction begin fail")
+	}
+	defer tx.Rollback()
+
+	inventory, err := dao.GetInventoryForUpdate(tx, merchant.Id, inventoryId)
+	if err != nil {
+		return errors.Wrap(err, ">>DeleteInventory, dao.GetInventoryForUpdate fail")
+	}
+	if inventory == nil || inventory.Id == 0 {
+		return xerr.NewErrCode(xerr.InventoryNotExist)
+	}
+	if inventory.Reserved > 0 {
+		return xerr.NewErrCode(xerr.StockReserved)
+	}
+
+	if err = dao.DeleteInventory(tx, merchant.Id, inventoryId); err != nil {
+		return errors.Wrap(err, ">>DeleteInventory, dao.DeleteInventory fail")
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		return errors.Wrap(err, ">>DeleteInventory, transaction commit fail")
+	}
+	return nil
+}
+
+// ReserveStock holds the stock of the tracked items when an order is placed, all or nothing.
+// The inventory rows are locked in the id order, so concurrent orders wait for each other and can't oversell.
+func ReserveStock(session *gorm.DB, req *dto.ReserveStockReq, expire time.Duration) error {
+	if expire <= 0 {
+		expire = DefaultStockReservationExpire
+	}
+
+	tx := session.Begin()
+	if err := tx.Error; err != nil {
+		return errors.Wrap(err, ">>ReserveStock, transaction begin fail")
+	}
+	defer tx.Rollback()
+
+	lowStocks, err := reserveStock(tx, req, time.Now().Add(expire))
+	if err != nil {
+		return errors.Wrap(err, ">>ReserveStock ")
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		return errors.Wrap(err, ">>ReserveStock, transaction commit fail")
+	}
+
+	notifyLowStock(req.MerchantId, lowStocks)
+	return nil
+}
+
+// reserveStock it must be called in a transaction, the low stocks are returned to be notified after the commit
+func reserveStock(tx *gorm.DB, req *dto.ReserveStockReq, expiresAt time.Time) ([]dao.Inventory, error) {
+	merchant, err := getMerchant(tx, req.MerchantId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>reserveStock ")
+	}
+
+	needs := make(map[string]int, len(req.Items))
+	productIds := make([]int64, 0, len(req.Items))
+	for _, item := range req.Items {
+		needs[stockKey(item.ProductId, item.VariantId)] += item.Quantity
+		productIds = append(productIds, item.ProductId)
+	}
+
+	inventories, err := dao.ListInventoriesByProducts(tx, productIds)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>reserveStock, dao.ListInventoriesByProducts fail")
+	}
+	quantities := make(map[int64]int)
+	for _, inventory := range inventories {
+		if need, ok := needs[stockKey(inventory.ProductId, inventory.VariantId)]; ok && inventory.MerchantId == merchant.Id {
+			quantities[inventory.Id] = need
+		}
+	}
+	//none of the items is tracked
+	if len(quantities) == 0 {
+		return nil, nil
+	}
+
+	inventoryIds := make([]int64, 0, len(quantities))
+	for inventoryId := range quantities {
+		inventoryIds = append(inventoryIds, inventoryId)
+	}
+	sort.Slice(inventoryIds, func(i, j int) bool { return inventoryIds[i] < inventoryIds[j] })
+
+	locked, err := dao.ListInventoriesForUpdate(tx, inventoryIds)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>reserveStock, dao.ListInventoriesForUpdate fail")
+	}
+	//an item stopped being tracked between the read and the locks
+	if len(locked) != len(inventoryIds) {
+		return nil, xerr.NewErrCodeMsg(xerr.InventoryNotExist, "the stock of an item changed, try again")
+	}
+
+	//checked under the inventory locks, a retry of the order waits for the first attempt and sees its reservations
+	count, err := dao.CountStockReservationsByOrder(tx, req.OrderRef, dao.StockReservationReserved)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>reserveStock, dao.CountStockReservationsByOrder fail")
+	}
+	if count > 0 {
+		return nil, xerr.NewErrCode(xerr.StockReservationExist)
+	}
+
+	today := storeToday(merchant.Timezone)
+	lowStocks := make([]dao.Inventory, 0)
+	for i := range locked {
+		inventory := &locked[i]
+		quantity := quantities[inventory.Id]
+
+		resetDailyStock(inventory, today)
+		if inventory.Available() < quantity {
+			return nil, xerr.NewErrCodeMsg(xerr.StockInsufficient, fmt.Sprintf("only %d left", max(inventory.Available(), 0)))
+		}
+		inventory.Reserved += quantity
+		if checkLowStock(inventory) {
+			lowStocks = append(lowStocks, *inventory)
+		}
+		if err = inventory.Save(tx); err != nil {
+			return nil, errors.Wrap(err, ">>reserveStock, inventory.Save fail")
+		}
+
+		reservation := dao.StockReservation{
+			InventoryId: inventory.Id,
+			MerchantId:  merchant.Id,
+			OrderRef:    req.OrderRef,
+			Quantity:    quantity,
+			Status:      dao.StockReservationReserved,
+			ExpiresAt:   expiresAt,
+		}
+		if err = reservation.Save(tx); err != nil {
+			//the unique index caught a concurrent reservation of the same order
+			if dao.IsUniqueViolation(err) {
+				return nil, xerr.NewErrCode(xerr.StockReservationExist)
+			}
+			return nil, errors.Wrap(err, ">>reserveStock, reservation.Save fail")
+		}
+	}
+
+	return lowStocks, nil
+}
+
+// CommitStockReservation takes the reserved stock out of the inventory once the order is paid
+func CommitStockReservation(session *gorm.DB, orderRef string) error {
+	if err := settleStockReservation(session, orderRef, dao.StockReservationCommitted, nil, false); err != nil {
+		return errors.Wrap(err, ">>CommitStockReservation ")
+	}
+	return nil
+}
+
+// ReleaseStockReservation gives the reserved stock back when the order is cancelled
+func ReleaseStockReservation(session *gorm.DB, orderRef, reason string) error {
+	if err := settleStockReservation(session, orderRef, dao.StockReservationReleased, nullableString(reason), false); err != nil {
+		return errors.Wrap(err, ">>ReleaseStockReservation ")
+	}
+	return nil
+}
+
+// ReleaseExpiredStockReservations releases the reservations of the orders not paid in time, returns the orders released.
+// Every instance may run it, the reservations are locked and settled only once.
+func ReleaseExpiredStockReservations(session *gorm.DB, limit int) (int, error) {
+	orderRefs, err := dao.ListExpiredStockReservationOrders(session, time.Now(), limit)
+	if err != nil {
+		return 0, errors.Wrap(err, ">>ReleaseExpiredStockReservations, dao.ListExpiredStockReservationOrders fail")
+	}
+
+	reason := releaseReasonPaymentTimeout
+	released := 0
+	for _, orderRef := range orderRefs {
+		if err = settleStockReservation(session, orderRef, dao.StockReservationReleased, &reason, true); err != nil {
+			logrus.Errorf("release expired stock reservation of order %s fail: %s", orderRef, err)
+			continue
+		}
+		released++
+	}
+	return released, nil
+}
+
+// settleStockReservation commits or releases the reservations of the order, an order already settled is ignored
+func settleStockReservation(session *gorm.DB, orderRef, status string, reason *string, onlyExpired bool) error {
+	tx := session.Begin()
+	if err := tx.Error; err != nil {
+		return errors.Wrap(err, ">>settleStockReservation, transaction begin fail")
+	}
+	defer tx.Rollback()
+
+	lowStocks, err := settleStock(tx, orderRef, status, reason, onlyExpired)
+	if err != nil {
+		return errors.Wrap(err, ">>settleStockReservation ")
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		return errors.Wrap(err, ">>settleStockReservation, transaction commit fail")
+	}
+
+	notifyLowStocks(lowStocks)
+	return nil
+}
+
+// settleStock it must be called in a transaction, the low stocks are returned to be notified after the commit.
+// Committing an order whose stock was already released fails, the items may be sold to someone else meanwhile.
+func settleStock(tx *gorm.DB, orderRef, status string, reason *string, onlyExpired bool) ([]dao.Inventory, error) {
+	reservations, err := dao.ListStockReservationsByOrderForUpdate(tx, orderRef, dao.StockReservationReserved)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>settleStock, dao.ListStockReservationsByOrderForUpdate fail")
+	}
+	if len(reservations) == 0 {
+		if status != dao.StockReservationCommitted {
+			return nil, nil
+		}
+		released, err := dao.CountStockReservationsByOrder(tx, orderRef, dao.StockReservationReleased)
+		if err != nil {
+			return nil, errors.Wrap(err, ">>settleStock, dao.CountStockReservationsByOrder fail")
+		}
+		if released > 0 {
+			return nil, xerr.NewErrCode(xerr.StockReservationReleased)
+		}
+		return nil, nil
+	}
+
+	now := time.Now()
+	reservationIds := make([]int64, 0, len(reservations))
+	quantities := make(map[int64]int, len(reservations))
+	inventoryIds := make([]int64, 0, len(reservations))
+	for _, reservation := range reservations {
+		//the order was paid or extended meanwhile
+		if onlyExpired && reservation.ExpiresAt.After(now) {
+			return nil, nil
+		}
+		reservationIds = append(reservationIds, reservation.Id)
+		if _, ok := quantities[reservation.InventoryId]; !ok {
+			inventoryIds = append(inventoryIds, reservation.InventoryId)
+		}
+		quantities[reservation.InventoryId] += reservation.Quantity
+	}
+
+	locked, err := dao.ListInventoriesForUpdate(tx, inventoryIds)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>settleStock, dao.ListInventoriesForUpdate fail")
+	}
+
+	lowStocks := make([]dao.Inventory, 0)
+	for i := range locked {
+		inventory := &locked[i]
+		quantity := quantities[inventory.Id]
+
+		inventory.Reserved = max(inventory.Reserved-quantity, 0)
+		if status == dao.StockReservationCommitted {
+			inventory.Quantity -= quantity
+		}
+		if checkLowStock(inventory) {
+			lowStocks = append(lowStocks, *inventory)
+		}
+		if err = inventory.Save(tx); err != nil {
+			return nil, errors.Wrap(err, ">>settleStock, inventory.Save fail")
+		}
+	}
+
+	if err = dao.UpdateStockReservationsStatus(tx, reservationIds, status, reason); err != nil {
+		return nil, errors.Wrap(err, ">>settleStock, dao.UpdateStockReservationsStatus fail")
+	}
+	return lowStocks, nil
+}
+
+// loadProductStocks the available stock of the tracked products and variants, by stockKey
+func loadProductStocks(session *gorm.DB, timezone string, products []dao.Product) (map[string]int, error) {
+	stocks := make(map[string]int)
+	if len(products) == 0 {
+		return stocks, nil
+	}
+
+	productIds := make([]int64, 0, len(products))
+	for _, product := range products {
+		productIds = append(productIds, product.Id)
+	}
+	inventories, err := dao.ListInventoriesByProducts(session, productIds)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>loadProductStocks, dao.ListInventoriesByProducts fail")
+	}
+
+	today := storeToday(timezone)
+	for i := range inventories {
+		resetDailyStock(&inventories[i], today)
+		stocks[stockKey(inventories[i].ProductId, inventories[i].VariantId)] = max(inventories[i].Available(), 0)
+	}
+	return stocks, nil
+}
+
+func validateStockItem(product *dao.Product, variantId *int64) error {
+	if len(product.Variants) > 0 {
+		if variantId == nil || product.Variant(*variantId) == nil {
+			return xerr.NewErrCodeMsg(xerr.RequestParamError, "a variant of the product is required")
+		}
+	} else if variantId != nil {
+		return xerr.NewErrCodeMsg(xerr.RequestParamError, "the product has no variant")
+	}
+	return nil
+}
+
+// resetDailyStock restores the daily quantity on the first change of the day, e.g. limited dishes
+func resetDailyStock(inventory *dao.Inventory, today string) {
+	if inventory.DailyQuantity == nil || (inventory.StockDate != nil && *inventory.StockDate == today) {
+		return
+	}
+	inventory.Quantity = *inventory.DailyQuantity
+	inventory.StockDate = &today
+	inventory.LowStockNotified = false
+}
+
+// checkLowStock true when the available stock just fell to the threshold, the merchant is notified once until it is restocked
+func checkLowStock(inventory *dao.Inventory) bool {
+	if inventory.Available() > inventory.LowStockThreshold {
+		inventory.LowStockNotified = false
+		return false
+	}
+	if inventory.LowStockNotified {
+		return false
+	}
+	inventory.LowStockNotified = true
+	return true
+}
+
+// notifyLowStocks the inventories are of one merchant
+func notifyLowStocks(inventories []dao.Inventory) {
+	if len(inventories) > 0 {
+		notifyLowStock(inventories[0].MerchantId, inventories)
+	}
+}
+
+// notifyLowStock the merchant may not be connected, it sees the low stocks in the inventory list anyway
+func notifyLowStock(merchantId int64, inventories []dao.Inventory) {
+	if len(inventories) == 0 || global.GlobalClientSets.Broadcaster == nil {
+		return
+	}
+	conn := global.GlobalClientSets.Broadcaster.GetMerchantConn(merchantId)
+	if conn == nil {
+		return
+	}
+
+	for _, inventory := range inventories {
+		conn.Emit(EventInventoryLowStock, map[string]interface{}{
+			"inventoryId":       inventory.Id,
+			"productId":         inventory.ProductId,
+			"variantId":         inventory.VariantId,
+			"available":         inventory.Available(),
+			"lowStockThreshold": inventory.LowStockThreshold,
+		})
+	}
+	logrus.Infof("merchant %d notified of %d low stocks", merchantId, len(inventories))
+}
+
+func stockKey(productId int64, variantId *int64) string {
+	if variantId == nil {
+		return fmt.Sprintf("%d:0", productId)
+	}
+	return fmt.Sprintf("%d:%d", productId, *variantId)
+}
+
+func storeToday(timezone string) string {
+	return time.Now().In(loadLocation(timezone)).Format(openhours.DateLayout)
+}
+
+func newInventoryResp(inventory *dao.Inventory, product *dao.Product) dto.InventoryResp {
+	resp := dto.InventoryResp{
+		Id:                inventory.Id,
+		ProductId:         inventory.ProductId,
+		ProductName:       product.Name,
+		VariantId:         inventory.VariantId,
+		Quantity:          inventory.Quantity,
+		Reserved:          inventory.Reserved,
+		Available:         inventory.Available(),
+		LowStockThreshold: inventory.LowStockThreshold,
+		IsLowStock:        inventory.Available() <= inventory.LowStockThreshold,
+		DailyQuantity:     inventory.DailyQuantity,
+	}
+	if inventory.VariantId != nil {
+		if variant := product.Variant(*inventory.VariantId); variant != nil {
+			resp.VariantName = &variant.Name
+			resp.Sku = &variant.Sku
+		}
+	}
+	return resp
+}
@@ -0,0 +1,114 @@
+package logic
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/tespkg/bytes-be/common/xerr"
+	"github.com/tespkg/bytes-be/svc/staff/model/dao"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+	"gorm.io/gorm"
+)
+
+// createTestMerchant an approved merchant in Muscat
+func createTestMerchant(t *testing.T, db *gorm.DB) *dao.Merchant {
+	t.Helper()
+
+	email := uuid.NewString() + "@example.com"
+	user := dao.User{Uuid: uuid.NewString(), Email: &email, IsEnabled: true}
+	if err := user.Save(db); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	merchant := dao.Merchant{
+		UserId:       user.Id,
+		LegalName:    "Test Kitchen LLC",
+		CrNumber:     uuid.NewString(),
+		ContactName:  "Test",
+		ContactPhone: "+96890000000",
+		Address:      "Muscat",
+		Status:       dao.MerchantStatusApproved,
+		Timezone:     "Asia/Muscat",
+	}
+	if err := merchant.Save(db); err != nil {
+		t.Fatalf("create merchant: %v", err)
+	}
+	return &merchant
+}
+
+// createTestProduct a food of the merchant, quantity > 0 tracks its stock
+func createTestProduct(t *testing.T, db *gorm.DB, merchant *dao.Merchant, name string, quantity int) *dao.Product {
+	t.Helper()
+
+	product := dao.Product{MerchantId: merchant.Id, Kind: dao.ProductKindFood, Name: name, Price: 1000, Currency: "OMR", IsAvailable: true}
+	if err := product.Save(db); err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+
+	if quantity > 0 {
+		inventory := dao.Inventory{MerchantId: merchant.Id, ProductId: product.Id, Quantity: quantity}
+		if err := inventory.Save(db); err != nil {
+			t.Fatalf("create inventory: %v", err)
+		}
+	}
+	return &product
+}
+
+func isErrCode(err error, code uint32) bool {
+	var codeErr *xerr.CodeError
+	return errors.As(err, &codeErr) && codeErr.GetErrCode() == code
+}
+
+func TestReserveStockOnceByOrder(t *testing.T) {
+	db := setUpLogicEnv(t, false)
+	merchant := createTestMerchant(t, db)
+	product := createTestProduct(t, db, merchant, "Shuwa", 10)
+	req := &dto.ReserveStockReq{
+		MerchantId: merchant.Id,
+		OrderRef:   uuid.NewString(),
+		Items:      []dto.StockItem{{ProductId: product.Id, Quantity: 2}},
+	}
+
+	// concurrent retries of the order reserve the stock once
+	var wg sync.WaitGroup
+	results := make([]error, 5)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = ReserveStock(db, req, 0)
+		}(i)
+	}
+	wg.Wait()
+
+	reserved := 0
+	for _, err := range results {
+		switch {
+		case err == nil:
+			reserved++
+		case !isErrCode(err, xerr.StockReservationExist):
+			t.Fatalf("reserve stock: %v", err)
+		}
+	}
+	if reserved != 1 {
+		t.Fatalf("got %d reservations, want 1", reserved)
+	}
+
+	inventories, err := dao.ListInventoriesByProducts(db, []int64{product.Id})
+	if err != nil || len(inventories) != 1 {
+		t.Fatalf("list inventories: %v %+v", err, inventories)
+	}
+	if inventories[0].Reserved != 2 {
+		t.Fatalf("got %d reserved, want 2", inventories[0].Reserved)
+	}
+
+	// a released order can be reserved again
+	if err = ReleaseStockReservation(db, req.OrderRef, "cancelled"); err != nil {
+		t.Fatalf("release stock reservation: %v", err)
+	}
+	if err = ReserveStock(db, req, 0); err != nil {
+		t.Fatalf("reserve stock again: %v", err)
+	}
+}
@@ -0,0 +1,279 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/tespkg/bytes-be/common/token"
+	"github.com/tespkg/bytes-be/common/verifycode"
+	"github.com/tespkg/bytes-be/common/xerr"
+	"github.com/tespkg/bytes-be/svc/staff/model/dao"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"strings"
+)
+
+// MerchantRegister creates the merchant in pending status, the merchant APIs are usable after the admin approves it
+func MerchantRegister(ctx context.Context, session *gorm.DB, req *dto.MerchantRegisterReq) (*dto.MerchantRegisterResp, error) {
+	role, err := dao.GetRoleByName(session, dao.RoleMerchant)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>MerchantRegister, get role fail")
+	}
+	if role == nil || role.Id == 0 {
+		return nil, errors.New(">>MerchantRegister, role not found")
+	}
+
+	if err = checkMerchantCrNumber(session, req.CrNumber, 0); err != nil {
+		return nil, errors.Wrap(err, ">>MerchantRegister ")
+	}
+	//the logo can only be uploaded once registered
+	if err = validateMerchantImage(0, req.Logo); err != nil {
+		return nil, err
+	}
+
+	//an existing user of the phone becomes a merchant
+	user, err := dao.GetEnabledUserByPhone(session, req.Phone)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>MerchantRegister, dao.GetEnabledUserByPhone fail")
+	}
+	if user != nil && user.Id != 0 {
+		//reload with the roles
+		if user, err = dao.GetUserById(session, user.Id); err != nil {
+			return nil, errors.Wrap(err, ">>MerchantRegister, dao.GetUserById fail")
+		}
+		for _, name := range user.RoleNames() {
+			if name == dao.RoleMerchant {
+				return nil, xerr.NewErrCode(xerr.MerchantRegistered)
+			}
+		}
+	}
+
+	if req.Email != "" {
+		owner, err := dao.GetEnabledUserByEmail(session, req.Email)
+		if err != nil {
+			return nil, errors.Wrap(err, ">>MerchantRegister, dao.GetEnabledUserByEmail fail")
+		}
+		if owner != nil && owner.Id != 0 && (user == nil || owner.Id != user.Id) {
+			return nil, xerr.NewErrCode(xerr.EmailRegistered)
+		}
+	}
+
+	if err = verifycode.Verify(ctx, verifycode.PurposeRegister, verifycode.ChannelPhone, req.Phone, req.Code); err != nil {
+		return nil, errors.Wrap(err, ">>MerchantRegister, verifycode.Verify fail")
+	}
+
+	tx := session.Begin()
+	if err = tx.Error; err != nil {
+		return nil, errors.Wrap(err, ">>MerchantRegister, transaction begin fail")
+	}
+	defer tx.Rollback()
+
+	//create user
+	roles := []string{dao.RoleMerchant}
+	if user == nil || user.Id == 0 {
+		hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, errors.Wrap(err, ">>MerchantRegister, bcrypt.GenerateFromPassword fail")
+		}
+		user = &dao.User{
+			Uuid:      uuid.NewString(),
+			Email:     nullableString(req.Email),
+			Phone:     &(req.Phone),
+			Password:  hash,
+			IsEnabled: true,
+		}
+		if err = user.Save(tx); err != nil {
+			return nil, errors.Wrap(err, ">>MerchantRegister, user.Save fail")
+		}
+	} else {
+		roles = append(user.RoleNames(), dao.RoleMerchant)
+	}
+
+	//create user_role
+	userRole := dao.UserRole{
+		UserId: user.Id,
+		RoleId: role.Id,
+	}
+	if err = userRole.Save(tx); err != nil {
+		return nil, errors.Wrap(err, ">>MerchantRegister, userRole.Save fail")
+	}
+
+	//create merchant
+	merchant := &dao.Merchant{
+		UserId:   user.Id,
+		Status:   dao.MerchantStatusPending,
+		Timezone: defaultStoreTimezone(),
+	}
+	fillMerchant(merchant, &req.MerchantInfoReq)
+	if err = merchant.Save(tx); err != nil {
+		return nil, errors.Wrap(err, ">>MerchantRegister, merchant.Save fail")
+	}
+
+	history := dao.MerchantStatusHistory{
+		MerchantId: merchant.Id,
+		ToStatus:   dao.MerchantStatusPending,
+		OperatorId: user.Id,
+	}
+	if err = history.Save(tx); err != nil {
+		return nil, errors.Wrap(err, ">>MerchantRegister, history.Save fail")
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		return nil, errors.Wrap(err, ">>MerchantRegister, transaction commit fail")
+	}
+
+	pair, err := token.GenTokenPair(&token.GenTokenDto{
+		Session: session,
+		UserId:  fmt.Sprintf("%d", user.Id),
+		Roles:   roles,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, ">>MerchantRegister, token.GenTokenPair fail")
+	}
+
+	return &dto.MerchantRegisterResp{
+		MerchantId: merchant.Id,
+		TokenResp:  newTokenResp(pair),
+	}, nil
+}
+
+func MerchantLogin(ctx context.Context, session *gorm.DB, req *dto.MerchantLoginReq) (*dto.MerchantLoginResp, error) {
+	user, err := getRoleUser(session, dao.RoleMerchant, req.Phone, req.Email)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>MerchantLogin ")
+	}
+	if user == nil || user.Id == 0 {
+		return nil, xerr.NewErrCode(xerr.UserNotExist)
+	}
+	if !user.IsEnabled {
+		return nil, xerr.NewErrCode(xerr.UserDisabled)
+	}
+
+	if err = checkLoginCredential(ctx, user, req.Phone, req.Email, req.Password, req.Code); err != nil {
+		return nil, errors.Wrap(err, ">>MerchantLogin ")
+	}
+
+	pair, err := token.GenTokenPair(&token.GenTokenDto{
+		Session:       session,
+		UserId:        fmt.Sprintf("%d", user.Id),
+		Platform:      req.Platform,
+		Imei:          req.Imei,
+		ClientVersion: req.ClientVersion,
+		Model:         req.Model,
+		SystemVersion: req.SystemVersion,
+		Roles:         user.RoleNames(),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, ">>MerchantLogin, token.GenTokenPair fail")
+	}
+
+	return &dto.MerchantLoginResp{
+		TokenResp: newTokenResp(pair),
+	}, nil
+}
+
+func GetMerchantProfile(session *gorm.DB, merchant *dao.Merchant) (*dto.MerchantResp, error) {
+	documents, err := dao.ListMerchantDocuments(session, merchant.Id)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>GetMerchantProfile, dao.ListMerchantDocuments fail")
+	}
+
+	resp := newMerchantResp(merchant)
+	resp.Documents = newMerchantDocumentResps(documents)
+	return resp, nil
+}
+
+// UpdateMerchantProfile the information can only change before approval, a rejected merchant is submitted again
+func UpdateMerchantProfile(session *gorm.DB, user *dao.User, merchant *dao.Merchant, req *dto.MerchantInfoReq) (*dto.MerchantResp, error) {
+	if merchant.Status != dao.MerchantStatusPending && merchant.Status != dao.MerchantStatusRejected {
+		return nil, xerr.NewErrCode(xerr.MerchantStatusInvalid)
+	}
+
+	if err := checkMerchantCrNumber(session, req.CrNumber, merchant.Id); err != nil {
+		return nil, errors.Wrap(err, ">>UpdateMerchantProfile ")
+	}
+	if err := validateMerchantImage(merchant.Id, req.Logo); err != nil {
+		return nil, err
+	}
+
+	tx := session.Begin()
+	if err := tx.Error; err != nil {
+		return nil, errors.Wrap(err, ">>UpdateMerchantProfile, transaction begin fail")
+	}
+	defer tx.Rollback()
+
+	locked, err := dao.GetMerchantByIdForUpdate(tx, merchant.Id)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>UpdateMerchantProfile, dao.GetMerchantByIdForUpdate fail")
+	}
+	if locked == nil || locked.Id == 0 {
+		return nil, xerr.NewErrCode(xerr.MerchantNotExist)
+	}
+	fillMerchant(locked, req)
+
+	if locked.Status == dao.MerchantStatusRejected {
+		if err = changeMerchantStatus(tx, locked, dao.MerchantStatusPending, "", user.Id); err != nil {
+			return nil, errors.Wrap(err, ">>UpdateMerchantProfile ")
+		}
+	} else if err = locked.Save(tx); err != nil {
+		return nil, errors.Wrap(err, ">>UpdateMerchantProfile, merchant.Save fail")
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		return nil, errors.Wrap(err, ">>UpdateMerchantProfile, transaction commit fail")
+	}
+
+	return newMerchantResp(locked), nil
+}
+
+func checkMerchantCrNumber(session *gorm.DB, crNumber string, merchantId int64) error {
+	owner, err := dao.GetMerchantByCrNumber(session, strings.TrimSpace(crNumber))
+	if err != nil {
+		return errors.Wrap(err, ">>checkMerchantCrNumber, dao.GetMerchantByCrNumber fail")
+	}
+	if owner != nil && owner.Id != 0 && owner.Id != merchantId {
+		return xerr.NewErrCode(xerr.MerchantCrNumberExist)
+	}
+	return nil
+}
+
+func fillMerchant(merchant *dao.Merchant, req *dto.MerchantInfoReq) {
+	merchant.LegalName = strings.TrimSpace(req.LegalName)
+	merchant.TradeName = nullableString(req.TradeName)
+	merchant.CrNumber = strings.TrimSpace(req.CrNumber)
+	merchant.ContactName = strings.TrimSpace(req.ContactName)
+	merchant.ContactPhone = strings.TrimSpace(req.ContactPhone)
+	merchant.ContactEmail = nullableString(req.ContactEmail)
+	merchant.Logo = nullableString(req.Logo)
+	merchant.Address = strings.TrimSpace(req.Address)
+	merchant.Latitude = *req.Latitude
+	merchant.Longitude = *req.Longitude
+}
+
+func newMerchantResp(merchant *dao.Merchant) *dto.MerchantResp {
+	resp := &dto.MerchantResp{
+		Id:           merchant.Id,
+		UserId:       merchant.UserId,
+		LegalName:    merchant.LegalName,
+		TradeName:    merchant.TradeName,
+		CrNumber:     merchant.CrNumber,
+		ContactName:  merchant.ContactName,
+		ContactPhone: merchant.ContactPhone,
+		ContactEmail: merchant.ContactEmail,
+		Logo:         newImageResp(merchant.Logo),
+		Address:      merchant.Address,
+		Latitude:     merchant.Latitude,
+		Longitude:    merchant.Longitude,
+		Status:       merchant.Status,
+		StatusReason: merchant.StatusReason,
+	}
+	if merchant.ApprovedAt != nil {
+		resp.ApprovedAt = merchant.ApprovedAt.Unix()
+	}
+	if merchant.CreatedAt != nil {
+		resp.CreatedAt = merchant.CreatedAt.Unix()
+	}
+	return resp
+}
@@ -0,0 +1,126 @@
+package logic
+
+import (
+	"github.com/pkg/errors"
+	"github.com/tespkg/bytes-be/common/xerr"
+	"github.com/tespkg/bytes-be/svc/staff/model/dao"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+	"gorm.io/gorm"
+	"net/http"
+	"path/filepath"
+)
+
+// MaxMerchantDocumentSize documents are kept in postgres, scans bigger than this should be compressed
+const MaxMerchantDocumentSize = 10 << 20
+
+var merchantDocumentContentTypes = map[string]struct{}{
+	"application/pdf": {},
+	"image/jpeg":      {},
+	"image/png":       {},
+}
+
+// ValidateMerchantDocument the content type is sniffed from the content, the one sent by the client isn't trusted
+func ValidateMerchantDocument(docType string, content []byte) (string, error) {
+	switch docType {
+	case dao.MerchantDocumentCrCertificate, dao.MerchantDocumentOwnerId, dao.MerchantDocumentFoodLicense, dao.MerchantDocumentOther:
+	default:
+		return "", errors.New("type must be cr_certificate, owner_id, food_license or other")
+	}
+
+	if len(content) == 0 {
+		return "", errors.New("the file is empty")
+	}
+	if len(content) > MaxMerchantDocumentSize {
+		return "", errors.Errorf("the file exceeds %d bytes", MaxMerchantDocumentSize)
+	}
+
+	contentType := http.DetectContentType(content)
+	if _, ok := merchantDocumentContentTypes[contentType]; !ok {
+		return "", errors.Errorf("the file type %s isn't allowed, only pdf, jpeg and png", contentType)
+	}
+	return contentType, nil
+}
+
+// UploadMerchantDocument a suspended merchant can't change the documents
+func UploadMerchantDocument(session *gorm.DB, merchant *dao.Merchant, docType, fileName, contentType string, content []byte) (*dto.MerchantDocumentResp, error) {
+	if merchant.Status == dao.MerchantStatusSuspended {
+		return nil, xerr.NewErrCode(xerr.MerchantStatusInvalid)
+	}
+
+	document := &dao.MerchantDocument{
+		MerchantId:  merchant.Id,
+		Type:        docType,
+		FileName:    filepath.Base(fileName),
+		ContentType: contentType,
+		Size:        int64(len(content)),
+		Content:     content,
+	}
+	if err := document.Save(session); err != nil {
+		return nil, errors.Wrap(err, ">>UploadMerchantDocument, document.Save fail")
+	}
+
+	return &newMerchantDocumentResps([]dao.MerchantDocument{*document})[0], nil
+}
+
+func ListMerchantDocuments(session *gorm.DB, merchant *dao.Merchant) (*dto.ListMerchantDocumentResp, error) {
+	documents, err := dao.ListMerchantDocuments(session, merchant.Id)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>ListMerchantDocuments, dao.ListMerchantDocuments fail")
+	}
+
+	return &dto.ListMerchantDocumentResp{Documents: newMerchantDocumentResps(documents)}, nil
+}
+
+// DeleteMerchantDocument the reviewed documents of an approved merchant can't be removed
+func DeleteMerchantDocument(session *gorm.DB, merchant *dao.Merchant, documentId int64) error {
+	if merchant.Status != dao.MerchantStatusPending && merchant.Status != dao.MerchantStatusRejected {
+		return xerr.NewErrCode(xerr.MerchantStatusInvalid)
+	}
+
+	if _, err := getMerchantDocument(session, merchant.Id, documentId); err != nil {
+		return errors.Wrap(err, ">>DeleteMerchantDocument ")
+	}
+
+	if err := dao.DeleteMerchantDocument(session, merchant.Id, documentId); err != nil {
+		return errors.Wrap(err, ">>DeleteMerchantDocument, dao.DeleteMerchantDocument fail")
+	}
+	return nil
+}
+
+// GetMerchantDocument loads the content for the admin review
+func GetMerchantDocument(session *gorm.DB, merchantId, documentId int64) (*dao.MerchantDocument, error) {
+	document, err := getMerchantDocument(session, merchantId, documentId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>GetMerchantDocument ")
+	}
+	return document, nil
+}
+
+func getMerchantDocument(session *gorm.DB, merchantId, documentId int64) (*dao.MerchantDocument, error) {
+	document, err := dao.GetMerchantDocument(session, merchantId, documentId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>getMerchantDocument, dao.GetMerchantDocument fail")
+	}
+	if document == nil || document.Id == 0 {
+		return nil, xerr.NewErrCode(xerr.MerchantDocumentNotExist)
+	}
+	return document, nil
+}
+
+func newMerchantDocumentResps(documents []dao.MerchantDocument) []dto.MerchantDocumentResp {
+	resps := make([]dto.MerchantDocumentResp, 0, len(documents))
+	for _, document := range documents {
+		resp := dto.MerchantDocumentResp{
+			Id:          document.Id,
+			Type:        document.Type,
+			FileName:    document.FileName,
+			ContentType: document.ContentType,
+			Size:        document.Size,
+		}
+		if document.CreatedAt != nil {
+			resp.CreatedAt = document.CreatedAt.Unix()
+		}
+		resps = append(resps, resp)
+	}
+	return resps
+}
@@ -0,0 +1,179 @@
+package logic
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/tespkg/bytes-be/common/global"
+	"github.com/tespkg/bytes-be/common/xerr"
+	"github.com/tespkg/bytes-be/svc/staff/model/dao"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+	"gorm.io/gorm"
+	"time"
+)
+
+// EventMerchantStatus is emitted to the socket of the merchant when the admin changes its status
+const EventMerchantStatus = "merchant-status"
+
+const defaultPageSize = 20
+
+// merchantTransitions the statuses each status can move to by an admin review
+var merchantTransitions = map[string][]string{
+	dao.MerchantStatusPending:   {dao.MerchantStatusApproved, dao.MerchantStatusRejected},
+	dao.MerchantStatusApproved:  {dao.MerchantStatusSuspended},
+	dao.MerchantStatusSuspended: {dao.MerchantStatusApproved},
+}
+
+func ListMerchants(session *gorm.DB, req *dto.ListMerchantReq) (*dto.ListMerchantResp, error) {
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	merchants, total, err := dao.ListMerchants(session, req.Status, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>ListMerchants, dao.ListMerchants fail")
+	}
+
+	resp := &dto.ListMerchantResp{Total: total, Merchants: make([]dto.MerchantResp, 0, len(merchants))}
+	for i := range merchants {
+		resp.Merchants = append(resp.Merchants, *newMerchantResp(&merchants[i]))
+	}
+	return resp, nil
+}
+
+func GetMerchantDetail(session *gorm.DB, merchantId int64) (*dto.MerchantDetailResp, error) {
+	merchant, err := getMerchant(session, merchantId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>GetMerchantDetail ")
+	}
+
+	profile, err := GetMerchantProfile(session, merchant)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>GetMerchantDetail ")
+	}
+
+	histories, err := dao.ListMerchantStatusHistories(session, merchant.Id)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>GetMerchantDetail, dao.ListMerchantStatusHistories fail")
+	}
+
+	resp := &dto.MerchantDetailResp{
+		MerchantResp: *profile,
+		Histories:    make([]dto.MerchantStatusHistoryResp, 0, len(histories)),
+	}
+	for _, history := range histories {
+		item := dto.MerchantStatusHistoryResp{
+			FromStatus: history.FromStatus,
+			ToStatus:   history.ToStatus,
+			Reason:     history.Reason,
+			OperatorId: history.OperatorId,
+		}
+		if history.CreatedAt != nil {
+			item.CreatedAt = history.CreatedAt.Unix()
+		}
+		resp.Histories = append(resp.Histories, item)
+	}
+	return resp, nil
+}
+
+// ReviewMerchant moves the merchant to the status if the current status allows it, and records the history
+func ReviewMerchant(session *gorm.DB, operator *dao.User, merchantId int64, status, reason string) (*dto.MerchantResp, error) {
+	if (status == dao.MerchantStatusRejected || status == dao.MerchantStatusSuspended) && reason == "" {
+		return nil, xerr.NewErrCodeMsg(xerr.RequestParamError, "reason is required")
+	}
+
+	tx := session.Begin()
+	if err := tx.Error; err != nil {
+		return nil, errors.Wrap(err, ">>ReviewMerchant, transaction begin fail")
+	}
+	defer tx.Rollback()
+
+	merchant, err := dao.GetMerchantByIdForUpdate(tx, merchantId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>ReviewMerchant, dao.GetMerchantByIdForUpdate fail")
+	}
+	if merchant == nil || merchant.Id == 0 {
+		return nil, xerr.NewErrCode(xerr.MerchantNotExist)
+	}
+
+	if !canTransitMerchant(merchant.Status, status) {
+		return nil, xerr.NewErrCode(xerr.MerchantStatusInvalid)
+	}
+	if err = changeMerchantStatus(tx, merchant, status, reason, operator.Id); err != nil {
+		return nil, errors.Wrap(err, ">>ReviewMerchant ")
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		return nil, errors.Wrap(err, ">>ReviewMerchant, transaction commit fail")
+	}
+
+	notifyMerchantStatus(merchant)
+	return newMerchantResp(merchant), nil
+}
+
+func canTransitMerchant(from, to string) bool {
+	for _, status := range merchantTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// changeMerchantStatus saves the merchant with the new status and the history, it must be called in a transaction
+func changeMerchantStatus(tx *gorm.DB, merchant *dao.Merchant, status, reason string, operatorId int64) error {
+	from := merchant.Status
+	merchant.Status = status
+	merchant.StatusReason = nullableString(reason)
+	if status == dao.MerchantStatusApproved && merchant.ApprovedAt == nil {
+		now := time.Now()
+		merchant.ApprovedAt = &now
+	}
+	if err := merchant.Save(tx); err != nil {
+		return errors.Wrap(err, ">>changeMerchantStatus, merchant.Save fail")
+	}
+
+	history := dao.MerchantStatusHistory{
+		MerchantId: merchant.Id,
+		FromStatus: &from,
+		ToStatus:   status,
+		Reason:     merchant.StatusReason,
+		OperatorId: operatorId,
+	}
+	if err := history.Save(tx); err != nil {
+		return errors.Wrap(err, ">>changeMerchantStatus, history.Save fail")
+	}
+	return nil
+}
+
+// notifyMerchantStatus the merchant may not be connected, it sees the status on the next profile read anyway
+func notifyMerchantStatus(merchant *dao.Merchant) {
+	if global.GlobalClientSets.Broadcaster == nil {
+		return
+	}
+	conn := global.GlobalClientSets.Broadcaster.GetMerchantConn(merchant.Id)
+	if conn == nil {
+		return
+	}
+
+	conn.Emit(EventMerchantStatus, map[string]interface{}{
+		"merchantId": merchant.Id,
+		"status":     merchant.Status,
+		"reason":     merchant.StatusReason,
+	})
+	logrus.Infof("merchant %d notified of status %s", merchant.Id, merchant.Status)
+}
+
+func getMerchant(session *gorm.DB, merchantId int64) (*dao.Merchant, error) {
+	merchant, err := dao.GetMerchantById(session, merchantId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>getMerchant, dao.GetMerchantById fail")
+	}
+	if merchant == nil || merchant.Id == 0 {
+		return nil, xerr.NewErrCode(xerr.MerchantNotExist)
+	}
+	return merchant, nil
+}
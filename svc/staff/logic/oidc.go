@@ -0,0 +1,157 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/tespkg/bytes-be/common/token"
+	"github.com/tespkg/bytes-be/common/xerr"
+	"github.com/tespkg/bytes-be/internal/oidc"
+	"github.com/tespkg/bytes-be/svc/staff/model/dao"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+	"gorm.io/gorm"
+	"net/http"
+)
+
+// CustomerOidcLogin verifies the id token and logs in the linked user.
+// An unknown identity is linked to the customer with the same verified email, or a new customer is created.
+func CustomerOidcLogin(ctx context.Context, session *gorm.DB, verifier oidc.Verifier, req *dto.CustomerOidcLoginReq) (*dto.CustomerLoginResp, error) {
+	if verifier == nil {
+		return nil, xerr.NewErrCodeMsg(xerr.ThirdPartyLoginFail, "third party login is not enabled")
+	}
+
+	identity, err := verifier.Verify(ctx, req.Provider, req.IdToken, req.Nonce)
+	if err != nil {
+		logrus.Errorf("verify %s id token fail: %s", req.Provider, err)
+		return nil, xerr.NewErrCode(xerr.ThirdPartyLoginFail)
+	}
+
+	user, err := getOrLinkOidcUser(session, identity)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>CustomerOidcLogin ")
+	}
+	if !user.IsEnabled {
+		return nil, xerr.NewErrCode(xerr.UserDisabled)
+	}
+
+	//general login token
+	pair, err := token.GenTokenPair(&token.GenTokenDto{
+		Session:       session,
+		UserId:        fmt.Sprintf("%d", user.Id),
+		Platform:      req.Platform,
+		Imei:          req.Imei,
+		ClientVersion: req.ClientVersion,
+		Model:         req.Model,
+		SystemVersion: req.SystemVersion,
+		Roles:         user.RoleNames(),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, ">>CustomerOidcLogin, token.GenTokenPair fail")
+	}
+
+	return &dto.CustomerLoginResp{
+		TokenResp: newTokenResp(pair),
+	}, nil
+}
+
+func getOrLinkOidcUser(session *gorm.DB, identity *oidc.Identity) (*dao.User, error) {
+	linked, err := dao.GetUserIdentity(session, identity.Provider, identity.Subject)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>getOrLinkOidcUser, dao.GetUserIdentity fail")
+	}
+	if linked != nil && linked.Id > 0 {
+		user, err := dao.GetUserById(session, linked.UserId)
+		if err != nil {
+			return nil, errors.Wrap(err, ">>getOrLinkOidcUser, dao.GetUserById fail")
+		}
+		if user == nil || user.Id == 0 {
+			return nil, xerr.NewErrCode(xerr.UserNotExist)
+		}
+		return user, nil
+	}
+
+	//an unverified email could belong to someone else, it is neither linked nor stored
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, xerr.NewErrCodeMsg(xerr.ThirdPartyLoginFail, "a verified email is required")
+	}
+	identity.Email = normalizeEmail(identity.Email)
+
+	user, err := dao.GetUserByEmailAndRole(session, identity.Email, dao.RoleCustomer)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>getOrLinkOidcUser, dao.GetUserByEmailAndRole fail")
+	}
+	if user == nil || user.Id == 0 {
+		//the email is unique among the enabled users of any role, a merchant or an admin isn't linked to a customer login
+		owner, err := dao.GetEnabledUserByEmail(session, identity.Email)
+		if err != nil {
+			return nil, errors.Wrap(err, ">>getOrLinkOidcUser, dao.GetEnabledUserByEmail fail")
+		}
+		if owner != nil && owner.Id > 0 {
+			return nil, xerr.NewHttpError(http.StatusConflict, "the email belongs to an account which isn't a customer")
+		}
+	}
+
+	tx := session.Begin()
+	if err = tx.Error; err != nil {
+		return nil, errors.Wrap(err, ">>getOrLinkOidcUser, transaction begin fail")
+	}
+	defer tx.Rollback()
+
+	if user == nil || user.Id == 0 {
+		role, err := dao.GetRoleByName(tx, dao.RoleCustomer)
+		if err != nil {
+			return nil, errors.Wrap(err, ">>getOrLinkOidcUser, get role fail")
+		}
+		if role == nil || role.Id == 0 {
+			return nil, errors.New(">>getOrLinkOidcUser, role not found")
+		}
+
+		//create user without password, it can be set by the reset password flow
+		user = &dao.User{
+			Uuid:      uuid.NewString(),
+			Email:     &identity.Email,
+			IsEnabled: true,
+		}
+		if err = user.Save(tx); err != nil {
+			return nil, errors.Wrap(err, ">>getOrLinkOidcUser, user.Save fail")
+		}
+
+		userRole := dao.UserRole{
+			UserId: user.Id,
+			RoleId: role.Id,
+		}
+		if err = userRole.Save(tx); err != nil {
+			return nil, errors.Wrap(err, ">>getOrLinkOidcUser, userRole.Save fail")
+		}
+
+		customer := dao.Customer{
+			UserId: user.Id,
+		}
+		if identity.Name != "" {
+			customer.FirstName = &identity.Name
+		}
+		if err = customer.Save(tx); err != nil {
+			return nil, errors.Wrap(err, ">>getOrLinkOidcUser, customer.Save fail")
+		}
+
+		user.Roles = []dao.Role{*role}
+	}
+
+	userIdentity := dao.UserIdentity{
+		UserId:   user.Id,
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    &identity.Email,
+	}
+	if err = userIdentity.Save(tx); err != nil {
+		return nil, errors.Wrap(err, ">>getOrLinkOidcUser, userIdentity.Save fail")
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		return nil, errors.Wrap(err, ">>getOrLinkOidcUser, transaction commit fail")
+	}
+
+	return user, nil
+}
@@ -0,0 +1,119 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/tespkg/bytes-be/common/xerr"
+	"github.com/tespkg/bytes-be/internal/oidc"
+	"github.com/tespkg/bytes-be/internal/oidc/oidctest"
+	"github.com/tespkg/bytes-be/svc/staff/model/dao"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+	"gorm.io/gorm"
+)
+
+const (
+	testOidcProvider = "test"
+	testOidcClientId = "bytes-app"
+)
+
+func newTestOidcVerifier(t *testing.T) (oidc.Verifier, *oidctest.Issuer) {
+	t.Helper()
+
+	issuer, err := oidctest.NewIssuer()
+	if err != nil {
+		t.Fatalf("new issuer: %v", err)
+	}
+	t.Cleanup(issuer.Close)
+
+	verifier, err := oidc.New([]oidc.ProviderConfig{{Name: testOidcProvider, Issuer: issuer.URL, ClientIds: []string{testOidcClientId}}})
+	if err != nil {
+		t.Fatalf("new verifier: %v", err)
+	}
+	return verifier, issuer
+}
+
+// oidcLoginUser logs in with an id token of the subject and returns the id of the logged in user
+func oidcLoginUser(t *testing.T, db *gorm.DB, verifier oidc.Verifier, issuer *oidctest.Issuer, subject, email string, extra map[string]interface{}) string {
+	t.Helper()
+
+	idToken, err := issuer.IDToken(testOidcClientId, subject, email, extra)
+	if err != nil {
+		t.Fatalf("sign id token: %v", err)
+	}
+	resp, err := CustomerOidcLogin(context.Background(), db, verifier, &dto.CustomerOidcLoginReq{Provider: testOidcProvider, IdToken: idToken})
+	if err != nil {
+		t.Fatalf("oidc login: %v", err)
+	}
+
+	claims, err := verifyTestToken(t, db, resp.LoginToken)
+	if err != nil {
+		t.Fatalf("verify token: %v", err)
+	}
+	return claims.Subject
+}
+
+func TestCustomerOidcLoginCreatesCustomer(t *testing.T) {
+	db := setUpLogicEnv(t, false)
+	verifier, issuer := newTestOidcVerifier(t)
+	subject := uuid.NewString()
+
+	userId := oidcLoginUser(t, db, verifier, issuer, subject, uuid.NewString()+"@example.com", nil)
+
+	// the identity is linked now, even a changed email logs in the same customer
+	if again := oidcLoginUser(t, db, verifier, issuer, subject, uuid.NewString()+"@example.com", nil); again != userId {
+		t.Fatalf("second login: got user %s, want %s", again, userId)
+	}
+}
+
+func TestCustomerOidcLoginLinksCustomerByEmail(t *testing.T) {
+	db := setUpLogicEnv(t, false)
+	verifier, issuer := newTestOidcVerifier(t)
+	email, registered := registerTestCustomer(t, db)
+
+	claims, err := verifyTestToken(t, db, registered.LoginToken)
+	if err != nil {
+		t.Fatalf("verify token: %v", err)
+	}
+
+	if userId := oidcLoginUser(t, db, verifier, issuer, uuid.NewString(), email, nil); userId != claims.Subject {
+		t.Fatalf("oidc login: got user %s, want the registered %s", userId, claims.Subject)
+	}
+}
+
+func TestCustomerOidcLoginRejectsUnverifiedEmail(t *testing.T) {
+	db := setUpLogicEnv(t, false)
+	verifier, issuer := newTestOidcVerifier(t)
+	email, _ := registerTestCustomer(t, db)
+
+	idToken, err := issuer.IDToken(testOidcClientId, uuid.NewString(), email, map[string]interface{}{"email_verified": false})
+	if err != nil {
+		t.Fatalf("sign id token: %v", err)
+	}
+	if _, err = CustomerOidcLogin(context.Background(), db, verifier, &dto.CustomerOidcLoginReq{Provider: testOidcProvider, IdToken: idToken}); err == nil {
+		t.Fatal("oidc login: an unverified email is linked to the registered customer")
+	}
+}
+
+func TestCustomerOidcLoginRejectsEmailOfMerchant(t *testing.T) {
+	db := setUpLogicEnv(t, false)
+	verifier, issuer := newTestOidcVerifier(t)
+	merchant := createTestMerchant(t, db)
+	user, err := dao.GetUserById(db, merchant.UserId)
+	if err != nil || user == nil {
+		t.Fatalf("get merchant user: %v", err)
+	}
+
+	idToken, err := issuer.IDToken(testOidcClientId, uuid.NewString(), *user.Email, nil)
+	if err != nil {
+		t.Fatalf("sign id token: %v", err)
+	}
+	_, err = CustomerOidcLogin(context.Background(), db, verifier, &dto.CustomerOidcLoginReq{Provider: testOidcProvider, IdToken: idToken})
+	var httpErr *xerr.HttpError
+	if !errors.As(err, &httpErr) || httpErr.GetHttpCode() != http.StatusConflict {
+		t.Fatalf("oidc login: got %v, want a conflict", err)
+	}
+}
@@ -0,0 +1,326 @@
+package logic
+
+import (
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/tespkg/bytes-be/common/xerr"
+	"github.com/tespkg/bytes-be/svc/staff/model/dao"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+	"gorm.io/gorm"
+	"time"
+)
+
+const (
+	cancelReasonCustomer = "cancelled by the customer"
+	cancelReasonTimeout  = "not accepted in time"
+)
+
+// PlaceOrder prices the lines from the catalog of the open store and reserves the stock of the tracked items,
+// the order and its reservations are saved together. The order is cancelled if the merchant doesn't accept it in time.
+func PlaceOrder(session *gorm.DB, user *dao.User, req *dto.PlaceOrderReq) (*dto.OrderResp, error) {
+	store, err := GetStore(session, req.StoreId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>PlaceOrder ")
+	}
+	if !store.IsOpen {
+		return nil, xerr.NewErrCode(xerr.DeliveryTimeOutOperatingHours)
+	}
+
+	order := &dao.Order{
+		OrderRef:   uuid.NewString(),
+		UserId:     user.Id,
+		MerchantId: store.Id,
+		Status:     dao.OrderStatusPlaced,
+		Items:      make([]dao.OrderItem, 0, len(req.Items)),
+		ExpiresAt:  time.Now().Add(DefaultStockReservationExpire),
+	}
+	stock := &dto.ReserveStockReq{MerchantId: store.Id, OrderRef: order.OrderRef, Items: make([]dto.StockItem, 0, len(req.Items))}
+	products := make(map[int64]*dao.Product, len(req.Items))
+	for i := range req.Items {
+		line := &req.Items[i]
+		product, ok := products[line.ProductId]
+		if !ok {
+			if _, product, err = getStoreProduct(session, store.Id, line.ProductId); err != nil {
+				return nil, errors.Wrap(err, ">>PlaceOrder ")
+			}
+			products[line.ProductId] = product
+		}
+		if order.Currency == "" {
+			order.Currency = product.Currency
+		}
+		if product.Currency != order.Currency {
+			return nil, xerr.NewErrCodeMsg(xerr.ProductSelectionInvalid, "the products are priced in different currencies")
+		}
+
+		price, err := PriceProductSelection(product, &line.ProductSelectionReq)
+		if err != nil {
+			return nil, err
+		}
+
+		item := dao.OrderItem{
+			ProductId: product.Id,
+			VariantId: line.VariantId,
+			OptionIds: line.OptionIds,
+			Name:      product.Name,
+			Quantity:  line.Quantity,
+			UnitPrice: price,
+		}
+		var variantId *int64
+		if variant := product.Variant(line.VariantId); variant != nil {
+			item.Name += " - " + variant.Name
+			variantId = &variant.Id
+		}
+		order.Items = append(order.Items, item)
+		order.Total += price * int64(line.Quantity)
+		stock.Items = append(stock.Items, dto.StockItem{ProductId: product.Id, VariantId: variantId, Quantity: line.Quantity})
+	}
+
+	tx := session.Begin()
+	if err = tx.Error; err != nil {
+		return nil, errors.Wrap(err, ">>PlaceOrder, transaction begin fail")
+	}
+	defer tx.Rollback()
+
+	if err = order.Save(tx); err != nil {
+		return nil, errors.Wrap(err, ">>PlaceOrder, order.Save fail")
+	}
+	lowStocks, err := reserveStock(tx, stock, order.ExpiresAt)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>PlaceOrder ")
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		return nil, errors.Wrap(err, ">>PlaceOrder, transaction commit fail")
+	}
+
+	notifyLowStock(store.Id, lowStocks)
+	return newOrderResp(order), nil
+}
+
+func ListCustomerOrders(session *gorm.DB, user *dao.User, req *dto.ListOrderReq) (*dto.ListOrderResp, error) {
+	resp, err := listOrders(session, user.Id, 0, req)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>ListCustomerOrders ")
+	}
+	return resp, nil
+}
+
+func GetCustomerOrder(session *gorm.DB, user *dao.User, orderRef string) (*dto.OrderResp, error) {
+	order, err := dao.GetOrder(session, orderRef)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>GetCustomerOrder, dao.GetOrder fail")
+	}
+	if order == nil || order.UserId != user.Id {
+		return nil, xerr.NewErrCode(xerr.OrderNotExist)
+	}
+	return newOrderResp(order), nil
+}
+
+// CancelOrder the customer can cancel the order until the merchant accepts it, the reserved stock is given back
+func CancelOrder(session *gorm.DB, user *dao.User, orderRef, reason string) (*dto.OrderResp, error) {
+	if reason == "" {
+		reason = cancelReasonCustomer
+	}
+
+	tx := session.Begin()
+	if err := tx.Error; err != nil {
+		return nil, errors.Wrap(err, ">>CancelOrder, transaction begin fail")
+	}
+	defer tx.Rollback()
+
+	order, err := dao.GetOrderForUpdate(tx, orderRef)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>CancelOrder, dao.GetOrderForUpdate fail")
+	}
+	if order == nil || order.UserId != user.Id {
+		return nil, xerr.NewErrCode(xerr.OrderNotExist)
+	}
+	if order.Status != dao.OrderStatusPlaced {
+		return nil, xerr.NewErrCode(xerr.OrderStatusInvalid)
+	}
+
+	lowStocks, err := cancelOrder(tx, order, reason)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>CancelOrder ")
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		return nil, errors.Wrap(err, ">>CancelOrder, transaction commit fail")
+	}
+
+	notifyLowStocks(lowStocks)
+	return newOrderResp(order), nil
+}
+
+func ListMerchantOrders(session *gorm.DB, merchant *dao.Merchant, req *dto.ListOrderReq) (*dto.ListOrderResp, error) {
+	resp, err := listOrders(session, 0, merchant.Id, req)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>ListMerchantOrders ")
+	}
+	return resp, nil
+}
+
+// AcceptOrder takes the reserved stock out of the inventory, an order not accepted in time is cancelled instead
+func AcceptOrder(session *gorm.DB, merchant *dao.Merchant, orderRef string) (*dto.OrderResp, error) {
+	tx := session.Begin()
+	if err := tx.Error; err != nil {
+		return nil, errors.Wrap(err, ">>AcceptOrder, transaction begin fail")
+	}
+	defer tx.Rollback()
+
+	order, err := dao.GetOrderForUpdate(tx, orderRef)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>AcceptOrder, dao.GetOrderForUpdate fail")
+	}
+	if order == nil || order.MerchantId != merchant.Id {
+		return nil, xerr.NewErrCode(xerr.OrderNotExist)
+	}
+	if order.Status != dao.OrderStatusPlaced {
+		return nil, xerr.NewErrCode(xerr.OrderStatusInvalid)
+	}
+
+	//the reservations expire with the order, the sweeper may have released them already
+	if !order.ExpiresAt.After(time.Now()) {
+		lowStocks, err := cancelOrder(tx, order, cancelReasonTimeout)
+		if err != nil {
+			return nil, errors.Wrap(err, ">>AcceptOrder ")
+		}
+		if err = tx.Commit().Error; err != nil {
+			return nil, errors.Wrap(err, ">>AcceptOrder, transaction commit fail")
+		}
+		notifyLowStocks(lowStocks)
+		return nil, xerr.NewErrCode(xerr.OrderExpired)
+	}
+
+	lowStocks, err := settleStock(tx, order.OrderRef, dao.StockReservationCommitted, nil, false)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>AcceptOrder ")
+	}
+	order.Status = dao.OrderStatusAccepted
+	if err = order.Save(tx); err != nil {
+		return nil, errors.Wrap(err, ">>AcceptOrder, order.Save fail")
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		return nil, errors.Wrap(err, ">>AcceptOrder, transaction commit fail")
+	}
+
+	notifyLowStocks(lowStocks)
+	return newOrderResp(order), nil
+}
+
+// CancelExpiredOrders cancels the orders not accepted in time and releases their stock, returns the orders cancelled.
+// Every instance may run it, the orders are locked and cancelled only once.
+func CancelExpiredOrders(session *gorm.DB, limit int) (int, error) {
+	orderRefs, err := dao.ListExpiredOrderRefs(session, time.Now(), limit)
+	if err != nil {
+		return 0, errors.Wrap(err, ">>CancelExpiredOrders, dao.ListExpiredOrderRefs fail")
+	}
+
+	cancelled := 0
+	for _, orderRef := range orderRefs {
+		ok, err := cancelExpiredOrder(session, orderRef)
+		if err != nil {
+			logrus.Errorf("cancel expired order %s fail: %s", orderRef, err)
+			continue
+		}
+		if ok {
+			cancelled++
+		}
+	}
+	return cancelled, nil
+}
+
+// cancelExpiredOrder false if the order was accepted or cancelled meanwhile
+func cancelExpiredOrder(session *gorm.DB, orderRef string) (bool, error) {
+	tx := session.Begin()
+	if err := tx.Error; err != nil {
+		return false, errors.Wrap(err, ">>cancelExpiredOrder, transaction begin fail")
+	}
+	defer tx.Rollback()
+
+	order, err := dao.GetOrderForUpdate(tx, orderRef)
+	if err != nil {
+		return false, errors.Wrap(err, ">>cancelExpiredOrder, dao.GetOrderForUpdate fail")
+	}
+	if order == nil || order.Status != dao.OrderStatusPlaced || order.ExpiresAt.After(time.Now()) {
+		return false, nil
+	}
+
+	lowStocks, err := cancelOrder(tx, order, cancelReasonTimeout)
+	if err != nil {
+		return false, errors.Wrap(err, ">>cancelExpiredOrder ")
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		return false, errors.Wrap(err, ">>cancelExpiredOrder, transaction commit fail")
+	}
+
+	notifyLowStocks(lowStocks)
+	return true, nil
+}
+
+// cancelOrder releases the stock of the locked order, it must be called in a transaction
+func cancelOrder(tx *gorm.DB, order *dao.Order, reason string) ([]dao.Inventory, error) {
+	lowStocks, err := settleStock(tx, order.OrderRef, dao.StockReservationReleased, &reason, false)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>cancelOrder ")
+	}
+
+	order.Status = dao.OrderStatusCancelled
+	order.CancelReason = &reason
+	if err = order.Save(tx); err != nil {
+		return nil, errors.Wrap(err, ">>cancelOrder, order.Save fail")
+	}
+	return lowStocks, nil
+}
+
+func listOrders(session *gorm.DB, userId, merchantId int64, req *dto.ListOrderReq) (*dto.ListOrderResp, error) {
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	orders, total, err := dao.ListOrders(session, userId, merchantId, req.Status, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>listOrders, dao.ListOrders fail")
+	}
+
+	resp := &dto.ListOrderResp{Total: total, Orders: make([]dto.OrderResp, 0, len(orders))}
+	for i := range orders {
+		resp.Orders = append(resp.Orders, *newOrderResp(&orders[i]))
+	}
+	return resp, nil
+}
+
+func newOrderResp(order *dao.Order) *dto.OrderResp {
+	resp := &dto.OrderResp{
+		OrderRef:     order.OrderRef,
+		StoreId:      order.MerchantId,
+		Status:       order.Status,
+		Items:        make([]dto.OrderItemResp, 0, len(order.Items)),
+		Total:        order.Total,
+		Currency:     order.Currency,
+		ExpiresAt:    order.ExpiresAt.Unix(),
+		CancelReason: order.CancelReason,
+	}
+	for _, item := range order.Items {
+		resp.Items = append(resp.Items, dto.OrderItemResp{
+			ProductId: item.ProductId,
+			VariantId: item.VariantId,
+			OptionIds: item.OptionIds,
+			Name:      item.Name,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+			Amount:    item.UnitPrice * int64(item.Quantity),
+		})
+	}
+	if order.CreatedAt != nil {
+		resp.CreatedAt = order.CreatedAt.Unix()
+	}
+	return resp
+}
@@ -0,0 +1,148 @@
+package logic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tespkg/bytes-be/common/xerr"
+	"github.com/tespkg/bytes-be/svc/staff/model/dao"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+	"gorm.io/gorm"
+)
+
+// openTestStore opens the store of the merchant all week round
+func openTestStore(t *testing.T, db *gorm.DB, merchant *dao.Merchant) {
+	t.Helper()
+
+	for weekday := 0; weekday < 7; weekday++ {
+		hour := dao.MerchantHour{MerchantId: merchant.Id, Weekday: weekday, OpenTime: "00:00", CloseTime: "24:00"}
+		if err := db.Create(&hour).Error; err != nil {
+			t.Fatalf("create merchant hour: %v", err)
+		}
+	}
+}
+
+func mustInventory(t *testing.T, db *gorm.DB, productId int64) dao.Inventory {
+	t.Helper()
+
+	inventories, err := dao.ListInventoriesByProducts(db, []int64{productId})
+	if err != nil || len(inventories) != 1 {
+		t.Fatalf("list inventories: %v %+v", err, inventories)
+	}
+	return inventories[0]
+}
+
+func placeTestOrder(t *testing.T, db *gorm.DB, user *dao.User, merchant *dao.Merchant, product *dao.Product, quantity int) *dto.OrderResp {
+	t.Helper()
+
+	req := &dto.PlaceOrderReq{
+		StoreId: merchant.Id,
+		Items:   []dto.OrderItemReq{{ProductSelectionReq: dto.ProductSelectionReq{ProductId: product.Id}, Quantity: quantity}},
+	}
+	order, err := PlaceOrder(db, user, req)
+	if err != nil {
+		t.Fatalf("place order: %v", err)
+	}
+	return order
+}
+
+func TestOrderReservesTheStock(t *testing.T) {
+	db := setUpLogicEnv(t, false)
+	merchant := createTestMerchant(t, db)
+	openTestStore(t, db, merchant)
+	product := createTestProduct(t, db, merchant, "Halwa", 5)
+	customer := &dao.User{Id: merchant.UserId}
+
+	order := placeTestOrder(t, db, customer, merchant, product, 2)
+	if order.Status != dao.OrderStatusPlaced || order.Total != 2*product.Price {
+		t.Fatalf("unexpected order %+v", order)
+	}
+	if inventory := mustInventory(t, db, product.Id); inventory.Reserved != 2 || inventory.Quantity != 5 {
+		t.Fatalf("got %+v after the order, want 2 reserved", inventory)
+	}
+
+	// the stock of another order can't go past the available one
+	req := &dto.PlaceOrderReq{
+		StoreId: merchant.Id,
+		Items:   []dto.OrderItemReq{{ProductSelectionReq: dto.ProductSelectionReq{ProductId: product.Id}, Quantity: 4}},
+	}
+	if _, err := PlaceOrder(db, customer, req); !isErrCode(err, xerr.StockInsufficient) {
+		t.Fatalf("place an order over the stock: got %v", err)
+	}
+
+	// the cancellation gives the stock back, once
+	if _, err := CancelOrder(db, customer, order.OrderRef, ""); err != nil {
+		t.Fatalf("cancel order: %v", err)
+	}
+	if inventory := mustInventory(t, db, product.Id); inventory.Reserved != 0 || inventory.Quantity != 5 {
+		t.Fatalf("got %+v after the cancellation, want nothing reserved", inventory)
+	}
+	if _, err := CancelOrder(db, customer, order.OrderRef, ""); !isErrCode(err, xerr.OrderStatusInvalid) {
+		t.Fatalf("cancel order again: got %v", err)
+	}
+	if _, err := AcceptOrder(db, merchant, order.OrderRef); !isErrCode(err, xerr.OrderStatusInvalid) {
+		t.Fatalf("accept a cancelled order: got %v", err)
+	}
+
+	// the acceptance takes the stock out of the inventory
+	order = placeTestOrder(t, db, customer, merchant, product, 3)
+	if _, err := CancelOrder(db, &dao.User{Id: customer.Id + 1}, order.OrderRef, ""); !isErrCode(err, xerr.OrderNotExist) {
+		t.Fatalf("cancel the order of another customer: got %v", err)
+	}
+	if _, err := AcceptOrder(db, merchant, order.OrderRef); err != nil {
+		t.Fatalf("accept order: %v", err)
+	}
+	if inventory := mustInventory(t, db, product.Id); inventory.Reserved != 0 || inventory.Quantity != 2 {
+		t.Fatalf("got %+v after the acceptance, want 2 left", inventory)
+	}
+	if _, err := CancelOrder(db, customer, order.OrderRef, ""); !isErrCode(err, xerr.OrderStatusInvalid) {
+		t.Fatalf("cancel an accepted order: got %v", err)
+	}
+}
+
+func TestExpiredOrderIsCancelled(t *testing.T) {
+	db := setUpLogicEnv(t, false)
+	merchant := createTestMerchant(t, db)
+	openTestStore(t, db, merchant)
+	product := createTestProduct(t, db, merchant, "Mishkak", 5)
+	customer := &dao.User{Id: merchant.UserId}
+
+	expire := func(orderRef string) {
+		past := time.Now().Add(-time.Minute)
+		if err := db.Model(&dao.Order{}).Where("order_ref = ?", orderRef).Update("expires_at", past).Error; err != nil {
+			t.Fatalf("expire order: %v", err)
+		}
+		if err := db.Model(&dao.StockReservation{}).Where("order_ref = ?", orderRef).Update("expires_at", past).Error; err != nil {
+			t.Fatalf("expire reservations: %v", err)
+		}
+	}
+
+	// an order accepted too late is cancelled with its stock released
+	late := placeTestOrder(t, db, customer, merchant, product, 2)
+	expire(late.OrderRef)
+	if _, err := AcceptOrder(db, merchant, late.OrderRef); !isErrCode(err, xerr.OrderExpired) {
+		t.Fatalf("accept an expired order: got %v", err)
+	}
+	if order, err := GetCustomerOrder(db, customer, late.OrderRef); err != nil || order.Status != dao.OrderStatusCancelled {
+		t.Fatalf("got %+v %v, want the order cancelled", order, err)
+	}
+
+	// the sweeper cancels the order even when the stock sweeper released the reservations first
+	swept := placeTestOrder(t, db, customer, merchant, product, 3)
+	expire(swept.OrderRef)
+	if _, err := ReleaseExpiredStockReservations(db, 100); err != nil {
+		t.Fatalf("release expired stock reservations: %v", err)
+	}
+	if err := CommitStockReservation(db, swept.OrderRef); !isErrCode(err, xerr.StockReservationReleased) {
+		t.Fatalf("commit a released reservation: got %v", err)
+	}
+	if _, err := CancelExpiredOrders(db, 100); err != nil {
+		t.Fatalf("cancel expired orders: %v", err)
+	}
+	if order, err := GetCustomerOrder(db, customer, swept.OrderRef); err != nil || order.Status != dao.OrderStatusCancelled {
+		t.Fatalf("got %+v %v, want the order cancelled", order, err)
+	}
+	if inventory := mustInventory(t, db, product.Id); inventory.Reserved != 0 || inventory.Quantity != 5 {
+		t.Fatalf("got %+v after the expiries, want nothing reserved", inventory)
+	}
+}
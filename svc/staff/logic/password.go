@@ -0,0 +1,137 @@
+package logic
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/tespkg/bytes-be/common/token"
+	"github.com/tespkg/bytes-be/common/verifycode"
+	"github.com/tespkg/bytes-be/common/xerr"
+	"github.com/tespkg/bytes-be/svc/staff/model/dao"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"strconv"
+)
+
+// ForgotPassword sends a verify code if the customer exists, it doesn't tell whether the account exists
+func ForgotPassword(ctx context.Context, session *gorm.DB, req *dto.ForgotPasswordReq, ip string) error {
+	user, err := getCustomerUser(session, req.Phone, req.Email)
+	if err != nil {
+		return errors.Wrap(err, ">>ForgotPassword ")
+	}
+	if user == nil || user.Id == 0 || !user.IsEnabled {
+		return nil
+	}
+
+	channel, _ := verifycode.Target(req.Phone, req.Email)
+	sendReq := &dto.SendVerifyCodeReq{Purpose: verifycode.PurposeResetPassword}
+	if channel == verifycode.ChannelPhone {
+		sendReq.Phone = req.Phone
+	} else {
+		sendReq.Email = req.Email
+	}
+	if err = SendVerifyCode(ctx, sendReq, ip); err != nil {
+		return errors.Wrap(err, ">>ForgotPassword, SendVerifyCode fail")
+	}
+	return nil
+}
+
+func ResetPassword(ctx context.Context, session *gorm.DB, req *dto.ResetPasswordReq) error {
+	user, err := getCustomerUser(session, req.Phone, req.Email)
+	if err != nil {
+		return errors.Wrap(err, ">>ResetPassword ")
+	}
+	if user == nil || user.Id == 0 || !user.IsEnabled {
+		return xerr.NewErrCode(xerr.VerifyCodeInvalid)
+	}
+
+	channel, target := verifycode.Target(req.Phone, req.Email)
+	if err = verifycode.Verify(ctx, verifycode.PurposeResetPassword, channel, target, req.Code); err != nil {
+		return errors.Wrap(err, ">>ResetPassword, verifycode.Verify fail")
+	}
+
+	if err = updatePassword(ctx, session, user.Id, req.Password); err != nil {
+		return errors.Wrap(err, ">>ResetPassword ")
+	}
+	return nil
+}
+
+// ChangePassword keeps the current device logged in with a new token pair, every other token is invalidated
+func ChangePassword(ctx context.Context, session *gorm.DB, claims *token.UserClaims, req *dto.ChangePasswordReq) (*dto.ChangePasswordResp, error) {
+	userId, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>ChangePassword, strconv.ParseInt fail")
+	}
+
+	user, err := dao.GetUserById(session, userId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>ChangePassword, dao.GetUserById fail")
+	}
+	if user == nil || user.Id == 0 {
+		return nil, xerr.NewErrCode(xerr.UserNotExist)
+	}
+	if user.Password == nil || bcrypt.CompareHashAndPassword(user.Password, []byte(req.OldPassword)) != nil {
+		return nil, xerr.NewErrCode(xerr.UserPasswordInvalid)
+	}
+
+	if err = updatePassword(ctx, session, user.Id, req.NewPassword); err != nil {
+		return nil, errors.Wrap(err, ">>ChangePassword ")
+	}
+
+	pair, err := token.GenTokenPair(&token.GenTokenDto{
+		Session:       session,
+		UserId:        claims.Subject,
+		Platform:      claims.Platform,
+		Imei:          claims.IMEI,
+		ClientVersion: claims.ClientVersion,
+		Model:         claims.Model,
+		SystemVersion: claims.SystemVersion,
+		Roles:         user.RoleNames(),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, ">>ChangePassword, token.GenTokenPair fail")
+	}
+
+	return &dto.ChangePasswordResp{
+		TokenResp: newTokenResp(pair),
+	}, nil
+}
+
+// updatePassword saves the new hash and logs out every session.
+// token.RevokeAllSessions rejects the access tokens issued before by the session generation, whatever key
+// signed them, and revokes the refresh tokens, which never depended on the password hash.
+func updatePassword(ctx context.Context, session *gorm.DB, userId int64, password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return errors.Wrap(err, ">>updatePassword, bcrypt.GenerateFromPassword fail")
+	}
+
+	if err = dao.UpdateUserPassword(session, userId, hash); err != nil {
+		return errors.Wrap(err, ">>updatePassword, dao.UpdateUserPassword fail")
+	}
+
+	if err = token.RevokeAllSessions(ctx, session, userId); err != nil {
+		return errors.Wrap(err, ">>updatePassword, token.RevokeAllSessions fail")
+	}
+	return nil
+}
+
+func getCustomerUser(session *gorm.DB, phone, email string) (*dao.User, error) {
+	return getRoleUser(session, dao.RoleCustomer, phone, email)
+}
+
+func getRoleUser(session *gorm.DB, role, phone, email string) (*dao.User, error) {
+	if phone != "" {
+		user, err := dao.GetUserByPhoneAndRole(session, phone, role)
+		if err != nil {
+			return nil, errors.Wrap(err, ">>getRoleUser, dao.GetUserByPhoneAndRole fail")
+		}
+		return user, nil
+	}
+
+	user, err := dao.GetUserByEmailAndRole(session, email, role)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>getRoleUser, dao.GetUserByEmailAndRole fail")
+	}
+	return user, nil
+}
@@ -0,0 +1,139 @@
+package logic
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/tespkg/bytes-be/common/global"
+	"github.com/tespkg/bytes-be/common/token"
+	"github.com/tespkg/bytes-be/common/verifycode"
+	"github.com/tespkg/bytes-be/internal/testenv"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+	"gorm.io/gorm"
+)
+
+const testPassword = "old-password"
+
+// setUpLogicEnv a migrated postgres and an in-memory redis for the global clients
+func setUpLogicEnv(t *testing.T, singleLogin bool) *gorm.DB {
+	t.Helper()
+
+	db := testenv.Postgres(t)
+	client, _ := testenv.Redis(t)
+	testenv.Global(t, global.ClientSet{
+		RedisClient:             client,
+		EnableSingleLogin:       singleLogin,
+		TokenExpireDurationHour: 24,
+	})
+	return db
+}
+
+// registerTestCustomer registers a customer with an email and the test password
+func registerTestCustomer(t *testing.T, db *gorm.DB) (string, *dto.TokenResp) {
+	t.Helper()
+
+	email := uuid.NewString() + "@example.com"
+	resp, err := CustomerRegister(db, &dto.CustomerRegisterReq{Email: email, Password: testPassword})
+	if err != nil {
+		t.Fatalf("register customer: %v", err)
+	}
+	return email, &resp.TokenResp
+}
+
+func verifyTestToken(t *testing.T, db *gorm.DB, loginToken string) (*token.UserClaims, error) {
+	t.Helper()
+
+	ctx, err := token.VerifyToken(context.Background(), db, loginToken)
+	if err != nil {
+		return nil, err
+	}
+	return ctx.Value(token.ClaimsCtx).(*token.UserClaims), nil
+}
+
+// assertLoggedOut checks the tokens issued before can neither be used nor refreshed
+func assertLoggedOut(t *testing.T, db *gorm.DB, old *dto.TokenResp) {
+	t.Helper()
+
+	if _, err := verifyTestToken(t, db, old.LoginToken); err == nil {
+		t.Fatal("verify token: the token issued before is still valid")
+	}
+	if _, err := token.RotateRefreshToken(context.Background(), db, old.RefreshToken); err == nil {
+		t.Fatal("rotate refresh token: the refresh token issued before still works")
+	}
+}
+
+func assertLoggedIn(t *testing.T, db *gorm.DB, fresh *dto.TokenResp) {
+	t.Helper()
+
+	if _, err := verifyTestToken(t, db, fresh.LoginToken); err != nil {
+		t.Fatalf("verify token: the new token is rejected: %v", err)
+	}
+}
+
+func testSingleLogin(t *testing.T, test func(t *testing.T, singleLogin bool)) {
+	for _, singleLogin := range []bool{false, true} {
+		t.Run("single_login="+strconv.FormatBool(singleLogin), func(t *testing.T) {
+			test(t, singleLogin)
+		})
+	}
+}
+
+func TestChangePasswordLogsOutOtherTokens(t *testing.T) {
+	testSingleLogin(t, func(t *testing.T, singleLogin bool) {
+		db := setUpLogicEnv(t, singleLogin)
+		ctx := context.Background()
+		_, old := registerTestCustomer(t, db)
+
+		claims, err := verifyTestToken(t, db, old.LoginToken)
+		if err != nil {
+			t.Fatalf("verify token: %v", err)
+		}
+
+		resp, err := ChangePassword(ctx, db, claims, &dto.ChangePasswordReq{OldPassword: testPassword, NewPassword: "new-password"})
+		if err != nil {
+			t.Fatalf("change password: %v", err)
+		}
+
+		assertLoggedOut(t, db, old)
+		assertLoggedIn(t, db, &resp.TokenResp)
+	})
+}
+
+func TestResetPasswordLogsOutEveryToken(t *testing.T) {
+	testSingleLogin(t, func(t *testing.T, singleLogin bool) {
+		db := setUpLogicEnv(t, singleLogin)
+		ctx := context.Background()
+		email, old := registerTestCustomer(t, db)
+
+		// the code ForgotPassword sends to the customer
+		code, err := verifycode.Issue(ctx, verifycode.PurposeResetPassword, verifycode.ChannelEmail, email, "127.0.0.1")
+		if err != nil {
+			t.Fatalf("issue verify code: %v", err)
+		}
+		if err = ResetPassword(ctx, db, &dto.ResetPasswordReq{Email: email, Code: code.Value, Password: "new-password"}); err != nil {
+			t.Fatalf("reset password: %v", err)
+		}
+
+		assertLoggedOut(t, db, old)
+
+		resp, err := CustomerLogin(ctx, db, &dto.CustomerLoginReq{Email: email, Password: "new-password"})
+		if err != nil {
+			t.Fatalf("login: %v", err)
+		}
+		assertLoggedIn(t, db, &resp.TokenResp)
+	})
+}
+
+func TestForgotPasswordOfUnknownCustomer(t *testing.T) {
+	db := setUpLogicEnv(t, false)
+	ctx := context.Background()
+	_, old := registerTestCustomer(t, db)
+
+	// nothing is sent and nobody is logged out, without telling the account doesn't exist
+	if err := ForgotPassword(ctx, db, &dto.ForgotPasswordReq{Email: uuid.NewString() + "@example.com"}, "127.0.0.1"); err != nil {
+		t.Fatalf("forgot password: %v", err)
+	}
+	assertLoggedIn(t, db, old)
+}
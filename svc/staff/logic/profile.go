@@ -0,0 +1,165 @@
+package logic
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/tespkg/bytes-be/common/verifycode"
+	"github.com/tespkg/bytes-be/common/xerr"
+	"github.com/tespkg/bytes-be/svc/staff/model/dao"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+	"github.com/tespkg/bytes-be/svc/utils"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+const birthdayLayout = "2006-01-02"
+
+func GetCustomerProfile(session *gorm.DB, user *dao.User) (*dto.CustomerProfileResp, error) {
+	customer, err := getCustomer(session, user.Id)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>GetCustomerProfile ")
+	}
+
+	return newCustomerProfileResp(user, customer), nil
+}
+
+// UpdateCustomerProfile empty names or nationality clear the field
+func UpdateCustomerProfile(session *gorm.DB, user *dao.User, req *dto.UpdateCustomerProfileReq) (*dto.CustomerProfileResp, error) {
+	customer, err := getCustomer(session, user.Id)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>UpdateCustomerProfile ")
+	}
+
+	updates := make(map[string]interface{})
+	if req.FirstName != nil {
+		customer.FirstName = nullableString(*req.FirstName)
+		updates["first_name"] = customer.FirstName
+	}
+	if req.LastName != nil {
+		customer.LastName = nullableString(*req.LastName)
+		updates["last_name"] = customer.LastName
+	}
+	if req.Gender != nil {
+		customer.Gender = req.Gender
+		updates["gender"] = customer.Gender
+	}
+	if req.Birthday != nil {
+		customer.Birthday = nullableString(*req.Birthday)
+		updates["birthday"] = customer.Birthday
+	}
+	if req.Nationality != nil {
+		customer.Nationality = nullableString(strings.ToUpper(*req.Nationality))
+		updates["nationality"] = customer.Nationality
+	}
+
+	if len(updates) > 0 {
+		if err = dao.UpdateCustomer(session, customer.Id, updates); err != nil {
+			return nil, errors.Wrap(err, ">>UpdateCustomerProfile, dao.UpdateCustomer fail")
+		}
+	}
+
+	return newCustomerProfileResp(user, customer), nil
+}
+
+// ValidateCustomerProfile checks gender, birthday and nationality before any update
+func ValidateCustomerProfile(req *dto.UpdateCustomerProfileReq) error {
+	if req.Gender != nil && *req.Gender != dao.CustomerGenderMale && *req.Gender != dao.CustomerGenderFemale {
+		return errors.New("gender must be 0 (male) or 1 (female)")
+	}
+
+	if req.Birthday != nil && *req.Birthday != "" {
+		birthday, err := time.Parse(birthdayLayout, *req.Birthday)
+		if err != nil {
+			return errors.New("birthday must be in YYYY-MM-DD format")
+		}
+		if birthday.After(time.Now()) {
+			return errors.New("birthday can't be in the future")
+		}
+	}
+
+	if req.Nationality != nil && *req.Nationality != "" && !utils.IsCountryCode(*req.Nationality) {
+		return errors.New("nationality must be an ISO 3166-1 alpha-2 country code")
+	}
+
+	return nil
+}
+
+// ChangePhone the code must have been sent to the new phone with the change_phone purpose
+func ChangePhone(ctx context.Context, session *gorm.DB, user *dao.User, req *dto.ChangePhoneReq) error {
+	owner, err := dao.GetEnabledUserByPhone(session, req.Phone)
+	if err != nil {
+		return errors.Wrap(err, ">>ChangePhone, dao.GetEnabledUserByPhone fail")
+	}
+	if owner != nil && owner.Id != 0 && owner.Id != user.Id {
+		return xerr.NewErrCode(xerr.PhoneRegistered)
+	}
+
+	if err = verifycode.Verify(ctx, verifycode.PurposeChangePhone, verifycode.ChannelPhone, req.Phone, req.Code); err != nil {
+		return errors.Wrap(err, ">>ChangePhone, verifycode.Verify fail")
+	}
+
+	if err = dao.UpdateUserPhone(session, user.Id, req.Phone); err != nil {
+		return errors.Wrap(err, ">>ChangePhone, dao.UpdateUserPhone fail")
+	}
+	return nil
+}
+
+// ChangeEmail the code must have been sent to the new email with the change_email purpose
+func ChangeEmail(ctx context.Context, session *gorm.DB, user *dao.User, req *dto.ChangeEmailReq) error {
+	email := normalizeEmail(req.Email)
+	owner, err := dao.GetEnabledUserByEmail(session, email)
+	if err != nil {
+		return errors.Wrap(err, ">>ChangeEmail, dao.GetEnabledUserByEmail fail")
+	}
+	if owner != nil && owner.Id != 0 && owner.Id != user.Id {
+		return xerr.NewErrCode(xerr.EmailRegistered)
+	}
+
+	if err = verifycode.Verify(ctx, verifycode.PurposeChangeEmail, verifycode.ChannelEmail, email, req.Code); err != nil {
+		return errors.Wrap(err, ">>ChangeEmail, verifycode.Verify fail")
+	}
+
+	if err = dao.UpdateUserEmail(session, user.Id, email); err != nil {
+		return errors.Wrap(err, ">>ChangeEmail, dao.UpdateUserEmail fail")
+	}
+	return nil
+}
+
+func getCustomer(session *gorm.DB, userId int64) (*dao.Customer, error) {
+	customer, err := dao.GetCustomerByUserId(session, userId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>getCustomer, dao.GetCustomerByUserId fail")
+	}
+	if customer == nil || customer.Id == 0 {
+		return nil, xerr.NewErrCode(xerr.UserNotExist)
+	}
+	return customer, nil
+}
+
+func newCustomerProfileResp(user *dao.User, customer *dao.Customer) *dto.CustomerProfileResp {
+	return &dto.CustomerProfileResp{
+		UserId:      user.Id,
+		Uuid:        user.Uuid,
+		Email:       user.Email,
+		Phone:       user.Phone,
+		FirstName:   customer.FirstName,
+		LastName:    customer.LastName,
+		Gender:      customer.Gender,
+		Birthday:    customer.Birthday,
+		Nationality: customer.Nationality,
+	}
+}
+
+// normalizeEmail the emails are stored trimmed in lower case, so the unique index can't be bypassed by the case
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func nullableString(s string) *string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,59 @@
+package logic
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/tespkg/bytes-be/common/verifycode"
+	"github.com/tespkg/bytes-be/common/xerr"
+	"github.com/tespkg/bytes-be/svc/staff/model/dao"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+	"gorm.io/gorm"
+)
+
+// changeTestEmail changes the email of the registered customer with an issued code
+func changeTestEmail(t *testing.T, db *gorm.DB, registered *dto.TokenResp, email string) (*dao.User, error) {
+	t.Helper()
+
+	claims, err := verifyTestToken(t, db, registered.LoginToken)
+	if err != nil {
+		t.Fatalf("verify token: %v", err)
+	}
+	userId, _ := strconv.ParseInt(claims.Subject, 10, 64)
+	user, err := dao.GetUserById(db, userId)
+	if err != nil || user == nil {
+		t.Fatalf("get user: %v", err)
+	}
+
+	code, err := verifycode.Issue(context.Background(), verifycode.PurposeChangeEmail, verifycode.ChannelEmail, email, "")
+	if err != nil {
+		t.Fatalf("issue code: %v", err)
+	}
+	if err = ChangeEmail(context.Background(), db, user, &dto.ChangeEmailReq{Email: email, Code: code.Value}); err != nil {
+		return nil, err
+	}
+	return dao.GetUserById(db, userId)
+}
+
+func TestChangeEmailIsNormalized(t *testing.T) {
+	db := setUpLogicEnv(t, false)
+	taken, _ := registerTestCustomer(t, db)
+	_, registered := registerTestCustomer(t, db)
+
+	// the email of another user in another case
+	if _, err := changeTestEmail(t, db, registered, " "+strings.ToUpper(taken)); !isErrCode(err, xerr.EmailRegistered) {
+		t.Fatalf("change email: got %v, want the email registered", err)
+	}
+
+	email := "New." + uuid.NewString() + "@Example.com"
+	user, err := changeTestEmail(t, db, registered, email)
+	if err != nil {
+		t.Fatalf("change email: %v", err)
+	}
+	if user.Email == nil || *user.Email != strings.ToLower(email) {
+		t.Fatalf("got email %v, want %s", user.Email, strings.ToLower(email))
+	}
+}
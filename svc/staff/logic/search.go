@@ -0,0 +1,267 @@
+package logic
+
+import (
+	"encoding/base64"
+	"github.com/pkg/errors"
+	"github.com/tespkg/bytes-be/common/xerr"
+	"github.com/tespkg/bytes-be/svc/staff/model/dao"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+	"gorm.io/gorm"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	SearchLangEnglish = "en"
+	SearchLangArabic  = "ar"
+
+	// maxSearchTerms the words after are ignored
+	maxSearchTerms = 8
+)
+
+// SearchProducts ranks the available products by name, category and description
+func SearchProducts(session *gorm.DB, req *dto.SearchProductReq) (*dto.SearchProductResp, error) {
+	config, tsQuery, err := searchQuery(req.Query, req.Lang)
+	if err != nil {
+		return nil, err
+	}
+	after, err := decodeSearchCursor(req.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	if req.MinPrice != nil && req.MaxPrice != nil && *req.MinPrice > *req.MaxPrice {
+		return nil, xerr.NewErrCodeMsg(xerr.RequestParamError, "minPrice must not exceed maxPrice")
+	}
+	excluded, err := parseAllergenFilter(&req.AllergenFilterReq)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.SearchProductResp{Products: []dto.SearchProductHit{}}
+	merchantIds, err := searchMerchantIds(session, req.StoreId, req.OpenNow)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>SearchProducts ")
+	}
+	if merchantIds != nil && len(merchantIds) == 0 {
+		return resp, nil
+	}
+
+	limit := searchLimit(req.Limit)
+	hits, err := dao.SearchProducts(session, &dao.ProductSearch{
+		ProductFilter: dao.ProductFilter{
+			MerchantIds:      merchantIds,
+			CategoryId:       req.CategoryId,
+			MinPrice:         req.MinPrice,
+			MaxPrice:         req.MaxPrice,
+			ExcludeAllergens: excluded,
+		},
+		Config:  config,
+		TsQuery: tsQuery,
+		After:   after,
+		Limit:   limit + 1,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, ">>SearchProducts, dao.SearchProducts fail")
+	}
+
+	if len(hits) > limit {
+		hits = hits[:limit]
+		last := hits[limit-1]
+		resp.NextCursor = encodeSearchCursor(last.Rank, last.Id)
+	}
+	for i := range hits {
+		resp.Products = append(resp.Products, newSearchProductHit(&hits[i], hits[i].Rank))
+	}
+	return resp, nil
+}
+
+// SearchStores ranks the approved stores by name and address
+func SearchStores(session *gorm.DB, req *dto.SearchStoreReq) (*dto.SearchStoreResp, error) {
+	config, tsQuery, err := searchQuery(req.Query, req.Lang)
+	if err != nil {
+		return nil, err
+	}
+	after, err := decodeSearchCursor(req.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.SearchStoreResp{Stores: []dto.SearchStoreHit{}}
+	merchantIds, err := searchMerchantIds(session, 0, req.OpenNow)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>SearchStores ")
+	}
+	if merchantIds != nil && len(merchantIds) == 0 {
+		return resp, nil
+	}
+
+	limit := searchLimit(req.Limit)
+	hits, err := dao.SearchStores(session, &dao.StoreSearch{
+		Config:      config,
+		TsQuery:     tsQuery,
+		MerchantIds: merchantIds,
+		After:       after,
+		Limit:       limit + 1,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, ">>SearchStores, dao.SearchStores fail")
+	}
+
+	if len(hits) > limit {
+		hits = hits[:limit]
+		last := hits[limit-1]
+		resp.NextCursor = encodeSearchCursor(last.Rank, last.Id)
+	}
+
+	merchants := make([]dao.Merchant, 0, len(hits))
+	for _, hit := range hits {
+		merchants = append(merchants, hit.Merchant)
+	}
+	now := time.Now()
+	schedules, err := loadStoreSchedules(session, merchants, now)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>SearchStores ")
+	}
+	for i := range hits {
+		resp.Stores = append(resp.Stores, dto.SearchStoreHit{
+			StoreResp: newStoreResp(&hits[i].Merchant, schedules[hits[i].Id], now),
+			Score:     hits[i].Rank,
+		})
+	}
+	return resp, nil
+}
+
+// searchMerchantIds nil means no filter, empty means no store matches
+func searchMerchantIds(session *gorm.DB, storeId int64, openNow bool) ([]int64, error) {
+	if !openNow {
+		if storeId > 0 {
+			return []int64{storeId}, nil
+		}
+		return nil, nil
+	}
+
+	var merchants []dao.Merchant
+	if storeId > 0 {
+		merchant, err := dao.GetMerchantById(session, storeId)
+		if err != nil {
+			return nil, errors.Wrap(err, ">>searchMerchantIds, dao.GetMerchantById fail")
+		}
+		if merchant == nil || merchant.Id == 0 {
+			return []int64{}, nil
+		}
+		merchants = []dao.Merchant{*merchant}
+	} else {
+		approved, _, err := dao.ListMerchants(session, dao.MerchantStatusApproved, 0, math.MaxInt32)
+		if err != nil {
+			return nil, errors.Wrap(err, ">>searchMerchantIds, dao.ListMerchants fail")
+		}
+		merchants = approved
+	}
+
+	now := time.Now()
+	schedules, err := loadStoreSchedules(session, merchants, now)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>searchMerchantIds ")
+	}
+
+	ids := make([]int64, 0, len(merchants))
+	for _, merchant := range merchants {
+		if isOpen, _ := storeOpening(schedules[merchant.Id], now); isOpen {
+			ids = append(ids, merchant.Id)
+		}
+	}
+	return ids, nil
+}
+
+// searchQuery the text search configuration and a to_tsquery expression matching every word as a prefix.
+// The words are split on anything but letters, digits and marks, so the expression has no operator of the user
+func searchQuery(q, lang string) (string, string, error) {
+	terms := strings.FieldsFunc(q, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && !unicode.IsMark(r)
+	})
+	if len(terms) == 0 {
+		return "", "", xerr.NewErrCodeMsg(xerr.RequestParamError, "q must have a word")
+	}
+	if len(terms) > maxSearchTerms {
+		terms = terms[:maxSearchTerms]
+	}
+
+	if lang == "" {
+		lang = detectSearchLang(q)
+	}
+	config := dao.SearchConfigEnglish
+	if lang == SearchLangArabic {
+		config = dao.SearchConfigArabic
+	}
+
+	for i := range terms {
+		terms[i] = strings.ToLower(terms[i]) + ":*"
+	}
+	return config, strings.Join(terms, " & "), nil
+}
+
+// detectSearchLang arabic if q has an arabic letter
+func detectSearchLang(q string) string {
+	for _, r := range q {
+		if unicode.Is(unicode.Arabic, r) {
+			return SearchLangArabic
+		}
+	}
+	return SearchLangEnglish
+}
+
+func searchLimit(limit int) int {
+	if limit <= 0 {
+		return defaultPageSize
+	}
+	return limit
+}
+
+func encodeSearchCursor(rank float32, id int64) string {
+	raw := strconv.FormatFloat(float64(rank), 'g', -1, 32) + ":" + strconv.FormatInt(id, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeSearchCursor(cursor string) (*dao.SearchCursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+
+	invalid := xerr.NewErrCodeMsg(xerr.RequestParamError, "invalid cursor")
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, invalid
+	}
+	rankPart, idPart, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, invalid
+	}
+	rank, err := strconv.ParseFloat(rankPart, 32)
+	if err != nil {
+		return nil, invalid
+	}
+	id, err := strconv.ParseInt(idPart, 10, 64)
+	if err != nil {
+		return nil, invalid
+	}
+	return &dao.SearchCursor{Rank: float32(rank), Id: id}, nil
+}
+
+func newSearchProductHit(hit *dao.ProductSearchHit, score float32) dto.SearchProductHit {
+	return dto.SearchProductHit{
+		Id:          hit.Id,
+		StoreId:     hit.MerchantId,
+		StoreName:   hit.StoreName,
+		CategoryId:  hit.CategoryId,
+		Kind:        hit.Kind,
+		Name:        hit.Name,
+		Description: hit.Description,
+		Image:       newImageResp(hit.Image),
+		Price:       hit.Price,
+		Currency:    hit.Currency,
+		Score:       score,
+	}
+}
@@ -0,0 +1,295 @@
+package logic
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"github.com/pkg/errors"
+	"github.com/tespkg/bytes-be/common/semantic"
+	"github.com/tespkg/bytes-be/common/xerr"
+	"github.com/tespkg/bytes-be/svc/staff/model/dao"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+	"gorm.io/gorm"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	SemanticModeSemantic = "semantic"
+	SemanticModeHybrid   = "hybrid"
+
+	// semanticCandidates the first count of the nearest products, and of the best keyword matches in the hybrid mode, ranked before the filters
+	semanticCandidates    = 200
+	defaultSemanticWeight = 0.7
+
+	semanticSyncBatch = 500
+	// semanticSyncOverlap the embeddings committed late by another instance are read again by the next sync
+	semanticSyncOverlap = time.Minute
+)
+
+// SemanticSearchProducts ranks the available products by the similarity of their embedding to the one of q
+func SemanticSearchProducts(ctx context.Context, session *gorm.DB, req *dto.SemanticSearchReq) (*dto.SemanticSearchResp, error) {
+	if !semantic.Enabled() {
+		return nil, xerr.NewErrCode(xerr.SemanticSearchDisabled)
+	}
+	query := strings.Join(strings.Fields(req.Query), " ")
+	if query == "" {
+		return nil, xerr.NewErrCodeMsg(xerr.RequestParamError, "q must have a word")
+	}
+	if req.MinPrice != nil && req.MaxPrice != nil && *req.MinPrice > *req.MaxPrice {
+		return nil, xerr.NewErrCodeMsg(xerr.RequestParamError, "minPrice must not exceed maxPrice")
+	}
+	excluded, err := parseAllergenFilter(&req.AllergenFilterReq)
+	if err != nil {
+		return nil, err
+	}
+
+	hybrid := req.Mode == SemanticModeHybrid
+	var config, tsQuery string
+	if hybrid {
+		if config, tsQuery, err = searchQuery(query, req.Lang); err != nil {
+			return nil, err
+		}
+	}
+
+	resp := &dto.SemanticSearchResp{Products: []dto.SearchProductHit{}}
+	merchantIds, err := searchMerchantIds(session, req.StoreId, req.OpenNow)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>SemanticSearchProducts ")
+	}
+	if merchantIds != nil && len(merchantIds) == 0 {
+		return resp, nil
+	}
+
+	vectors, err := semantic.Embed(ctx, []string{query}, false)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>SemanticSearchProducts, semantic.Embed fail")
+	}
+	vector, ok := vectors[query]
+	if !ok {
+		return nil, errors.New(">>SemanticSearchProducts, the query is not embedded")
+	}
+
+	filter := dao.ProductFilter{
+		MerchantIds:      merchantIds,
+		CategoryId:       req.CategoryId,
+		MinPrice:         req.MinPrice,
+		MaxPrice:         req.MaxPrice,
+		ExcludeAllergens: excluded,
+	}
+	weight := defaultSemanticWeight
+	if req.Weight != nil {
+		weight = *req.Weight
+	}
+
+	index := semantic.Default()
+	limit := searchLimit(req.Limit)
+	var scores map[int64]float32
+	var hits []dao.ProductSearchHit
+	// the filters apply after the ranking, the candidates widen until they fill the page or run out
+	for candidates := semanticCandidates; ; candidates *= 2 {
+		nearest := index.Search(vector, candidates, merchantIds)
+		scores = make(map[int64]float32, len(nearest))
+		for _, hit := range nearest {
+			scores[hit.Id] = hit.Score
+		}
+
+		var keywordHits []dao.ProductSearchHit
+		if hybrid {
+			keywordHits, err = dao.SearchProducts(session, &dao.ProductSearch{
+				ProductFilter: filter,
+				Config:        config,
+				TsQuery:       tsQuery,
+				Limit:         candidates,
+			})
+			if err != nil {
+				return nil, errors.Wrap(err, ">>SemanticSearchProducts, dao.SearchProducts fail")
+			}
+			scores = blendSearchScores(scores, index.Similarity(vector, productSearchHitIds(keywordHits)), keywordHits, float32(weight))
+		}
+
+		ids := make([]int64, 0, len(scores))
+		for id := range scores {
+			ids = append(ids, id)
+		}
+		if hits, err = dao.ListProductSearchHits(session, ids, &filter); err != nil {
+			return nil, errors.Wrap(err, ">>SemanticSearchProducts, dao.ListProductSearchHits fail")
+		}
+		if len(hits) >= limit || (len(nearest) < candidates && len(keywordHits) < candidates) {
+			break
+		}
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if scores[hits[i].Id] != scores[hits[j].Id] {
+			return scores[hits[i].Id] > scores[hits[j].Id]
+		}
+		return hits[i].Id > hits[j].Id
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	for i := range hits {
+		resp.Products = append(resp.Products, newSearchProductHit(&hits[i], scores[hits[i].Id]))
+	}
+	return resp, nil
+}
+
+// blendSearchScores weight of the similarity plus the rest of the keyword rank relative to the best keyword match.
+// similarities has the similarity of the keyword hits not among the nearest products
+func blendSearchScores(nearest, similarities map[int64]float32, keywordHits []dao.ProductSearchHit, weight float32) map[int64]float32 {
+	keyword := make(map[int64]float32, len(keywordHits))
+	if len(keywordHits) > 0 && keywordHits[0].Rank > 0 {
+		best := keywordHits[0].Rank
+		for _, hit := range keywordHits {
+			keyword[hit.Id] = hit.Rank / best
+		}
+	}
+
+	blended := make(map[int64]float32, len(nearest)+len(keywordHits))
+	for id, score := range nearest {
+		blended[id] = weight * score
+	}
+	for _, hit := range keywordHits {
+		if _, ok := nearest[hit.Id]; !ok {
+			blended[hit.Id] = weight * similarities[hit.Id]
+		}
+	}
+	for id := range blended {
+		blended[id] += (1 - weight) * keyword[id]
+	}
+	return blended
+}
+
+// EmbedProducts embeds up to limit products changed after their embedding and drops the embeddings of the deleted products.
+// A product whose text is unchanged isn't embedded again, the count of the products checked is returned
+func EmbedProducts(ctx context.Context, session *gorm.DB, limit int) (int, error) {
+	if !semantic.Enabled() {
+		return 0, nil
+	}
+
+	if _, err := dao.DeleteStaleProductEmbeddings(session); err != nil {
+		return 0, errors.Wrap(err, ">>EmbedProducts, dao.DeleteStaleProductEmbeddings fail")
+	}
+
+	sources, err := dao.ListProductsToEmbed(session, semantic.Model(), limit)
+	if err != nil {
+		return 0, errors.Wrap(err, ">>EmbedProducts, dao.ListProductsToEmbed fail")
+	}
+	if len(sources) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]int64, 0, len(sources))
+	for _, source := range sources {
+		ids = append(ids, source.Id)
+	}
+	existing, err := dao.GetProductEmbeddings(session, ids)
+	if err != nil {
+		return 0, errors.Wrap(err, ">>EmbedProducts, dao.GetProductEmbeddings fail")
+	}
+
+	texts := make([]string, len(sources))
+	changed := make([]bool, len(sources))
+	var unchanged []int64
+	var pending []string
+	for i, source := range sources {
+		texts[i] = productEmbeddingText(&source)
+		if embedding, ok := existing[source.Id]; ok && embedding.MerchantId == source.MerchantId &&
+			embedding.ContentHash == contentHash(texts[i]) {
+			unchanged = append(unchanged, source.Id)
+			continue
+		}
+		changed[i] = true
+		pending = append(pending, texts[i])
+	}
+
+	if len(pending) > 0 {
+		vectors, err := semantic.Embed(ctx, pending, true)
+		if err != nil {
+			return 0, errors.Wrap(err, ">>EmbedProducts, semantic.Embed fail")
+		}
+
+		for i, source := range sources {
+			if !changed[i] {
+				continue
+			}
+			vector, ok := vectors[texts[i]]
+			if !ok {
+				// not embedded this time, the product stays stale until the next sync
+				continue
+			}
+			embedding := &dao.ProductEmbedding{
+				ProductId:   source.Id,
+				MerchantId:  source.MerchantId,
+				Model:       semantic.Model(),
+				ContentHash: contentHash(texts[i]),
+				Embedding:   vector,
+			}
+			if err = embedding.Save(session); err != nil {
+				return 0, errors.Wrap(err, ">>EmbedProducts, embedding.Save fail")
+			}
+		}
+	}
+
+	if err = dao.TouchProductEmbeddings(session, unchanged); err != nil {
+		return 0, errors.Wrap(err, ">>EmbedProducts, dao.TouchProductEmbeddings fail")
+	}
+	return len(sources), nil
+}
+
+// SyncSemanticIndex loads the embeddings changed after since into the index, a zero since loads all of them.
+// The since of the next sync is returned
+func SyncSemanticIndex(session *gorm.DB, since time.Time) (time.Time, error) {
+	from := since
+	if !since.IsZero() {
+		from = since.Add(-semanticSyncOverlap)
+	}
+
+	index := semantic.Default()
+	synced := since
+	err := dao.ScanProductEmbeddings(session, from, semanticSyncBatch, func(embeddings []dao.ProductEmbedding) error {
+		for _, embedding := range embeddings {
+			if (embedding.DeletedAt != nil && embedding.DeletedAt.Valid) || embedding.Model != semantic.Model() {
+				index.Remove(embedding.ProductId)
+			} else {
+				index.Put(embedding.ProductId, embedding.MerchantId, embedding.Embedding)
+			}
+			if embedding.UpdatedAt != nil && embedding.UpdatedAt.After(synced) {
+				synced = *embedding.UpdatedAt
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return since, errors.Wrap(err, ">>SyncSemanticIndex, dao.ScanProductEmbeddings fail")
+	}
+	return synced, nil
+}
+
+// productEmbeddingText the name, category and description on their own lines,
+// so trimming the parenthesized parts of the name stays on the name
+func productEmbeddingText(source *dao.ProductEmbeddingSource) string {
+	parts := []string{strings.TrimSpace(source.Name)}
+	if source.CategoryName != nil && strings.TrimSpace(*source.CategoryName) != "" {
+		parts = append(parts, strings.TrimSpace(*source.CategoryName))
+	}
+	if source.Description != nil && strings.TrimSpace(*source.Description) != "" {
+		parts = append(parts, strings.Join(strings.Fields(*source.Description), " "))
+	}
+	return strings.Join(parts, "\n")
+}
+
+func contentHash(text string) string {
+	sum := sha1.Sum([]byte(semantic.Model() + "\n" + text))
+	return hex.EncodeToString(sum[:])
+}
+
+func productSearchHitIds(hits []dao.ProductSearchHit) []int64 {
+	ids := make([]int64, 0, len(hits))
+	for _, hit := range hits {
+		ids = append(ids, hit.Id)
+	}
+	return ids
+}
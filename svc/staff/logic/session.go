@@ -0,0 +1,80 @@
+package logic
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/tespkg/bytes-be/common/token"
+	"github.com/tespkg/bytes-be/common/xerr"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+)
+
+func ListSessions(session *gorm.DB, claims *token.UserClaims) (*dto.ListSessionResp, error) {
+	userId, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>ListSessions, strconv.ParseInt fail")
+	}
+
+	refreshTokens, err := token.ListSessions(session, userId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>ListSessions ")
+	}
+
+	resp := &dto.ListSessionResp{Sessions: make([]dto.SessionResp, 0, len(refreshTokens))}
+	for _, refreshToken := range refreshTokens {
+		item := dto.SessionResp{
+			SessionId:     refreshToken.FamilyId,
+			Platform:      refreshToken.Platform,
+			Model:         refreshToken.Model,
+			ClientVersion: refreshToken.ClientVersion,
+			SystemVersion: refreshToken.SystemVersion,
+			Current:       refreshToken.FamilyId == claims.SessionId,
+		}
+		if refreshToken.CreatedAt != nil {
+			item.CreatedAt = refreshToken.CreatedAt.Unix()
+			item.LastSeenAt = item.CreatedAt
+		}
+		if refreshToken.LastSeenAt != nil {
+			item.LastSeenAt = refreshToken.LastSeenAt.Unix()
+		}
+		resp.Sessions = append(resp.Sessions, item)
+	}
+
+	return resp, nil
+}
+
+func Logout(ctx context.Context, session *gorm.DB, claims *token.UserClaims) error {
+	if err := token.RevokeCurrentSession(ctx, session, claims); err != nil {
+		return errors.Wrap(err, ">>Logout ")
+	}
+	return nil
+}
+
+func RevokeSession(ctx context.Context, session *gorm.DB, claims *token.UserClaims, sessionId string) error {
+	userId, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, ">>RevokeSession, strconv.ParseInt fail")
+	}
+
+	if err = token.RevokeSession(ctx, session, userId, sessionId); err != nil {
+		if errors.Is(err, token.SessionNotFoundError) {
+			return xerr.NewHttpError(http.StatusNotFound, err.Error())
+		}
+		return errors.Wrap(err, ">>RevokeSession ")
+	}
+	return nil
+}
+
+func RevokeAllSessions(ctx context.Context, session *gorm.DB, claims *token.UserClaims) error {
+	userId, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, ">>RevokeAllSessions, strconv.ParseInt fail")
+	}
+
+	if err = token.RevokeAllSessions(ctx, session, userId); err != nil {
+		return errors.Wrap(err, ">>RevokeAllSessions ")
+	}
+	return nil
+}
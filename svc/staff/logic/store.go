@@ -0,0 +1,113 @@
+package logic
+
+import (
+	"github.com/pkg/errors"
+	"github.com/tespkg/bytes-be/common/openhours"
+	"github.com/tespkg/bytes-be/common/token"
+	"github.com/tespkg/bytes-be/common/xerr"
+	"github.com/tespkg/bytes-be/svc/staff/model/dao"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+	"gorm.io/gorm"
+	"math"
+	"time"
+)
+
+// ListStores lists the approved merchants with their opening state, and the delivery zone if a location is given.
+// openNow and the location filter after evaluating the hours and zones, so they load every approved merchant before paging.
+func ListStores(session *gorm.DB, claims *token.UserClaims, req *dto.ListStoreReq) (*dto.ListStoreResp, error) {
+	lat, lon, located, err := resolveDeliveryLocation(session, claims, &req.DeliveryLocationReq)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>ListStores ")
+	}
+	filtered := req.OpenNow || located
+
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	offset, limit := (page-1)*pageSize, pageSize
+	if filtered {
+		offset, limit = 0, math.MaxInt32
+	}
+	merchants, total, err := dao.ListMerchants(session, dao.MerchantStatusApproved, offset, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>ListStores, dao.ListMerchants fail")
+	}
+
+	now := time.Now()
+	schedules, err := loadStoreSchedules(session, merchants, now)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>ListStores ")
+	}
+
+	var zones map[int64]*dao.DeliveryZone
+	if located {
+		if zones, err = findDeliveryZones(session, nil, lat, lon); err != nil {
+			return nil, errors.Wrap(err, ">>ListStores ")
+		}
+	}
+
+	stores := make([]dto.StoreResp, 0, len(merchants))
+	for i := range merchants {
+		store := newStoreResp(&merchants[i], schedules[merchants[i].Id], now)
+		if req.OpenNow && !store.IsOpen {
+			continue
+		}
+		if located {
+			zone, ok := zones[merchants[i].Id]
+			if !ok {
+				continue
+			}
+			store.Delivery = newStoreDeliveryResp(zone)
+		}
+		stores = append(stores, store)
+	}
+
+	if filtered {
+		total = int64(len(stores))
+		start, end := min((page-1)*pageSize, len(stores)), min(page*pageSize, len(stores))
+		stores = stores[start:end]
+	}
+
+	return &dto.ListStoreResp{Total: total, Stores: stores}, nil
+}
+
+func GetStore(session *gorm.DB, storeId int64) (*dto.StoreResp, error) {
+	merchant, err := dao.GetMerchantById(session, storeId)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>GetStore, dao.GetMerchantById fail")
+	}
+	if merchant == nil || merchant.Id == 0 || merchant.Status != dao.MerchantStatusApproved {
+		return nil, xerr.NewErrCode(xerr.MerchantNotExist)
+	}
+
+	now := time.Now()
+	schedules, err := loadStoreSchedules(session, []dao.Merchant{*merchant}, now)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>GetStore ")
+	}
+
+	store := newStoreResp(merchant, schedules[merchant.Id], now)
+	return &store, nil
+}
+
+func newStoreResp(merchant *dao.Merchant, schedule *openhours.Schedule, now time.Time) dto.StoreResp {
+	store := dto.StoreResp{
+		Id:        merchant.Id,
+		Name:      merchant.LegalName,
+		Logo:      newImageResp(merchant.Logo),
+		Address:   merchant.Address,
+		Latitude:  merchant.Latitude,
+		Longitude: merchant.Longitude,
+		Timezone:  merchant.Timezone,
+	}
+	if merchant.TradeName != nil {
+		store.Name = *merchant.TradeName
+	}
+	store.IsOpen, store.NextOpeningAt = storeOpening(schedule, now)
+	return store
+}
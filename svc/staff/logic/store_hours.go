@@ -0,0 +1,322 @@
+package logic
+
+import (
+	"github.com/golang-module/carbon/v2"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/tespkg/bytes-be/common/openhours"
+	"github.com/tespkg/bytes-be/common/xerr"
+	"github.com/tespkg/bytes-be/svc/staff/model/dao"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+func GetStoreHours(session *gorm.DB, merchant *dao.Merchant) (*dto.StoreHoursResp, error) {
+	now := time.Now()
+	schedules, err := loadStoreSchedules(session, []dao.Merchant{*merchant}, now)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>GetStoreHours ")
+	}
+
+	hours, err := dao.ListMerchantHours(session, []int64{merchant.Id})
+	if err != nil {
+		return nil, errors.Wrap(err, ">>GetStoreHours, dao.ListMerchantHours fail")
+	}
+
+	resp := &dto.StoreHoursResp{
+		Timezone:    merchant.Timezone,
+		Hours:       make([]dto.StoreWeeklyShift, 0, len(hours)),
+		PauseReason: merchant.PauseReason,
+	}
+	for _, hour := range hours {
+		resp.Hours = append(resp.Hours, dto.StoreWeeklyShift{
+			Weekday:    hour.Weekday,
+			StoreShift: dto.StoreShift{OpenTime: hour.OpenTime, CloseTime: hour.CloseTime},
+		})
+	}
+	if merchant.PausedUntil != nil && merchant.PausedUntil.After(now) {
+		resp.PausedUntil = merchant.PausedUntil.Unix()
+	}
+	resp.IsOpen, resp.NextOpeningAt = storeOpening(schedules[merchant.Id], now)
+	return resp, nil
+}
+
+// UpdateStoreHours replaces the weekly shifts, the shifts of a weekday must not overlap
+func UpdateStoreHours(session *gorm.DB, merchant *dao.Merchant, req *dto.StoreHoursReq) (*dto.StoreHoursResp, error) {
+	timezone := strings.TrimSpace(req.Timezone)
+	if timezone == "" {
+		timezone = merchant.Timezone
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return nil, xerr.NewErrCodeMsg(xerr.RequestParamError, "unknown timezone "+timezone)
+	}
+
+	weekly := make(map[int][]openhours.Shift)
+	hours := make([]dao.MerchantHour, 0, len(req.Hours))
+	for _, item := range req.Hours {
+		shift, err := openhours.ParseShift(item.OpenTime, item.CloseTime)
+		if err != nil {
+			return nil, xerr.NewErrCodeMsg(xerr.RequestParamError, err.Error())
+		}
+		weekly[item.Weekday] = append(weekly[item.Weekday], shift)
+		hours = append(hours, dao.MerchantHour{
+			MerchantId: merchant.Id,
+			Weekday:    item.Weekday,
+			OpenTime:   item.OpenTime,
+			CloseTime:  item.CloseTime,
+		})
+	}
+	for _, shifts := range weekly {
+		if err := openhours.ValidateShifts(shifts); err != nil {
+			return nil, xerr.NewErrCodeMsg(xerr.RequestParamError, err.Error())
+		}
+	}
+
+	tx := session.Begin()
+	if err := tx.Error; err != nil {
+		return nil, errors.Wrap(err, ">>UpdateStoreHours, transaction begin fail")
+	}
+	defer tx.Rollback()
+
+	if err := dao.ReplaceMerchantHours(tx, merchant.Id, hours); err != nil {
+		return nil, errors.Wrap(err, ">>UpdateStoreHours, dao.ReplaceMerchantHours fail")
+	}
+	if timezone != merchant.Timezone {
+		if err := dao.UpdateMerchantTimezone(tx, merchant.Id, timezone); err != nil {
+			return nil, errors.Wrap(err, ">>UpdateStoreHours, dao.UpdateMerchantTimezone fail")
+		}
+		merchant.Timezone = timezone
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.Wrap(err, ">>UpdateStoreHours, transaction commit fail")
+	}
+
+	return GetStoreHours(session, merchant)
+}
+
+// ListStoreSpecialDays the special days from today on
+func ListStoreSpecialDays(session *gorm.DB, merchant *dao.Merchant) (*dto.ListStoreSpecialDayResp, error) {
+	today := time.Now().In(storeLocation(merchant)).Format(openhours.DateLayout)
+	days, err := dao.ListMerchantSpecialDays(session, []int64{merchant.Id}, today, "9999-12-31")
+	if err != nil {
+		return nil, errors.Wrap(err, ">>ListStoreSpecialDays, dao.ListMerchantSpecialDays fail")
+	}
+
+	resp := &dto.ListStoreSpecialDayResp{SpecialDays: make([]dto.StoreSpecialDayResp, 0, len(days))}
+	for _, day := range days {
+		resp.SpecialDays = append(resp.SpecialDays, newStoreSpecialDayResp(&day))
+	}
+	return resp, nil
+}
+
+// SaveStoreSpecialDay creates or replaces the special day of the date
+func SaveStoreSpecialDay(session *gorm.DB, merchant *dao.Merchant, req *dto.StoreSpecialDayReq) (*dto.StoreSpecialDayResp, error) {
+	if _, err := time.Parse(openhours.DateLayout, req.Date); err != nil {
+		return nil, xerr.NewErrCodeMsg(xerr.RequestParamError, "date must be in YYYY-MM-DD format")
+	}
+	if !req.Closed && len(req.Shifts) == 0 {
+		return nil, xerr.NewErrCodeMsg(xerr.RequestParamError, "shifts are required unless closed")
+	}
+
+	day := &dao.MerchantSpecialDay{
+		MerchantId: merchant.Id,
+		Date:       req.Date,
+		Closed:     req.Closed,
+		Shifts:     []dao.MerchantShift{},
+		Note:       nullableString(req.Note),
+	}
+	if !req.Closed {
+		shifts := make([]openhours.Shift, 0, len(req.Shifts))
+		for _, item := range req.Shifts {
+			shift, err := openhours.ParseShift(item.OpenTime, item.CloseTime)
+			if err != nil {
+				return nil, xerr.NewErrCodeMsg(xerr.RequestParamError, err.Error())
+			}
+			shifts = append(shifts, shift)
+			day.Shifts = append(day.Shifts, dao.MerchantShift{OpenTime: item.OpenTime, CloseTime: item.CloseTime})
+		}
+		if err := openhours.ValidateShifts(shifts); err != nil {
+			return nil, xerr.NewErrCodeMsg(xerr.RequestParamError, err.Error())
+		}
+	}
+
+	tx := session.Begin()
+	if err := tx.Error; err != nil {
+		return nil, errors.Wrap(err, ">>SaveStoreSpecialDay, transaction begin fail")
+	}
+	defer tx.Rollback()
+
+	if err := dao.DeleteMerchantSpecialDay(tx, merchant.Id, req.Date); err != nil {
+		return nil, errors.Wrap(err, ">>SaveStoreSpecialDay, dao.DeleteMerchantSpecialDay fail")
+	}
+	if err := day.Save(tx); err != nil {
+		return nil, errors.Wrap(err, ">>SaveStoreSpecialDay, day.Save fail")
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.Wrap(err, ">>SaveStoreSpecialDay, transaction commit fail")
+	}
+
+	resp := newStoreSpecialDayResp(day)
+	return &resp, nil
+}
+
+func DeleteStoreSpecialDay(session *gorm.DB, merchant *dao.Merchant, date string) error {
+	if err := dao.DeleteMerchantSpecialDay(session, merchant.Id, date); err != nil {
+		return errors.Wrap(err, ">>DeleteStoreSpecialDay, dao.DeleteMerchantSpecialDay fail")
+	}
+	return nil
+}
+
+// PauseStore closes the store for a while whatever the hours, e.g. when the kitchen is too busy
+func PauseStore(session *gorm.DB, merchant *dao.Merchant, req *dto.PauseStoreReq) (*dto.StoreHoursResp, error) {
+	until := time.Now().Add(time.Duration(req.Minutes) * time.Minute)
+	reason := nullableString(req.Reason)
+	if err := dao.UpdateMerchantPause(session, merchant.Id, &until, reason); err != nil {
+		return nil, errors.Wrap(err, ">>PauseStore, dao.UpdateMerchantPause fail")
+	}
+
+	merchant.PausedUntil, merchant.PauseReason = &until, reason
+	return GetStoreHours(session, merchant)
+}
+
+func ResumeStore(session *gorm.DB, merchant *dao.Merchant) (*dto.StoreHoursResp, error) {
+	if err := dao.UpdateMerchantPause(session, merchant.Id, nil, nil); err != nil {
+		return nil, errors.Wrap(err, ">>ResumeStore, dao.UpdateMerchantPause fail")
+	}
+
+	merchant.PausedUntil, merchant.PauseReason = nil, nil
+	return GetStoreHours(session, merchant)
+}
+
+// EnsureStoreOpenAt is checked when an order is placed or scheduled, at is the delivery time
+func EnsureStoreOpenAt(session *gorm.DB, merchantId int64, at time.Time) error {
+	merchant, err := getMerchant(session, merchantId)
+	if err != nil {
+		return errors.Wrap(err, ">>EnsureStoreOpenAt ")
+	}
+
+	schedules, err := loadStoreSchedules(session, []dao.Merchant{*merchant}, at)
+	if err != nil {
+		return errors.Wrap(err, ">>EnsureStoreOpenAt ")
+	}
+	if schedule := schedules[merchant.Id]; schedule == nil || !schedule.IsOpenAt(at) {
+		return xerr.NewErrCode(xerr.DeliveryTimeOutOperatingHours)
+	}
+	return nil
+}
+
+// loadStoreSchedules builds the schedules of the merchants with the special days around the time,
+// a merchant with an invalid timezone or shift is left out and considered closed
+func loadStoreSchedules(session *gorm.DB, merchants []dao.Merchant, at time.Time) (map[int64]*openhours.Schedule, error) {
+	schedules := make(map[int64]*openhours.Schedule, len(merchants))
+	if len(merchants) == 0 {
+		return schedules, nil
+	}
+
+	merchantIds := make([]int64, 0, len(merchants))
+	for _, merchant := range merchants {
+		merchantIds = append(merchantIds, merchant.Id)
+	}
+
+	hours, err := dao.ListMerchantHours(session, merchantIds)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>loadStoreSchedules, dao.ListMerchantHours fail")
+	}
+
+	//a day of margin on both sides covers every timezone and the overnight shifts
+	from := at.AddDate(0, 0, -2).Format(openhours.DateLayout)
+	to := at.AddDate(0, 0, 16).Format(openhours.DateLayout)
+	days, err := dao.ListMerchantSpecialDays(session, merchantIds, from, to)
+	if err != nil {
+		return nil, errors.Wrap(err, ">>loadStoreSchedules, dao.ListMerchantSpecialDays fail")
+	}
+
+	for _, merchant := range merchants {
+		schedule, err := openhours.NewSchedule(merchant.Timezone)
+		if err != nil {
+			logrus.Errorf("merchant %d schedule fail: %s", merchant.Id, err)
+			continue
+		}
+		schedule.PausedUntil = merchant.PausedUntil
+		schedules[merchant.Id] = schedule
+	}
+
+	for _, hour := range hours {
+		schedule := schedules[hour.MerchantId]
+		if schedule == nil {
+			continue
+		}
+		shift, err := openhours.ParseShift(hour.OpenTime, hour.CloseTime)
+		if err != nil {
+			logrus.Errorf("merchant %d hour %d fail: %s", hour.MerchantId, hour.Id, err)
+			continue
+		}
+		weekday := time.Weekday(hour.Weekday)
+		schedule.Weekly[weekday] = append(schedule.Weekly[weekday], shift)
+	}
+
+	for _, day := range days {
+		schedule := schedules[day.MerchantId]
+		if schedule == nil {
+			continue
+		}
+		special := openhours.SpecialDay{Closed: day.Closed}
+		for _, item := range day.Shifts {
+			shift, err := openhours.ParseShift(item.OpenTime, item.CloseTime)
+			if err != nil {
+				logrus.Errorf("merchant %d special day %s fail: %s", day.MerchantId, day.Date, err)
+				continue
+			}
+			special.Shifts = append(special.Shifts, shift)
+		}
+		schedule.Special[day.Date] = special
+	}
+
+	return schedules, nil
+}
+
+// storeOpening whether the store is open now, and when it opens next
+func storeOpening(schedule *openhours.Schedule, now time.Time) (bool, int64) {
+	if schedule == nil {
+		return false, 0
+	}
+	next, ok := schedule.NextOpening(now)
+	if !ok {
+		return false, 0
+	}
+	return schedule.IsOpenAt(now), next.Unix()
+}
+
+func storeLocation(merchant *dao.Merchant) *time.Location {
+	return loadLocation(merchant.Timezone)
+}
+
+// loadLocation falls back to the local timezone, the timezone is validated when it is saved
+func loadLocation(timezone string) *time.Location {
+	if location, err := time.LoadLocation(timezone); err == nil {
+		return location
+	}
+	return time.Local
+}
+
+// defaultStoreTimezone the timezone of the market set up for carbon
+func defaultStoreTimezone() string {
+	return carbon.Now().Location()
+}
+
+func newStoreSpecialDayResp(day *dao.MerchantSpecialDay) dto.StoreSpecialDayResp {
+	resp := dto.StoreSpecialDayResp{
+		Date:   day.Date,
+		Closed: day.Closed,
+		Shifts: make([]dto.StoreShift, 0, len(day.Shifts)),
+		Note:   day.Note,
+	}
+	for _, shift := range day.Shifts {
+		resp.Shifts = append(resp.Shifts, dto.StoreShift{OpenTime: shift.OpenTime, CloseTime: shift.CloseTime})
+	}
+	return resp
+}
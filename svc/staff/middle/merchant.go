@@ -0,0 +1,66 @@
+package middle
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/tespkg/bytes-be/svc/staff/model/dao"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+)
+
+// WithMerchant loads the merchant of the token user, it must be used after WithToken
+func WithMerchant(db *gorm.DB) gin.HandlerFunc {
+	EmptyClaimsErr := errors.New("empty claims")
+	EmptyMerchantErr := errors.New("empty merchant")
+
+	return func(c *gin.Context) {
+		claims, ok := getClaims(c)
+		if !ok {
+			http.Error(c.Writer, EmptyClaimsErr.Error(), http.StatusUnauthorized)
+			c.Abort()
+			return
+		}
+
+		userId, _ := strconv.ParseInt(claims.Subject, 10, 64)
+		merchant, err := dao.GetMerchantByUserId(db, userId)
+		if err != nil {
+			http.Error(c.Writer, EmptyMerchantErr.Error(), http.StatusInternalServerError)
+			c.Abort()
+			return
+		}
+		if merchant == nil || merchant.Id == 0 {
+			http.Error(c.Writer, EmptyMerchantErr.Error(), http.StatusForbidden)
+			c.Abort()
+			return
+		}
+
+		c.Set("merchant", merchant)
+		c.Next()
+	}
+}
+
+// RequireMerchantStatus lets the request pass if the merchant is in any of the statuses, it must be used after WithMerchant
+func RequireMerchantStatus(statuses ...string) gin.HandlerFunc {
+	ForbiddenErr := errors.New("the merchant status doesn't allow the request")
+
+	return func(c *gin.Context) {
+		merchant, ok := c.Get("merchant")
+		if !ok {
+			http.Error(c.Writer, ForbiddenErr.Error(), http.StatusForbidden)
+			c.Abort()
+			return
+		}
+
+		status := merchant.(*dao.Merchant).Status
+		for _, s := range statuses {
+			if s == status {
+				c.Next()
+				return
+			}
+		}
+
+		http.Error(c.Writer, ForbiddenErr.Error(), http.StatusForbidden)
+		c.Abort()
+	}
+}
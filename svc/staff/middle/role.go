@@ -0,0 +1,95 @@
+package middle
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/tespkg/bytes-be/common/token"
+	"github.com/tespkg/bytes-be/svc/staff/model/dao"
+	"net/http"
+)
+
+const (
+	PermissionCustomerProfile  = "customer:profile"
+	PermissionMerchantManage   = "merchant:manage"
+	PermissionMerchantReview   = "merchant:review"
+	PermissionIngredientReview = "ingredient:review"
+	PermissionCatalogWrite     = "catalog:write"
+	PermissionDelivery         = "delivery:handle"
+)
+
+// rolePermissions admin is granted every permission
+var rolePermissions = map[string][]string{
+	dao.RoleCustomer: {PermissionCustomerProfile},
+	dao.RoleMerchant: {PermissionMerchantManage, PermissionCatalogWrite},
+	dao.RoleDriver:   {PermissionDelivery},
+}
+
+// RequireRole lets the request pass if the token carries any of the roles, it must be used after WithToken.
+// Roles are read from the claims, so no database query is needed.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	ForbiddenErr := errors.New("permission denied")
+
+	return func(c *gin.Context) {
+		claims, ok := getClaims(c)
+		if !ok {
+			http.Error(c.Writer, ForbiddenErr.Error(), http.StatusUnauthorized)
+			c.Abort()
+			return
+		}
+
+		for _, role := range roles {
+			if claims.HasRole(role) {
+				c.Next()
+				return
+			}
+		}
+
+		http.Error(c.Writer, ForbiddenErr.Error(), http.StatusForbidden)
+		c.Abort()
+	}
+}
+
+// RequirePermission lets the request pass if any role of the token grants the permission
+func RequirePermission(permission string) gin.HandlerFunc {
+	ForbiddenErr := errors.New("permission denied")
+
+	return func(c *gin.Context) {
+		claims, ok := getClaims(c)
+		if !ok {
+			http.Error(c.Writer, ForbiddenErr.Error(), http.StatusUnauthorized)
+			c.Abort()
+			return
+		}
+
+		if HasPermission(claims.Roles, permission) {
+			c.Next()
+			return
+		}
+
+		http.Error(c.Writer, ForbiddenErr.Error(), http.StatusForbidden)
+		c.Abort()
+	}
+}
+
+func HasPermission(roles []string, permission string) bool {
+	for _, role := range roles {
+		if role == dao.RoleAdmin {
+			return true
+		}
+		for _, p := range rolePermissions[role] {
+			if p == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func getClaims(c *gin.Context) (*token.UserClaims, bool) {
+	claims, ok := c.Get("claims")
+	if !ok {
+		return nil, false
+	}
+	userClaims, ok := claims.(*token.UserClaims)
+	return userClaims, ok
+}
@@ -3,6 +3,7 @@ package middle
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"github.com/tespkg/bytes-be/common/token"
 	"github.com/tespkg/bytes-be/svc/staff/model/dao"
 	"gorm.io/gorm"
@@ -32,6 +33,9 @@ func WithToken(db *gorm.DB) gin.HandlerFunc {
 			c.Set("token", accessToken)
 			claims := ctx.Value(token.ClaimsCtx).(*token.UserClaims)
 			c.Set("claims", claims)
+			if err = token.TouchSession(ctx, db, claims); err != nil {
+				logrus.Errorf("touch session fail: %s", err)
+			}
 			c.Next()
 		}
 	}
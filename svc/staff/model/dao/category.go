@@ -0,0 +1,62 @@
+package dao
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+type Category struct {
+	Id          int64           `json:"id" gorm:"column:id"`
+	MerchantId  int64           `json:"merchantId" gorm:"column:merchant_id"`
+	Name        string          `json:"name" gorm:"column:name"`
+	Description *string         `json:"description" gorm:"column:description"`
+	SortOrder   int             `json:"sortOrder" gorm:"column:sort_order"`
+	IsAvailable bool            `json:"isAvailable" gorm:"column:is_available"`
+	CreatedAt   *time.Time      `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt   *time.Time      `json:"updatedAt" gorm:"column:updated_at"`
+	DeletedAt   *gorm.DeletedAt `json:"deletedAt" gorm:"column:deleted_at"`
+}
+
+func (c *Category) TableName() string {
+	return "categories"
+}
+
+func (c *Category) Save(db *gorm.DB) error {
+	return db.Save(c).Error
+}
+
+func ListCategories(db *gorm.DB, merchantId int64, onlyAvailable bool) ([]Category, error) {
+	query := db.Model(&Category{}).Where("merchant_id = ?", merchantId)
+	if onlyAvailable {
+		query = query.Where("is_available")
+	}
+
+	var categories []Category
+	if err := query.Order("sort_order ASC, id ASC").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+func GetCategory(db *gorm.DB, merchantId, id int64) (*Category, error) {
+	var category *Category
+	if err := db.Model(&Category{}).
+		Where("id = ? AND merchant_id = ?", id, merchantId).
+		First(&category).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// DeleteCategory the products of the category are kept without category, it must be called in a transaction
+func DeleteCategory(db *gorm.DB, merchantId, id int64) error {
+	if err := db.Model(&Product{}).
+		Where("category_id = ? AND merchant_id = ?", id, merchantId).
+		Updates(map[string]interface{}{"category_id": nil, "updated_at": time.Now()}).Error; err != nil {
+		return err
+	}
+	return db.Where("id = ? AND merchant_id = ?", id, merchantId).Delete(&Category{}).Error
+}
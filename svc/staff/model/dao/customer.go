@@ -1,6 +1,7 @@
 package dao
 
 import (
+	"github.com/pkg/errors"
 	"gorm.io/gorm"
 	"time"
 )
@@ -32,3 +33,19 @@ func (c *Customer) TableName() string {
 func (c *Customer) Save(db *gorm.DB) error {
 	return db.Save(c).Error
 }
+
+func GetCustomerByUserId(db *gorm.DB, userId int64) (*Customer, error) {
+	var customer *Customer
+	if err := db.Model(&Customer{}).Where("user_id = ?", userId).
+		First(&customer).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return customer, nil
+}
+
+// UpdateCustomer updates the given columns only
+func UpdateCustomer(db *gorm.DB, id int64, updates map[string]interface{}) error {
+	updates["updated_at"] = time.Now()
+	return db.Model(&Customer{}).Where("id = ?", id).Updates(updates).Error
+}
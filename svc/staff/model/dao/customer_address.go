@@ -1,10 +1,17 @@
 package dao
 
 import (
+	"github.com/pkg/errors"
 	"gorm.io/gorm"
 	"time"
 )
 
+const (
+	CustomerAddressLabelHome  = "home"
+	CustomerAddressLabelWork  = "work"
+	CustomerAddressLabelOther = "other"
+)
+
 type CustomerAddress struct {
 	Id         int64           `json:"id" gorm:"column:id"`
 	CustomerId int64           `json:"customerId" gorm:"column:customer_id"`
@@ -16,6 +23,8 @@ type CustomerAddress struct {
 	Address    string          `json:"address" gorm:"column:address"`
 	Longitude  float64         `json:"longitude" gorm:"column:longitude"`
 	Latitude   float64         `json:"latitude" gorm:"column:latitude"`
+	Label      *string         `json:"label" gorm:"column:label"`
+	IsDefault  bool            `json:"isDefault" gorm:"column:is_default"`
 	CreatedAt  *time.Time      `json:"createdAt" gorm:"column:created_at"`
 	UpdatedAt  *time.Time      `json:"updatedAt" gorm:"column:updated_at"`
 	DeletedAt  *gorm.DeletedAt `json:"deletedAt" gorm:"column:deleted_at"`
@@ -28,3 +37,63 @@ func (c *CustomerAddress) TableName() string {
 func (c *CustomerAddress) Save(db *gorm.DB) error {
 	return db.Save(c).Error
 }
+
+// ListCustomerAddresses the default address goes first
+func ListCustomerAddresses(db *gorm.DB, customerId int64) ([]CustomerAddress, error) {
+	var addresses []CustomerAddress
+	if err := db.Model(&CustomerAddress{}).
+		Where("customer_id = ? AND deleted_at IS NULL", customerId).
+		Order("is_default DESC, id DESC").
+		Find(&addresses).Error; err != nil {
+		return nil, err
+	}
+
+	return addresses, nil
+}
+
+func GetCustomerAddress(db *gorm.DB, customerId, id int64) (*CustomerAddress, error) {
+	var address *CustomerAddress
+	if err := db.Model(&CustomerAddress{}).
+		Where("id = ? AND customer_id = ? AND deleted_at IS NULL", id, customerId).
+		First(&address).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return address, nil
+}
+
+func CountCustomerAddresses(db *gorm.DB, customerId int64) (int64, error) {
+	var count int64
+	if err := db.Model(&CustomerAddress{}).
+		Where("customer_id = ? AND deleted_at IS NULL", customerId).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// ClearDefaultCustomerAddress must run before setting a new default, only one default is allowed per customer
+func ClearDefaultCustomerAddress(db *gorm.DB, customerId int64) error {
+	return db.Model(&CustomerAddress{}).
+		Where("customer_id = ? AND is_default AND deleted_at IS NULL", customerId).
+		Updates(map[string]interface{}{"is_default": false, "updated_at": time.Now()}).Error
+}
+
+// SetDefaultCustomerAddressToLatest makes the latest address the default one, used when the default is deleted
+func SetDefaultCustomerAddressToLatest(db *gorm.DB, customerId int64) error {
+	latest := db.Model(&CustomerAddress{}).Select("id").
+		Where("customer_id = ? AND deleted_at IS NULL", customerId).
+		Order("id DESC").Limit(1)
+
+	return db.Model(&CustomerAddress{}).
+		Where("id = (?)", latest).
+		Updates(map[string]interface{}{"is_default": true, "updated_at": time.Now()}).Error
+}
+
+func DeleteCustomerAddress(db *gorm.DB, customerId, id int64) error {
+	now := time.Now()
+	return db.Model(&CustomerAddress{}).
+		Where("id = ? AND customer_id = ? AND deleted_at IS NULL", id, customerId).
+		Updates(map[string]interface{}{"is_default": false, "deleted_at": now, "updated_at": now}).Error
+}
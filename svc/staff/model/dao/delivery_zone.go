@@ -0,0 +1,80 @@
+package dao
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+// DeliveryZone an area the merchant delivers to, with the bounding box of the area to prefilter the points.
+// Prices are in minor units of the currency
+type DeliveryZone struct {
+	Id          int64           `json:"id" gorm:"column:id"`
+	MerchantId  int64           `json:"merchantId" gorm:"column:merchant_id"`
+	Name        string          `json:"name" gorm:"column:name"`
+	Area        json.RawMessage `json:"area" gorm:"column:area;serializer:json"`
+	MinLon      float64         `json:"minLon" gorm:"column:min_lon"`
+	MinLat      float64         `json:"minLat" gorm:"column:min_lat"`
+	MaxLon      float64         `json:"maxLon" gorm:"column:max_lon"`
+	MaxLat      float64         `json:"maxLat" gorm:"column:max_lat"`
+	MinOrder    int64           `json:"minOrder" gorm:"column:min_order"`
+	DeliveryFee int64           `json:"deliveryFee" gorm:"column:delivery_fee"`
+	IsActive    bool            `json:"isActive" gorm:"column:is_active"`
+	CreatedAt   *time.Time      `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt   *time.Time      `json:"updatedAt" gorm:"column:updated_at"`
+	DeletedAt   *gorm.DeletedAt `json:"deletedAt" gorm:"column:deleted_at"`
+}
+
+func (d *DeliveryZone) TableName() string {
+	return "delivery_zones"
+}
+
+func (d *DeliveryZone) Save(db *gorm.DB) error {
+	return db.Save(d).Error
+}
+
+func ListDeliveryZones(db *gorm.DB, merchantId int64) ([]DeliveryZone, error) {
+	var zones []DeliveryZone
+	if err := db.Model(&DeliveryZone{}).
+		Where("merchant_id = ?", merchantId).
+		Order("id ASC").
+		Find(&zones).Error; err != nil {
+		return nil, err
+	}
+
+	return zones, nil
+}
+
+// ListDeliveryZonesAround the active zones whose bounding box has the point, merchantIds empty means all the merchants.
+// The point still has to be tested against the area
+func ListDeliveryZonesAround(db *gorm.DB, merchantIds []int64, lon, lat float64) ([]DeliveryZone, error) {
+	query := db.Model(&DeliveryZone{}).
+		Where("is_active").
+		Where("min_lat <= ? AND max_lat >= ? AND min_lon <= ? AND max_lon >= ?", lat, lat, lon, lon)
+	if len(merchantIds) > 0 {
+		query = query.Where("merchant_id IN ?", merchantIds)
+	}
+
+	var zones []DeliveryZone
+	if err := query.Order("id ASC").Find(&zones).Error; err != nil {
+		return nil, err
+	}
+
+	return zones, nil
+}
+
+func GetDeliveryZone(db *gorm.DB, merchantId, id int64) (*DeliveryZone, error) {
+	var zone *DeliveryZone
+	if err := db.Model(&DeliveryZone{}).
+		Where("id = ? AND merchant_id = ?", id, merchantId).
+		First(&zone).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return zone, nil
+}
+
+func DeleteDeliveryZone(db *gorm.DB, merchantId, id int64) error {
+	return db.Where("id = ? AND merchant_id = ?", id, merchantId).Delete(&DeliveryZone{}).Error
+}
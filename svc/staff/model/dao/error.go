@@ -0,0 +1,15 @@
+package dao
+
+import (
+	"errors"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// uniqueViolation the postgres error code of a duplicated key
+const uniqueViolation = "23505"
+
+// IsUniqueViolation reports whether the insert or update hit a unique index
+func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
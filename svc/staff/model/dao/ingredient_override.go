@@ -0,0 +1,71 @@
+package dao
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+// IngredientOverride the list confirmed for a dish name, the ingredient analysis returns it instead of the model one.
+// Dish is the name in lower case with single spaces, Source is merchant or admin.
+// MerchantId is the merchant the override applies to, nil for an admin review which applies to every merchant
+type IngredientOverride struct {
+	Id          int64      `json:"id" gorm:"column:id"`
+	Dish        string     `json:"dish" gorm:"column:dish"`
+	MerchantId  *int64     `json:"merchantId" gorm:"column:merchant_id"`
+	Ingredients []string   `json:"ingredients" gorm:"column:ingredients;serializer:json"`
+	Source      string     `json:"source" gorm:"column:source"`
+	ProductId   *int64     `json:"productId" gorm:"column:product_id"`
+	OperatorId  int64      `json:"operatorId" gorm:"column:operator_id"`
+	CreatedAt   *time.Time `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt   *time.Time `json:"updatedAt" gorm:"column:updated_at"`
+}
+
+func (i *IngredientOverride) TableName() string {
+	return "ingredient_overrides"
+}
+
+func (i *IngredientOverride) Save(db *gorm.DB) error {
+	return db.Save(i).Error
+}
+
+// GetIngredientOverride the override of the dish for the merchant, nil merchantId for the one of the admins
+func GetIngredientOverride(db *gorm.DB, dish string, merchantId *int64) (*IngredientOverride, error) {
+	query := db.Model(&IngredientOverride{}).Where("dish = ?", dish)
+	if merchantId != nil {
+		query = query.Where("merchant_id = ?", *merchantId)
+	} else {
+		query = query.Where("merchant_id IS NULL")
+	}
+
+	var override IngredientOverride
+	if err := query.First(&override).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &override, nil
+}
+
+// ListIngredientOverrides the overrides of the dishes for the merchant and the ones of the admins
+func ListIngredientOverrides(db *gorm.DB, merchantId int64, dishes []string) ([]IngredientOverride, error) {
+	if len(dishes) == 0 {
+		return nil, nil
+	}
+
+	var overrides []IngredientOverride
+	if err := db.Model(&IngredientOverride{}).
+		Where("dish IN ?", dishes).
+		Where("merchant_id IS NULL OR merchant_id = ?", merchantId).
+		Find(&overrides).Error; err != nil {
+		return nil, err
+	}
+
+	return overrides, nil
+}
+
+func DeleteIngredientOverride(db *gorm.DB, dish string, merchantId int64) error {
+	return db.Where("dish = ? AND merchant_id = ?", dish, merchantId).Delete(&IngredientOverride{}).Error
+}
@@ -0,0 +1,112 @@
+package dao
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// Inventory the stock of a variant, or of a product without variants. An item without inventory isn't tracked.
+type Inventory struct {
+	Id                int64           `json:"id" gorm:"column:id"`
+	MerchantId        int64           `json:"merchantId" gorm:"column:merchant_id"`
+	ProductId         int64           `json:"productId" gorm:"column:product_id"`
+	VariantId         *int64          `json:"variantId" gorm:"column:variant_id"`
+	Quantity          int             `json:"quantity" gorm:"column:quantity"`
+	Reserved          int             `json:"reserved" gorm:"column:reserved"`
+	LowStockThreshold int             `json:"lowStockThreshold" gorm:"column:low_stock_threshold"`
+	LowStockNotified  bool            `json:"lowStockNotified" gorm:"column:low_stock_notified"`
+	DailyQuantity     *int            `json:"dailyQuantity" gorm:"column:daily_quantity"`
+	StockDate         *string         `json:"stockDate" gorm:"column:stock_date"`
+	CreatedAt         *time.Time      `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt         *time.Time      `json:"updatedAt" gorm:"column:updated_at"`
+	DeletedAt         *gorm.DeletedAt `json:"deletedAt" gorm:"column:deleted_at"`
+}
+
+func (i *Inventory) TableName() string {
+	return "inventories"
+}
+
+func (i *Inventory) Save(db *gorm.DB) error {
+	return db.Save(i).Error
+}
+
+// Available the stock which can still be reserved
+func (i *Inventory) Available() int {
+	return i.Quantity - i.Reserved
+}
+
+func ListInventories(db *gorm.DB, merchantId int64) ([]Inventory, error) {
+	var inventories []Inventory
+	if err := db.Model(&Inventory{}).
+		Where("merchant_id = ?", merchantId).
+		Order("product_id ASC, variant_id ASC NULLS FIRST").
+		Find(&inventories).Error; err != nil {
+		return nil, err
+	}
+
+	return inventories, nil
+}
+
+func ListInventoriesByProducts(db *gorm.DB, productIds []int64) ([]Inventory, error) {
+	var inventories []Inventory
+	if err := db.Model(&Inventory{}).
+		Where("product_id IN ?", productIds).
+		Find(&inventories).Error; err != nil {
+		return nil, err
+	}
+
+	return inventories, nil
+}
+
+// GetInventoryForUpdate locks the row, it must be called in a transaction
+func GetInventoryForUpdate(db *gorm.DB, merchantId, id int64) (*Inventory, error) {
+	var inventory *Inventory
+	if err := db.Model(&Inventory{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND merchant_id = ?", id, merchantId).
+		First(&inventory).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return inventory, nil
+}
+
+// GetInventoryByItemForUpdate locks the row, it must be called in a transaction
+func GetInventoryByItemForUpdate(db *gorm.DB, productId int64, variantId *int64) (*Inventory, error) {
+	query := db.Model(&Inventory{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("product_id = ?", productId)
+	if variantId == nil {
+		query = query.Where("variant_id IS NULL")
+	} else {
+		query = query.Where("variant_id = ?", *variantId)
+	}
+
+	var inventory *Inventory
+	if err := query.First(&inventory).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return inventory, nil
+}
+
+// ListInventoriesForUpdate locks the rows in the id order, so concurrent transactions can't deadlock,
+// it must be called in a transaction
+func ListInventoriesForUpdate(db *gorm.DB, ids []int64) ([]Inventory, error) {
+	var inventories []Inventory
+	if err := db.Model(&Inventory{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", ids).
+		Order("id ASC").
+		Find(&inventories).Error; err != nil {
+		return nil, err
+	}
+
+	return inventories, nil
+}
+
+func DeleteInventory(db *gorm.DB, merchantId, id int64) error {
+	return db.Where("id = ? AND merchant_id = ?", id, merchantId).Delete(&Inventory{}).Error
+}
@@ -0,0 +1,121 @@
+package dao
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+const (
+	MerchantStatusPending   = "pending"
+	MerchantStatusApproved  = "approved"
+	MerchantStatusRejected  = "rejected"
+	MerchantStatusSuspended = "suspended"
+)
+
+type Merchant struct {
+	Id           int64           `json:"id" gorm:"column:id"`
+	UserId       int64           `json:"userId" gorm:"column:user_id"`
+	LegalName    string          `json:"legalName" gorm:"column:legal_name"`
+	TradeName    *string         `json:"tradeName" gorm:"column:trade_name"`
+	CrNumber     string          `json:"crNumber" gorm:"column:cr_number"`
+	ContactName  string          `json:"contactName" gorm:"column:contact_name"`
+	ContactPhone string          `json:"contactPhone" gorm:"column:contact_phone"`
+	ContactEmail *string         `json:"contactEmail" gorm:"column:contact_email"`
+	Logo         *string         `json:"logo" gorm:"column:logo"`
+	Address      string          `json:"address" gorm:"column:address"`
+	Longitude    float64         `json:"longitude" gorm:"column:longitude"`
+	Latitude     float64         `json:"latitude" gorm:"column:latitude"`
+	Status       string          `json:"status" gorm:"column:status"`
+	StatusReason *string         `json:"statusReason" gorm:"column:status_reason"`
+	ApprovedAt   *time.Time      `json:"approvedAt" gorm:"column:approved_at"`
+	Timezone     string          `json:"timezone" gorm:"column:timezone"`
+	PausedUntil  *time.Time      `json:"pausedUntil" gorm:"column:paused_until"`
+	PauseReason  *string         `json:"pauseReason" gorm:"column:pause_reason"`
+	CreatedAt    *time.Time      `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt    *time.Time      `json:"updatedAt" gorm:"column:updated_at"`
+	DeletedAt    *gorm.DeletedAt `json:"deletedAt" gorm:"column:deleted_at"`
+}
+
+func (m *Merchant) TableName() string {
+	return "merchants"
+}
+
+func (m *Merchant) Save(db *gorm.DB) error {
+	return db.Save(m).Error
+}
+
+func GetMerchantById(db *gorm.DB, id int64) (*Merchant, error) {
+	var merchant *Merchant
+	if err := db.Model(&Merchant{}).Where("id = ?", id).
+		First(&merchant).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return merchant, nil
+}
+
+// GetMerchantByIdForUpdate locks the row, it must be called in a transaction
+func GetMerchantByIdForUpdate(db *gorm.DB, id int64) (*Merchant, error) {
+	var merchant *Merchant
+	if err := db.Model(&Merchant{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).
+		First(&merchant).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return merchant, nil
+}
+
+func GetMerchantByUserId(db *gorm.DB, userId int64) (*Merchant, error) {
+	var merchant *Merchant
+	if err := db.Model(&Merchant{}).Where("user_id = ?", userId).
+		First(&merchant).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return merchant, nil
+}
+
+func GetMerchantByCrNumber(db *gorm.DB, crNumber string) (*Merchant, error) {
+	var merchant *Merchant
+	if err := db.Model(&Merchant{}).Where("cr_number = ?", crNumber).
+		First(&merchant).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return merchant, nil
+}
+
+// ListMerchants the oldest first so the review queue is handled in order, empty status lists every merchant
+func ListMerchants(db *gorm.DB, status string, offset, limit int) ([]Merchant, int64, error) {
+	query := db.Model(&Merchant{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var merchants []Merchant
+	if err := query.Order("id ASC").Offset(offset).Limit(limit).Find(&merchants).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return merchants, total, nil
+}
+
+// UpdateMerchantPause nil until resumes the merchant
+func UpdateMerchantPause(db *gorm.DB, id int64, until *time.Time, reason *string) error {
+	return db.Model(&Merchant{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"paused_until": until, "pause_reason": reason, "updated_at": time.Now()}).Error
+}
+
+func UpdateMerchantTimezone(db *gorm.DB, id int64, timezone string) error {
+	return db.Model(&Merchant{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"timezone": timezone, "updated_at": time.Now()}).Error
+}
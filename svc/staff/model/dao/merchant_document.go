@@ -0,0 +1,64 @@
+package dao
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+const (
+	MerchantDocumentCrCertificate = "cr_certificate"
+	MerchantDocumentOwnerId       = "owner_id"
+	MerchantDocumentFoodLicense   = "food_license"
+	MerchantDocumentOther         = "other"
+)
+
+type MerchantDocument struct {
+	Id          int64           `json:"id" gorm:"column:id"`
+	MerchantId  int64           `json:"merchantId" gorm:"column:merchant_id"`
+	Type        string          `json:"type" gorm:"column:type"`
+	FileName    string          `json:"fileName" gorm:"column:file_name"`
+	ContentType string          `json:"contentType" gorm:"column:content_type"`
+	Size        int64           `json:"size" gorm:"column:size"`
+	Content     []byte          `json:"-" gorm:"column:content"`
+	CreatedAt   *time.Time      `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt   *time.Time      `json:"updatedAt" gorm:"column:updated_at"`
+	DeletedAt   *gorm.DeletedAt `json:"deletedAt" gorm:"column:deleted_at"`
+}
+
+func (m *MerchantDocument) TableName() string {
+	return "merchant_documents"
+}
+
+func (m *MerchantDocument) Save(db *gorm.DB) error {
+	return db.Save(m).Error
+}
+
+// ListMerchantDocuments the content is not loaded
+func ListMerchantDocuments(db *gorm.DB, merchantId int64) ([]MerchantDocument, error) {
+	var documents []MerchantDocument
+	if err := db.Model(&MerchantDocument{}).
+		Omit("content").
+		Where("merchant_id = ?", merchantId).
+		Order("id ASC").
+		Find(&documents).Error; err != nil {
+		return nil, err
+	}
+
+	return documents, nil
+}
+
+func GetMerchantDocument(db *gorm.DB, merchantId, id int64) (*MerchantDocument, error) {
+	var document *MerchantDocument
+	if err := db.Model(&MerchantDocument{}).
+		Where("id = ? AND merchant_id = ?", id, merchantId).
+		First(&document).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return document, nil
+}
+
+func DeleteMerchantDocument(db *gorm.DB, merchantId, id int64) error {
+	return db.Where("id = ? AND merchant_id = ?", id, merchantId).Delete(&MerchantDocument{}).Error
+}
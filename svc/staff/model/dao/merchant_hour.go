@@ -0,0 +1,49 @@
+package dao
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// MerchantHour a weekly shift, a merchant may have several shifts per weekday
+type MerchantHour struct {
+	Id         int64           `json:"id" gorm:"column:id"`
+	MerchantId int64           `json:"merchantId" gorm:"column:merchant_id"`
+	Weekday    int             `json:"weekday" gorm:"column:weekday"`
+	OpenTime   string          `json:"openTime" gorm:"column:open_time"`
+	CloseTime  string          `json:"closeTime" gorm:"column:close_time"`
+	CreatedAt  *time.Time      `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt  *time.Time      `json:"updatedAt" gorm:"column:updated_at"`
+	DeletedAt  *gorm.DeletedAt `json:"deletedAt" gorm:"column:deleted_at"`
+}
+
+func (m *MerchantHour) TableName() string {
+	return "merchant_hours"
+}
+
+func (m *MerchantHour) Save(db *gorm.DB) error {
+	return db.Save(m).Error
+}
+
+func ListMerchantHours(db *gorm.DB, merchantIds []int64) ([]MerchantHour, error) {
+	var hours []MerchantHour
+	if err := db.Model(&MerchantHour{}).
+		Where("merchant_id IN ?", merchantIds).
+		Order("merchant_id ASC, weekday ASC, open_time ASC").
+		Find(&hours).Error; err != nil {
+		return nil, err
+	}
+
+	return hours, nil
+}
+
+// ReplaceMerchantHours drops the current week and saves the new one, it must be called in a transaction
+func ReplaceMerchantHours(db *gorm.DB, merchantId int64, hours []MerchantHour) error {
+	if err := db.Where("merchant_id = ?", merchantId).Delete(&MerchantHour{}).Error; err != nil {
+		return err
+	}
+	if len(hours) == 0 {
+		return nil
+	}
+	return db.Create(&hours).Error
+}
@@ -0,0 +1,61 @@
+package dao
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+type MerchantShift struct {
+	OpenTime  string `json:"openTime"`
+	CloseTime string `json:"closeTime"`
+}
+
+// MerchantSpecialDay overrides the weekly hours of a date, e.g. a holiday closed or with shorter hours
+type MerchantSpecialDay struct {
+	Id         int64           `json:"id" gorm:"column:id"`
+	MerchantId int64           `json:"merchantId" gorm:"column:merchant_id"`
+	Date       string          `json:"date" gorm:"column:date"`
+	Closed     bool            `json:"closed" gorm:"column:closed"`
+	Shifts     []MerchantShift `json:"shifts" gorm:"column:shifts;serializer:json"`
+	Note       *string         `json:"note" gorm:"column:note"`
+	CreatedAt  *time.Time      `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt  *time.Time      `json:"updatedAt" gorm:"column:updated_at"`
+	DeletedAt  *gorm.DeletedAt `json:"deletedAt" gorm:"column:deleted_at"`
+}
+
+func (m *MerchantSpecialDay) TableName() string {
+	return "merchant_special_days"
+}
+
+func (m *MerchantSpecialDay) Save(db *gorm.DB) error {
+	return db.Save(m).Error
+}
+
+// ListMerchantSpecialDays the dates between from and to, both included
+func ListMerchantSpecialDays(db *gorm.DB, merchantIds []int64, from, to string) ([]MerchantSpecialDay, error) {
+	var days []MerchantSpecialDay
+	if err := db.Model(&MerchantSpecialDay{}).
+		Where("merchant_id IN ? AND date >= ? AND date <= ?", merchantIds, from, to).
+		Order("merchant_id ASC, date ASC").
+		Find(&days).Error; err != nil {
+		return nil, err
+	}
+
+	return days, nil
+}
+
+func GetMerchantSpecialDayByDate(db *gorm.DB, merchantId int64, date string) (*MerchantSpecialDay, error) {
+	var day *MerchantSpecialDay
+	if err := db.Model(&MerchantSpecialDay{}).
+		Where("merchant_id = ? AND date = ?", merchantId, date).
+		First(&day).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return day, nil
+}
+
+func DeleteMerchantSpecialDay(db *gorm.DB, merchantId int64, date string) error {
+	return db.Where("merchant_id = ? AND date = ?", merchantId, date).Delete(&MerchantSpecialDay{}).Error
+}
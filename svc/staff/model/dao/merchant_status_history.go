@@ -0,0 +1,36 @@
+package dao
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+type MerchantStatusHistory struct {
+	Id         int64      `json:"id" gorm:"column:id"`
+	MerchantId int64      `json:"merchantId" gorm:"column:merchant_id"`
+	FromStatus *string    `json:"fromStatus" gorm:"column:from_status"`
+	ToStatus   string     `json:"toStatus" gorm:"column:to_status"`
+	Reason     *string    `json:"reason" gorm:"column:reason"`
+	OperatorId int64      `json:"operatorId" gorm:"column:operator_id"`
+	CreatedAt  *time.Time `json:"createdAt" gorm:"column:created_at"`
+}
+
+func (m *MerchantStatusHistory) TableName() string {
+	return "merchant_status_histories"
+}
+
+func (m *MerchantStatusHistory) Save(db *gorm.DB) error {
+	return db.Save(m).Error
+}
+
+func ListMerchantStatusHistories(db *gorm.DB, merchantId int64) ([]MerchantStatusHistory, error) {
+	var histories []MerchantStatusHistory
+	if err := db.Model(&MerchantStatusHistory{}).
+		Where("merchant_id = ?", merchantId).
+		Order("id ASC").
+		Find(&histories).Error; err != nil {
+		return nil, err
+	}
+
+	return histories, nil
+}
@@ -0,0 +1,86 @@
+package dao
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// ModifierGroup a choice on the product, e.g. extras of a dish, between MinSelect and MaxSelect options are picked
+type ModifierGroup struct {
+	Id        int64           `json:"id" gorm:"column:id"`
+	ProductId int64           `json:"productId" gorm:"column:product_id"`
+	Name      string          `json:"name" gorm:"column:name"`
+	MinSelect int             `json:"minSelect" gorm:"column:min_select"`
+	MaxSelect int             `json:"maxSelect" gorm:"column:max_select"`
+	SortOrder int             `json:"sortOrder" gorm:"column:sort_order"`
+	CreatedAt *time.Time      `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt *time.Time      `json:"updatedAt" gorm:"column:updated_at"`
+	DeletedAt *gorm.DeletedAt `json:"deletedAt" gorm:"column:deleted_at"`
+
+	Options []ModifierOption `json:"options" gorm:"foreignKey:group_id;"`
+}
+
+func (m *ModifierGroup) TableName() string {
+	return "modifier_groups"
+}
+
+// Save the options are saved apart
+func (m *ModifierGroup) Save(db *gorm.DB) error {
+	return db.Omit("Options").Save(m).Error
+}
+
+// Option nil if the option is not of the group, the options must be preloaded
+func (m *ModifierGroup) Option(id int64) *ModifierOption {
+	for i := range m.Options {
+		if m.Options[i].Id == id {
+			return &m.Options[i]
+		}
+	}
+	return nil
+}
+
+type ModifierOption struct {
+	Id          int64           `json:"id" gorm:"column:id"`
+	GroupId     int64           `json:"groupId" gorm:"column:group_id"`
+	Name        string          `json:"name" gorm:"column:name"`
+	Price       int64           `json:"price" gorm:"column:price"`
+	SortOrder   int             `json:"sortOrder" gorm:"column:sort_order"`
+	IsAvailable bool            `json:"isAvailable" gorm:"column:is_available"`
+	CreatedAt   *time.Time      `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt   *time.Time      `json:"updatedAt" gorm:"column:updated_at"`
+	DeletedAt   *gorm.DeletedAt `json:"deletedAt" gorm:"column:deleted_at"`
+}
+
+func (m *ModifierOption) TableName() string {
+	return "modifier_options"
+}
+
+func (m *ModifierOption) Save(db *gorm.DB) error {
+	return db.Save(m).Error
+}
+
+// DeleteModifierGroups deletes the groups of the product except the kept ones, with their options
+func DeleteModifierGroups(db *gorm.DB, productId int64, keepIds []int64) error {
+	groups := db.Model(&ModifierGroup{}).Select("id").Where("product_id = ?", productId)
+	if len(keepIds) > 0 {
+		groups = groups.Where("id NOT IN ?", keepIds)
+	}
+	if err := db.Where("group_id IN (?)", groups).Delete(&ModifierOption{}).Error; err != nil {
+		return err
+	}
+
+	query := db.Where("product_id = ?", productId)
+	if len(keepIds) > 0 {
+		query = query.Where("id NOT IN ?", keepIds)
+	}
+	return query.Delete(&ModifierGroup{}).Error
+}
+
+// DeleteModifierOptions deletes the options of the group except the kept ones
+func DeleteModifierOptions(db *gorm.DB, groupId int64, keepIds []int64) error {
+	query := db.Where("group_id = ?", groupId)
+	if len(keepIds) > 0 {
+		query = query.Where("id NOT IN ?", keepIds)
+	}
+	return query.Delete(&ModifierOption{}).Error
+}
@@ -0,0 +1,113 @@
+package dao
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+const (
+	OrderStatusPlaced    = "placed"
+	OrderStatusAccepted  = "accepted"
+	OrderStatusCancelled = "cancelled"
+)
+
+// OrderItem a line of the order, the unit price of the variant and options is kept as priced when placed
+type OrderItem struct {
+	ProductId int64   `json:"productId"`
+	VariantId int64   `json:"variantId"`
+	OptionIds []int64 `json:"optionIds"`
+	Name      string  `json:"name"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice int64   `json:"unitPrice"`
+}
+
+// Order the total is in minor units of the currency, the stock of the tracked items is reserved by the order ref
+type Order struct {
+	Id           int64           `json:"id" gorm:"column:id"`
+	OrderRef     string          `json:"orderRef" gorm:"column:order_ref"`
+	UserId       int64           `json:"userId" gorm:"column:user_id"`
+	MerchantId   int64           `json:"merchantId" gorm:"column:merchant_id"`
+	Status       string          `json:"status" gorm:"column:status"`
+	Items        []OrderItem     `json:"items" gorm:"column:items;serializer:json"`
+	Total        int64           `json:"total" gorm:"column:total"`
+	Currency     string          `json:"currency" gorm:"column:currency"`
+	ExpiresAt    time.Time       `json:"expiresAt" gorm:"column:expires_at"`
+	CancelReason *string         `json:"cancelReason" gorm:"column:cancel_reason"`
+	CreatedAt    *time.Time      `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt    *time.Time      `json:"updatedAt" gorm:"column:updated_at"`
+	DeletedAt    *gorm.DeletedAt `json:"deletedAt" gorm:"column:deleted_at"`
+}
+
+func (o *Order) TableName() string {
+	return "orders"
+}
+
+func (o *Order) Save(db *gorm.DB) error {
+	return db.Save(o).Error
+}
+
+// ListOrders of a customer or of a merchant, a zero id isn't filtered on
+func ListOrders(db *gorm.DB, userId, merchantId int64, status string, offset, limit int) ([]Order, int64, error) {
+	query := db.Model(&Order{})
+	if userId > 0 {
+		query = query.Where("user_id = ?", userId)
+	}
+	if merchantId > 0 {
+		query = query.Where("merchant_id = ?", merchantId)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var orders []Order
+	if err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&orders).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return orders, total, nil
+}
+
+func GetOrder(db *gorm.DB, orderRef string) (*Order, error) {
+	var order *Order
+	if err := db.Model(&Order{}).
+		Where("order_ref = ?", orderRef).
+		First(&order).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// GetOrderForUpdate locks the row, it must be called in a transaction
+func GetOrderForUpdate(db *gorm.DB, orderRef string) (*Order, error) {
+	var order *Order
+	if err := db.Model(&Order{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("order_ref = ?", orderRef).
+		First(&order).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// ListExpiredOrderRefs the placed orders not accepted in time
+func ListExpiredOrderRefs(db *gorm.DB, now time.Time, limit int) ([]string, error) {
+	var orderRefs []string
+	if err := db.Model(&Order{}).
+		Where("status = ? AND expires_at < ?", OrderStatusPlaced, now).
+		Order("id ASC").
+		Limit(limit).
+		Pluck("order_ref", &orderRefs).Error; err != nil {
+		return nil, err
+	}
+
+	return orderRefs, nil
+}
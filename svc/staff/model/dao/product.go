@@ -0,0 +1,128 @@
+package dao
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+const (
+	ProductKindFood     = "food"
+	ProductKindClothing = "clothing"
+
+	CurrencyOMR = "OMR"
+)
+
+// Product prices are in minor units of the currency, e.g. 1500 baisa is 1.500 OMR
+type Product struct {
+	Id          int64           `json:"id" gorm:"column:id"`
+	MerchantId  int64           `json:"merchantId" gorm:"column:merchant_id"`
+	CategoryId  *int64          `json:"categoryId" gorm:"column:category_id"`
+	Sku         *string         `json:"sku" gorm:"column:sku"`
+	Kind        string          `json:"kind" gorm:"column:kind"`
+	Name        string          `json:"name" gorm:"column:name"`
+	Description *string         `json:"description" gorm:"column:description"`
+	Image       *string         `json:"image" gorm:"column:image"`
+	Price       int64           `json:"price" gorm:"column:price"`
+	Currency    string          `json:"currency" gorm:"column:currency"`
+	SortOrder   int             `json:"sortOrder" gorm:"column:sort_order"`
+	IsAvailable bool            `json:"isAvailable" gorm:"column:is_available"`
+	CreatedAt   *time.Time      `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt   *time.Time      `json:"updatedAt" gorm:"column:updated_at"`
+	DeletedAt   *gorm.DeletedAt `json:"deletedAt" gorm:"column:deleted_at"`
+
+	Variants []ProductVariant `json:"variants" gorm:"foreignKey:product_id;"`
+
+	ModifierGroups []ModifierGroup `json:"modifierGroups" gorm:"foreignKey:product_id;"`
+}
+
+func (p *Product) TableName() string {
+	return "products"
+}
+
+// Save the variants and modifier groups are saved apart
+func (p *Product) Save(db *gorm.DB) error {
+	return db.Omit("Variants", "ModifierGroups").Save(p).Error
+}
+
+// Variant nil if the variant is not of the product, the variants must be preloaded
+func (p *Product) Variant(id int64) *ProductVariant {
+	for i := range p.Variants {
+		if p.Variants[i].Id == id {
+			return &p.Variants[i]
+		}
+	}
+	return nil
+}
+
+func preloadProduct(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Variants", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sort_order ASC, id ASC")
+		}).
+		Preload("ModifierGroups", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sort_order ASC, id ASC")
+		}).
+		Preload("ModifierGroups.Options", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sort_order ASC, id ASC")
+		})
+}
+
+// ListProducts categoryId 0 means all the categories
+func ListProducts(db *gorm.DB, merchantId, categoryId int64, onlyAvailable bool) ([]Product, error) {
+	query := db.Model(&Product{}).Where("merchant_id = ?", merchantId)
+	if categoryId > 0 {
+		query = query.Where("category_id = ?", categoryId)
+	}
+	if onlyAvailable {
+		query = query.Where("is_available")
+	}
+
+	var products []Product
+	if err := preloadProduct(query).
+		Order("sort_order ASC, id ASC").
+		Find(&products).Error; err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+func GetProduct(db *gorm.DB, merchantId, id int64) (*Product, error) {
+	var product *Product
+	if err := preloadProduct(db.Model(&Product{})).
+		Where("id = ? AND merchant_id = ?", id, merchantId).
+		First(&product).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+// GetProductBySku the sku is unique per merchant
+func GetProductBySku(db *gorm.DB, merchantId int64, sku string) (*Product, error) {
+	var product *Product
+	if err := preloadProduct(db.Model(&Product{})).
+		Where("merchant_id = ? AND sku = ?", merchantId, sku).
+		First(&product).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+func UpdateProductAvailability(db *gorm.DB, merchantId, id int64, isAvailable bool) error {
+	return db.Model(&Product{}).Where("id = ? AND merchant_id = ?", id, merchantId).
+		Updates(map[string]interface{}{"is_available": isAvailable, "updated_at": time.Now()}).Error
+}
+
+// DeleteProduct deletes the product with its variants and modifiers, it must be called in a transaction
+func DeleteProduct(db *gorm.DB, merchantId, id int64) error {
+	if err := DeleteProductVariants(db, id, nil); err != nil {
+		return err
+	}
+	if err := DeleteModifierGroups(db, id, nil); err != nil {
+		return err
+	}
+	return db.Where("id = ? AND merchant_id = ?", id, merchantId).Delete(&Product{}).Error
+}
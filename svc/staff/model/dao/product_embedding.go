@@ -0,0 +1,114 @@
+package dao
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// ProductEmbedding the vector of the name, category and description of a product for the semantic search.
+// The embedding of a deleted product is soft deleted, so the indexes of the other instances drop it on their next sync
+type ProductEmbedding struct {
+	ProductId   int64           `json:"productId" gorm:"column:product_id;primaryKey"`
+	MerchantId  int64           `json:"merchantId" gorm:"column:merchant_id"`
+	Model       string          `json:"model" gorm:"column:model"`
+	ContentHash string          `json:"contentHash" gorm:"column:content_hash"`
+	Embedding   []float32       `json:"embedding" gorm:"column:embedding;serializer:json"`
+	CreatedAt   *time.Time      `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt   *time.Time      `json:"updatedAt" gorm:"column:updated_at"`
+	DeletedAt   *gorm.DeletedAt `json:"deletedAt" gorm:"column:deleted_at"`
+}
+
+func (e *ProductEmbedding) TableName() string {
+	return "product_embeddings"
+}
+
+// Save inserts or replaces the embedding of the product
+func (e *ProductEmbedding) Save(db *gorm.DB) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "product_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"merchant_id", "model", "content_hash", "embedding", "updated_at", "deleted_at"}),
+	}).Create(e).Error
+}
+
+// ProductEmbeddingSource the text of a product to embed
+type ProductEmbeddingSource struct {
+	Id           int64   `gorm:"column:id"`
+	MerchantId   int64   `gorm:"column:merchant_id"`
+	Name         string  `gorm:"column:name"`
+	Description  *string `gorm:"column:description"`
+	CategoryName *string `gorm:"column:category_name"`
+}
+
+// ListProductsToEmbed the products without an embedding of the model, or whose product or category changed after it
+func ListProductsToEmbed(db *gorm.DB, model string, limit int) ([]ProductEmbeddingSource, error) {
+	var sources []ProductEmbeddingSource
+	if err := db.Model(&Product{}).
+		Select("products.id, products.merchant_id, products.name, products.description, categories.name AS category_name").
+		Joins("LEFT JOIN categories ON categories.id = products.category_id AND categories.deleted_at IS NULL").
+		Joins("LEFT JOIN product_embeddings ON product_embeddings.product_id = products.id").
+		Where("product_embeddings.product_id IS NULL OR product_embeddings.deleted_at IS NOT NULL OR "+
+			"product_embeddings.model <> ? OR products.updated_at > product_embeddings.updated_at OR "+
+			"categories.updated_at > product_embeddings.updated_at", model).
+		Order("products.id ASC").
+		Limit(limit).
+		Scan(&sources).Error; err != nil {
+		return nil, err
+	}
+
+	return sources, nil
+}
+
+// GetProductEmbeddings the embeddings by product id
+func GetProductEmbeddings(db *gorm.DB, productIds []int64) (map[int64]*ProductEmbedding, error) {
+	found := make(map[int64]*ProductEmbedding, len(productIds))
+	if len(productIds) == 0 {
+		return found, nil
+	}
+
+	var embeddings []ProductEmbedding
+	if err := db.Model(&ProductEmbedding{}).
+		Where("product_id IN ?", productIds).
+		Find(&embeddings).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range embeddings {
+		found[embeddings[i].ProductId] = &embeddings[i]
+	}
+	return found, nil
+}
+
+// TouchProductEmbeddings marks the embeddings as up to date with their products
+func TouchProductEmbeddings(db *gorm.DB, productIds []int64) error {
+	if len(productIds) == 0 {
+		return nil
+	}
+	return db.Model(&ProductEmbedding{}).
+		Where("product_id IN ?", productIds).
+		Update("updated_at", time.Now()).Error
+}
+
+// DeleteStaleProductEmbeddings soft deletes the embeddings of the deleted products
+func DeleteStaleProductEmbeddings(db *gorm.DB) (int64, error) {
+	now := time.Now()
+	deleted := db.Unscoped().Model(&Product{}).Select("id").Where("deleted_at IS NOT NULL")
+	tx := db.Model(&ProductEmbedding{}).
+		Where("product_id IN (?)", deleted).
+		Updates(map[string]interface{}{"deleted_at": now, "updated_at": now})
+	return tx.RowsAffected, tx.Error
+}
+
+// ScanProductEmbeddings calls fn with the embeddings changed after since in batches, the deleted ones included.
+// A zero since scans all the live embeddings
+func ScanProductEmbeddings(db *gorm.DB, since time.Time, batch int, fn func([]ProductEmbedding) error) error {
+	query := db.Model(&ProductEmbedding{})
+	if !since.IsZero() {
+		query = db.Unscoped().Model(&ProductEmbedding{}).Where("updated_at > ?", since)
+	}
+
+	var embeddings []ProductEmbedding
+	return query.FindInBatches(&embeddings, batch, func(tx *gorm.DB, _ int) error {
+		return fn(embeddings)
+	}).Error
+}
@@ -0,0 +1,139 @@
+package dao
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+// the review status of an ingredient list, a flagged one waits in the queue of the admins.
+// failed is a food without any list after a failed analysis, reconfirm a confirmed list of a renamed food
+const (
+	IngredientStatusGenerated = "generated"
+	IngredientStatusFlagged   = "flagged"
+	IngredientStatusConfirmed = "confirmed"
+	IngredientStatusFailed    = "failed"
+	IngredientStatusReconfirm = "reconfirm"
+)
+
+// IngredientStatusesReviewed the statuses of a list the allergen filters rely on, a flagged one is still in doubt
+var IngredientStatusesReviewed = []string{IngredientStatusGenerated, IngredientStatusConfirmed, IngredientStatusReconfirm}
+
+// IngredientSourceStub the source of a list of the stub provider, it is never used by the allergen filters
+const IngredientSourceStub = "stub"
+
+// ProductIngredient the ingredients and allergens of a food, generated by the ingredient analysis until the merchant
+// or an admin confirms them. Source is who wrote the list, llm, stub, merchant or admin.
+// AnalysisError is the last failure of the analysis, cleared by the next success
+type ProductIngredient struct {
+	ProductId        int64      `json:"productId" gorm:"column:product_id;primaryKey"`
+	MerchantId       int64      `json:"merchantId" gorm:"column:merchant_id"`
+	Ingredients      []string   `json:"ingredients" gorm:"column:ingredients;serializer:json"`
+	Allergens        []string   `json:"allergens" gorm:"column:allergens;serializer:json"`
+	Status           string     `json:"status" gorm:"column:status"`
+	Source           string     `json:"source" gorm:"column:source"`
+	Confidence       *float64   `json:"confidence" gorm:"column:confidence"`
+	AnalyzedName     *string    `json:"analyzedName" gorm:"column:analyzed_name"`
+	AnalysisError    *string    `json:"analysisError" gorm:"column:analysis_error"`
+	AnalysisFailedAt *time.Time `json:"analysisFailedAt" gorm:"column:analysis_failed_at"`
+	ConfirmedBy      *int64     `json:"confirmedBy" gorm:"column:confirmed_by"`
+	ConfirmedAt      *time.Time `json:"confirmedAt" gorm:"column:confirmed_at"`
+	CreatedAt        *time.Time `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt        *time.Time `json:"updatedAt" gorm:"column:updated_at"`
+}
+
+func (p *ProductIngredient) TableName() string {
+	return "product_ingredients"
+}
+
+func (p *ProductIngredient) Save(db *gorm.DB) error {
+	return db.Save(p).Error
+}
+
+func GetProductIngredient(db *gorm.DB, productId int64) (*ProductIngredient, error) {
+	var ingredient ProductIngredient
+	if err := db.Model(&ProductIngredient{}).
+		Where("product_id = ?", productId).
+		First(&ingredient).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &ingredient, nil
+}
+
+// GetProductIngredients the ingredients by product id, the products not analyzed are missing
+func GetProductIngredients(db *gorm.DB, productIds []int64) (map[int64]*ProductIngredient, error) {
+	found := make(map[int64]*ProductIngredient, len(productIds))
+	if len(productIds) == 0 {
+		return found, nil
+	}
+
+	var ingredients []ProductIngredient
+	if err := db.Model(&ProductIngredient{}).
+		Where("product_id IN ?", productIds).
+		Find(&ingredients).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range ingredients {
+		found[ingredients[i].ProductId] = &ingredients[i]
+	}
+	return found, nil
+}
+
+// ListProductsToAnalyze the foods never analyzed, or renamed since their list was generated. A confirmed list is kept,
+// a food whose analysis failed after retryBefore waits for the next round
+func ListProductsToAnalyze(db *gorm.DB, retryBefore time.Time, limit int) ([]Product, error) {
+	var products []Product
+	if err := db.Model(&Product{}).
+		Joins("LEFT JOIN product_ingredients ON product_ingredients.product_id = products.id").
+		Where("products.kind = ?", ProductKindFood).
+		Where("product_ingredients.product_id IS NULL OR (product_ingredients.status IN ? AND "+
+			"product_ingredients.analyzed_name IS DISTINCT FROM products.name)",
+			[]string{IngredientStatusGenerated, IngredientStatusFlagged, IngredientStatusFailed}).
+		Where("product_ingredients.analysis_failed_at IS NULL OR product_ingredients.analysis_failed_at < ?", retryBefore).
+		Order("products.id ASC").
+		Limit(limit).
+		Find(&products).Error; err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+// ProductIngredientReview an ingredient list with its product and store for the admins
+type ProductIngredientReview struct {
+	ProductIngredient
+	ProductName string `gorm:"column:product_name"`
+	StoreName   string `gorm:"column:store_name"`
+}
+
+// ListProductIngredientReviews the lists of the live products by status, the oldest change first
+func ListProductIngredientReviews(db *gorm.DB, status string, offset, limit int) ([]ProductIngredientReview, int64, error) {
+	query := db.Table("product_ingredients").
+		Joins("JOIN products ON products.id = product_ingredients.product_id").
+		Joins("JOIN merchants ON merchants.id = product_ingredients.merchant_id").
+		Where("products.deleted_at IS NULL AND merchants.deleted_at IS NULL").
+		Where("product_ingredients.status = ?", status)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var reviews []ProductIngredientReview
+	if err := query.
+		Select("product_ingredients.*, products.name AS product_name, " +
+			"COALESCE(merchants.trade_name, merchants.legal_name) AS store_name").
+		Order("product_ingredients.updated_at ASC, product_ingredients.product_id ASC").
+		Offset(offset).
+		Limit(limit).
+		Scan(&reviews).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return reviews, total, nil
+}
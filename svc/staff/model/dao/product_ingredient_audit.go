@@ -0,0 +1,52 @@
+package dao
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// the actions recorded in the audit trail of the ingredient lists
+const (
+	IngredientActionAnalyze      = "analyze"
+	IngredientActionUpdate       = "update"
+	IngredientActionConfirm      = "confirm"
+	IngredientActionAdminUpdate  = "admin_update"
+	IngredientActionAdminApprove = "admin_approve"
+	IngredientActionRename       = "rename"
+)
+
+// ProductIngredientAudit the list of a product after an action, OperatorId is nil for the analysis and the rename
+type ProductIngredientAudit struct {
+	Id          int64      `json:"id" gorm:"column:id"`
+	ProductId   int64      `json:"productId" gorm:"column:product_id"`
+	MerchantId  int64      `json:"merchantId" gorm:"column:merchant_id"`
+	Action      string     `json:"action" gorm:"column:action"`
+	Source      string     `json:"source" gorm:"column:source"`
+	Status      string     `json:"status" gorm:"column:status"`
+	Ingredients []string   `json:"ingredients" gorm:"column:ingredients;serializer:json"`
+	Allergens   []string   `json:"allergens" gorm:"column:allergens;serializer:json"`
+	Confidence  *float64   `json:"confidence" gorm:"column:confidence"`
+	OperatorId  *int64     `json:"operatorId" gorm:"column:operator_id"`
+	Note        *string    `json:"note" gorm:"column:note"`
+	CreatedAt   *time.Time `json:"createdAt" gorm:"column:created_at"`
+}
+
+func (p *ProductIngredientAudit) TableName() string {
+	return "product_ingredient_audits"
+}
+
+func (p *ProductIngredientAudit) Save(db *gorm.DB) error {
+	return db.Save(p).Error
+}
+
+func ListProductIngredientAudits(db *gorm.DB, productId int64) ([]ProductIngredientAudit, error) {
+	var audits []ProductIngredientAudit
+	if err := db.Model(&ProductIngredientAudit{}).
+		Where("product_id = ?", productId).
+		Order("id ASC").
+		Find(&audits).Error; err != nil {
+		return nil, err
+	}
+
+	return audits, nil
+}
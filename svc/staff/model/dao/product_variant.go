@@ -0,0 +1,52 @@
+package dao
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+// ProductVariant a SKU of the product, e.g. a size and color of a shirt
+type ProductVariant struct {
+	Id          int64             `json:"id" gorm:"column:id"`
+	MerchantId  int64             `json:"merchantId" gorm:"column:merchant_id"`
+	ProductId   int64             `json:"productId" gorm:"column:product_id"`
+	Sku         string            `json:"sku" gorm:"column:sku"`
+	Name        string            `json:"name" gorm:"column:name"`
+	Attributes  map[string]string `json:"attributes" gorm:"column:attributes;serializer:json"`
+	Price       *int64            `json:"price" gorm:"column:price"`
+	SortOrder   int               `json:"sortOrder" gorm:"column:sort_order"`
+	IsAvailable bool              `json:"isAvailable" gorm:"column:is_available"`
+	CreatedAt   *time.Time        `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt   *time.Time        `json:"updatedAt" gorm:"column:updated_at"`
+	DeletedAt   *gorm.DeletedAt   `json:"deletedAt" gorm:"column:deleted_at"`
+}
+
+func (p *ProductVariant) TableName() string {
+	return "product_variants"
+}
+
+func (p *ProductVariant) Save(db *gorm.DB) error {
+	return db.Save(p).Error
+}
+
+// GetProductVariantBySku the sku is unique per merchant
+func GetProductVariantBySku(db *gorm.DB, merchantId int64, sku string) (*ProductVariant, error) {
+	var variant *ProductVariant
+	if err := db.Model(&ProductVariant{}).
+		Where("merchant_id = ? AND sku = ?", merchantId, sku).
+		First(&variant).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return variant, nil
+}
+
+// DeleteProductVariants deletes the variants of the product except the kept ones
+func DeleteProductVariants(db *gorm.DB, productId int64, keepIds []int64) error {
+	query := db.Where("product_id = ?", productId)
+	if len(keepIds) > 0 {
+		query = query.Where("id NOT IN ?", keepIds)
+	}
+	return query.Delete(&ProductVariant{}).Error
+}
@@ -0,0 +1,106 @@
+package dao
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type RefreshToken struct {
+	Id            int64      `json:"id" gorm:"column:id"`
+	UserId        int64      `json:"userId" gorm:"column:user_id"`
+	FamilyId      string     `json:"familyId" gorm:"column:family_id"`
+	TokenHash     string     `json:"-" gorm:"column:token_hash"`
+	AccessJti     string     `json:"accessJti" gorm:"column:access_jti"`
+	Platform      string     `json:"platform" gorm:"column:platform"`
+	Imei          string     `json:"imei" gorm:"column:imei"`
+	ClientVersion string     `json:"clientVersion" gorm:"column:client_version"`
+	Model         string     `json:"model" gorm:"column:model"`
+	SystemVersion string     `json:"systemVersion" gorm:"column:system_version"`
+	ExpiresAt     time.Time  `json:"expiresAt" gorm:"column:expires_at"`
+	RevokedAt     *time.Time `json:"revokedAt" gorm:"column:revoked_at"`
+	ReplacedById  *int64     `json:"replacedById" gorm:"column:replaced_by_id"`
+	LastSeenAt    *time.Time `json:"lastSeenAt" gorm:"column:last_seen_at"`
+	CreatedAt     *time.Time `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt     *time.Time `json:"updatedAt" gorm:"column:updated_at"`
+}
+
+func (r *RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+func (r *RefreshToken) Save(db *gorm.DB) error {
+	return db.Save(r).Error
+}
+
+// GetRefreshTokenByHashForUpdate locks the row, it must be called in a transaction
+func GetRefreshTokenByHashForUpdate(db *gorm.DB, tokenHash string) (*RefreshToken, error) {
+	var refreshToken *RefreshToken
+	if err := db.Model(&RefreshToken{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ?", tokenHash).
+		First(&refreshToken).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return refreshToken, nil
+}
+
+// RevokeRefreshTokenFamily revokes the active tokens of a family and returns them
+func RevokeRefreshTokenFamily(db *gorm.DB, familyId string, revokedAt time.Time) ([]RefreshToken, error) {
+	var refreshTokens []RefreshToken
+	if err := db.Model(&refreshTokens).
+		Clauses(clause.Returning{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyId).
+		Updates(map[string]interface{}{"revoked_at": revokedAt, "updated_at": revokedAt}).Error; err != nil {
+		return nil, err
+	}
+
+	return refreshTokens, nil
+}
+
+// ListActiveRefreshTokensByUserId every family has one active token, so it is a list of sessions
+func ListActiveRefreshTokensByUserId(db *gorm.DB, userId int64) ([]RefreshToken, error) {
+	var refreshTokens []RefreshToken
+	if err := db.Model(&RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > now()", userId).
+		Order("id DESC").
+		Find(&refreshTokens).Error; err != nil {
+		return nil, err
+	}
+
+	return refreshTokens, nil
+}
+
+func GetActiveRefreshTokenByFamilyId(db *gorm.DB, familyId string) (*RefreshToken, error) {
+	var refreshToken *RefreshToken
+	if err := db.Model(&RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyId).
+		First(&refreshToken).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return refreshToken, nil
+}
+
+// RevokeRefreshTokensByUserId revokes the active tokens of all families of the user except the kept one
+func RevokeRefreshTokensByUserId(db *gorm.DB, userId int64, keepFamilyId string, revokedAt time.Time) ([]RefreshToken, error) {
+	var refreshTokens []RefreshToken
+	if err := db.Model(&refreshTokens).
+		Clauses(clause.Returning{}).
+		Where("user_id = ? AND family_id <> ? AND revoked_at IS NULL", userId, keepFamilyId).
+		Updates(map[string]interface{}{"revoked_at": revokedAt, "updated_at": revokedAt}).Error; err != nil {
+		return nil, err
+	}
+
+	return refreshTokens, nil
+}
+
+// TouchRefreshTokenFamily records the last seen time of a session, at most once per interval
+func TouchRefreshTokenFamily(db *gorm.DB, familyId string, seenAt time.Time, interval time.Duration) error {
+	return db.Model(&RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyId).
+		Where("last_seen_at IS NULL OR last_seen_at < ?", seenAt.Add(-interval)).
+		Update("last_seen_at", seenAt).Error
+}
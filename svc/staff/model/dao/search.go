@@ -0,0 +1,176 @@
+package dao
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// the text search configurations, the tsvector columns are kept by the triggers of the migration
+const (
+	SearchConfigEnglish = "english"
+	SearchConfigArabic  = "arabic"
+)
+
+var searchColumns = map[string]string{
+	SearchConfigEnglish: "search_en",
+	SearchConfigArabic:  "search_ar",
+}
+
+// SearchCursor the last hit of the previous page, the hits are ordered by rank then id descending
+type SearchCursor struct {
+	Rank float32
+	Id   int64
+}
+
+// ProductFilter MerchantIds empty means every approved merchant.
+// ExcludeAllergens hides the foods with any of the allergens, and the foods without a reviewed list
+type ProductFilter struct {
+	MerchantIds      []int64
+	CategoryId       int64
+	MinPrice         *int64
+	MaxPrice         *int64
+	ExcludeAllergens []string
+}
+
+// ProductSearch TsQuery is a to_tsquery expression
+type ProductSearch struct {
+	ProductFilter
+	Config  string
+	TsQuery string
+	After   *SearchCursor
+	Limit   int
+}
+
+type ProductSearchHit struct {
+	Id          int64   `gorm:"column:id"`
+	MerchantId  int64   `gorm:"column:merchant_id"`
+	StoreName   string  `gorm:"column:store_name"`
+	CategoryId  *int64  `gorm:"column:category_id"`
+	Kind        string  `gorm:"column:kind"`
+	Name        string  `gorm:"column:name"`
+	Description *string `gorm:"column:description"`
+	Image       *string `gorm:"column:image"`
+	Price       int64   `gorm:"column:price"`
+	Currency    string  `gorm:"column:currency"`
+	Rank        float32 `gorm:"column:rank"`
+}
+
+// StoreSearch TsQuery is a to_tsquery expression, MerchantIds empty means every approved merchant
+type StoreSearch struct {
+	Config      string
+	TsQuery     string
+	MerchantIds []int64
+	After       *SearchCursor
+	Limit       int
+}
+
+type StoreSearchHit struct {
+	Merchant
+	Rank float32 `gorm:"column:rank"`
+}
+
+// SearchProducts the available products of the approved merchants, the products of an unavailable category are hidden
+func SearchProducts(db *gorm.DB, search *ProductSearch) ([]ProductSearchHit, error) {
+	column, ok := searchColumns[search.Config]
+	if !ok {
+		return nil, errors.Errorf("unknown search config %s", search.Config)
+	}
+
+	matched := searchableProducts(db, &search.ProductFilter).
+		Select(productSearchColumns+", ts_rank_cd(products."+column+", to_tsquery(?, ?)) AS rank", search.Config, search.TsQuery).
+		Where("products."+column+" @@ to_tsquery(?, ?)", search.Config, search.TsQuery)
+
+	var hits []ProductSearchHit
+	if err := afterSearchCursor(db.Table("(?) AS hits", matched), search.After).
+		Order("hits.rank DESC, hits.id DESC").
+		Limit(search.Limit).
+		Scan(&hits).Error; err != nil {
+		return nil, err
+	}
+
+	return hits, nil
+}
+
+// ListProductSearchHits the searchable products among the ids, unordered and without rank
+func ListProductSearchHits(db *gorm.DB, ids []int64, filter *ProductFilter) ([]ProductSearchHit, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var hits []ProductSearchHit
+	if err := searchableProducts(db, filter).
+		Select(productSearchColumns).
+		Where("products.id IN ?", ids).
+		Scan(&hits).Error; err != nil {
+		return nil, err
+	}
+
+	return hits, nil
+}
+
+const productSearchColumns = "products.id, products.merchant_id, products.category_id, products.kind, products.name, " +
+	"products.description, products.image, products.price, products.currency, " +
+	"COALESCE(merchants.trade_name, merchants.legal_name) AS store_name"
+
+// searchableProducts the available products of the approved merchants, the products of an unavailable category are hidden
+func searchableProducts(db *gorm.DB, filter *ProductFilter) *gorm.DB {
+	query := db.Table("products").
+		Joins("JOIN merchants ON merchants.id = products.merchant_id").
+		Joins("LEFT JOIN categories ON categories.id = products.category_id").
+		Where("products.deleted_at IS NULL AND products.is_available").
+		Where("merchants.deleted_at IS NULL AND merchants.status = ?", MerchantStatusApproved).
+		Where("(products.category_id IS NULL OR (categories.deleted_at IS NULL AND categories.is_available))")
+	if len(filter.MerchantIds) > 0 {
+		query = query.Where("products.merchant_id IN ?", filter.MerchantIds)
+	}
+	if filter.CategoryId > 0 {
+		query = query.Where("products.category_id = ?", filter.CategoryId)
+	}
+	if filter.MinPrice != nil {
+		query = query.Where("products.price >= ?", *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		query = query.Where("products.price <= ?", *filter.MaxPrice)
+	}
+	if len(filter.ExcludeAllergens) > 0 {
+		query = query.Where("products.kind <> ? OR EXISTS (SELECT 1 FROM product_ingredients "+
+			"WHERE product_ingredients.product_id = products.id AND product_ingredients.status IN ? AND "+
+			"product_ingredients.source <> ? AND jsonb_array_length(product_ingredients.ingredients) > 0 AND NOT EXISTS "+
+			"(SELECT 1 FROM jsonb_array_elements_text(product_ingredients.allergens) AS allergen WHERE allergen IN ?))",
+			ProductKindFood, IngredientStatusesReviewed, IngredientSourceStub, filter.ExcludeAllergens)
+	}
+	return query
+}
+
+func SearchStores(db *gorm.DB, search *StoreSearch) ([]StoreSearchHit, error) {
+	column, ok := searchColumns[search.Config]
+	if !ok {
+		return nil, errors.Errorf("unknown search config %s", search.Config)
+	}
+
+	matched := db.Table("merchants").
+		Select("merchants.*, ts_rank_cd(merchants."+column+", to_tsquery(?, ?)) AS rank", search.Config, search.TsQuery).
+		Where("merchants."+column+" @@ to_tsquery(?, ?)", search.Config, search.TsQuery).
+		Where("merchants.deleted_at IS NULL AND merchants.status = ?", MerchantStatusApproved)
+	if len(search.MerchantIds) > 0 {
+		matched = matched.Where("merchants.id IN ?", search.MerchantIds)
+	}
+
+	var hits []StoreSearchHit
+	if err := afterSearchCursor(db.Table("(?) AS hits", matched), search.After).
+		Order("hits.rank DESC, hits.id DESC").
+		Limit(search.Limit).
+		Scan(&hits).Error; err != nil {
+		return nil, err
+	}
+
+	return hits, nil
+}
+
+// afterSearchCursor the rank is a real in postgres, the float32 of the cursor compares equal to it
+func afterSearchCursor(query *gorm.DB, after *SearchCursor) *gorm.DB {
+	if after == nil {
+		return query
+	}
+	return query.Where("hits.rank < ? OR (hits.rank = ? AND hits.id < ?)", after.Rank, after.Rank, after.Id)
+}
@@ -0,0 +1,80 @@
+package dao
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+const (
+	StockReservationReserved  = "reserved"
+	StockReservationCommitted = "committed"
+	StockReservationReleased  = "released"
+)
+
+// StockReservation holds the stock of an order until it is paid, cancelled or timed out
+type StockReservation struct {
+	Id            int64           `json:"id" gorm:"column:id"`
+	InventoryId   int64           `json:"inventoryId" gorm:"column:inventory_id"`
+	MerchantId    int64           `json:"merchantId" gorm:"column:merchant_id"`
+	OrderRef      string          `json:"orderRef" gorm:"column:order_ref"`
+	Quantity      int             `json:"quantity" gorm:"column:quantity"`
+	Status        string          `json:"status" gorm:"column:status"`
+	ExpiresAt     time.Time       `json:"expiresAt" gorm:"column:expires_at"`
+	ReleaseReason *string         `json:"releaseReason" gorm:"column:release_reason"`
+	CreatedAt     *time.Time      `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt     *time.Time      `json:"updatedAt" gorm:"column:updated_at"`
+	DeletedAt     *gorm.DeletedAt `json:"deletedAt" gorm:"column:deleted_at"`
+}
+
+func (s *StockReservation) TableName() string {
+	return "stock_reservations"
+}
+
+func (s *StockReservation) Save(db *gorm.DB) error {
+	return db.Save(s).Error
+}
+
+func CountStockReservationsByOrder(db *gorm.DB, orderRef, status string) (int64, error) {
+	var count int64
+	if err := db.Model(&StockReservation{}).
+		Where("order_ref = ? AND status = ?", orderRef, status).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// ListStockReservationsByOrderForUpdate locks the rows, it must be called in a transaction
+func ListStockReservationsByOrderForUpdate(db *gorm.DB, orderRef, status string) ([]StockReservation, error) {
+	var reservations []StockReservation
+	if err := db.Model(&StockReservation{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("order_ref = ? AND status = ?", orderRef, status).
+		Order("id ASC").
+		Find(&reservations).Error; err != nil {
+		return nil, err
+	}
+
+	return reservations, nil
+}
+
+// ListExpiredStockReservationOrders the orders holding reservations past their expiry
+func ListExpiredStockReservationOrders(db *gorm.DB, now time.Time, limit int) ([]string, error) {
+	var orderRefs []string
+	if err := db.Model(&StockReservation{}).
+		Where("status = ? AND expires_at < ?", StockReservationReserved, now).
+		Distinct("order_ref").
+		Limit(limit).
+		Pluck("order_ref", &orderRefs).Error; err != nil {
+		return nil, err
+	}
+
+	return orderRefs, nil
+}
+
+func UpdateStockReservationsStatus(db *gorm.DB, ids []int64, status string, reason *string) error {
+	return db.Model(&StockReservation{}).Where("id IN ?", ids).
+		Updates(map[string]interface{}{"status": status, "release_reason": reason, "updated_at": time.Now()}).Error
+}
@@ -3,6 +3,7 @@ package dao
 import (
 	"github.com/pkg/errors"
 	"gorm.io/gorm"
+	"strings"
 	"time"
 )
 
@@ -32,6 +33,15 @@ func (u *User) Save(db *gorm.DB) error {
 	return db.Save(u).Error
 }
 
+// RoleNames the roles must be preloaded
+func (u *User) RoleNames() []string {
+	names := make([]string, 0, len(u.Roles))
+	for _, role := range u.Roles {
+		names = append(names, role.Role)
+	}
+	return names
+}
+
 func GetUserById(db *gorm.DB, id int64) (*User, error) {
 	var user *User
 	if err := db.Model(&User{}).Where("id = ?", id).
@@ -48,7 +58,7 @@ func GetUserByPhoneAndRole(db *gorm.DB, phone, role string) (*User, error) {
 	if err := db.Model(&User{}).
 		Joins("LEFT JOIN user_roles ur ON users.id = ur.user_id").
 		Joins("LEFT JOIN roles r ON ur.role_id = r.id").
-		Where("users.phone = ? AND r.name = ?", phone, role).
+		Where("users.phone = ? AND r.role = ?", phone, role).
 		Distinct("users.*").
 		Preload("Roles").Preload("UserRoles").Preload("Customers").
 		First(&user).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
@@ -63,7 +73,7 @@ func GetUserByEmailAndRole(db *gorm.DB, email, role string) (*User, error) {
 	if err := db.Model(&User{}).
 		Joins("LEFT JOIN user_roles ur ON users.id = ur.user_id").
 		Joins("LEFT JOIN roles r ON ur.role_id = r.id").
-		Where("users.email = ? AND r.name = ?", email, role).
+		Where("lower(users.email) = lower(?) AND r.role = ?", strings.TrimSpace(email), role).
 		Distinct("users.*").
 		Preload("Roles").Preload("UserRoles").Preload("Customers").
 		First(&user).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
@@ -72,3 +82,40 @@ func GetUserByEmailAndRole(db *gorm.DB, email, role string) (*User, error) {
 
 	return user, nil
 }
+
+func UpdateUserPassword(db *gorm.DB, id int64, password []byte) error {
+	return db.Model(&User{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"password": password, "updated_at": time.Now()}).Error
+}
+
+// GetEnabledUserByPhone the phone is unique among enabled users whatever the role
+func GetEnabledUserByPhone(db *gorm.DB, phone string) (*User, error) {
+	var user *User
+	if err := db.Model(&User{}).Where("phone = ? AND is_enabled = true", phone).
+		First(&user).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// GetEnabledUserByEmail the email is unique among enabled users whatever the role, the case is ignored
+func GetEnabledUserByEmail(db *gorm.DB, email string) (*User, error) {
+	var user *User
+	if err := db.Model(&User{}).Where("lower(email) = lower(?) AND is_enabled = true", strings.TrimSpace(email)).
+		First(&user).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func UpdateUserPhone(db *gorm.DB, id int64, phone string) error {
+	return db.Model(&User{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"phone": phone, "updated_at": time.Now()}).Error
+}
+
+func UpdateUserEmail(db *gorm.DB, id int64, email string) error {
+	return db.Model(&User{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"email": email, "updated_at": time.Now()}).Error
+}
@@ -0,0 +1,37 @@
+package dao
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+type UserIdentity struct {
+	Id        int64           `json:"id" gorm:"column:id"`
+	UserId    int64           `json:"userId" gorm:"column:user_id"`
+	Provider  string          `json:"provider" gorm:"column:provider"`
+	Subject   string          `json:"subject" gorm:"column:subject"`
+	Email     *string         `json:"email" gorm:"column:email"`
+	CreatedAt *time.Time      `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt *time.Time      `json:"updatedAt" gorm:"column:updated_at"`
+	DeletedAt *gorm.DeletedAt `json:"deletedAt" gorm:"column:deleted_at"`
+}
+
+func (u *UserIdentity) TableName() string {
+	return "user_identities"
+}
+
+func (u *UserIdentity) Save(db *gorm.DB) error {
+	return db.Save(u).Error
+}
+
+func GetUserIdentity(db *gorm.DB, provider, subject string) (*UserIdentity, error) {
+	var identity *UserIdentity
+	if err := db.Model(&UserIdentity{}).
+		Where("provider = ? AND subject = ? AND deleted_at IS NULL", provider, subject).
+		First(&identity).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return identity, nil
+}
@@ -0,0 +1,34 @@
+package dto
+
+// CustomerAddressReq the structured fields and address are filled by reverse geocoding when they are empty,
+// so the app may only send the pin
+type CustomerAddressReq struct {
+	Label     string   `json:"label"` // home, work, other
+	IsDefault bool     `json:"isDefault"`
+	Country   string   `json:"country"`
+	State     string   `json:"state"`
+	City      string   `json:"city"`
+	Street    string   `json:"street"`
+	ZipCode   string   `json:"zipCode"`
+	Address   string   `json:"address"`
+	Latitude  *float64 `json:"latitude" binding:"required,min=-90,max=90"`
+	Longitude *float64 `json:"longitude" binding:"required,min=-180,max=180"`
+}
+
+type CustomerAddressResp struct {
+	Id        int64   `json:"id"`
+	Label     *string `json:"label"`
+	IsDefault bool    `json:"isDefault"`
+	Country   *string `json:"country"`
+	State     *string `json:"state"`
+	City      *string `json:"city"`
+	Street    *string `json:"street"`
+	ZipCode   *string `json:"zipCode"`
+	Address   string  `json:"address"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+type ListCustomerAddressResp struct {
+	Addresses []CustomerAddressResp `json:"addresses"`
+}
@@ -0,0 +1,12 @@
+package dto
+
+type TokenResp struct {
+	LoginToken            string `json:"loginToken"`
+	LoginTokenExpiresAt   int64  `json:"loginTokenExpiresAt"`
+	RefreshToken          string `json:"refreshToken"`
+	RefreshTokenExpiresAt int64  `json:"refreshTokenExpiresAt"`
+}
+
+type RefreshTokenReq struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
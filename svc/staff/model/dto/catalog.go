@@ -0,0 +1,150 @@
+package dto
+
+type CategoryReq struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	SortOrder   int    `json:"sortOrder"`
+	IsAvailable *bool  `json:"isAvailable"` // defaults to true
+}
+
+type CategoryResp struct {
+	Id          int64   `json:"id"`
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+	SortOrder   int     `json:"sortOrder"`
+	IsAvailable bool    `json:"isAvailable"`
+}
+
+type ListCategoryResp struct {
+	Categories []CategoryResp `json:"categories"`
+}
+
+// ProductVariantReq a variant without id is created, the variants missing in the request are deleted
+type ProductVariantReq struct {
+	Id          int64             `json:"id"`
+	Sku         string            `json:"sku" binding:"required"`
+	Name        string            `json:"name" binding:"required"`
+	Attributes  map[string]string `json:"attributes"`                      // e.g. {"size":"M","color":"red"}
+	Price       *int64            `json:"price" binding:"omitempty,min=0"` // null means the product price
+	SortOrder   int               `json:"sortOrder"`
+	IsAvailable *bool             `json:"isAvailable"` // defaults to true
+}
+
+type ModifierOptionReq struct {
+	Id          int64  `json:"id"`
+	Name        string `json:"name" binding:"required"`
+	Price       int64  `json:"price" binding:"min=0"` // added to the item price
+	SortOrder   int    `json:"sortOrder"`
+	IsAvailable *bool  `json:"isAvailable"` // defaults to true
+}
+
+// ModifierGroupReq a group without id is created, the groups missing in the request are deleted
+type ModifierGroupReq struct {
+	Id        int64               `json:"id"`
+	Name      string              `json:"name" binding:"required"`
+	MinSelect int                 `json:"minSelect" binding:"min=0"`
+	MaxSelect int                 `json:"maxSelect" binding:"min=1"`
+	SortOrder int                 `json:"sortOrder"`
+	Options   []ModifierOptionReq `json:"options" binding:"required,min=1,dive"`
+}
+
+// ProductReq prices are in minor units of the currency, e.g. 1500 is 1.500 OMR
+type ProductReq struct {
+	CategoryId     *int64              `json:"categoryId"`
+	Sku            string              `json:"sku"` // unique per merchant, generated if empty, kept on update if empty
+	Kind           string              `json:"kind" binding:"required,oneof=food clothing"`
+	Name           string              `json:"name" binding:"required"`
+	Description    string              `json:"description"`
+	Image          string              `json:"image"` // key of an uploaded image
+	Price          *int64              `json:"price" binding:"required,min=0"`
+	SortOrder      int                 `json:"sortOrder"`
+	IsAvailable    *bool               `json:"isAvailable"`             // defaults to true
+	Variants       []ProductVariantReq `json:"variants" binding:"dive"` // required for clothing
+	ModifierGroups []ModifierGroupReq  `json:"modifierGroups" binding:"dive"`
+}
+
+type ProductVariantResp struct {
+	Id          int64             `json:"id"`
+	Sku         string            `json:"sku"`
+	Name        string            `json:"name"`
+	Attributes  map[string]string `json:"attributes"`
+	Price       int64             `json:"price"` // the product price if the variant has none
+	SortOrder   int               `json:"sortOrder"`
+	IsAvailable bool              `json:"isAvailable"`
+	Stock       *int              `json:"stock"` // available stock, null if not tracked
+}
+
+type ModifierOptionResp struct {
+	Id          int64  `json:"id"`
+	Name        string `json:"name"`
+	Price       int64  `json:"price"`
+	SortOrder   int    `json:"sortOrder"`
+	IsAvailable bool   `json:"isAvailable"`
+}
+
+type ModifierGroupResp struct {
+	Id        int64                `json:"id"`
+	Name      string               `json:"name"`
+	MinSelect int                  `json:"minSelect"`
+	MaxSelect int                  `json:"maxSelect"`
+	SortOrder int                  `json:"sortOrder"`
+	Options   []ModifierOptionResp `json:"options"`
+}
+
+type ProductResp struct {
+	Id             int64                  `json:"id"`
+	CategoryId     *int64                 `json:"categoryId"`
+	Sku            string                 `json:"sku"`
+	Kind           string                 `json:"kind"`
+	Name           string                 `json:"name"`
+	Description    *string                `json:"description"`
+	Image          *ImageResp             `json:"image"`
+	Price          int64                  `json:"price"`
+	Currency       string                 `json:"currency"`
+	SortOrder      int                    `json:"sortOrder"`
+	IsAvailable    bool                   `json:"isAvailable"`
+	Stock          *int                   `json:"stock"`       // available stock of a product without variants, null if not tracked
+	Ingredients    *ProductIngredientResp `json:"ingredients"` // null if not analyzed yet
+	Variants       []ProductVariantResp   `json:"variants"`
+	ModifierGroups []ModifierGroupResp    `json:"modifierGroups"`
+}
+
+type ListProductReq struct {
+	CategoryId int64 `form:"categoryId"`
+}
+
+type ListProductResp struct {
+	Products []ProductResp `json:"products"`
+}
+
+type ProductAvailabilityReq struct {
+	IsAvailable *bool `json:"isAvailable" binding:"required"`
+}
+
+// ProductSelectionReq a product picked by the customer, with the variant and the modifier options
+type ProductSelectionReq struct {
+	ProductId int64   `json:"productId" binding:"required"`
+	VariantId int64   `json:"variantId"` // required if the product has variants
+	OptionIds []int64 `json:"optionIds"`
+}
+
+// ProductPriceResp the unit price in minor units of the selection, with the options
+type ProductPriceResp struct {
+	ProductId int64   `json:"productId"`
+	VariantId int64   `json:"variantId"`
+	OptionIds []int64 `json:"optionIds"`
+	UnitPrice int64   `json:"unitPrice"`
+	Currency  string  `json:"currency"`
+}
+
+type CatalogCategoryResp struct {
+	CategoryResp
+	Products []ProductResp `json:"products"`
+}
+
+// StoreCatalogResp the available categories and products of a store, the products without category go to others
+type StoreCatalogResp struct {
+	Store      StoreResp             `json:"store"`
+	Categories []CatalogCategoryResp `json:"categories"`
+	Others     []ProductResp         `json:"others"`
+}
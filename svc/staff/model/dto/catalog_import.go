@@ -0,0 +1,48 @@
+package dto
+
+// CatalogRow a row of the catalog file, the rows with the same productSku are one product.
+// A row with sku is a variant of the product, a product without variants has one row without sku.
+// Prices are in minor units of the currency, attributes are "size=M;color=red" in csv
+type CatalogRow struct {
+	Row          int               `json:"-"` // the line in csv or the position from 1 in json, for the error report
+	ProductSku   string            `json:"productSku"`
+	Sku          string            `json:"sku"`
+	Name         string            `json:"name"`
+	Kind         string            `json:"kind"`
+	Category     string            `json:"category"` // created if the merchant has no category of the name
+	Description  string            `json:"description"`
+	Image        string            `json:"image"` // key of an uploaded image or an external url
+	Price        *int64            `json:"price"`
+	VariantName  string            `json:"variantName"`
+	VariantPrice *int64            `json:"variantPrice"` // null means the product price
+	Attributes   map[string]string `json:"attributes"`
+	IsAvailable  *bool             `json:"isAvailable"` // defaults to true, of the variant in a variant row
+}
+
+type CatalogImportReq struct {
+	Format string `form:"format" binding:"omitempty,oneof=csv json"` // defaults to the extension of the file
+	DryRun bool   `form:"dryRun"`
+}
+
+type CatalogExportReq struct {
+	Format string `form:"format" binding:"omitempty,oneof=csv json"` // defaults to csv
+}
+
+type CatalogImportError struct {
+	Row     int    `json:"row"` // 0 if the error is of the whole file
+	Sku     string `json:"sku"`
+	Message string `json:"message"`
+}
+
+// CatalogImportResp nothing is saved if any row fails or in dry run, the counts are what the import does
+type CatalogImportResp struct {
+	DryRun          bool                 `json:"dryRun"`
+	Imported        bool                 `json:"imported"`
+	Rows            int                  `json:"rows"`
+	ProductsCreated int                  `json:"productsCreated"`
+	ProductsUpdated int                  `json:"productsUpdated"`
+	VariantsCreated int                  `json:"variantsCreated"`
+	VariantsUpdated int                  `json:"variantsUpdated"`
+	CategoriesAdded int                  `json:"categoriesAdded"`
+	Errors          []CatalogImportError `json:"errors"`
+}
@@ -1,6 +1,7 @@
 package dto
 
 type SendVerifyCodeReq struct {
-	Phone string `json:"phone"`
-	Email string `json:"email"`
+	Phone   string `json:"phone"`
+	Email   string `json:"email"`
+	Purpose string `json:"purpose"`
 }
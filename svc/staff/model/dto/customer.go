@@ -1,12 +1,73 @@
 package dto
 
+// CustomerRegisterReq Code is sent to the phone, or to the email without a phone. Both are verified when both are given,
+// EmailCode is the one of the email then
 type CustomerRegisterReq struct {
-	Email    string `json:"email"`
-	Phone    string `json:"phone" binding:"required"`
-	Password string `json:"password" binding:"required"`
-	Code     string `json:"code" binding:"required"`
+	Email     string `json:"email"`
+	Phone     string `json:"phone"`
+	Password  string `json:"password" binding:"required"`
+	Code      string `json:"code" binding:"required"`
+	EmailCode string `json:"emailCode"`
 }
 
 type CustomerRegisterResp struct {
-	LoginToken string `json:"loginToken"`
+	TokenResp
+}
+
+type CustomerLoginReq struct {
+	Email         string `json:"email"`
+	Phone         string `json:"phone"`
+	Password      string `json:"password"`
+	Code          string `json:"code"`
+	Platform      string `json:"platform"`
+	Imei          string `json:"imei"`
+	ClientVersion string `json:"clientVersion"`
+	Model         string `json:"model"`
+	SystemVersion string `json:"systemVersion"`
+}
+
+type CustomerLoginResp struct {
+	TokenResp
+}
+
+type CustomerOidcLoginReq struct {
+	Provider      string `json:"provider" binding:"required"`
+	IdToken       string `json:"idToken" binding:"required"`
+	Nonce         string `json:"nonce"`
+	Platform      string `json:"platform"`
+	Imei          string `json:"imei"`
+	ClientVersion string `json:"clientVersion"`
+	Model         string `json:"model"`
+	SystemVersion string `json:"systemVersion"`
+}
+
+type CustomerProfileResp struct {
+	UserId      int64   `json:"userId"`
+	Uuid        string  `json:"uuid"`
+	Email       *string `json:"email"`
+	Phone       *string `json:"phone"`
+	FirstName   *string `json:"firstName"`
+	LastName    *string `json:"lastName"`
+	Gender      *int    `json:"gender"`      // 0:male, 1:female
+	Birthday    *string `json:"birthday"`    // YYYY-MM-DD
+	Nationality *string `json:"nationality"` // ISO 3166-1 alpha-2
+}
+
+// UpdateCustomerProfileReq only the given fields are updated
+type UpdateCustomerProfileReq struct {
+	FirstName   *string `json:"firstName"`
+	LastName    *string `json:"lastName"`
+	Gender      *int    `json:"gender"`
+	Birthday    *string `json:"birthday"`
+	Nationality *string `json:"nationality"`
+}
+
+type ChangePhoneReq struct {
+	Phone string `json:"phone" binding:"required"`
+	Code  string `json:"code" binding:"required"`
+}
+
+type ChangeEmailReq struct {
+	Email string `json:"email" binding:"required,email"`
+	Code  string `json:"code" binding:"required"`
 }
@@ -0,0 +1,48 @@
+package dto
+
+import "encoding/json"
+
+// DeliveryZoneReq prices are in minor units of the currency
+type DeliveryZoneReq struct {
+	Name        string          `json:"name" binding:"required"`
+	Area        json.RawMessage `json:"area" binding:"required" swaggertype:"object"` // GeoJSON Polygon or MultiPolygon, [lon, lat] in WGS84
+	MinOrder    int64           `json:"minOrder" binding:"min=0"`
+	DeliveryFee int64           `json:"deliveryFee" binding:"min=0"`
+	IsActive    *bool           `json:"isActive"` // defaults to true
+}
+
+type DeliveryZoneResp struct {
+	Id          int64           `json:"id"`
+	Name        string          `json:"name"`
+	Area        json.RawMessage `json:"area" swaggertype:"object"`
+	MinOrder    int64           `json:"minOrder"`
+	DeliveryFee int64           `json:"deliveryFee"`
+	Currency    string          `json:"currency"`
+	IsActive    bool            `json:"isActive"`
+}
+
+type ListDeliveryZoneResp struct {
+	Zones []DeliveryZoneResp `json:"zones"`
+}
+
+// DeliveryLocationReq an address of the customer, or a point
+type DeliveryLocationReq struct {
+	AddressId int64    `form:"addressId"`
+	Latitude  *float64 `form:"lat" binding:"omitempty,min=-90,max=90"`
+	Longitude *float64 `form:"lon" binding:"omitempty,min=-180,max=180"`
+}
+
+type DeliveryQuoteReq struct {
+	DeliveryLocationReq
+	Subtotal  *int64 `form:"subtotal" binding:"omitempty,min=0"`  // checked against the minimum order if given
+	DeliverAt *int64 `form:"deliverAt" binding:"omitempty,min=0"` // unix seconds of a scheduled delivery, defaults to now, the store must be open then
+}
+
+// StoreDeliveryResp the zone of the store covering the location
+type StoreDeliveryResp struct {
+	ZoneId      int64  `json:"zoneId"`
+	ZoneName    string `json:"zoneName"`
+	MinOrder    int64  `json:"minOrder"`
+	DeliveryFee int64  `json:"deliveryFee"`
+	Currency    string `json:"currency"`
+}
@@ -0,0 +1,50 @@
+package dto
+
+type GeoSearchReq struct {
+	Query    string `form:"q" binding:"required"`
+	Limit    int    `form:"limit" binding:"omitempty,min=1,max=20"`
+	Bounded  bool   `form:"bounded"` // only return the results inside the market
+	Language string `form:"lang"`
+}
+
+type GeoReverseReq struct {
+	Latitude  *float64 `form:"lat" binding:"required,min=-90,max=90"`
+	Longitude *float64 `form:"lon" binding:"required,min=-180,max=180"`
+	Language  string   `form:"lang"`
+}
+
+// GeoFeatureCollection a GeoJSON FeatureCollection
+type GeoFeatureCollection struct {
+	Type     string       `json:"type"`
+	Features []GeoFeature `json:"features"`
+}
+
+type GeoFeature struct {
+	Type       string      `json:"type"`
+	Geometry   GeoGeometry `json:"geometry"`
+	Bbox       []float64   `json:"bbox,omitempty"`
+	Properties GeoPlace    `json:"properties"`
+}
+
+// GeoGeometry coordinates are [lon, lat]
+type GeoGeometry struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+type GeoPlace struct {
+	PlaceId     int64  `json:"placeId"`
+	OsmType     string `json:"osmType"`
+	OsmId       int64  `json:"osmId"`
+	Category    string `json:"category"`
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+	Country     string `json:"country"`
+	CountryCode string `json:"countryCode"`
+	State       string `json:"state"`
+	City        string `json:"city"`
+	Street      string `json:"street"`
+	HouseNumber string `json:"houseNumber"`
+	ZipCode     string `json:"zipCode"`
+}
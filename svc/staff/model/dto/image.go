@@ -0,0 +1,8 @@
+package dto
+
+// ImageResp key is sent back in the product image or the merchant logo, the urls are for display
+type ImageResp struct {
+	Key        string            `json:"key"`
+	Url        string            `json:"url"`
+	Thumbnails map[string]string `json:"thumbnails"` // small: 160px, medium: 480px
+}
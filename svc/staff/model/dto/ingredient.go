@@ -0,0 +1,76 @@
+package dto
+
+import "time"
+
+// AllergenFilterReq the codes of the 14 major allergens, or aliases like dairy, comma separated or repeated.
+// The foods not analyzed yet are hidden too, as they can't be told free of the allergens
+type AllergenFilterReq struct {
+	ExcludeAllergens []string `form:"excludeAllergens"`
+}
+
+// ProductIngredientReq the list corrected by the merchant or an admin, allergens null detects them from the ingredients
+type ProductIngredientReq struct {
+	Ingredients []string  `json:"ingredients" binding:"required"`
+	Allergens   *[]string `json:"allergens"` // celery, gluten, crustaceans, eggs, fish, lupin, milk, molluscs, mustard, nuts, peanuts, sesame, soya, sulphites
+	Note        string    `json:"note"`      // kept in the audit trail
+}
+
+type ProductIngredientResp struct {
+	Ingredients   []string   `json:"ingredients"`
+	Allergens     []string   `json:"allergens"`
+	Status        string     `json:"status"`        // generated, flagged for an admin review, confirmed, reconfirm after a rename, or failed
+	Source        string     `json:"source"`        // llm, stub, merchant or admin
+	Confidence    *float64   `json:"confidence"`    // reported by the model between 0 and 1
+	AnalysisError *string    `json:"analysisError"` // the last failure of the analysis
+	ConfirmedAt   *time.Time `json:"confirmedAt"`
+}
+
+// IngredientApproveReq the note is kept in the audit trail
+type IngredientApproveReq struct {
+	Note string `json:"note"`
+}
+
+type IngredientAuditResp struct {
+	Action      string   `json:"action"` // analyze, update, confirm, admin_update, admin_approve or rename
+	Source      string   `json:"source"`
+	Status      string   `json:"status"`
+	Ingredients []string `json:"ingredients"`
+	Allergens   []string `json:"allergens"`
+	Confidence  *float64 `json:"confidence"`
+	OperatorId  *int64   `json:"operatorId"` // null for the analysis and the rename
+	Note        *string  `json:"note"`
+	CreatedAt   int64    `json:"createdAt"`
+}
+
+type ListIngredientAuditResp struct {
+	Audits []IngredientAuditResp `json:"audits"`
+}
+
+// ListIngredientReviewReq the flagged lists by default, the low confidence and empty results of the analysis
+type ListIngredientReviewReq struct {
+	Status   string `form:"status" binding:"omitempty,oneof=generated flagged confirmed reconfirm failed"`
+	Page     int    `form:"page" binding:"omitempty,min=1"`
+	PageSize int    `form:"pageSize" binding:"omitempty,min=1,max=100"`
+}
+
+type IngredientReviewResp struct {
+	ProductId   int64  `json:"productId"`
+	ProductName string `json:"productName"`
+	StoreId     int64  `json:"storeId"`
+	StoreName   string `json:"storeName"`
+	ProductIngredientResp
+}
+
+type ListIngredientReviewResp struct {
+	Total   int64                  `json:"total"`
+	Reviews []IngredientReviewResp `json:"reviews"`
+}
+
+type IngredientReviewDetailResp struct {
+	IngredientReviewResp
+	Audits []IngredientAuditResp `json:"audits"`
+}
+
+type StoreCatalogReq struct {
+	AllergenFilterReq
+}
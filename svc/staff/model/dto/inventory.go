@@ -0,0 +1,47 @@
+package dto
+
+// InventoryReq starts or updates the stock tracking of a variant, or of a product without variants
+type InventoryReq struct {
+	ProductId         int64  `json:"productId" binding:"required"`
+	VariantId         *int64 `json:"variantId"` // required if the product has variants
+	Quantity          *int   `json:"quantity" binding:"required,min=0"`
+	LowStockThreshold int    `json:"lowStockThreshold" binding:"min=0"`       // the merchant is notified when the available stock falls to it
+	DailyQuantity     *int   `json:"dailyQuantity" binding:"omitempty,min=0"` // the quantity is reset to it every day
+}
+
+// AdjustInventoryReq adds a restock or removes a loss, without overwriting concurrent reservations
+type AdjustInventoryReq struct {
+	Delta int `json:"delta" binding:"required"`
+}
+
+type InventoryResp struct {
+	Id                int64   `json:"id"`
+	ProductId         int64   `json:"productId"`
+	ProductName       string  `json:"productName"`
+	VariantId         *int64  `json:"variantId"`
+	VariantName       *string `json:"variantName"`
+	Sku               *string `json:"sku"`
+	Quantity          int     `json:"quantity"`
+	Reserved          int     `json:"reserved"`
+	Available         int     `json:"available"`
+	LowStockThreshold int     `json:"lowStockThreshold"`
+	IsLowStock        bool    `json:"isLowStock"`
+	DailyQuantity     *int    `json:"dailyQuantity"`
+}
+
+type ListInventoryResp struct {
+	Inventories []InventoryResp `json:"inventories"`
+}
+
+type StockItem struct {
+	ProductId int64  `json:"productId" binding:"required"`
+	VariantId *int64 `json:"variantId"`
+	Quantity  int    `json:"quantity" binding:"required,min=1"`
+}
+
+// ReserveStockReq the stock of an order, the order ref identifies it when it is paid or cancelled
+type ReserveStockReq struct {
+	MerchantId int64       `json:"merchantId" binding:"required"`
+	OrderRef   string      `json:"orderRef" binding:"required"`
+	Items      []StockItem `json:"items" binding:"required,min=1,dive"`
+}
@@ -0,0 +1,108 @@
+package dto
+
+// MerchantInfoReq the business information reviewed by the admin
+type MerchantInfoReq struct {
+	LegalName    string   `json:"legalName" binding:"required"`
+	TradeName    string   `json:"tradeName"`
+	CrNumber     string   `json:"crNumber" binding:"required"` // commercial registration number
+	ContactName  string   `json:"contactName" binding:"required"`
+	ContactPhone string   `json:"contactPhone" binding:"required"`
+	ContactEmail string   `json:"contactEmail" binding:"omitempty,email"`
+	Logo         string   `json:"logo"` // key of an uploaded image
+	Address      string   `json:"address" binding:"required"`
+	Latitude     *float64 `json:"latitude" binding:"required,min=-90,max=90"`
+	Longitude    *float64 `json:"longitude" binding:"required,min=-180,max=180"`
+}
+
+// MerchantRegisterReq the code is a register verify code sent to the phone.
+// An existing user of the phone, e.g. a customer, becomes a merchant and keeps the password.
+type MerchantRegisterReq struct {
+	Email    string `json:"email" binding:"omitempty,email"`
+	Phone    string `json:"phone" binding:"required"`
+	Password string `json:"password" binding:"required,min=6"`
+	Code     string `json:"code" binding:"required"`
+	MerchantInfoReq
+}
+
+type MerchantRegisterResp struct {
+	MerchantId int64 `json:"merchantId"`
+	TokenResp
+}
+
+type MerchantLoginReq struct {
+	Email         string `json:"email"`
+	Phone         string `json:"phone"`
+	Password      string `json:"password"`
+	Code          string `json:"code"`
+	Platform      string `json:"platform"`
+	Imei          string `json:"imei"`
+	ClientVersion string `json:"clientVersion"`
+	Model         string `json:"model"`
+	SystemVersion string `json:"systemVersion"`
+}
+
+type MerchantLoginResp struct {
+	TokenResp
+}
+
+type MerchantResp struct {
+	Id           int64                  `json:"id"`
+	UserId       int64                  `json:"userId"`
+	LegalName    string                 `json:"legalName"`
+	TradeName    *string                `json:"tradeName"`
+	CrNumber     string                 `json:"crNumber"`
+	ContactName  string                 `json:"contactName"`
+	ContactPhone string                 `json:"contactPhone"`
+	ContactEmail *string                `json:"contactEmail"`
+	Logo         *ImageResp             `json:"logo"`
+	Address      string                 `json:"address"`
+	Latitude     float64                `json:"latitude"`
+	Longitude    float64                `json:"longitude"`
+	Status       string                 `json:"status"` // pending, approved, rejected, suspended
+	StatusReason *string                `json:"statusReason"`
+	ApprovedAt   int64                  `json:"approvedAt"`
+	CreatedAt    int64                  `json:"createdAt"`
+	Documents    []MerchantDocumentResp `json:"documents,omitempty"`
+}
+
+type MerchantDetailResp struct {
+	MerchantResp
+	Histories []MerchantStatusHistoryResp `json:"histories"`
+}
+
+type MerchantDocumentResp struct {
+	Id          int64  `json:"id"`
+	Type        string `json:"type"` // cr_certificate, owner_id, food_license, other
+	FileName    string `json:"fileName"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+	CreatedAt   int64  `json:"createdAt"`
+}
+
+type ListMerchantDocumentResp struct {
+	Documents []MerchantDocumentResp `json:"documents"`
+}
+
+type MerchantStatusHistoryResp struct {
+	FromStatus *string `json:"fromStatus"`
+	ToStatus   string  `json:"toStatus"`
+	Reason     *string `json:"reason"`
+	OperatorId int64   `json:"operatorId"`
+	CreatedAt  int64   `json:"createdAt"`
+}
+
+type ListMerchantReq struct {
+	Status   string `form:"status"`
+	Page     int    `form:"page" binding:"omitempty,min=1"`
+	PageSize int    `form:"pageSize" binding:"omitempty,min=1,max=100"`
+}
+
+type ListMerchantResp struct {
+	Total     int64          `json:"total"`
+	Merchants []MerchantResp `json:"merchants"`
+}
+
+// MerchantReviewReq the reason is required to reject or suspend
+type MerchantReviewReq struct {
+	Reason string `json:"reason"`
+}
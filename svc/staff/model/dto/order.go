@@ -0,0 +1,50 @@
+package dto
+
+// OrderItemReq a line of the order, the price is taken from the catalog
+type OrderItemReq struct {
+	ProductSelectionReq
+	Quantity int `json:"quantity" binding:"required,min=1,max=99"`
+}
+
+type PlaceOrderReq struct {
+	StoreId int64          `json:"storeId" binding:"required"`
+	Items   []OrderItemReq `json:"items" binding:"required,min=1,max=50,dive"`
+}
+
+type CancelOrderReq struct {
+	Reason string `json:"reason" binding:"max=200"`
+}
+
+type ListOrderReq struct {
+	Status   string `form:"status"`
+	Page     int    `form:"page" binding:"omitempty,min=1"`
+	PageSize int    `form:"pageSize" binding:"omitempty,min=1,max=100"`
+}
+
+type OrderItemResp struct {
+	ProductId int64   `json:"productId"`
+	VariantId int64   `json:"variantId"`
+	OptionIds []int64 `json:"optionIds"`
+	Name      string  `json:"name"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice int64   `json:"unitPrice"`
+	Amount    int64   `json:"amount"`
+}
+
+// OrderResp the amounts are in minor units of the currency
+type OrderResp struct {
+	OrderRef     string          `json:"orderRef"`
+	StoreId      int64           `json:"storeId"`
+	Status       string          `json:"status"` // placed, accepted, cancelled
+	Items        []OrderItemResp `json:"items"`
+	Total        int64           `json:"total"`
+	Currency     string          `json:"currency"`
+	ExpiresAt    int64           `json:"expiresAt"` // a placed order is cancelled if not accepted by then
+	CancelReason *string         `json:"cancelReason"`
+	CreatedAt    int64           `json:"createdAt"`
+}
+
+type ListOrderResp struct {
+	Total  int64       `json:"total"`
+	Orders []OrderResp `json:"orders"`
+}
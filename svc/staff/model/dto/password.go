@@ -0,0 +1,22 @@
+package dto
+
+type ForgotPasswordReq struct {
+	Email string `json:"email"`
+	Phone string `json:"phone"`
+}
+
+type ResetPasswordReq struct {
+	Email    string `json:"email"`
+	Phone    string `json:"phone"`
+	Code     string `json:"code" binding:"required"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+type ChangePasswordReq struct {
+	OldPassword string `json:"oldPassword" binding:"required"`
+	NewPassword string `json:"newPassword" binding:"required,min=6"`
+}
+
+type ChangePasswordResp struct {
+	TokenResp
+}
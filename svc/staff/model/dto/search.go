@@ -0,0 +1,73 @@
+package dto
+
+// SearchProductReq every word of q is matched as a prefix, prices are in minor units of the currency.
+// The cursor is the nextCursor of the previous page of the same search
+type SearchProductReq struct {
+	Query      string `form:"q" binding:"required"`
+	Lang       string `form:"lang" binding:"omitempty,oneof=en ar"` // detected from q if empty
+	StoreId    int64  `form:"storeId"`
+	CategoryId int64  `form:"categoryId"`
+	MinPrice   *int64 `form:"minPrice" binding:"omitempty,min=0"`
+	MaxPrice   *int64 `form:"maxPrice" binding:"omitempty,min=0"`
+	OpenNow    bool   `form:"openNow"`
+	Cursor     string `form:"cursor"`
+	Limit      int    `form:"limit" binding:"omitempty,min=1,max=50"`
+	AllergenFilterReq
+}
+
+type SearchProductHit struct {
+	Id          int64      `json:"id"`
+	StoreId     int64      `json:"storeId"`
+	StoreName   string     `json:"storeName"`
+	CategoryId  *int64     `json:"categoryId"`
+	Kind        string     `json:"kind"`
+	Name        string     `json:"name"`
+	Description *string    `json:"description"`
+	Image       *ImageResp `json:"image"`
+	Price       int64      `json:"price"`
+	Currency    string     `json:"currency"`
+	Score       float32    `json:"score"`
+}
+
+type SearchProductResp struct {
+	Products   []SearchProductHit `json:"products"`
+	NextCursor string             `json:"nextCursor"` // empty on the last page
+}
+
+type SearchStoreReq struct {
+	Query   string `form:"q" binding:"required"`
+	Lang    string `form:"lang" binding:"omitempty,oneof=en ar"` // detected from q if empty
+	OpenNow bool   `form:"openNow"`
+	Cursor  string `form:"cursor"`
+	Limit   int    `form:"limit" binding:"omitempty,min=1,max=50"`
+}
+
+type SearchStoreHit struct {
+	StoreResp
+	Score float32 `json:"score"`
+}
+
+type SearchStoreResp struct {
+	Stores     []SearchStoreHit `json:"stores"`
+	NextCursor string           `json:"nextCursor"` // empty on the last page
+}
+
+// SemanticSearchReq q is matched by meaning, e.g. "something spicy with chicken".
+// The hybrid mode blends the keyword rank of q into the similarity by weight
+type SemanticSearchReq struct {
+	Query      string   `form:"q" binding:"required"`
+	Mode       string   `form:"mode" binding:"omitempty,oneof=semantic hybrid"` // defaults to semantic
+	Weight     *float64 `form:"weight" binding:"omitempty,min=0,max=1"`         // share of the similarity in the hybrid score, defaults to 0.7
+	Lang       string   `form:"lang" binding:"omitempty,oneof=en ar"`           // of the keyword search, detected from q if empty
+	StoreId    int64    `form:"storeId"`
+	CategoryId int64    `form:"categoryId"`
+	MinPrice   *int64   `form:"minPrice" binding:"omitempty,min=0"`
+	MaxPrice   *int64   `form:"maxPrice" binding:"omitempty,min=0"`
+	OpenNow    bool     `form:"openNow"`
+	Limit      int      `form:"limit" binding:"omitempty,min=1,max=50"`
+	AllergenFilterReq
+}
+
+type SemanticSearchResp struct {
+	Products []SearchProductHit `json:"products"`
+}
@@ -0,0 +1,16 @@
+package dto
+
+type SessionResp struct {
+	SessionId     string `json:"sessionId"`
+	Platform      string `json:"platform"`
+	Model         string `json:"model"`
+	ClientVersion string `json:"clientVersion"`
+	SystemVersion string `json:"systemVersion"`
+	CreatedAt     int64  `json:"createdAt"`
+	LastSeenAt    int64  `json:"lastSeenAt"`
+	Current       bool   `json:"current"`
+}
+
+type ListSessionResp struct {
+	Sessions []SessionResp `json:"sessions"`
+}
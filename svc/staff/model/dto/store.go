@@ -0,0 +1,75 @@
+package dto
+
+type StoreShift struct {
+	OpenTime  string `json:"openTime" binding:"required"`  // HH:MM
+	CloseTime string `json:"closeTime" binding:"required"` // HH:MM, not after openTime means the next day
+}
+
+type StoreWeeklyShift struct {
+	Weekday int `json:"weekday" binding:"min=0,max=6"` // 0:sunday ... 6:saturday
+	StoreShift
+}
+
+// StoreHoursReq replaces the whole week, a weekday without shift is closed
+type StoreHoursReq struct {
+	Timezone string             `json:"timezone"` // IANA name, defaults to the current one
+	Hours    []StoreWeeklyShift `json:"hours" binding:"dive"`
+}
+
+type StoreHoursResp struct {
+	Timezone      string             `json:"timezone"`
+	Hours         []StoreWeeklyShift `json:"hours"`
+	PausedUntil   int64              `json:"pausedUntil"`
+	PauseReason   *string            `json:"pauseReason"`
+	IsOpen        bool               `json:"isOpen"`
+	NextOpeningAt int64              `json:"nextOpeningAt"` // 0 if no opening in the coming two weeks
+}
+
+type StoreSpecialDayReq struct {
+	Date   string       `json:"date" binding:"required"` // YYYY-MM-DD in the store timezone
+	Closed bool         `json:"closed"`
+	Shifts []StoreShift `json:"shifts" binding:"dive"` // replaces the weekly shifts of the date if not closed
+	Note   string       `json:"note"`
+}
+
+type StoreSpecialDayResp struct {
+	Date   string       `json:"date"`
+	Closed bool         `json:"closed"`
+	Shifts []StoreShift `json:"shifts"`
+	Note   *string      `json:"note"`
+}
+
+type ListStoreSpecialDayResp struct {
+	SpecialDays []StoreSpecialDayResp `json:"specialDays"`
+}
+
+type PauseStoreReq struct {
+	Minutes int    `json:"minutes" binding:"required,min=1,max=1440"`
+	Reason  string `json:"reason"`
+}
+
+// ListStoreReq with a location only the stores delivering to it are listed
+type ListStoreReq struct {
+	DeliveryLocationReq
+	OpenNow  bool `form:"openNow"`
+	Page     int  `form:"page" binding:"omitempty,min=1"`
+	PageSize int  `form:"pageSize" binding:"omitempty,min=1,max=100"`
+}
+
+type StoreResp struct {
+	Id            int64              `json:"id"`
+	Name          string             `json:"name"`
+	Logo          *ImageResp         `json:"logo"`
+	Address       string             `json:"address"`
+	Latitude      float64            `json:"latitude"`
+	Longitude     float64            `json:"longitude"`
+	Timezone      string             `json:"timezone"`
+	IsOpen        bool               `json:"isOpen"`
+	NextOpeningAt int64              `json:"nextOpeningAt"`
+	Delivery      *StoreDeliveryResp `json:"delivery"` // the zone covering the location, null without location
+}
+
+type ListStoreResp struct {
+	Total  int64       `json:"total"`
+	Stores []StoreResp `json:"stores"`
+}
@@ -0,0 +1,151 @@
+package rest
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/tespkg/bytes-be/common/result"
+	"github.com/tespkg/bytes-be/svc/staff/logic"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+	"strconv"
+)
+
+// ListAddresses
+// @Summary list the addresses of the customer, the default one goes first
+// @Tags Customer
+// @Produce json
+// @Success 200 {object} result.ResponseSuccessBean[dto.ListCustomerAddressResp]
+// @Router /api/v1/customer/addresses [get]
+func (s *Server) ListAddresses(c *gin.Context) {
+	resp, err := logic.ListCustomerAddresses(s.db, getUser(c))
+	if err != nil {
+		logrus.Errorf("list addresses fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// GetAddress
+// @Summary get an address of the customer
+// @Tags Customer
+// @Produce json
+// @Param addressId path int true "address id"
+// @Success 200 {object} result.ResponseSuccessBean[dto.CustomerAddressResp]
+// @Router /api/v1/customer/addresses/{addressId} [get]
+func (s *Server) GetAddress(c *gin.Context) {
+	addressId, err := strconv.ParseInt(c.Param("addressId"), 10, 64)
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("invalid address id"))
+		return
+	}
+
+	resp, err := logic.GetCustomerAddress(s.db, getUser(c), addressId)
+	if err != nil {
+		logrus.Errorf("get address fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// CreateAddress
+// @Summary add an address, the empty fields are filled from the pin by reverse geocoding
+// @Tags Customer
+// @Accept json
+// @Produce json
+// @Param req body dto.CustomerAddressReq true "address request"
+// @Success 200 {object} result.ResponseSuccessBean[dto.CustomerAddressResp]
+// @Router /api/v1/customer/addresses [post]
+func (s *Server) CreateAddress(c *gin.Context) {
+	var req *dto.CustomerAddressReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.Error("c.ShouldBindJSON fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindJSON fail"))
+		return
+	}
+
+	if err := logic.ValidateCustomerAddress(req); err != nil {
+		result.ParamErrorResult(c.Writer, err)
+		return
+	}
+
+	resp, err := logic.CreateCustomerAddress(c.Request.Context(), s.db, getUser(c), req)
+	if err != nil {
+		logrus.Errorf("create address fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// UpdateAddress
+// @Summary replace an address, the empty fields are filled from the pin by reverse geocoding
+// @Tags Customer
+// @Accept json
+// @Produce json
+// @Param addressId path int true "address id"
+// @Param req body dto.CustomerAddressReq true "address request"
+// @Success 200 {object} result.ResponseSuccessBean[dto.CustomerAddressResp]
+// @Router /api/v1/customer/addresses/{addressId} [put]
+func (s *Server) UpdateAddress(c *gin.Context) {
+	addressId, err := strconv.ParseInt(c.Param("addressId"), 10, 64)
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("invalid address id"))
+		return
+	}
+
+	var req *dto.CustomerAddressReq
+	if err = c.ShouldBindJSON(&req); err != nil {
+		logrus.Error("c.ShouldBindJSON fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindJSON fail"))
+		return
+	}
+
+	if err = logic.ValidateCustomerAddress(req); err != nil {
+		result.ParamErrorResult(c.Writer, err)
+		return
+	}
+
+	resp, err := logic.UpdateCustomerAddress(c.Request.Context(), s.db, getUser(c), addressId, req)
+	if err != nil {
+		logrus.Errorf("update address fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// SetDefaultAddress
+// @Summary make an address the default one
+// @Tags Customer
+// @Produce json
+// @Param addressId path int true "address id"
+// @Success 200 {object} result.ResponseSuccessBean[dto.CustomerAddressResp]
+// @Router /api/v1/customer/addresses/{addressId}/default [post]
+func (s *Server) SetDefaultAddress(c *gin.Context) {
+	addressId, err := strconv.ParseInt(c.Param("addressId"), 10, 64)
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("invalid address id"))
+		return
+	}
+
+	resp, err := logic.SetDefaultCustomerAddress(s.db, getUser(c), addressId)
+	if err != nil {
+		logrus.Errorf("set default address fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// DeleteAddress
+// @Summary delete an address
+// @Tags Customer
+// @Produce json
+// @Param addressId path int true "address id"
+// @Success 200 {object} result.ResponseSuccessBean[NullJson]
+// @Router /api/v1/customer/addresses/{addressId} [delete]
+func (s *Server) DeleteAddress(c *gin.Context) {
+	addressId, err := strconv.ParseInt(c.Param("addressId"), 10, 64)
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("invalid address id"))
+		return
+	}
+
+	err = logic.DeleteCustomerAddress(s.db, getUser(c), addressId)
+	if err != nil {
+		logrus.Errorf("delete address fail: %s", err)
+	}
+	result.HttpResult(c.Writer, nil, err)
+}
@@ -0,0 +1,118 @@
+package rest
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/tespkg/bytes-be/common/result"
+	"github.com/tespkg/bytes-be/svc/staff/logic"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+	"strconv"
+)
+
+// ListIngredientReviews
+// @Summary list the ingredient lists of the foods by status, the oldest change first
+// @Tags Admin
+// @Produce json
+// @Param status query string false "flagged, generated, confirmed, reconfirm or failed, defaults to flagged"
+// @Param page query int false "page, defaults to 1"
+// @Param pageSize query int false "page size, defaults to 20"
+// @Success 200 {object} result.ResponseSuccessBean[dto.ListIngredientReviewResp]
+// @Router /api/v1/admin/ingredients [get]
+func (s *Server) ListIngredientReviews(c *gin.Context) {
+	var req dto.ListIngredientReviewReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		logrus.Error("c.ShouldBindQuery fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindQuery fail"))
+		return
+	}
+
+	resp, err := logic.ListIngredientReviews(s.db, &req)
+	if err != nil {
+		logrus.Errorf("list ingredient reviews fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// GetIngredientReview
+// @Summary get the ingredient list of a food with its audit trail
+// @Tags Admin
+// @Produce json
+// @Param productId path int true "product id"
+// @Success 200 {object} result.ResponseSuccessBean[dto.IngredientReviewDetailResp]
+// @Router /api/v1/admin/ingredients/{productId} [get]
+func (s *Server) GetIngredientReview(c *gin.Context) {
+	productId, err := strconv.ParseInt(c.Param("productId"), 10, 64)
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("invalid product id"))
+		return
+	}
+
+	resp, err := logic.GetIngredientReview(s.db, productId)
+	if err != nil {
+		logrus.Errorf("get ingredient review fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// ReviewProductIngredient
+// @Summary correct the ingredients and allergens of a food, the list is confirmed and overrides the analysis of the dish
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param productId path int true "product id"
+// @Param req body dto.ProductIngredientReq true "product ingredient request"
+// @Success 200 {object} result.ResponseSuccessBean[dto.ProductIngredientResp]
+// @Router /api/v1/admin/ingredients/{productId} [put]
+func (s *Server) ReviewProductIngredient(c *gin.Context) {
+	productId, err := strconv.ParseInt(c.Param("productId"), 10, 64)
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("invalid product id"))
+		return
+	}
+
+	var req *dto.ProductIngredientReq
+	if err = c.ShouldBindJSON(&req); err != nil {
+		logrus.Error("c.ShouldBindJSON fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindJSON fail"))
+		return
+	}
+
+	resp, err := logic.ReviewProductIngredient(s.db, getUser(c), productId, req)
+	if err != nil {
+		logrus.Errorf("review product ingredient fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// ApproveProductIngredient
+// @Summary confirm the ingredient list of a food as it is, the list overrides the analysis of the dish
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param productId path int true "product id"
+// @Param req body dto.IngredientApproveReq false "approve request"
+// @Success 200 {object} result.ResponseSuccessBean[dto.ProductIngredientResp]
+// @Router /api/v1/admin/ingredients/{productId}/approve [post]
+func (s *Server) ApproveProductIngredient(c *gin.Context) {
+	productId, err := strconv.ParseInt(c.Param("productId"), 10, 64)
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("invalid product id"))
+		return
+	}
+
+	var req dto.IngredientApproveReq
+	if c.Request.ContentLength != 0 {
+		if err = c.ShouldBindJSON(&req); err != nil {
+			logrus.Error("c.ShouldBindJSON fail:", err)
+			result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindJSON fail"))
+			return
+		}
+	}
+
+	resp, err := logic.ApproveProductIngredient(s.db, getUser(c), productId, &req)
+	if err != nil {
+		logrus.Errorf("approve product ingredient fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
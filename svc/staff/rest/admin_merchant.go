@@ -0,0 +1,152 @@
+package rest
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/tespkg/bytes-be/common/result"
+	"github.com/tespkg/bytes-be/svc/staff/logic"
+	"github.com/tespkg/bytes-be/svc/staff/model/dao"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+	"net/http"
+	"strconv"
+)
+
+// ListMerchants
+// @Summary list the merchants by status, the oldest first
+// @Tags Admin
+// @Produce json
+// @Param status query string false "pending, approved, rejected or suspended"
+// @Param page query int false "page, defaults to 1"
+// @Param pageSize query int false "page size, defaults to 20"
+// @Success 200 {object} result.ResponseSuccessBean[dto.ListMerchantResp]
+// @Router /api/v1/admin/merchants [get]
+func (s *Server) ListMerchants(c *gin.Context) {
+	var req dto.ListMerchantReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		logrus.Error("c.ShouldBindQuery fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindQuery fail"))
+		return
+	}
+
+	resp, err := logic.ListMerchants(s.db, &req)
+	if err != nil {
+		logrus.Errorf("list merchants fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// GetMerchantDetail
+// @Summary get the merchant with its documents and status history
+// @Tags Admin
+// @Produce json
+// @Param merchantId path int true "merchant id"
+// @Success 200 {object} result.ResponseSuccessBean[dto.MerchantDetailResp]
+// @Router /api/v1/admin/merchants/{merchantId} [get]
+func (s *Server) GetMerchantDetail(c *gin.Context) {
+	merchantId, err := strconv.ParseInt(c.Param("merchantId"), 10, 64)
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("invalid merchant id"))
+		return
+	}
+
+	resp, err := logic.GetMerchantDetail(s.db, merchantId)
+	if err != nil {
+		logrus.Errorf("get merchant detail fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// DownloadMerchantDocument
+// @Summary download a document of the merchant
+// @Tags Admin
+// @Produce octet-stream
+// @Param merchantId path int true "merchant id"
+// @Param documentId path int true "document id"
+// @Success 200 {file} file
+// @Router /api/v1/admin/merchants/{merchantId}/documents/{documentId} [get]
+func (s *Server) DownloadMerchantDocument(c *gin.Context) {
+	merchantId, err := strconv.ParseInt(c.Param("merchantId"), 10, 64)
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("invalid merchant id"))
+		return
+	}
+	documentId, err := strconv.ParseInt(c.Param("documentId"), 10, 64)
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("invalid document id"))
+		return
+	}
+
+	document, err := logic.GetMerchantDocument(s.db, merchantId, documentId)
+	if err != nil {
+		logrus.Errorf("get merchant document fail: %s", err)
+		result.HttpResult(c.Writer, nil, err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", document.FileName))
+	c.Data(http.StatusOK, document.ContentType, document.Content)
+}
+
+// ApproveMerchant
+// @Summary approve a pending or suspended merchant
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param merchantId path int true "merchant id"
+// @Param req body dto.MerchantReviewReq false "review request"
+// @Success 200 {object} result.ResponseSuccessBean[dto.MerchantResp]
+// @Router /api/v1/admin/merchants/{merchantId}/approve [post]
+func (s *Server) ApproveMerchant(c *gin.Context) {
+	s.reviewMerchant(c, dao.MerchantStatusApproved)
+}
+
+// RejectMerchant
+// @Summary reject a pending merchant, the merchant can update and submit again
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param merchantId path int true "merchant id"
+// @Param req body dto.MerchantReviewReq true "review request"
+// @Success 200 {object} result.ResponseSuccessBean[dto.MerchantResp]
+// @Router /api/v1/admin/merchants/{merchantId}/reject [post]
+func (s *Server) RejectMerchant(c *gin.Context) {
+	s.reviewMerchant(c, dao.MerchantStatusRejected)
+}
+
+// SuspendMerchant
+// @Summary suspend an approved merchant
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param merchantId path int true "merchant id"
+// @Param req body dto.MerchantReviewReq true "review request"
+// @Success 200 {object} result.ResponseSuccessBean[dto.MerchantResp]
+// @Router /api/v1/admin/merchants/{merchantId}/suspend [post]
+func (s *Server) SuspendMerchant(c *gin.Context) {
+	s.reviewMerchant(c, dao.MerchantStatusSuspended)
+}
+
+func (s *Server) reviewMerchant(c *gin.Context, status string) {
+	merchantId, err := strconv.ParseInt(c.Param("merchantId"), 10, 64)
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("invalid merchant id"))
+		return
+	}
+
+	var req dto.MerchantReviewReq
+	if c.Request.ContentLength != 0 {
+		if err = c.ShouldBindJSON(&req); err != nil {
+			logrus.Error("c.ShouldBindJSON fail:", err)
+			result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindJSON fail"))
+			return
+		}
+	}
+
+	resp, err := logic.ReviewMerchant(s.db, getUser(c), merchantId, status, req.Reason)
+	if err != nil {
+		logrus.Errorf("review merchant %d to %s fail: %s", merchantId, status, err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
@@ -0,0 +1,33 @@
+package rest
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/tespkg/bytes-be/common/result"
+	"github.com/tespkg/bytes-be/svc/staff/logic"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+)
+
+// RefreshToken
+// @Summary rotate the refresh token and issue a new login token
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param req body dto.RefreshTokenReq true "refresh token request"
+// @Success 200 {object} result.ResponseSuccessBean[dto.TokenResp]
+// @Router /api/v1/auth/refresh [post]
+func (s *Server) RefreshToken(c *gin.Context) {
+	var req *dto.RefreshTokenReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.Error("c.ShouldBindJSON fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindJSON fail"))
+		return
+	}
+
+	resp, err := logic.RefreshToken(c.Request.Context(), s.db, req)
+	if err != nil {
+		logrus.Errorf("refresh token fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
@@ -0,0 +1,413 @@
+package rest
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/tespkg/bytes-be/common/result"
+	"github.com/tespkg/bytes-be/svc/staff/logic"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+	"net/http"
+	"strconv"
+)
+
+// ListCategories
+// @Summary list the categories of the merchant
+// @Tags Catalog
+// @Produce json
+// @Success 200 {object} result.ResponseSuccessBean[dto.ListCategoryResp]
+// @Router /api/v1/merchant/categories [get]
+func (s *Server) ListCategories(c *gin.Context) {
+	resp, err := logic.ListCategories(s.db, getMerchant(c))
+	if err != nil {
+		logrus.Errorf("list categories fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// CreateCategory
+// @Summary create a category
+// @Tags Catalog
+// @Accept json
+// @Produce json
+// @Param req body dto.CategoryReq true "category request"
+// @Success 200 {object} result.ResponseSuccessBean[dto.CategoryResp]
+// @Router /api/v1/merchant/categories [post]
+func (s *Server) CreateCategory(c *gin.Context) {
+	var req *dto.CategoryReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.Error("c.ShouldBindJSON fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindJSON fail"))
+		return
+	}
+
+	resp, err := logic.CreateCategory(s.db, getMerchant(c), req)
+	if err != nil {
+		logrus.Errorf("create category fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// UpdateCategory
+// @Summary replace a category
+// @Tags Catalog
+// @Accept json
+// @Produce json
+// @Param categoryId path int true "category id"
+// @Param req body dto.CategoryReq true "category request"
+// @Success 200 {object} result.ResponseSuccessBean[dto.CategoryResp]
+// @Router /api/v1/merchant/categories/{categoryId} [put]
+func (s *Server) UpdateCategory(c *gin.Context) {
+	categoryId, err := strconv.ParseInt(c.Param("categoryId"), 10, 64)
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("invalid category id"))
+		return
+	}
+
+	var req *dto.CategoryReq
+	if err = c.ShouldBindJSON(&req); err != nil {
+		logrus.Error("c.ShouldBindJSON fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindJSON fail"))
+		return
+	}
+
+	resp, err := logic.UpdateCategory(s.db, getMerchant(c), categoryId, req)
+	if err != nil {
+		logrus.Errorf("update category fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// DeleteCategory
+// @Summary delete a category, its products are kept without category
+// @Tags Catalog
+// @Produce json
+// @Param categoryId path int true "category id"
+// @Success 200 {object} result.ResponseSuccessBean[NullJson]
+// @Router /api/v1/merchant/categories/{categoryId} [delete]
+func (s *Server) DeleteCategory(c *gin.Context) {
+	categoryId, err := strconv.ParseInt(c.Param("categoryId"), 10, 64)
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("invalid category id"))
+		return
+	}
+
+	err = logic.DeleteCategory(s.db, getMerchant(c), categoryId)
+	if err != nil {
+		logrus.Errorf("delete category fail: %s", err)
+	}
+	result.HttpResult(c.Writer, nil, err)
+}
+
+// ListProducts
+// @Summary list the products of the merchant with their variants and modifiers
+// @Tags Catalog
+// @Produce json
+// @Param categoryId query int false "category id"
+// @Success 200 {object} result.ResponseSuccessBean[dto.ListProductResp]
+// @Router /api/v1/merchant/products [get]
+func (s *Server) ListProducts(c *gin.Context) {
+	var req dto.ListProductReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		logrus.Error("c.ShouldBindQuery fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindQuery fail"))
+		return
+	}
+
+	resp, err := logic.ListProducts(s.db, getMerchant(c), &req)
+	if err != nil {
+		logrus.Errorf("list products fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// GetProduct
+// @Summary get a product with its variants and modifiers
+// @Tags Catalog
+// @Produce json
+// @Param productId path int true "product id"
+// @Success 200 {object} result.ResponseSuccessBean[dto.ProductResp]
+// @Router /api/v1/merchant/products/{productId} [get]
+func (s *Server) GetProduct(c *gin.Context) {
+	productId, err := strconv.ParseInt(c.Param("productId"), 10, 64)
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("invalid product id"))
+		return
+	}
+
+	resp, err := logic.GetProduct(s.db, getMerchant(c), productId)
+	if err != nil {
+		logrus.Errorf("get product fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// CreateProduct
+// @Summary create a product with its variants and modifiers, prices are in minor units
+// @Tags Catalog
+// @Accept json
+// @Produce json
+// @Param req body dto.ProductReq true "product request"
+// @Success 200 {object} result.ResponseSuccessBean[dto.ProductResp]
+// @Router /api/v1/merchant/products [post]
+func (s *Server) CreateProduct(c *gin.Context) {
+	var req *dto.ProductReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.Error("c.ShouldBindJSON fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindJSON fail"))
+		return
+	}
+
+	resp, err := logic.CreateProduct(s.db, getMerchant(c), req)
+	if err != nil {
+		logrus.Errorf("create product fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// UpdateProduct
+// @Summary replace a product, the variants and modifiers without id are created and the missing ones deleted
+// @Tags Catalog
+// @Accept json
+// @Produce json
+// @Param productId path int true "product id"
+// @Param req body dto.ProductReq true "product request"
+// @Success 200 {object} result.ResponseSuccessBean[dto.ProductResp]
+// @Router /api/v1/merchant/products/{productId} [put]
+func (s *Server) UpdateProduct(c *gin.Context) {
+	productId, err := strconv.ParseInt(c.Param("productId"), 10, 64)
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("invalid product id"))
+		return
+	}
+
+	var req *dto.ProductReq
+	if err = c.ShouldBindJSON(&req); err != nil {
+		logrus.Error("c.ShouldBindJSON fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindJSON fail"))
+		return
+	}
+
+	resp, err := logic.UpdateProduct(s.db, getMerchant(c), productId, req)
+	if err != nil {
+		logrus.Errorf("update product fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// UpdateProductAvailability
+// @Summary mark a product sold out or available again
+// @Tags Catalog
+// @Accept json
+// @Produce json
+// @Param productId path int true "product id"
+// @Param req body dto.ProductAvailabilityReq true "product availability request"
+// @Success 200 {object} result.ResponseSuccessBean[dto.ProductResp]
+// @Router /api/v1/merchant/products/{productId}/availability [patch]
+func (s *Server) UpdateProductAvailability(c *gin.Context) {
+	productId, err := strconv.ParseInt(c.Param("productId"), 10, 64)
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("invalid product id"))
+		return
+	}
+
+	var req *dto.ProductAvailabilityReq
+	if err = c.ShouldBindJSON(&req); err != nil {
+		logrus.Error("c.ShouldBindJSON fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindJSON fail"))
+		return
+	}
+
+	resp, err := logic.UpdateProductAvailability(s.db, getMerchant(c), productId, *req.IsAvailable)
+	if err != nil {
+		logrus.Errorf("update product availability fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// DeleteProduct
+// @Summary delete a product with its variants and modifiers
+// @Tags Catalog
+// @Produce json
+// @Param productId path int true "product id"
+// @Success 200 {object} result.ResponseSuccessBean[NullJson]
+// @Router /api/v1/merchant/products/{productId} [delete]
+func (s *Server) DeleteProduct(c *gin.Context) {
+	productId, err := strconv.ParseInt(c.Param("productId"), 10, 64)
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("invalid product id"))
+		return
+	}
+
+	err = logic.DeleteProduct(s.db, getMerchant(c), productId)
+	if err != nil {
+		logrus.Errorf("delete product fail: %s", err)
+	}
+	result.HttpResult(c.Writer, nil, err)
+}
+
+// GetStoreCatalog
+// @Summary get the available categories and products of a store
+// @Tags Store
+// @Produce json
+// @Param storeId path int true "store id"
+// @Param excludeAllergens query string false "comma separated allergens like peanuts,dairy, hides the foods with them or not analyzed yet"
+// @Success 200 {object} result.ResponseSuccessBean[dto.StoreCatalogResp]
+// @Router /api/v1/stores/{storeId}/catalog [get]
+func (s *Server) GetStoreCatalog(c *gin.Context) {
+	storeId, err := strconv.ParseInt(c.Param("storeId"), 10, 64)
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("invalid store id"))
+		return
+	}
+
+	var req dto.StoreCatalogReq
+	if err = c.ShouldBindQuery(&req); err != nil {
+		logrus.Error("c.ShouldBindQuery fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindQuery fail"))
+		return
+	}
+
+	resp, err := logic.GetStoreCatalog(s.db, storeId, &req)
+	if err != nil {
+		logrus.Errorf("get store catalog fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// GetStoreProduct
+// @Summary get an available product of a store
+// @Tags Store
+// @Produce json
+// @Param storeId path int true "store id"
+// @Param productId path int true "product id"
+// @Success 200 {object} result.ResponseSuccessBean[dto.ProductResp]
+// @Router /api/v1/stores/{storeId}/products/{productId} [get]
+func (s *Server) GetStoreProduct(c *gin.Context) {
+	storeId, err := strconv.ParseInt(c.Param("storeId"), 10, 64)
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("invalid store id"))
+		return
+	}
+	productId, err := strconv.ParseInt(c.Param("productId"), 10, 64)
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("invalid product id"))
+		return
+	}
+
+	resp, err := logic.GetStoreProduct(s.db, storeId, productId)
+	if err != nil {
+		logrus.Errorf("get store product fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// PriceStoreProduct
+// @Summary price the variant and the modifier options picked on an available product of a store
+// @Tags Store
+// @Accept json
+// @Produce json
+// @Param storeId path int true "store id"
+// @Param body body dto.ProductSelectionReq true "the selection"
+// @Success 200 {object} result.ResponseSuccessBean[dto.ProductPriceResp]
+// @Router /api/v1/stores/{storeId}/price [post]
+func (s *Server) PriceStoreProduct(c *gin.Context) {
+	storeId, err := strconv.ParseInt(c.Param("storeId"), 10, 64)
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("invalid store id"))
+		return
+	}
+
+	var req dto.ProductSelectionReq
+	if err = c.ShouldBindJSON(&req); err != nil {
+		logrus.Error("c.ShouldBindJSON fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindJSON fail"))
+		return
+	}
+
+	resp, err := logic.PriceStoreProduct(s.db, storeId, &req)
+	if err != nil {
+		logrus.Errorf("price store product fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// ImportCatalog
+// @Summary import the products from a csv or json file, upserted by SKU, nothing is saved if any row fails
+// @Tags Catalog
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "catalog up to 5MB, the columns of the export"
+// @Param format formData string false "csv or json, defaults to the extension of the file"
+// @Param dryRun formData bool false "validate and count without saving"
+// @Success 200 {object} result.ResponseSuccessBean[dto.CatalogImportResp]
+// @Router /api/v1/merchant/catalog/import [post]
+func (s *Server) ImportCatalog(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, logic.MaxCatalogFileSize+1<<20)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("file is required"))
+		return
+	}
+	if fileHeader.Size > logic.MaxCatalogFileSize {
+		result.ParamErrorResult(c.Writer, errors.Errorf("the file exceeds %d bytes", logic.MaxCatalogFileSize))
+		return
+	}
+
+	var req dto.CatalogImportReq
+	if err = c.ShouldBind(&req); err != nil {
+		logrus.Error("c.ShouldBind fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBind fail"))
+		return
+	}
+	format := req.Format
+	if format == "" {
+		format = logic.CatalogFormatOf(fileHeader.Filename)
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("the file can't be read"))
+		return
+	}
+	defer file.Close()
+
+	resp, err := logic.ImportCatalog(s.db, getMerchant(c), format, file, req.DryRun)
+	if err != nil {
+		logrus.Errorf("import catalog fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// ExportCatalog
+// @Summary export the products in the format of the import, one row per variant
+// @Tags Catalog
+// @Produce octet-stream
+// @Param format query string false "csv or json, defaults to csv"
+// @Success 200 {file} file
+// @Router /api/v1/merchant/catalog/export [get]
+func (s *Server) ExportCatalog(c *gin.Context) {
+	var req dto.CatalogExportReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		logrus.Error("c.ShouldBindQuery fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindQuery fail"))
+		return
+	}
+	format, contentType := logic.CatalogFormatCSV, "text/csv; charset=utf-8"
+	if req.Format == logic.CatalogFormatJSON {
+		format, contentType = logic.CatalogFormatJSON, "application/json; charset=utf-8"
+	}
+
+	var buf bytes.Buffer
+	if err := logic.ExportCatalog(s.db, getMerchant(c), format, &buf); err != nil {
+		logrus.Errorf("export catalog fail: %s", err)
+		result.HttpResult(c.Writer, nil, err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "catalog."+format))
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
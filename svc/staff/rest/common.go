@@ -5,6 +5,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/tespkg/bytes-be/common/result"
+	"github.com/tespkg/bytes-be/common/verifycode"
 	"github.com/tespkg/bytes-be/svc/staff/logic"
 	"github.com/tespkg/bytes-be/svc/staff/model/dto"
 )
@@ -16,6 +17,7 @@ import (
 // @Produce json
 // @Param email body string false "email"
 // @Param phone body string false "phone"
+// @Param purpose body string false "register, login, reset_password, change_phone or change_email, defaults to register"
 // @Success 200 {object} result.ResponseSuccessBean[NullJson]
 // @Router /api/v1/common/code/verify [post]
 func (s *Server) SendVerifyCode(c *gin.Context) {
@@ -31,7 +33,15 @@ func (s *Server) SendVerifyCode(c *gin.Context) {
 		return
 	}
 
-	err := logic.SendVerifyCode(c.Request.Context(), req)
+	if req.Purpose == "" {
+		req.Purpose = verifycode.PurposeRegister
+	}
+	if !verifycode.IsValidPurpose(req.Purpose) {
+		result.ParamErrorResult(c.Writer, errors.New("invalid purpose"))
+		return
+	}
+
+	err := logic.SendVerifyCode(c.Request.Context(), req, c.ClientIP())
 	if err != nil {
 		logrus.Errorf("send verify code fail: %s", err)
 	}
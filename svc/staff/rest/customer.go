@@ -1,13 +1,11 @@
 package rest
 
 import (
-	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
-	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
-	"github.com/tespkg/bytes-be/common/global"
 	"github.com/tespkg/bytes-be/common/result"
+	"github.com/tespkg/bytes-be/common/verifycode"
 	"github.com/tespkg/bytes-be/svc/staff/logic"
 	"github.com/tespkg/bytes-be/svc/staff/model/dao"
 	"github.com/tespkg/bytes-be/svc/staff/model/dto"
@@ -29,6 +27,11 @@ func (s *Server) Register(c *gin.Context) {
 		return
 	}
 
+	if req.Phone == "" && req.Email == "" {
+		result.ParamErrorResult(c.Writer, errors.New("phone or email is required"))
+		return
+	}
+
 	//check phone or email is registered
 	if req.Phone != "" {
 		user, err := dao.GetUserByPhoneAndRole(s.db, req.Phone, dao.RoleCustomer)
@@ -56,22 +59,22 @@ func (s *Server) Register(c *gin.Context) {
 		}
 	}
 
-	//verify code
-	key := fmt.Sprintf("bytes_be:verify_code:phone:%s", req.Phone)
-	val, err := global.GlobalClientSets.RedisClient.Get(c.Request.Context(), key).Result()
-	if err != nil {
-		if errors.Is(err, redis.Nil) {
-			result.ParamErrorResult(c.Writer, errors.New("verify code not found"))
-			return
-		}
-
-		result.ParamErrorResult(c.Writer, err)
+	//verify code, every channel saved must be verified
+	if req.Phone != "" && req.Email != "" && req.EmailCode == "" {
+		result.ParamErrorResult(c.Writer, errors.New("emailCode is required with both phone and email"))
 		return
 	}
-	if req.Code != val {
-		result.ParamErrorResult(c.Writer, errors.New("email verify code fail"))
+	channel, target := verifycode.Target(req.Phone, req.Email)
+	if err := verifycode.Verify(c.Request.Context(), verifycode.PurposeRegister, channel, target, req.Code); err != nil {
+		result.HttpResult(c.Writer, nil, err)
 		return
 	}
+	if req.Phone != "" && req.Email != "" {
+		if err := verifycode.Verify(c.Request.Context(), verifycode.PurposeRegister, verifycode.ChannelEmail, req.Email, req.EmailCode); err != nil {
+			result.HttpResult(c.Writer, nil, err)
+			return
+		}
+	}
 
 	//customer register
 	resp, err := logic.CustomerRegister(s.db, req)
@@ -80,3 +83,59 @@ func (s *Server) Register(c *gin.Context) {
 	}
 	result.HttpResult(c.Writer, resp, err)
 }
+
+// Login
+// @Summary customer login with password or verify code
+// @Tags Customer
+// @Accept json
+// @Produce json
+// @Param req body dto.CustomerLoginReq true "customer login request"
+// @Success 200 {object} result.ResponseSuccessBean[dto.CustomerLoginResp]
+// @Router /api/v1/customer/login [post]
+func (s *Server) Login(c *gin.Context) {
+	var req *dto.CustomerLoginReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.Error("c.ShouldBindJSON fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindJSON fail"))
+		return
+	}
+
+	if req.Phone == "" && req.Email == "" {
+		result.ParamErrorResult(c.Writer, errors.New("phone or email is required"))
+		return
+	}
+
+	if req.Password == "" && req.Code == "" {
+		result.ParamErrorResult(c.Writer, errors.New("password or code is required"))
+		return
+	}
+
+	resp, err := logic.CustomerLogin(c.Request.Context(), s.db, req)
+	if err != nil {
+		logrus.Errorf("customer login fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// OidcLogin
+// @Summary customer login with an OIDC id token (google, apple)
+// @Tags Customer
+// @Accept json
+// @Produce json
+// @Param req body dto.CustomerOidcLoginReq true "customer oidc login request"
+// @Success 200 {object} result.ResponseSuccessBean[dto.CustomerLoginResp]
+// @Router /api/v1/customer/login/oidc [post]
+func (s *Server) OidcLogin(c *gin.Context) {
+	var req *dto.CustomerOidcLoginReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.Error("c.ShouldBindJSON fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindJSON fail"))
+		return
+	}
+
+	resp, err := logic.CustomerOidcLogin(c.Request.Context(), s.db, s.oidcVerifier, req)
+	if err != nil {
+		logrus.Errorf("customer oidc login fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
@@ -0,0 +1,99 @@
+package rest
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/tespkg/bytes-be/common/result"
+	"github.com/tespkg/bytes-be/svc/staff/logic"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+	"strconv"
+)
+
+// ListDeliveryZones
+// @Summary list the delivery zones of the merchant
+// @Tags Store
+// @Produce json
+// @Success 200 {object} result.ResponseSuccessBean[dto.ListDeliveryZoneResp]
+// @Router /api/v1/merchant/delivery-zones [get]
+func (s *Server) ListDeliveryZones(c *gin.Context) {
+	resp, err := logic.ListDeliveryZones(s.db, getMerchant(c))
+	if err != nil {
+		logrus.Errorf("list delivery zones fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// CreateDeliveryZone
+// @Summary create a delivery zone from a GeoJSON polygon, with its minimum order and fee
+// @Tags Store
+// @Accept json
+// @Produce json
+// @Param req body dto.DeliveryZoneReq true "delivery zone request"
+// @Success 200 {object} result.ResponseSuccessBean[dto.DeliveryZoneResp]
+// @Router /api/v1/merchant/delivery-zones [post]
+func (s *Server) CreateDeliveryZone(c *gin.Context) {
+	var req *dto.DeliveryZoneReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.Error("c.ShouldBindJSON fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindJSON fail"))
+		return
+	}
+
+	resp, err := logic.CreateDeliveryZone(s.db, getMerchant(c), req)
+	if err != nil {
+		logrus.Errorf("create delivery zone fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// UpdateDeliveryZone
+// @Summary replace a delivery zone
+// @Tags Store
+// @Accept json
+// @Produce json
+// @Param zoneId path int true "delivery zone id"
+// @Param req body dto.DeliveryZoneReq true "delivery zone request"
+// @Success 200 {object} result.ResponseSuccessBean[dto.DeliveryZoneResp]
+// @Router /api/v1/merchant/delivery-zones/{zoneId} [put]
+func (s *Server) UpdateDeliveryZone(c *gin.Context) {
+	zoneId, err := strconv.ParseInt(c.Param("zoneId"), 10, 64)
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("invalid delivery zone id"))
+		return
+	}
+
+	var req *dto.DeliveryZoneReq
+	if err = c.ShouldBindJSON(&req); err != nil {
+		logrus.Error("c.ShouldBindJSON fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindJSON fail"))
+		return
+	}
+
+	resp, err := logic.UpdateDeliveryZone(s.db, getMerchant(c), zoneId, req)
+	if err != nil {
+		logrus.Errorf("update delivery zone fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// DeleteDeliveryZone
+// @Summary delete a delivery zone
+// @Tags Store
+// @Produce json
+// @Param zoneId path int true "delivery zone id"
+// @Success 200 {object} result.ResponseSuccessBean[NullJson]
+// @Router /api/v1/merchant/delivery-zones/{zoneId} [delete]
+func (s *Server) DeleteDeliveryZone(c *gin.Context) {
+	zoneId, err := strconv.ParseInt(c.Param("zoneId"), 10, 64)
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("invalid delivery zone id"))
+		return
+	}
+
+	err = logic.DeleteDeliveryZone(s.db, getMerchant(c), zoneId)
+	if err != nil {
+		logrus.Errorf("delete delivery zone fail: %s", err)
+	}
+	result.HttpResult(c.Writer, nil, err)
+}
@@ -0,0 +1,59 @@
+package rest
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/tespkg/bytes-be/common/result"
+	"github.com/tespkg/bytes-be/svc/staff/logic"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+)
+
+// GeoSearch
+// @Summary search addresses for autocomplete, the results are biased to the market
+// @Tags Geo
+// @Produce json
+// @Param q query string true "query"
+// @Param limit query int false "max results, defaults to 6"
+// @Param bounded query bool false "only return the results inside the market"
+// @Param lang query string false "accept language, defaults to the market language"
+// @Success 200 {object} result.ResponseSuccessBean[dto.GeoFeatureCollection]
+// @Router /api/v1/geo/search [get]
+func (s *Server) GeoSearch(c *gin.Context) {
+	var req dto.GeoSearchReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		logrus.Error("c.ShouldBindQuery fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindQuery fail"))
+		return
+	}
+
+	resp, err := logic.GeoSearch(c.Request.Context(), &req)
+	if err != nil {
+		logrus.Errorf("geo search fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// GeoReverse
+// @Summary resolve a pin to an address
+// @Tags Geo
+// @Produce json
+// @Param lat query number true "latitude"
+// @Param lon query number true "longitude"
+// @Param lang query string false "accept language, defaults to the market language"
+// @Success 200 {object} result.ResponseSuccessBean[dto.GeoFeatureCollection]
+// @Router /api/v1/geo/reverse [get]
+func (s *Server) GeoReverse(c *gin.Context) {
+	var req dto.GeoReverseReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		logrus.Error("c.ShouldBindQuery fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindQuery fail"))
+		return
+	}
+
+	resp, err := logic.GeoReverse(c.Request.Context(), &req)
+	if err != nil {
+		logrus.Errorf("geo reverse fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
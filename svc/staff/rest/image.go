@@ -0,0 +1,95 @@
+package rest
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/tespkg/bytes-be/common/media"
+	"github.com/tespkg/bytes-be/common/result"
+	"github.com/tespkg/bytes-be/svc/staff/logic"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UploadMerchantImage
+// @Summary upload a product image or the logo, jpeg or png up to 5MB, the thumbnails are generated
+// @Tags Merchant
+// @Accept multipart/form-data
+// @Produce json
+// @Param type formData string true "product or logo"
+// @Param file formData file true "image"
+// @Success 200 {object} result.ResponseSuccessBean[dto.ImageResp]
+// @Router /api/v1/merchant/images [post]
+func (s *Server) UploadMerchantImage(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, logic.MaxImageSize+1<<20)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("file is required"))
+		return
+	}
+	if fileHeader.Size > logic.MaxImageSize {
+		result.ParamErrorResult(c.Writer, errors.Errorf("the file exceeds %d bytes", logic.MaxImageSize))
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("the file can't be read"))
+		return
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, logic.MaxImageSize+1))
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("the file can't be read"))
+		return
+	}
+
+	imageType := c.PostForm("type")
+	if err = logic.ValidateImage(imageType, content); err != nil {
+		result.ParamErrorResult(c.Writer, err)
+		return
+	}
+
+	resp, err := logic.UploadMerchantImage(c.Request.Context(), getMerchant(c), imageType, content)
+	if err != nil {
+		logrus.Errorf("upload merchant image fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// GetFile
+// @Summary get a file by its signed url
+// @Tags Common
+// @Produce octet-stream
+// @Param key path string true "file key"
+// @Param expires query int true "expiry of the url"
+// @Param signature query string true "signature of the url"
+// @Success 200 {file} file
+// @Router /api/v1/files/{key} [get]
+func (s *Server) GetFile(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	object, err := logic.OpenFile(c.Request.Context(), key, c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		logrus.Errorf("get file fail: %s", err)
+		result.HttpResult(c.Writer, nil, err)
+		return
+	}
+	defer object.Body.Close()
+
+	//the file behind a key never changes, but the cached response must not outlive the signature
+	maxAge := int64(media.CacheMaxAge(c.Query("expires")) / time.Second)
+	c.Header("Cache-Control", "public, max-age="+strconv.FormatInt(maxAge, 10)+", immutable")
+	if object.Size > 0 {
+		c.Header("Content-Length", strconv.FormatInt(object.Size, 10))
+	}
+	c.Header("Content-Type", object.ContentType)
+	c.Status(http.StatusOK)
+	if _, err = io.Copy(c.Writer, object.Body); err != nil {
+		logrus.Errorf("write file %s fail: %s", key, err)
+	}
+}
@@ -0,0 +1,158 @@
+package rest
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/tespkg/bytes-be/common/result"
+	"github.com/tespkg/bytes-be/svc/staff/logic"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+	"strconv"
+	"time"
+)
+
+const (
+	ingredientAnalyzeInterval = time.Minute
+	ingredientAnalyzeBatch    = 20
+)
+
+// GetProductIngredient
+// @Summary get the ingredients and allergens of a food
+// @Tags Catalog
+// @Produce json
+// @Param productId path int true "product id"
+// @Success 200 {object} result.ResponseSuccessBean[dto.ProductIngredientResp]
+// @Router /api/v1/merchant/products/{productId}/ingredients [get]
+func (s *Server) GetProductIngredient(c *gin.Context) {
+	productId, err := strconv.ParseInt(c.Param("productId"), 10, 64)
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("invalid product id"))
+		return
+	}
+
+	resp, err := logic.GetProductIngredient(s.db, getMerchant(c), productId)
+	if err != nil {
+		logrus.Errorf("get product ingredient fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// UpdateProductIngredient
+// @Summary correct the ingredients and allergens of a food, the list is confirmed
+// @Tags Catalog
+// @Accept json
+// @Produce json
+// @Param productId path int true "product id"
+// @Param req body dto.ProductIngredientReq true "product ingredient request"
+// @Success 200 {object} result.ResponseSuccessBean[dto.ProductIngredientResp]
+// @Router /api/v1/merchant/products/{productId}/ingredients [put]
+func (s *Server) UpdateProductIngredient(c *gin.Context) {
+	productId, err := strconv.ParseInt(c.Param("productId"), 10, 64)
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("invalid product id"))
+		return
+	}
+
+	var req *dto.ProductIngredientReq
+	if err = c.ShouldBindJSON(&req); err != nil {
+		logrus.Error("c.ShouldBindJSON fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindJSON fail"))
+		return
+	}
+
+	resp, err := logic.UpdateProductIngredient(s.db, getMerchant(c), productId, req)
+	if err != nil {
+		logrus.Errorf("update product ingredient fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// ListProductIngredientAudits
+// @Summary list the changes of the ingredients and allergens of a food, the oldest first
+// @Tags Catalog
+// @Produce json
+// @Param productId path int true "product id"
+// @Success 200 {object} result.ResponseSuccessBean[dto.ListIngredientAuditResp]
+// @Router /api/v1/merchant/products/{productId}/ingredients/history [get]
+func (s *Server) ListProductIngredientAudits(c *gin.Context) {
+	productId, err := strconv.ParseInt(c.Param("productId"), 10, 64)
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("invalid product id"))
+		return
+	}
+
+	resp, err := logic.ListProductIngredientAudits(s.db, getMerchant(c), productId)
+	if err != nil {
+		logrus.Errorf("list product ingredient audits fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// ConfirmProductIngredient
+// @Summary confirm the generated ingredients and allergens of a food as they are
+// @Tags Catalog
+// @Produce json
+// @Param productId path int true "product id"
+// @Success 200 {object} result.ResponseSuccessBean[dto.ProductIngredientResp]
+// @Router /api/v1/merchant/products/{productId}/ingredients/confirm [post]
+func (s *Server) ConfirmProductIngredient(c *gin.Context) {
+	productId, err := strconv.ParseInt(c.Param("productId"), 10, 64)
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("invalid product id"))
+		return
+	}
+
+	resp, err := logic.ConfirmProductIngredient(s.db, getMerchant(c), productId)
+	if err != nil {
+		logrus.Errorf("confirm product ingredient fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// AnalyzeProductIngredient
+// @Summary generate the ingredients and allergens of a food again, replacing the confirmed ones
+// @Tags Catalog
+// @Produce json
+// @Param productId path int true "product id"
+// @Success 200 {object} result.ResponseSuccessBean[dto.ProductIngredientResp]
+// @Router /api/v1/merchant/products/{productId}/ingredients/analyze [post]
+func (s *Server) AnalyzeProductIngredient(c *gin.Context) {
+	productId, err := strconv.ParseInt(c.Param("productId"), 10, 64)
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("invalid product id"))
+		return
+	}
+
+	resp, err := logic.AnalyzeProductIngredient(c.Request.Context(), s.db, s.ingredientAnalysis, getMerchant(c), productId)
+	if err != nil {
+		logrus.Errorf("analyze product ingredient fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// analyzeProductIngredients generates the ingredients of the new and renamed foods, until the server shuts down
+func (s *Server) analyzeProductIngredients() {
+	if s.ingredientAnalysis == nil {
+		return
+	}
+
+	ticker := time.NewTicker(ingredientAnalyzeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.shutdownChan:
+			return
+		case <-ticker.C:
+			analyzed, err := logic.AnalyzeProducts(context.Background(), s.db, s.ingredientAnalysis, ingredientAnalyzeBatch)
+			if err != nil {
+				logrus.Errorf("analyze product ingredients fail: %s", err)
+				continue
+			}
+			if analyzed > 0 {
+				logrus.Infof("analyzed the ingredients of %d products", analyzed)
+			}
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package rest
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/tespkg/bytes-be/common/result"
+	"github.com/tespkg/bytes-be/svc/staff/logic"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+	"strconv"
+	"time"
+)
+
+const (
+	stockReservationSweepInterval = time.Minute
+	stockReservationSweepBatch    = 100
+)
+
+// ListInventories
+// @Summary list the tracked stocks of the merchant
+// @Tags Inventory
+// @Produce json
+// @Success 200 {object} result.ResponseSuccessBean[dto.ListInventoryResp]
+// @Router /api/v1/merchant/inventories [get]
+func (s *Server) ListInventories(c *gin.Context) {
+	resp, err := logic.ListInventories(s.db, getMerchant(c))
+	if err != nil {
+		logrus.Errorf("list inventories fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// SaveInventory
+// @Summary track the stock of a variant or a product without variants, or overwrite it
+// @Tags Inventory
+// @Accept json
+// @Produce json
+// @Param req body dto.InventoryReq true "inventory request"
+// @Success 200 {object} result.ResponseSuccessBean[dto.InventoryResp]
+// @Router /api/v1/merchant/inventories [put]
+func (s *Server) SaveInventory(c *gin.Context) {
+	var req *dto.InventoryReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.Error("c.ShouldBindJSON fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindJSON fail"))
+		return
+	}
+
+	resp, err := logic.SaveInventory(s.db, getMerchant(c), req)
+	if err != nil {
+		logrus.Errorf("save inventory fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// AdjustInventory
+// @Summary add a restock or remove a loss
+// @Tags Inventory
+// @Accept json
+// @Produce json
+// @Param inventoryId path int true "inventory id"
+// @Param req body dto.AdjustInventoryReq true "adjust inventory request"
+// @Success 200 {object} result.ResponseSuccessBean[dto.InventoryResp]
+// @Router /api/v1/merchant/inventories/{inventoryId}/adjust [post]
+func (s *Server) AdjustInventory(c *gin.Context) {
+	inventoryId, err := strconv.ParseInt(c.Param("inventoryId"), 10, 64)
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("invalid inventory id"))
+		return
+	}
+
+	var req *dto.AdjustInventoryReq
+	if err = c.ShouldBindJSON(&req); err != nil {
+		logrus.Error("c.ShouldBindJSON fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindJSON fail"))
+		return
+	}
+
+	resp, err := logic.AdjustInventory(s.db, getMerchant(c), inventoryId, req.Delta)
+	if err != nil {
+		logrus.Errorf("adjust inventory fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// DeleteInventory
+// @Summary stop tracking the stock, the item becomes unlimited
+// @Tags Inventory
+// @Produce json
+// @Param inventoryId path int true "inventory id"
+// @Success 200 {object} result.ResponseSuccessBean[NullJson]
+// @Router /api/v1/merchant/inventories/{inventoryId} [delete]
+func (s *Server) DeleteInventory(c *gin.Context) {
+	inventoryId, err := strconv.ParseInt(c.Param("inventoryId"), 10, 64)
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("invalid inventory id"))
+		return
+	}
+
+	err = logic.DeleteInventory(s.db, getMerchant(c), inventoryId)
+	if err != nil {
+		logrus.Errorf("delete inventory fail: %s", err)
+	}
+	result.HttpResult(c.Writer, nil, err)
+}
+
+// sweepStockReservations cancels the orders not accepted in time and releases the stock of the expired reservations, until the server shuts down
+func (s *Server) sweepStockReservations() {
+	ticker := time.NewTicker(stockReservationSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.shutdownChan:
+			return
+		case <-ticker.C:
+			cancelled, err := logic.CancelExpiredOrders(s.db, stockReservationSweepBatch)
+			if err != nil {
+				logrus.Errorf("cancel expired orders fail: %s", err)
+			}
+			if cancelled > 0 {
+				logrus.Infof("cancelled %d orders not accepted in time", cancelled)
+			}
+
+			released, err := logic.ReleaseExpiredStockReservations(s.db, stockReservationSweepBatch)
+			if err != nil {
+				logrus.Errorf("sweep stock reservations fail: %s", err)
+				continue
+			}
+			if released > 0 {
+				logrus.Infof("released the stock of %d expired orders", released)
+			}
+		}
+	}
+}
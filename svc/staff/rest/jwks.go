@@ -0,0 +1,18 @@
+package rest
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/tespkg/bytes-be/common/result"
+	"github.com/tespkg/bytes-be/common/token"
+)
+
+// JWKS
+// @Summary public keys to verify bytes-be tokens
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} token.JSONWebKeySet
+// @Router /.well-known/jwks.json [get]
+func (s *Server) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	result.OkJson(c.Writer, token.JWKS())
+}
@@ -0,0 +1,196 @@
+package rest
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/tespkg/bytes-be/common/result"
+	"github.com/tespkg/bytes-be/svc/staff/logic"
+	"github.com/tespkg/bytes-be/svc/staff/model/dao"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// MerchantRegister
+// @Summary merchant register, the merchant waits for the admin approval
+// @Tags Merchant
+// @Accept json
+// @Produce json
+// @Param req body dto.MerchantRegisterReq true "merchant register request"
+// @Success 200 {object} result.ResponseSuccessBean[dto.MerchantRegisterResp]
+// @Router /api/v1/merchant/register [post]
+func (s *Server) MerchantRegister(c *gin.Context) {
+	var req *dto.MerchantRegisterReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.Error("c.ShouldBindJSON fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindJSON fail"))
+		return
+	}
+
+	resp, err := logic.MerchantRegister(c.Request.Context(), s.db, req)
+	if err != nil {
+		logrus.Errorf("merchant register fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// MerchantLogin
+// @Summary merchant login with password or verify code
+// @Tags Merchant
+// @Accept json
+// @Produce json
+// @Param req body dto.MerchantLoginReq true "merchant login request"
+// @Success 200 {object} result.ResponseSuccessBean[dto.MerchantLoginResp]
+// @Router /api/v1/merchant/login [post]
+func (s *Server) MerchantLogin(c *gin.Context) {
+	var req *dto.MerchantLoginReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.Error("c.ShouldBindJSON fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindJSON fail"))
+		return
+	}
+
+	if req.Phone == "" && req.Email == "" {
+		result.ParamErrorResult(c.Writer, errors.New("phone or email is required"))
+		return
+	}
+
+	if req.Password == "" && req.Code == "" {
+		result.ParamErrorResult(c.Writer, errors.New("password or code is required"))
+		return
+	}
+
+	resp, err := logic.MerchantLogin(c.Request.Context(), s.db, req)
+	if err != nil {
+		logrus.Errorf("merchant login fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// GetMerchantProfile
+// @Summary get the merchant with its review status and documents
+// @Tags Merchant
+// @Produce json
+// @Success 200 {object} result.ResponseSuccessBean[dto.MerchantResp]
+// @Router /api/v1/merchant/profile [get]
+func (s *Server) GetMerchantProfile(c *gin.Context) {
+	resp, err := logic.GetMerchantProfile(s.db, getMerchant(c))
+	if err != nil {
+		logrus.Errorf("get merchant profile fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// UpdateMerchantProfile
+// @Summary update the merchant before approval, a rejected merchant is submitted for review again
+// @Tags Merchant
+// @Accept json
+// @Produce json
+// @Param req body dto.MerchantInfoReq true "merchant info request"
+// @Success 200 {object} result.ResponseSuccessBean[dto.MerchantResp]
+// @Router /api/v1/merchant/profile [put]
+func (s *Server) UpdateMerchantProfile(c *gin.Context) {
+	var req *dto.MerchantInfoReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.Error("c.ShouldBindJSON fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindJSON fail"))
+		return
+	}
+
+	resp, err := logic.UpdateMerchantProfile(s.db, getUser(c), getMerchant(c), req)
+	if err != nil {
+		logrus.Errorf("update merchant profile fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// UploadMerchantDocument
+// @Summary upload a document for the review, pdf, jpeg or png up to 10MB
+// @Tags Merchant
+// @Accept multipart/form-data
+// @Produce json
+// @Param type formData string true "cr_certificate, owner_id, food_license or other"
+// @Param file formData file true "document"
+// @Success 200 {object} result.ResponseSuccessBean[dto.MerchantDocumentResp]
+// @Router /api/v1/merchant/documents [post]
+func (s *Server) UploadMerchantDocument(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, logic.MaxMerchantDocumentSize+1<<20)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("file is required"))
+		return
+	}
+	if fileHeader.Size > logic.MaxMerchantDocumentSize {
+		result.ParamErrorResult(c.Writer, errors.Errorf("the file exceeds %d bytes", logic.MaxMerchantDocumentSize))
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("the file can't be read"))
+		return
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, logic.MaxMerchantDocumentSize+1))
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("the file can't be read"))
+		return
+	}
+
+	docType := c.PostForm("type")
+	contentType, err := logic.ValidateMerchantDocument(docType, content)
+	if err != nil {
+		result.ParamErrorResult(c.Writer, err)
+		return
+	}
+
+	resp, err := logic.UploadMerchantDocument(s.db, getMerchant(c), docType, fileHeader.Filename, contentType, content)
+	if err != nil {
+		logrus.Errorf("upload merchant document fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// ListMerchantDocuments
+// @Summary list the documents of the merchant
+// @Tags Merchant
+// @Produce json
+// @Success 200 {object} result.ResponseSuccessBean[dto.ListMerchantDocumentResp]
+// @Router /api/v1/merchant/documents [get]
+func (s *Server) ListMerchantDocuments(c *gin.Context) {
+	resp, err := logic.ListMerchantDocuments(s.db, getMerchant(c))
+	if err != nil {
+		logrus.Errorf("list merchant documents fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// DeleteMerchantDocument
+// @Summary delete a document before approval
+// @Tags Merchant
+// @Produce json
+// @Param documentId path int true "document id"
+// @Success 200 {object} result.ResponseSuccessBean[NullJson]
+// @Router /api/v1/merchant/documents/{documentId} [delete]
+func (s *Server) DeleteMerchantDocument(c *gin.Context) {
+	documentId, err := strconv.ParseInt(c.Param("documentId"), 10, 64)
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("invalid document id"))
+		return
+	}
+
+	err = logic.DeleteMerchantDocument(s.db, getMerchant(c), documentId)
+	if err != nil {
+		logrus.Errorf("delete merchant document fail: %s", err)
+	}
+	result.HttpResult(c.Writer, nil, err)
+}
+
+// getMerchant the merchant loaded by middle.WithMerchant
+func getMerchant(c *gin.Context) *dao.Merchant {
+	return c.MustGet("merchant").(*dao.Merchant)
+}
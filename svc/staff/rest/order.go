@@ -0,0 +1,135 @@
+package rest
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/tespkg/bytes-be/common/result"
+	"github.com/tespkg/bytes-be/svc/staff/logic"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+)
+
+// PlaceOrder
+// @Summary place an order on an open store, the stock of the tracked items is reserved until the merchant accepts it
+// @Tags Order
+// @Accept json
+// @Produce json
+// @Param req body dto.PlaceOrderReq true "place order request"
+// @Success 200 {object} result.ResponseSuccessBean[dto.OrderResp]
+// @Router /api/v1/customer/orders [post]
+func (s *Server) PlaceOrder(c *gin.Context) {
+	var req *dto.PlaceOrderReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.Error("c.ShouldBindJSON fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindJSON fail"))
+		return
+	}
+
+	resp, err := logic.PlaceOrder(s.db, getUser(c), req)
+	if err != nil {
+		logrus.Errorf("place order fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// ListCustomerOrders
+// @Summary list the orders of the customer, the last placed first
+// @Tags Order
+// @Produce json
+// @Param status query string false "placed, accepted or cancelled"
+// @Param page query int false "page, starts from 1"
+// @Param pageSize query int false "page size, defaults to 20"
+// @Success 200 {object} result.ResponseSuccessBean[dto.ListOrderResp]
+// @Router /api/v1/customer/orders [get]
+func (s *Server) ListCustomerOrders(c *gin.Context) {
+	var req dto.ListOrderReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		logrus.Error("c.ShouldBindQuery fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindQuery fail"))
+		return
+	}
+
+	resp, err := logic.ListCustomerOrders(s.db, getUser(c), &req)
+	if err != nil {
+		logrus.Errorf("list customer orders fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// GetCustomerOrder
+// @Summary get an order of the customer
+// @Tags Order
+// @Produce json
+// @Param orderRef path string true "order ref"
+// @Success 200 {object} result.ResponseSuccessBean[dto.OrderResp]
+// @Router /api/v1/customer/orders/{orderRef} [get]
+func (s *Server) GetCustomerOrder(c *gin.Context) {
+	resp, err := logic.GetCustomerOrder(s.db, getUser(c), c.Param("orderRef"))
+	if err != nil {
+		logrus.Errorf("get customer order fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// CancelOrder
+// @Summary cancel an order not accepted yet, the reserved stock is given back
+// @Tags Order
+// @Accept json
+// @Produce json
+// @Param orderRef path string true "order ref"
+// @Param req body dto.CancelOrderReq true "cancel order request, the reason is optional"
+// @Success 200 {object} result.ResponseSuccessBean[dto.OrderResp]
+// @Router /api/v1/customer/orders/{orderRef}/cancel [post]
+func (s *Server) CancelOrder(c *gin.Context) {
+	var req *dto.CancelOrderReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.Error("c.ShouldBindJSON fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindJSON fail"))
+		return
+	}
+
+	resp, err := logic.CancelOrder(s.db, getUser(c), c.Param("orderRef"), req.Reason)
+	if err != nil {
+		logrus.Errorf("cancel order fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// ListMerchantOrders
+// @Summary list the orders of the store, the last placed first
+// @Tags Order
+// @Produce json
+// @Param status query string false "placed, accepted or cancelled"
+// @Param page query int false "page, starts from 1"
+// @Param pageSize query int false "page size, defaults to 20"
+// @Success 200 {object} result.ResponseSuccessBean[dto.ListOrderResp]
+// @Router /api/v1/merchant/orders [get]
+func (s *Server) ListMerchantOrders(c *gin.Context) {
+	var req dto.ListOrderReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		logrus.Error("c.ShouldBindQuery fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindQuery fail"))
+		return
+	}
+
+	resp, err := logic.ListMerchantOrders(s.db, getMerchant(c), &req)
+	if err != nil {
+		logrus.Errorf("list merchant orders fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// AcceptOrder
+// @Summary accept a placed order, its reserved stock is taken out of the inventory
+// @Tags Order
+// @Produce json
+// @Param orderRef path string true "order ref"
+// @Success 200 {object} result.ResponseSuccessBean[dto.OrderResp]
+// @Router /api/v1/merchant/orders/{orderRef}/accept [post]
+func (s *Server) AcceptOrder(c *gin.Context) {
+	resp, err := logic.AcceptOrder(s.db, getMerchant(c), c.Param("orderRef"))
+	if err != nil {
+		logrus.Errorf("accept order fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
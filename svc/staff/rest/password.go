@@ -0,0 +1,89 @@
+package rest
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/tespkg/bytes-be/common/result"
+	"github.com/tespkg/bytes-be/svc/staff/logic"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+)
+
+// ForgotPassword
+// @Summary send a verify code to reset the password
+// @Tags Customer
+// @Accept json
+// @Produce json
+// @Param req body dto.ForgotPasswordReq true "forgot password request"
+// @Success 200 {object} result.ResponseSuccessBean[NullJson]
+// @Router /api/v1/customer/password/forgot [post]
+func (s *Server) ForgotPassword(c *gin.Context) {
+	var req *dto.ForgotPasswordReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.Error("c.ShouldBindJSON fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindJSON fail"))
+		return
+	}
+
+	if req.Phone == "" && req.Email == "" {
+		result.ParamErrorResult(c.Writer, errors.New("phone or email is required"))
+		return
+	}
+
+	err := logic.ForgotPassword(c.Request.Context(), s.db, req, c.ClientIP())
+	if err != nil {
+		logrus.Errorf("forgot password fail: %s", err)
+	}
+	result.HttpResult(c.Writer, nil, err)
+}
+
+// ResetPassword
+// @Summary reset the password with a verify code
+// @Tags Customer
+// @Accept json
+// @Produce json
+// @Param req body dto.ResetPasswordReq true "reset password request"
+// @Success 200 {object} result.ResponseSuccessBean[NullJson]
+// @Router /api/v1/customer/password/reset [post]
+func (s *Server) ResetPassword(c *gin.Context) {
+	var req *dto.ResetPasswordReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.Error("c.ShouldBindJSON fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindJSON fail"))
+		return
+	}
+
+	if req.Phone == "" && req.Email == "" {
+		result.ParamErrorResult(c.Writer, errors.New("phone or email is required"))
+		return
+	}
+
+	err := logic.ResetPassword(c.Request.Context(), s.db, req)
+	if err != nil {
+		logrus.Errorf("reset password fail: %s", err)
+	}
+	result.HttpResult(c.Writer, nil, err)
+}
+
+// ChangePassword
+// @Summary change the password, every other session is logged out
+// @Tags Customer
+// @Accept json
+// @Produce json
+// @Param req body dto.ChangePasswordReq true "change password request"
+// @Success 200 {object} result.ResponseSuccessBean[dto.ChangePasswordResp]
+// @Router /api/v1/customer/password/change [post]
+func (s *Server) ChangePassword(c *gin.Context) {
+	var req *dto.ChangePasswordReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.Error("c.ShouldBindJSON fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindJSON fail"))
+		return
+	}
+
+	resp, err := logic.ChangePassword(c.Request.Context(), s.db, getClaims(c), req)
+	if err != nil {
+		logrus.Errorf("change password fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
@@ -0,0 +1,98 @@
+package rest
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/tespkg/bytes-be/common/result"
+	"github.com/tespkg/bytes-be/svc/staff/logic"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+)
+
+// GetProfile
+// @Summary get the customer profile
+// @Tags Customer
+// @Produce json
+// @Success 200 {object} result.ResponseSuccessBean[dto.CustomerProfileResp]
+// @Router /api/v1/customer/profile [get]
+func (s *Server) GetProfile(c *gin.Context) {
+	resp, err := logic.GetCustomerProfile(s.db, getUser(c))
+	if err != nil {
+		logrus.Errorf("get profile fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// UpdateProfile
+// @Summary update the customer profile, only the given fields are changed
+// @Tags Customer
+// @Accept json
+// @Produce json
+// @Param req body dto.UpdateCustomerProfileReq true "update profile request"
+// @Success 200 {object} result.ResponseSuccessBean[dto.CustomerProfileResp]
+// @Router /api/v1/customer/profile [patch]
+func (s *Server) UpdateProfile(c *gin.Context) {
+	var req *dto.UpdateCustomerProfileReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.Error("c.ShouldBindJSON fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindJSON fail"))
+		return
+	}
+
+	if err := logic.ValidateCustomerProfile(req); err != nil {
+		result.ParamErrorResult(c.Writer, err)
+		return
+	}
+
+	resp, err := logic.UpdateCustomerProfile(s.db, getUser(c), req)
+	if err != nil {
+		logrus.Errorf("update profile fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// ChangePhone
+// @Summary change the phone with a change_phone verify code sent to the new phone
+// @Tags Customer
+// @Accept json
+// @Produce json
+// @Param req body dto.ChangePhoneReq true "change phone request"
+// @Success 200 {object} result.ResponseSuccessBean[NullJson]
+// @Router /api/v1/customer/phone/change [post]
+func (s *Server) ChangePhone(c *gin.Context) {
+	var req *dto.ChangePhoneReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.Error("c.ShouldBindJSON fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindJSON fail"))
+		return
+	}
+
+	err := logic.ChangePhone(c.Request.Context(), s.db, getUser(c), req)
+	if err != nil {
+		logrus.Errorf("change phone fail: %s", err)
+	}
+	result.HttpResult(c.Writer, nil, err)
+}
+
+// ChangeEmail
+// @Summary change the email with a change_email verify code sent to the new email
+// @Tags Customer
+// @Accept json
+// @Produce json
+// @Param req body dto.ChangeEmailReq true "change email request"
+// @Success 200 {object} result.ResponseSuccessBean[NullJson]
+// @Router /api/v1/customer/email/change [post]
+func (s *Server) ChangeEmail(c *gin.Context) {
+	var req *dto.ChangeEmailReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.Error("c.ShouldBindJSON fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindJSON fail"))
+		return
+	}
+
+	err := logic.ChangeEmail(c.Request.Context(), s.db, getUser(c), req)
+	if err != nil {
+		logrus.Errorf("change email fail: %s", err)
+	}
+	result.HttpResult(c.Writer, nil, err)
+}
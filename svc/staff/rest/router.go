@@ -6,6 +6,7 @@ import (
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 	"github.com/tespkg/bytes-be/svc/staff/middle"
+	"github.com/tespkg/bytes-be/svc/staff/model/dao"
 )
 
 func (s *Server) ginRouter() {
@@ -17,6 +18,8 @@ func (s *Server) ginRouter() {
 	engine.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler,
 		ginSwagger.URL("doc.json")))
 
+	engine.GET("/.well-known/jwks.json", s.JWKS)
+
 	engine.Use(middle.WithTimezone())
 
 	v1 := engine.Group("/api/v1")
@@ -24,18 +27,176 @@ func (s *Server) ginRouter() {
 	{
 		s.routerCommon(v1.Group("/common"))
 	}
+	{
+		v1.GET("/files/*key", s.GetFile)
+	}
 	{
 		s.routerCustomer(v1.Group("/customer"))
 	}
+	{
+		s.routerAuth(v1.Group("/auth"))
+	}
+	{
+		s.routerGeo(v1.Group("/geo"))
+	}
+	{
+		s.routerMerchant(v1.Group("/merchant"))
+	}
+	{
+		s.routerStore(v1.Group("/stores"))
+	}
+	{
+		s.routerSearch(v1.Group("/search"))
+	}
+	{
+		s.routerDriver(v1.Group("/driver"))
+	}
+	{
+		s.routerAdmin(v1.Group("/admin"))
+	}
 }
 
 func (s *Server) routerCommon(group *gin.RouterGroup, mws ...gin.HandlerFunc) {
 	group.POST("/code/verify", s.SendVerifyCode)
 }
 
+func (s *Server) routerAuth(group *gin.RouterGroup, mws ...gin.HandlerFunc) {
+	group.POST("/refresh", s.RefreshToken)
+}
+
+func (s *Server) routerGeo(group *gin.RouterGroup, mws ...gin.HandlerFunc) {
+	group.Use(middle.WithToken(s.db))
+
+	group.GET("/search", s.GeoSearch)
+	group.GET("/reverse", s.GeoReverse)
+}
+
 func (s *Server) routerCustomer(group *gin.RouterGroup, mws ...gin.HandlerFunc) {
 	group.POST("/register", s.Register)
+	group.POST("/login", s.Login)
+	group.POST("/login/oidc", s.OidcLogin)
+	group.POST("/password/forgot", s.ForgotPassword)
+	group.POST("/password/reset", s.ResetPassword)
+
+	group.Use(middle.WithToken(s.db))
+	group.Use(middle.RequireRole(dao.RoleCustomer))
+	group.Use(middle.WithUserInfo(s.db))
+
+	group.POST("/logout", s.Logout)
+	group.POST("/password/change", s.ChangePassword)
+	group.GET("/sessions", s.ListSessions)
+	group.DELETE("/sessions", s.RevokeAllSessions)
+	group.DELETE("/sessions/:sessionId", s.RevokeSession)
+	group.GET("/profile", s.GetProfile)
+	group.PATCH("/profile", s.UpdateProfile)
+	group.POST("/phone/change", s.ChangePhone)
+	group.POST("/email/change", s.ChangeEmail)
+	group.GET("/addresses", s.ListAddresses)
+	group.POST("/addresses", s.CreateAddress)
+	group.GET("/addresses/:addressId", s.GetAddress)
+	group.PUT("/addresses/:addressId", s.UpdateAddress)
+	group.DELETE("/addresses/:addressId", s.DeleteAddress)
+	group.POST("/addresses/:addressId/default", s.SetDefaultAddress)
+	group.GET("/orders", s.ListCustomerOrders)
+	group.POST("/orders", s.PlaceOrder)
+	group.GET("/orders/:orderRef", s.GetCustomerOrder)
+	group.POST("/orders/:orderRef/cancel", s.CancelOrder)
+}
+
+func (s *Server) routerMerchant(group *gin.RouterGroup, mws ...gin.HandlerFunc) {
+	group.POST("/register", s.MerchantRegister)
+	group.POST("/login", s.MerchantLogin)
+
+	group.Use(middle.WithToken(s.db))
+	group.Use(middle.RequireRole(dao.RoleMerchant))
+	group.Use(middle.WithUserInfo(s.db))
+	group.Use(middle.WithMerchant(s.db))
+
+	group.GET("/profile", s.GetMerchantProfile)
+	group.PUT("/profile", s.UpdateMerchantProfile)
+	group.GET("/documents", s.ListMerchantDocuments)
+	group.POST("/documents", s.UploadMerchantDocument)
+	group.DELETE("/documents/:documentId", s.DeleteMerchantDocument)
+
+	//the store can only be set up once the merchant is approved
+	approved := group.Group("", middle.RequireMerchantStatus(dao.MerchantStatusApproved))
+	approved.POST("/images", s.UploadMerchantImage)
+	approved.GET("/hours", s.GetStoreHours)
+	approved.PUT("/hours", s.UpdateStoreHours)
+	approved.GET("/special-days", s.ListStoreSpecialDays)
+	approved.PUT("/special-days", s.SaveStoreSpecialDay)
+	approved.DELETE("/special-days/:date", s.DeleteStoreSpecialDay)
+	approved.POST("/pause", s.PauseStore)
+	approved.DELETE("/pause", s.ResumeStore)
+	approved.GET("/delivery-zones", s.ListDeliveryZones)
+	approved.POST("/delivery-zones", s.CreateDeliveryZone)
+	approved.PUT("/delivery-zones/:zoneId", s.UpdateDeliveryZone)
+	approved.DELETE("/delivery-zones/:zoneId", s.DeleteDeliveryZone)
+	approved.GET("/categories", s.ListCategories)
+	approved.POST("/categories", s.CreateCategory)
+	approved.PUT("/categories/:categoryId", s.UpdateCategory)
+	approved.DELETE("/categories/:categoryId", s.DeleteCategory)
+	approved.GET("/products", s.ListProducts)
+	approved.POST("/products", s.CreateProduct)
+	approved.GET("/products/:productId", s.GetProduct)
+	approved.PUT("/products/:productId", s.UpdateProduct)
+	approved.PATCH("/products/:productId/availability", s.UpdateProductAvailability)
+	approved.DELETE("/products/:productId", s.DeleteProduct)
+	approved.GET("/products/:productId/ingredients", s.GetProductIngredient)
+	approved.PUT("/products/:productId/ingredients", s.UpdateProductIngredient)
+	approved.GET("/products/:productId/ingredients/history", s.ListProductIngredientAudits)
+	approved.POST("/products/:productId/ingredients/confirm", s.ConfirmProductIngredient)
+	approved.POST("/products/:productId/ingredients/analyze", s.AnalyzeProductIngredient)
+	approved.POST("/catalog/import", s.ImportCatalog)
+	approved.GET("/catalog/export", s.ExportCatalog)
+	approved.GET("/inventories", s.ListInventories)
+	approved.PUT("/inventories", s.SaveInventory)
+	approved.POST("/inventories/:inventoryId/adjust", s.AdjustInventory)
+	approved.DELETE("/inventories/:inventoryId", s.DeleteInventory)
+	approved.GET("/orders", s.ListMerchantOrders)
+	approved.POST("/orders/:orderRef/accept", s.AcceptOrder)
+}
+
+func (s *Server) routerStore(group *gin.RouterGroup, mws ...gin.HandlerFunc) {
+	group.Use(middle.WithToken(s.db))
+
+	group.GET("", s.ListStores)
+	group.GET("/:storeId", s.GetStore)
+	group.GET("/:storeId/delivery", s.GetStoreDeliveryQuote)
+	group.GET("/:storeId/catalog", s.GetStoreCatalog)
+	group.GET("/:storeId/products/:productId", s.GetStoreProduct)
+	group.POST("/:storeId/price", s.PriceStoreProduct)
+}
+
+func (s *Server) routerSearch(group *gin.RouterGroup, mws ...gin.HandlerFunc) {
+	group.Use(middle.WithToken(s.db))
+
+	group.GET("/products", s.SearchProducts)
+	group.GET("/stores", s.SearchStores)
+	group.GET("/semantic", s.SemanticSearch)
+}
+
+func (s *Server) routerDriver(group *gin.RouterGroup, mws ...gin.HandlerFunc) {
+	group.Use(middle.WithToken(s.db))
+	group.Use(middle.RequireRole(dao.RoleDriver))
+}
 
+func (s *Server) routerAdmin(group *gin.RouterGroup, mws ...gin.HandlerFunc) {
 	group.Use(middle.WithToken(s.db))
+	group.Use(middle.RequireRole(dao.RoleAdmin))
 	group.Use(middle.WithUserInfo(s.db))
+
+	merchants := group.Group("/merchants", middle.RequirePermission(middle.PermissionMerchantReview))
+	merchants.GET("", s.ListMerchants)
+	merchants.GET("/:merchantId", s.GetMerchantDetail)
+	merchants.GET("/:merchantId/documents/:documentId", s.DownloadMerchantDocument)
+	merchants.POST("/:merchantId/approve", s.ApproveMerchant)
+	merchants.POST("/:merchantId/reject", s.RejectMerchant)
+	merchants.POST("/:merchantId/suspend", s.SuspendMerchant)
+
+	ingredients := group.Group("/ingredients", middle.RequirePermission(middle.PermissionIngredientReview))
+	ingredients.GET("", s.ListIngredientReviews)
+	ingredients.GET("/:productId", s.GetIngredientReview)
+	ingredients.PUT("/:productId", s.ReviewProductIngredient)
+	ingredients.POST("/:productId/approve", s.ApproveProductIngredient)
 }
@@ -0,0 +1,147 @@
+package rest
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/tespkg/bytes-be/common/result"
+	"github.com/tespkg/bytes-be/common/semantic"
+	"github.com/tespkg/bytes-be/svc/staff/logic"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+	"time"
+)
+
+const (
+	semanticSyncInterval = time.Minute
+	semanticEmbedBatch   = 50
+	// semanticEmbedMaxBatches bounds the embedding of a sync, a bulk import is embedded over the next syncs
+	semanticEmbedMaxBatches = 20
+)
+
+// SearchProducts
+// @Summary search the dishes and clothing by name, category and description, every word matches as a prefix
+// @Tags Search
+// @Produce json
+// @Param q query string true "search words"
+// @Param lang query string false "en or ar, detected from q if empty"
+// @Param storeId query int false "only the products of the store"
+// @Param categoryId query int false "only the products of the category"
+// @Param minPrice query int false "minimum price in minor units"
+// @Param maxPrice query int false "maximum price in minor units"
+// @Param openNow query bool false "only the products of the stores open now"
+// @Param excludeAllergens query string false "comma separated allergens like peanuts,dairy, hides the foods with them or not analyzed yet"
+// @Param cursor query string false "nextCursor of the previous page"
+// @Param limit query int false "page size, defaults to 20"
+// @Success 200 {object} result.ResponseSuccessBean[dto.SearchProductResp]
+// @Router /api/v1/search/products [get]
+func (s *Server) SearchProducts(c *gin.Context) {
+	var req dto.SearchProductReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		logrus.Error("c.ShouldBindQuery fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindQuery fail"))
+		return
+	}
+
+	resp, err := logic.SearchProducts(s.db, &req)
+	if err != nil {
+		logrus.Errorf("search products fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// SearchStores
+// @Summary search the stores by name and address, every word matches as a prefix
+// @Tags Search
+// @Produce json
+// @Param q query string true "search words"
+// @Param lang query string false "en or ar, detected from q if empty"
+// @Param openNow query bool false "only the stores open now"
+// @Param cursor query string false "nextCursor of the previous page"
+// @Param limit query int false "page size, defaults to 20"
+// @Success 200 {object} result.ResponseSuccessBean[dto.SearchStoreResp]
+// @Router /api/v1/search/stores [get]
+func (s *Server) SearchStores(c *gin.Context) {
+	var req dto.SearchStoreReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		logrus.Error("c.ShouldBindQuery fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindQuery fail"))
+		return
+	}
+
+	resp, err := logic.SearchStores(s.db, &req)
+	if err != nil {
+		logrus.Errorf("search stores fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// SemanticSearch
+// @Summary search the dishes and clothing by meaning, e.g. "something spicy with chicken", optionally blended with the keyword search
+// @Tags Search
+// @Produce json
+// @Param q query string true "search text"
+// @Param mode query string false "semantic or hybrid, defaults to semantic"
+// @Param weight query number false "share of the similarity in the hybrid score between 0 and 1, defaults to 0.7"
+// @Param lang query string false "en or ar of the keyword search, detected from q if empty"
+// @Param storeId query int false "only the products of the store"
+// @Param categoryId query int false "only the products of the category"
+// @Param minPrice query int false "minimum price in minor units"
+// @Param maxPrice query int false "maximum price in minor units"
+// @Param openNow query bool false "only the products of the stores open now"
+// @Param excludeAllergens query string false "comma separated allergens like peanuts,dairy, hides the foods with them or not analyzed yet"
+// @Param limit query int false "count of the products, defaults to 20"
+// @Success 200 {object} result.ResponseSuccessBean[dto.SemanticSearchResp]
+// @Router /api/v1/search/semantic [get]
+func (s *Server) SemanticSearch(c *gin.Context) {
+	var req dto.SemanticSearchReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		logrus.Error("c.ShouldBindQuery fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindQuery fail"))
+		return
+	}
+
+	resp, err := logic.SemanticSearchProducts(c.Request.Context(), s.db, &req)
+	if err != nil {
+		logrus.Errorf("semantic search fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// syncSemanticIndex embeds the changed products and loads the embeddings into the index, until the server shuts down
+func (s *Server) syncSemanticIndex() {
+	if !semantic.Enabled() {
+		return
+	}
+
+	since, err := logic.SyncSemanticIndex(s.db, time.Time{})
+	if err != nil {
+		logrus.Errorf("load semantic index fail: %s", err)
+	}
+	logrus.Infof("semantic index loaded with %d products", semantic.Default().Len())
+
+	ticker := time.NewTicker(semanticSyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.shutdownChan:
+			return
+		case <-ticker.C:
+			for i := 0; i < semanticEmbedMaxBatches; i++ {
+				checked, err := logic.EmbedProducts(context.Background(), s.db, semanticEmbedBatch)
+				if err != nil {
+					logrus.Errorf("embed products fail: %s", err)
+					break
+				}
+				if checked < semanticEmbedBatch {
+					break
+				}
+			}
+
+			if since, err = logic.SyncSemanticIndex(s.db, since); err != nil {
+				logrus.Errorf("sync semantic index fail: %s", err)
+			}
+		}
+	}
+}
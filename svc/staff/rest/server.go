@@ -12,9 +12,19 @@ import (
 	"github.com/redis/go-redis/v9"
 	cors "github.com/rs/cors/wrapper/gin"
 	"github.com/sirupsen/logrus"
+	"github.com/tespkg/bytes-be/common/geo"
+	"github.com/tespkg/bytes-be/common/global"
+	"github.com/tespkg/bytes-be/common/media"
+	"github.com/tespkg/bytes-be/common/semantic"
+	"github.com/tespkg/bytes-be/common/token"
+	"github.com/tespkg/bytes-be/common/verifycode"
 	"github.com/tespkg/bytes-be/config"
+	"github.com/tespkg/bytes-be/internal/geocode"
 	"github.com/tespkg/bytes-be/internal/ingredient"
+	"github.com/tespkg/bytes-be/internal/oidc"
+	"github.com/tespkg/bytes-be/internal/storage"
 	bytesmatch "github.com/tespkg/bytes-be/proto/bytes_match"
+	"github.com/tespkg/bytes-be/svc/staff/logic"
 	"github.com/tespkg/bytes-be/svc/utils"
 	"github.com/tespkg/clickpay"
 	"github.com/tespkg/smartpay"
@@ -45,7 +55,9 @@ type Server struct {
 	smsClient smspb.SMSClient
 	sesClient sespb.SESClient
 
-	nominatimClient *utils.Client
+	geocoder geocode.Geocoder
+
+	storage storage.Storage
 
 	bytesMatchClient bytesmatch.BytesMatchClient
 
@@ -54,6 +66,8 @@ type Server struct {
 
 	ingredientAnalysis ingredient.Analysis
 
+	oidcVerifier oidc.Verifier
+
 	socketServer *socketio.Server
 
 	shutdownChan chan struct{}
@@ -98,9 +112,14 @@ func (s *Server) Load(config config.Config) error {
 		return err
 	}
 
-	//load nominatim client
-	if s.config.NominatimAddr != "" {
-		s.nominatimClient = utils.NewClient(s.config.NominatimAddr)
+	//load nominatim geocoder
+	if err := s.loadGeocoder(); err != nil {
+		return err
+	}
+
+	//load object storage
+	if err := s.loadStorage(); err != nil {
+		return err
 	}
 
 	//load bytes match grpc client
@@ -127,6 +146,52 @@ func (s *Server) Load(config config.Config) error {
 	s.socketServer = initSocketIOServer()
 
 	//set global
+	global.SetUp(global.ClientSet{
+		RedisClient:             s.redisCli,
+		EnableSingleLogin:       s.config.ServiceBasicConfig.SingleLogin,
+		SMSClient:               s.smsClient,
+		SESClient:               s.sesClient,
+		EmailSender:             s.config.ServiceBasicConfig.EmailSender,
+		TokenExpireDurationHour: getTokenExpireDurationHour(s.config.ServiceBasicConfig.LoginTokenExpireDuration),
+		AccessTokenExpireSecond: s.config.ServiceBasicConfig.AccessTokenExpireDuration,
+		JwtSignedSecret:         s.config.JwtSignedSecret,
+		Broadcaster:             global.NewBroadcast(),
+	})
+	//load oidc verifier
+	if err := s.loadOidc(); err != nil {
+		return err
+	}
+
+	//load jwt signing keys
+	if err := s.loadJwtKeys(); err != nil {
+		return err
+	}
+
+	geo.SetUp(s.geocoder, geo.Options{
+		Market:             getMarketViewBox(s.config.Geo.Market.ViewBox),
+		CountryCodes:       s.config.Geo.Market.CountryCodes,
+		AcceptLanguage:     s.config.Geo.Market.AcceptLanguage,
+		CacheExpire:        time.Duration(s.config.Geo.CacheExpireSeconds) * time.Second,
+		RateLimitPerSecond: s.config.Geo.RateLimitPerSecond,
+	})
+
+	media.SetUp(s.storage, media.Options{
+		PublicBaseUrl:   s.config.Storage.PublicBaseUrl,
+		SignBaseUrl:     strings.TrimRight(s.config.ServiceBasicConfig.DomainAddr, "/") + "/api/v1/files",
+		SignSecret:      s.config.Storage.SignSecret,
+		SignedUrlExpire: time.Duration(s.config.Storage.SignedUrlExpireSeconds) * time.Second,
+	})
+
+	semantic.SetUp(s.ingredientAnalysis)
+
+	verifycode.SetUp(verifycode.Options{
+		Length:              s.config.VerifyCode.Length,
+		Expire:              time.Duration(s.config.VerifyCode.ExpireSeconds) * time.Second,
+		MaxAttempts:         s.config.VerifyCode.MaxAttempts,
+		ResendInterval:      time.Duration(s.config.VerifyCode.ResendIntervalSeconds) * time.Second,
+		MaxSendPerHour:      s.config.VerifyCode.MaxSendPerHour,
+		MaxSendPerHourPerIp: s.config.VerifyCode.MaxSendPerHourPerIp,
+	})
 
 	s.engin = gin.New()
 	s.ginRouter()
@@ -154,6 +219,10 @@ func (s *Server) Run(readyChan chan bool) {
 	}()
 	defer s.socketServer.Close()
 
+	go s.sweepStockReservations()
+	go s.syncSemanticIndex()
+	go s.analyzeProductIngredients()
+
 	listener, err := net.Listen("tcp", s.httpServer.Addr)
 	if err != nil {
 		logrus.WithField("err", err).Fatal("unable to listen REST")
@@ -312,7 +381,11 @@ func (s *Server) loadIngredientAnalysis() error {
 		return nil
 	}
 
-	ia, err := ingredient.New()
+	ia, err := ingredient.New(
+		ingredient.WithConfig(s.config.Ingredient),
+		ingredient.WithRedis(s.redisCli),
+		ingredient.WithOverrideStore(logic.NewIngredientOverrideStore(s.db)),
+	)
 	if err != nil {
 		return errors.New("init ingredient analysis fail :" + err.Error())
 	}
@@ -322,6 +395,110 @@ func (s *Server) loadIngredientAnalysis() error {
 	return nil
 }
 
+func (s *Server) loadOidc() error {
+	if len(s.config.Oidc.Providers) == 0 {
+		return nil
+	}
+
+	verifier, err := oidc.New(s.config.Oidc.Providers)
+	if err != nil {
+		return errors.Wrap(err, "init oidc verifier fail")
+	}
+
+	s.oidcVerifier = verifier
+
+	return nil
+}
+
+func (s *Server) loadJwtKeys() error {
+	if len(s.config.Jwt.Keys) == 0 {
+		logrus.Warn("no jwt key configured, tokens are signed with HS256 user secrets")
+		return nil
+	}
+
+	files := make([]token.KeyFile, 0, len(s.config.Jwt.Keys))
+	for _, key := range s.config.Jwt.Keys {
+		files = append(files, token.KeyFile{
+			Kid:            key.Kid,
+			PrivateKeyFile: key.PrivateKeyFile,
+			PublicKeyFile:  key.PublicKeyFile,
+		})
+	}
+
+	ks, err := token.LoadKeySet(files)
+	if err != nil {
+		return errors.Wrap(err, "load jwt keys fail")
+	}
+	token.SetUpKeySet(ks)
+
+	return nil
+}
+
+func (s *Server) loadGeocoder() error {
+	nominatimConfig := s.config.Geo.Nominatim
+	if nominatimConfig.Addr == "" {
+		nominatimConfig.Addr = s.config.NominatimAddr
+	}
+	if nominatimConfig.Addr == "" {
+		logrus.Warn("no nominatim addr configured, geocoding is disabled")
+		return nil
+	}
+
+	geocoder, err := geocode.NewNominatim(nominatimConfig, geocode.WithLimiter(geo.Wait))
+	if err != nil {
+		return errors.Wrap(err, "init nominatim geocoder fail")
+	}
+	s.geocoder = geocoder
+
+	return nil
+}
+
+func (s *Server) loadStorage() error {
+	switch s.config.Storage.Backend {
+	case "local":
+		local, err := storage.NewLocal(s.config.Storage.LocalDir)
+		if err != nil {
+			return errors.Wrap(err, "init local storage fail")
+		}
+		s.storage = local
+	case "meerastorage", "":
+		if s.config.Meerastorage.EndPoint == "" {
+			logrus.Warn("no meerastorage endpoint configured, image upload is disabled")
+			return nil
+		}
+		meerastorage, err := storage.NewMeerastorage(storage.MeerastorageConfig{
+			Endpoint:       s.config.Meerastorage.EndPoint,
+			Bucket:         s.config.Meerastorage.Bucket,
+			TimeoutSeconds: s.config.Meerastorage.TimeoutSeconds,
+		})
+		if err != nil {
+			return errors.Wrap(err, "init meerastorage fail")
+		}
+		s.storage = meerastorage
+	default:
+		return errors.Errorf("unsupported storage backend %s", s.config.Storage.Backend)
+	}
+
+	return nil
+}
+
+// getMarketViewBox view_box is [min lon, min lat, max lon, max lat], anything else disables the market bias
+func getMarketViewBox(viewBox []float64) *geocode.ViewBox {
+	if len(viewBox) != 4 {
+		if len(viewBox) != 0 {
+			logrus.Warnf("invalid market view box %v, geocoding isn't biased", viewBox)
+		}
+		return nil
+	}
+
+	return &geocode.ViewBox{
+		MinLon: viewBox[0],
+		MinLat: viewBox[1],
+		MaxLon: viewBox[2],
+		MaxLat: viewBox[3],
+	}
+}
+
 func newGrpcConn(hostAndPort, caPath, clientCrt, clientKey string) (*grpc.ClientConn, error) {
 	if caPath == "" {
 		return grpc.Dial(hostAndPort, grpc.WithInsecure())
@@ -366,6 +543,14 @@ func doServe(cfg config.ServerREST, httpServer *http.Server, listener net.Listen
 	return httpServer.ServeTLS(listener, cfg.CertPath, cfg.KeyPath)
 }
 
+// getTokenExpireDurationHour converts login_token_expire_duration (seconds) to hours, defaults to 4 weeks
+func getTokenExpireDurationHour(seconds int) int {
+	if seconds < 3600 {
+		return 24 * 28
+	}
+	return seconds / 3600
+}
+
 func getGormLoggerLevel(logLevel string) logger.LogLevel {
 	switch logLevel {
 	case "silent":
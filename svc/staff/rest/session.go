@@ -0,0 +1,77 @@
+package rest
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/tespkg/bytes-be/common/result"
+	"github.com/tespkg/bytes-be/common/token"
+	"github.com/tespkg/bytes-be/svc/staff/logic"
+	"github.com/tespkg/bytes-be/svc/staff/model/dao"
+)
+
+// Logout
+// @Summary logout the current session
+// @Tags Customer
+// @Produce json
+// @Success 200 {object} result.ResponseSuccessBean[NullJson]
+// @Router /api/v1/customer/logout [post]
+func (s *Server) Logout(c *gin.Context) {
+	err := logic.Logout(c.Request.Context(), s.db, getClaims(c))
+	if err != nil {
+		logrus.Errorf("logout fail: %s", err)
+	}
+	result.HttpResult(c.Writer, nil, err)
+}
+
+// ListSessions
+// @Summary list the devices holding a token
+// @Tags Customer
+// @Produce json
+// @Success 200 {object} result.ResponseSuccessBean[dto.ListSessionResp]
+// @Router /api/v1/customer/sessions [get]
+func (s *Server) ListSessions(c *gin.Context) {
+	resp, err := logic.ListSessions(s.db, getClaims(c))
+	if err != nil {
+		logrus.Errorf("list sessions fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// RevokeSession
+// @Summary revoke one session
+// @Tags Customer
+// @Produce json
+// @Param sessionId path string true "session id"
+// @Success 200 {object} result.ResponseSuccessBean[NullJson]
+// @Router /api/v1/customer/sessions/{sessionId} [delete]
+func (s *Server) RevokeSession(c *gin.Context) {
+	err := logic.RevokeSession(c.Request.Context(), s.db, getClaims(c), c.Param("sessionId"))
+	if err != nil {
+		logrus.Errorf("revoke session fail: %s", err)
+	}
+	result.HttpResult(c.Writer, nil, err)
+}
+
+// RevokeAllSessions
+// @Summary revoke all sessions, including the current one
+// @Tags Customer
+// @Produce json
+// @Success 200 {object} result.ResponseSuccessBean[NullJson]
+// @Router /api/v1/customer/sessions [delete]
+func (s *Server) RevokeAllSessions(c *gin.Context) {
+	err := logic.RevokeAllSessions(c.Request.Context(), s.db, getClaims(c))
+	if err != nil {
+		logrus.Errorf("revoke all sessions fail: %s", err)
+	}
+	result.HttpResult(c.Writer, nil, err)
+}
+
+// getClaims claims are set by middle.WithToken
+func getClaims(c *gin.Context) *token.UserClaims {
+	return c.MustGet("claims").(*token.UserClaims)
+}
+
+// getUser the user loaded by middle.WithUserInfo
+func getUser(c *gin.Context) *dao.User {
+	return c.MustGet("user").(*dao.User)
+}
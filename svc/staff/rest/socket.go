@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"github.com/tespkg/bytes-be/common/global"
 	"github.com/tespkg/bytes-be/common/token"
+	"github.com/tespkg/bytes-be/svc/staff/model/dao"
 	"net/http"
 	"strconv"
 	"tespkg.in/kit/log"
@@ -21,6 +22,7 @@ const (
 	NamespaceRoot = "/"
 
 	RoleCustomer = "customer"
+	RoleMerchant = global.BroadcastRoleMerchant
 
 	EventChatConnectReply = "recv-connect"
 )
@@ -58,14 +60,38 @@ func (s *Server) OnConnection() {
 		case RoleCustomer:
 			ctx, err := token.VerifyToken(context.Background(), s.db, accessToken)
 			if err != nil {
-				log.Errorf("token verify failed, token=%s, errors: %s\n", accessToken, err.Error())
+				log.Errorf("token verify failed, errors: %s\n", err.Error())
 				c.Emit(EventChatConnectReply, socketIOResponseErrorWithCode(ResponseSocketIOCode401, err))
+				return nil
 			}
 
 			claims := ctx.Value(token.ClaimsCtx).(*token.UserClaims)
 			userId, _ := strconv.ParseInt(claims.Subject, 10, 64)
 
 			entityId = strconv.FormatInt(userId, 10)
+		case RoleMerchant:
+			ctx, err := token.VerifyToken(context.Background(), s.db, accessToken)
+			if err != nil {
+				log.Errorf("token verify failed, errors: %s\n", err.Error())
+				c.Emit(EventChatConnectReply, socketIOResponseErrorWithCode(ResponseSocketIOCode401, err))
+				return nil
+			}
+
+			claims := ctx.Value(token.ClaimsCtx).(*token.UserClaims)
+			if !claims.HasRole(dao.RoleMerchant) {
+				c.Emit(EventChatConnectReply, socketIOResponseErrorWithCode(ResponseSocketIOCode401, errors.New("not a merchant")))
+				return nil
+			}
+
+			userId, _ := strconv.ParseInt(claims.Subject, 10, 64)
+			merchant, err := dao.GetMerchantByUserId(s.db, userId)
+			if err != nil || merchant == nil || merchant.Id == 0 {
+				c.Emit(EventChatConnectReply, socketIOResponseErrorWithCode(ResponseSocketIOCode401, errors.New("merchant not found")))
+				return nil
+			}
+
+			// the key is the same as global.Broadcast.AddMerchant, so GetMerchantConn finds it
+			entityId = strconv.FormatInt(merchant.Id, 10)
 		default:
 			c.Emit(EventChatConnectReply, socketIOResponseErrorWithCode(ResponseSocketIOCode401, errors.New("invalid role")))
 			return nil
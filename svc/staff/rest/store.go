@@ -0,0 +1,218 @@
+package rest
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/tespkg/bytes-be/common/result"
+	"github.com/tespkg/bytes-be/svc/staff/logic"
+	"github.com/tespkg/bytes-be/svc/staff/model/dto"
+	"strconv"
+)
+
+// GetStoreHours
+// @Summary get the weekly hours of the store with its current opening state
+// @Tags Store
+// @Produce json
+// @Success 200 {object} result.ResponseSuccessBean[dto.StoreHoursResp]
+// @Router /api/v1/merchant/hours [get]
+func (s *Server) GetStoreHours(c *gin.Context) {
+	resp, err := logic.GetStoreHours(s.db, getMerchant(c))
+	if err != nil {
+		logrus.Errorf("get store hours fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// UpdateStoreHours
+// @Summary replace the weekly hours of the store, a weekday may have several shifts
+// @Tags Store
+// @Accept json
+// @Produce json
+// @Param req body dto.StoreHoursReq true "store hours request"
+// @Success 200 {object} result.ResponseSuccessBean[dto.StoreHoursResp]
+// @Router /api/v1/merchant/hours [put]
+func (s *Server) UpdateStoreHours(c *gin.Context) {
+	var req *dto.StoreHoursReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.Error("c.ShouldBindJSON fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindJSON fail"))
+		return
+	}
+
+	resp, err := logic.UpdateStoreHours(s.db, getMerchant(c), req)
+	if err != nil {
+		logrus.Errorf("update store hours fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// ListStoreSpecialDays
+// @Summary list the coming holidays and special days of the store
+// @Tags Store
+// @Produce json
+// @Success 200 {object} result.ResponseSuccessBean[dto.ListStoreSpecialDayResp]
+// @Router /api/v1/merchant/special-days [get]
+func (s *Server) ListStoreSpecialDays(c *gin.Context) {
+	resp, err := logic.ListStoreSpecialDays(s.db, getMerchant(c))
+	if err != nil {
+		logrus.Errorf("list store special days fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// SaveStoreSpecialDay
+// @Summary create or replace the special day of a date, it overrides the weekly hours
+// @Tags Store
+// @Accept json
+// @Produce json
+// @Param req body dto.StoreSpecialDayReq true "store special day request"
+// @Success 200 {object} result.ResponseSuccessBean[dto.StoreSpecialDayResp]
+// @Router /api/v1/merchant/special-days [put]
+func (s *Server) SaveStoreSpecialDay(c *gin.Context) {
+	var req *dto.StoreSpecialDayReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.Error("c.ShouldBindJSON fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindJSON fail"))
+		return
+	}
+
+	resp, err := logic.SaveStoreSpecialDay(s.db, getMerchant(c), req)
+	if err != nil {
+		logrus.Errorf("save store special day fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// DeleteStoreSpecialDay
+// @Summary delete the special day of a date, the weekly hours apply again
+// @Tags Store
+// @Produce json
+// @Param date path string true "YYYY-MM-DD"
+// @Success 200 {object} result.ResponseSuccessBean[NullJson]
+// @Router /api/v1/merchant/special-days/{date} [delete]
+func (s *Server) DeleteStoreSpecialDay(c *gin.Context) {
+	err := logic.DeleteStoreSpecialDay(s.db, getMerchant(c), c.Param("date"))
+	if err != nil {
+		logrus.Errorf("delete store special day fail: %s", err)
+	}
+	result.HttpResult(c.Writer, nil, err)
+}
+
+// PauseStore
+// @Summary close the store temporarily, e.g. when it is too busy
+// @Tags Store
+// @Accept json
+// @Produce json
+// @Param req body dto.PauseStoreReq true "pause store request"
+// @Success 200 {object} result.ResponseSuccessBean[dto.StoreHoursResp]
+// @Router /api/v1/merchant/pause [post]
+func (s *Server) PauseStore(c *gin.Context) {
+	var req *dto.PauseStoreReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.Error("c.ShouldBindJSON fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindJSON fail"))
+		return
+	}
+
+	resp, err := logic.PauseStore(s.db, getMerchant(c), req)
+	if err != nil {
+		logrus.Errorf("pause store fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// ResumeStore
+// @Summary resume the paused store
+// @Tags Store
+// @Produce json
+// @Success 200 {object} result.ResponseSuccessBean[dto.StoreHoursResp]
+// @Router /api/v1/merchant/pause [delete]
+func (s *Server) ResumeStore(c *gin.Context) {
+	resp, err := logic.ResumeStore(s.db, getMerchant(c))
+	if err != nil {
+		logrus.Errorf("resume store fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// ListStores
+// @Summary list the stores with their opening state, and the delivery zone if a location is given
+// @Tags Store
+// @Produce json
+// @Param openNow query bool false "only the stores open now"
+// @Param addressId query int false "only the stores delivering to the address of the customer"
+// @Param lat query number false "only the stores delivering to the point, with lon"
+// @Param lon query number false "only the stores delivering to the point, with lat"
+// @Param page query int false "page, from 1"
+// @Param pageSize query int false "page size, defaults to 20"
+// @Success 200 {object} result.ResponseSuccessBean[dto.ListStoreResp]
+// @Router /api/v1/stores [get]
+func (s *Server) ListStores(c *gin.Context) {
+	var req dto.ListStoreReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		logrus.Error("c.ShouldBindQuery fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindQuery fail"))
+		return
+	}
+
+	resp, err := logic.ListStores(s.db, getClaims(c), &req)
+	if err != nil {
+		logrus.Errorf("list stores fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// GetStore
+// @Summary get the store with its opening state
+// @Tags Store
+// @Produce json
+// @Param storeId path int true "store id"
+// @Success 200 {object} result.ResponseSuccessBean[dto.StoreResp]
+// @Router /api/v1/stores/{storeId} [get]
+func (s *Server) GetStore(c *gin.Context) {
+	storeId, err := strconv.ParseInt(c.Param("storeId"), 10, 64)
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("invalid store id"))
+		return
+	}
+
+	resp, err := logic.GetStore(s.db, storeId)
+	if err != nil {
+		logrus.Errorf("get store fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}
+
+// GetStoreDeliveryQuote
+// @Summary get the delivery zone of the store covering the location, with the minimum order and fee
+// @Tags Store
+// @Produce json
+// @Param storeId path int true "store id"
+// @Param addressId query int false "address of the customer"
+// @Param lat query number false "latitude, with lon if no address"
+// @Param lon query number false "longitude, with lat if no address"
+// @Param subtotal query int false "order subtotal in minor units, checked against the minimum order"
+// @Param deliverAt query int false "unix seconds of a scheduled delivery, checked against the store hours"
+// @Success 200 {object} result.ResponseSuccessBean[dto.StoreDeliveryResp]
+// @Router /api/v1/stores/{storeId}/delivery [get]
+func (s *Server) GetStoreDeliveryQuote(c *gin.Context) {
+	storeId, err := strconv.ParseInt(c.Param("storeId"), 10, 64)
+	if err != nil {
+		result.ParamErrorResult(c.Writer, errors.New("invalid store id"))
+		return
+	}
+
+	var req dto.DeliveryQuoteReq
+	if err = c.ShouldBindQuery(&req); err != nil {
+		logrus.Error("c.ShouldBindQuery fail:", err)
+		result.ParamErrorResult(c.Writer, errors.New("c.ShouldBindQuery fail"))
+		return
+	}
+
+	resp, err := logic.GetStoreDeliveryQuote(s.db, getClaims(c), storeId, &req)
+	if err != nil {
+		logrus.Errorf("get store delivery quote fail: %s", err)
+	}
+	result.HttpResult(c.Writer, resp, err)
+}